@@ -1,21 +1,56 @@
 package blobstore
 
 import (
+	"errors"
 	"io"
 	"os"
+	"time"
 
-	boshdavcli "github.com/cloudfoundry/bosh-davcli/client"
+	boshcrypto "github.com/cloudfoundry/bosh-utils/crypto"
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshretry "github.com/cloudfoundry/bosh-utils/retrystrategy"
 	boshsys "github.com/cloudfoundry/bosh-utils/system"
 	boshuuid "github.com/cloudfoundry/bosh-utils/uuid"
 )
 
+// LocalBlobTempFilePrefix is used to name temp files that blobs are downloaded into
+const LocalBlobTempFilePrefix = "bosh-init-local-blob"
+
+// DefaultContentType is used for blobs added without a specific content type
+const DefaultContentType = "application/octet-stream"
+
 type Blobstore interface {
 	Get(blobID string) (LocalBlob, error)
-	Add(sourcePath string) (blobID string, err error)
+
+	// GetTo writes blob contents into dst without saving them to a file
+	GetTo(blobID string, dst io.Writer) error
+
+	GetWithDigest(blobID, destinationPath string, expectedDigest boshcrypto.Digest) error
+
+	// Add and AddWithDigest store contentType with uploaded blob;
+	// empty contentType means DefaultContentType
+	Add(sourcePath, contentType string) (blobID string, err error)
+	AddWithDigest(sourcePath, contentType string) (blobID string, digest boshcrypto.MultipleDigest, err error)
+
+	Exists(blobID string) (bool, error)
+	Delete(blobID string) error
+	Copy(srcBlobID string) (dstBlobID string, err error)
+
+	// Sign returns pre-signed URL allowing to GET or PUT blob until expiry
+	// without blobstore credentials; returns ErrSigningNotSupported
+	// if blobstore backend cannot sign URLs
+	Sign(blobID string, action string, expiry time.Duration) (string, error)
+
+	// List returns IDs of all blobs present in the blobstore;
+	// returns ErrListingNotSupported if blobstore backend cannot list blobs
+	List() ([]string, error)
 }
 
+var ErrSigningNotSupported = errors.New("Blobstore does not support signing blob URLs")
+
+var ErrListingNotSupported = errors.New("Blobstore does not support listing blobs")
+
 type Config struct {
 	Endpoint string
 	Username string
@@ -23,57 +58,297 @@ type Config struct {
 }
 
 type blobstore struct {
-	davClient     boshdavcli.Client
+	davClient     DavClient
 	uuidGenerator boshuuid.Generator
 	fs            boshsys.FileSystem
+	retryPolicy   RetryPolicy
+	progressFunc  ProgressFunc
+	metrics       blobstoreMetrics
 	logger        boshlog.Logger
 	logTag        string
 }
 
-func NewBlobstore(davClient boshdavcli.Client, uuidGenerator boshuuid.Generator, fs boshsys.FileSystem, logger boshlog.Logger) Blobstore {
+// Options configure optional blobstore features;
+// zero value makes a single attempt per request and disables all features
+type Options struct {
+	RetryPolicy RetryPolicy
+
+	// ProgressFunc is called as blob contents are transferred
+	ProgressFunc ProgressFunc
+
+	// Metrics receives counters and latencies of blobstore operations
+	Metrics MetricsSink
+}
+
+func NewBlobstore(
+	davClient DavClient,
+	uuidGenerator boshuuid.Generator,
+	fs boshsys.FileSystem,
+	opts Options,
+	logger boshlog.Logger,
+) Blobstore {
 	return &blobstore{
 		davClient:     davClient,
 		uuidGenerator: uuidGenerator,
 		fs:            fs,
+		retryPolicy:   opts.RetryPolicy,
+		progressFunc:  opts.ProgressFunc,
+		metrics:       blobstoreMetrics{sink: opts.Metrics},
 		logger:        logger,
 		logTag:        "blobstore",
 	}
 }
 
 func (b *blobstore) Get(blobID string) (LocalBlob, error) {
-	file, err := b.fs.TempFile("bosh-init-local-blob")
+	file, err := b.fs.TempFile(LocalBlobTempFilePrefix)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Creating temp file for blob")
+	}
+
 	destinationPath := file.Name()
 	err = file.Close()
 	if err != nil {
 		return nil, bosherr.WrapErrorf(err, "Closing new temp file '%s'", destinationPath)
 	}
 
-	b.logger.Debug(b.logTag, "Downloading blob %s to %s", blobID, destinationPath)
+	download, err := b.downloadWithRetry(blobID, fileDownloadDst{path: destinationPath, fs: b.fs})
+	if err != nil {
+		return nil, err
+	}
+
+	return NewLocalBlob(destinationPath, download.contentType, b.fs, b.logger), nil
+}
+
+// GetTo streams blob contents into dst; since contents written to dst
+// cannot be taken back failed attempts are only retried if nothing was written yet
+// or if blobstore allows to resume download from where it stopped
+func (b *blobstore) GetTo(blobID string, dst io.Writer) error {
+	_, err := b.downloadWithRetry(blobID, &writerDownloadDst{writer: dst})
+	return err
+}
+
+// partialDownload keeps track of blob contents saved by failed download attempts
+type partialDownload struct {
+	// offset is number of bytes already saved to destination path
+	offset int64
+
+	// contentType is reported by the blobstore when full contents are requested
+	contentType string
+}
+
+// downloadDst receives blob contents downloaded by one or more attempts
+type downloadDst interface {
+	// open returns writer for contents downloaded by the next attempt;
+	// resumed is true if contents continue ones saved by previous attempts
+	open(resumed bool) (io.WriteCloser, error)
+
+	String() string
+}
+
+type fileDownloadDst struct {
+	path string
+	fs   boshsys.FileSystem
+}
+
+// open truncates file unless download is resumed
+func (d fileDownloadDst) open(resumed bool) (io.WriteCloser, error) {
+	flags := os.O_RDWR | os.O_CREATE | os.O_TRUNC
+	if resumed {
+		flags = os.O_WRONLY | os.O_APPEND
+	}
 
-	readCloser, err := b.davClient.Get(blobID)
+	file, err := d.fs.OpenFile(d.path, flags, 0666)
 	if err != nil {
-		return nil, bosherr.WrapErrorf(err, "Getting blob %s from blobstore", blobID)
+		return nil, bosherr.WrapErrorf(err, "Opening file for blob at %s", d.path)
 	}
+
+	return file, nil
+}
+
+func (d fileDownloadDst) String() string { return d.path }
+
+type writerDownloadDst struct {
+	writer  io.Writer
+	written int64
+}
+
+// open fails if download has to start over after some contents
+// were already written since writer cannot be truncated
+func (d *writerDownloadDst) open(resumed bool) (io.WriteCloser, error) {
+	if !resumed && d.written > 0 {
+		return nil, bosherr.Errorf(
+			"Cannot download blob again since %d bytes were already written to destination writer", d.written)
+	}
+
+	return d, nil
+}
+
+func (d *writerDownloadDst) Write(p []byte) (int, error) {
+	n, err := d.writer.Write(p)
+	d.written += int64(n)
+	return n, err
+}
+
+// Close does not close writer since it's owned by the caller
+func (d *writerDownloadDst) Close() error { return nil }
+
+func (d *writerDownloadDst) String() string { return "destination writer" }
+
+// downloadWithRetry downloads blob to destination; retried attempts
+// resume from where previous attempt stopped if blobstore supports range requests.
+// Returned download state includes blob's content type.
+func (b *blobstore) downloadWithRetry(blobID string, dst downloadDst) (*partialDownload, error) {
+	b.logger.Debug(b.logTag, "Downloading blob %s to %s", blobID, dst)
+
+	partial := &partialDownload{}
+
+	retryable := boshretry.NewRetryable(func() (bool, error) {
+		return b.download(blobID, dst, partial)
+	})
+
+	return partial, newBackoffRetryStrategy(b.retryPolicy, retryable, b.logger).Try()
+}
+
+func (b *blobstore) download(blobID string, dst downloadDst, partial *partialDownload) (bool, error) {
+	offset := partial.offset
+
+	b.metrics.count(MetricGetCount, 1)
+
+	start := time.Now()
+	readCloser, info, resumed, err := b.getFrom(blobID, offset)
+	b.metrics.since(MetricGetDuration, start)
+
+	if err != nil {
+		b.metrics.davErr(MetricGetErrorsPrefix, err)
+		return isRetryableDavErr(err), bosherr.WrapErrorf(err, "Getting blob %s from blobstore", blobID)
+	}
+
+	if !resumed {
+		offset = 0
+	}
+
+	if len(info.ContentType) > 0 {
+		partial.contentType = info.ContentType
+	}
+
+	total := info.ContentLength
+	if info.ContentLength >= 0 {
+		total += offset
+	}
+
+	readCloser = newResumedProgressReadCloser(readCloser, offset, total, b.progressFunc)
+
 	defer func() {
 		if err = readCloser.Close(); err != nil {
 			b.logger.Warn(b.logTag, "Couldn't close davClient.Get reader: %s", err.Error())
 		}
 	}()
 
-	targetFile, err := b.fs.OpenFile(destinationPath, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0666)
+	target, err := dst.open(resumed)
 	if err != nil {
-		return nil, bosherr.WrapErrorf(err, "Opening file for blob at %s", destinationPath)
+		return false, err
 	}
 
-	_, err = io.Copy(targetFile, readCloser)
+	written, err := io.Copy(target, readCloser)
+	b.metrics.count(MetricGetBytes, written)
+
 	if err != nil {
-		return nil, bosherr.WrapErrorf(err, "Saving blob to %s", destinationPath)
+		target.Close()
+
+		partial.offset = offset + written
+
+		return true, bosherr.WrapErrorf(err, "Saving blob to %s", dst)
 	}
 
-	return NewLocalBlob(destinationPath, b.fs, b.logger), nil
+	err = target.Close()
+	if err != nil {
+		return false, bosherr.WrapErrorf(err, "Closing blob file at %s", dst)
+	}
+
+	return false, nil
 }
 
-func (b *blobstore) Add(sourcePath string) (string, error) {
+// getFrom gets blob contents starting at offset; returned bool is false
+// if full contents were returned because offset is 0 or blobstore ignored range
+func (b *blobstore) getFrom(blobID string, offset int64) (io.ReadCloser, BlobInfo, bool, error) {
+	if offset == 0 {
+		readCloser, info, err := b.davClient.GetWithInfo(blobID)
+		return readCloser, info, false, err
+	}
+
+	b.logger.Debug(b.logTag, "Resuming download of blob %s from byte %d", blobID, offset)
+
+	readCloser, contentLength, resumed, err := b.davClient.GetRange(blobID, offset)
+	if err == nil && !resumed {
+		b.logger.Debug(b.logTag, "Blobstore does not support range requests, downloading blob %s again", blobID)
+	}
+
+	return readCloser, BlobInfo{ContentLength: contentLength}, resumed, err
+}
+
+// GetWithDigest downloads blob into a temp file and moves it
+// to the destination path only if its digest matches expected digest
+func (b *blobstore) GetWithDigest(blobID, destinationPath string, expectedDigest boshcrypto.Digest) error {
+	tempFile, err := b.fs.TempFile(LocalBlobTempFilePrefix)
+	if err != nil {
+		return bosherr.WrapError(err, "Creating temp file for blob")
+	}
+
+	tempPath := tempFile.Name()
+
+	defer func() {
+		if err := b.fs.RemoveAll(tempPath); err != nil {
+			b.logger.Warn(b.logTag, "Couldn't remove temp file '%s': %s", tempPath, err.Error())
+		}
+	}()
+
+	err = tempFile.Close()
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Closing temp file '%s'", tempPath)
+	}
+
+	_, err = b.downloadWithRetry(blobID, fileDownloadDst{path: tempPath, fs: b.fs})
+	if err != nil {
+		return err
+	}
+
+	// Digest is calculated over the whole file since download may have been resumed
+	actualDigest, err := b.fileDigest(tempPath, expectedDigest.Algorithm())
+	if err != nil {
+		return err
+	}
+
+	if actualDigest.String() != expectedDigest.String() {
+		return bosherr.Errorf("Expected blob %s to have digest '%s' but was '%s'",
+			blobID, expectedDigest.String(), actualDigest.String())
+	}
+
+	err = b.fs.Rename(tempPath, destinationPath)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Moving blob to %s", destinationPath)
+	}
+
+	return nil
+}
+
+func (b *blobstore) fileDigest(path string, algorithm boshcrypto.Algorithm) (boshcrypto.Digest, error) {
+	file, err := b.fs.OpenFile(path, os.O_RDONLY, 0)
+	if err != nil {
+		return nil, bosherr.WrapErrorf(err, "Opening file for reading %s", path)
+	}
+
+	defer file.Close()
+
+	digest, err := algorithm.CreateDigest(file)
+	if err != nil {
+		return nil, bosherr.WrapErrorf(err, "Calculating digest of %s", path)
+	}
+
+	return digest, nil
+}
+
+// Add uploads file without calculating digest of its contents
+func (b *blobstore) Add(sourcePath, contentType string) (string, error) {
 	blobID, err := b.uuidGenerator.Generate()
 	if err != nil {
 		return "", bosherr.WrapError(err, "Generating Blob ID")
@@ -81,9 +356,56 @@ func (b *blobstore) Add(sourcePath string) (string, error) {
 
 	b.logger.Debug(b.logTag, "Uploading blob %s from %s", blobID, sourcePath)
 
+	err = b.uploadWithRetry(sourcePath, blobID, contentType, nil)
+	if err != nil {
+		return "", err
+	}
+
+	return blobID, nil
+}
+
+// AddWithDigest uploads file and returns SHA1 and SHA256 digests of its contents
+// calculated while uploading so that file does not need to be read again
+func (b *blobstore) AddWithDigest(sourcePath, contentType string) (string, boshcrypto.MultipleDigest, error) {
+	blobID, err := b.uuidGenerator.Generate()
+	if err != nil {
+		return "", boshcrypto.MultipleDigest{}, bosherr.WrapError(err, "Generating Blob ID")
+	}
+
+	b.logger.Debug(b.logTag, "Uploading blob %s from %s", blobID, sourcePath)
+
+	digestWriter := newMultipleDigestWriter()
+
+	err = b.uploadWithRetry(sourcePath, blobID, contentType, digestWriter)
+	if err != nil {
+		return "", boshcrypto.MultipleDigest{}, err
+	}
+
+	return blobID, digestWriter.Digest(), nil
+}
+
+// uploadWithRetry uploads file at sourcePath as blobID;
+// if digestWriter is given it's fed with the file contents.
+// Whole file is uploaded with a single PUT on each attempt since dav servers
+// used by the agent have no multipart API that would allow resuming uploads.
+func (b *blobstore) uploadWithRetry(sourcePath, blobID, contentType string, digestWriter *multipleDigestWriter) error {
+	if len(contentType) == 0 {
+		contentType = DefaultContentType
+	}
+
+	retryable := boshretry.NewRetryable(func() (bool, error) {
+		return b.upload(sourcePath, blobID, contentType, digestWriter)
+	})
+
+	return newBackoffRetryStrategy(b.retryPolicy, retryable, b.logger).Try()
+}
+
+// upload puts file into blobstore; if digestWriter is given
+// it is reset and fed with uploaded contents
+func (b *blobstore) upload(sourcePath, blobID, contentType string, digestWriter *multipleDigestWriter) (bool, error) {
 	file, err := b.fs.OpenFile(sourcePath, os.O_RDONLY, 0)
 	if err != nil {
-		return "", bosherr.WrapErrorf(err, "Opening file for reading %s", sourcePath)
+		return false, bosherr.WrapErrorf(err, "Opening file for reading %s", sourcePath)
 	}
 	defer func() {
 		if err := file.Close(); err != nil {
@@ -93,13 +415,111 @@ func (b *blobstore) Add(sourcePath string) (string, error) {
 
 	fileInfo, err := file.Stat()
 	if err != nil {
-		return "", bosherr.WrapErrorf(err, "Getting fileInfo from %s", sourcePath)
+		return false, bosherr.WrapErrorf(err, "Getting fileInfo from %s", sourcePath)
+	}
+
+	content := newProgressReadCloser(file, fileInfo.Size(), b.progressFunc)
+
+	if digestWriter != nil {
+		digestWriter.Reset()
+		content = teeReadCloser{io.TeeReader(content, digestWriter), content}
+	}
+
+	err = b.put(blobID, content, fileInfo.Size(), contentType)
+	if err != nil {
+		return isRetryableDavErr(err), bosherr.WrapErrorf(
+			err, "Putting file '%s' into blobstore (via DAVClient) as blobID '%s'", sourcePath, blobID)
 	}
 
-	err = b.davClient.Put(blobID, file, fileInfo.Size())
+	return false, nil
+}
+
+// put uploads content recording metrics for each uploaded blob
+func (b *blobstore) put(blobID string, content io.ReadCloser, size int64, contentType string) error {
+	b.metrics.count(MetricAddCount, 1)
+
+	start := time.Now()
+	err := b.davClient.PutWithContentType(blobID, content, size, contentType)
+	b.metrics.since(MetricAddDuration, start)
+
 	if err != nil {
-		return "", bosherr.WrapErrorf(err, "Putting file '%s' into blobstore (via DAVClient) as blobID '%s'", sourcePath, blobID)
+		b.metrics.davErr(MetricAddErrorsPrefix, err)
+		return err
 	}
 
-	return blobID, nil
+	b.metrics.count(MetricAddBytes, size)
+
+	return nil
+}
+
+func (b *blobstore) Exists(blobID string) (bool, error) {
+	b.logger.Debug(b.logTag, "Checking if blob %s exists", blobID)
+
+	exists, err := b.davClient.Exists(blobID)
+	if err != nil {
+		return false, bosherr.WrapErrorf(err, "Checking if blob %s exists in blobstore", blobID)
+	}
+
+	return exists, nil
+}
+
+// Delete removes blob from the blobstore; deleting already absent blob is not an error
+func (b *blobstore) Delete(blobID string) error {
+	b.logger.Debug(b.logTag, "Deleting blob %s", blobID)
+
+	err := b.davClient.Delete(blobID)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Deleting blob %s from blobstore", blobID)
+	}
+
+	return nil
+}
+
+// Sign is not supported since dav blobstore is only accessible with basic auth credentials
+func (b *blobstore) Sign(blobID string, action string, expiry time.Duration) (string, error) {
+	return "", ErrSigningNotSupported
+}
+
+// List is not supported since dav servers do not provide a way to list stored blobs
+func (b *blobstore) List() ([]string, error) {
+	return nil, ErrListingNotSupported
+}
+
+// Copy duplicates blob under a new blob ID using server-side copy if possible,
+// otherwise blob is downloaded into a temp file and uploaded again
+func (b *blobstore) Copy(srcBlobID string) (string, error) {
+	dstBlobID, err := b.uuidGenerator.Generate()
+	if err != nil {
+		return "", bosherr.WrapError(err, "Generating Blob ID")
+	}
+
+	b.logger.Debug(b.logTag, "Copying blob %s to %s", srcBlobID, dstBlobID)
+
+	err = b.davClient.Copy(srcBlobID, dstBlobID)
+	if err == nil {
+		return dstBlobID, nil
+	} else if err != ErrCopyNotSupported {
+		return "", bosherr.WrapErrorf(err, "Copying blob %s to %s", srcBlobID, dstBlobID)
+	}
+
+	b.logger.Debug(b.logTag, "Server-side copy is not supported, downloading blob %s", srcBlobID)
+
+	localBlob, err := b.Get(srcBlobID)
+	if err != nil {
+		return "", err
+	}
+
+	defer localBlob.DeleteSilently()
+
+	err = b.uploadWithRetry(localBlob.Path(), dstBlobID, localBlob.ContentType(), nil)
+	if err != nil {
+		return "", err
+	}
+
+	return dstBlobID, nil
+}
+
+type teeReadCloser struct {
+	io.Reader
+	io.Closer
 }
@@ -5,7 +5,6 @@ import (
 	"net/http"
 	"net/url"
 
-	boshdavcli "github.com/cloudfoundry/bosh-davcli/client"
 	boshdavcliconf "github.com/cloudfoundry/bosh-davcli/config"
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
@@ -20,30 +19,60 @@ type Factory interface {
 type blobstoreFactory struct {
 	uuidGenerator boshuuid.Generator
 	fs            boshsys.FileSystem
+	options       Options
 	logger        boshlog.Logger
 }
 
-func NewBlobstoreFactory(uuidGenerator boshuuid.Generator, fs boshsys.FileSystem, logger boshlog.Logger) Factory {
+// NewBlobstoreFactory returns factory that configures created dav blobstores with given options
+func NewBlobstoreFactory(uuidGenerator boshuuid.Generator, fs boshsys.FileSystem, options Options, logger boshlog.Logger) Factory {
 	return blobstoreFactory{
 		uuidGenerator: uuidGenerator,
 		fs:            fs,
+		options:       options,
 		logger:        logger,
 	}
 }
 
+// Create returns blobstore backend selected by URL scheme:
+// http(s)://user:pass@host:port for dav and file:///path/to/dir for a local directory
 func (f blobstoreFactory) Create(blobstoreURL string, httpClient *http.Client) (Blobstore, error) {
+	parsedURL, err := url.Parse(blobstoreURL)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Creating blobstore config")
+	}
+
+	switch parsedURL.Scheme {
+	case "http", "https":
+		return f.createDav(blobstoreURL, httpClient)
+
+	case "file":
+		if len(parsedURL.Host) > 0 || len(parsedURL.Path) == 0 {
+			return nil, bosherr.Errorf("Expected file blobstore URL to specify absolute directory path (e.g. file:///var/blobs) but was '%s'", blobstoreURL)
+		}
+
+		return NewFSBlobstore(parsedURL.Path, f.uuidGenerator, f.fs, f.logger), nil
+
+	case "s3":
+		return nil, bosherr.Error("S3 blobstore is not supported yet")
+
+	default:
+		return nil, bosherr.Errorf("Expected blobstore URL scheme to be one of http, https or file but was '%s'", parsedURL.Scheme)
+	}
+}
+
+func (f blobstoreFactory) createDav(blobstoreURL string, httpClient *http.Client) (Blobstore, error) {
 	blobstoreConfig, err := f.parseBlobstoreURL(blobstoreURL)
 	if err != nil {
 		return nil, bosherr.WrapError(err, "Creating blobstore config")
 	}
 
-	davClient := boshdavcli.NewClient(boshdavcliconf.Config{
+	davClient := NewDavClient(boshdavcliconf.Config{
 		Endpoint: fmt.Sprintf("%s/blobs", blobstoreConfig.Endpoint),
 		User:     blobstoreConfig.Username,
 		Password: blobstoreConfig.Password,
 	}, httpClient, f.logger)
 
-	return NewBlobstore(davClient, f.uuidGenerator, f.fs, f.logger), nil
+	return NewBlobstore(davClient, f.uuidGenerator, f.fs, f.options, f.logger), nil
 }
 
 func (f blobstoreFactory) parseBlobstoreURL(blobstoreURL string) (Config, error) {
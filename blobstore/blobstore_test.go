@@ -1,13 +1,18 @@
 package blobstore_test
 
 import (
+	"bytes"
 	"errors"
+	"io"
 	"io/ioutil"
 	"strings"
+	"time"
 
 	. "github.com/cloudfoundry/bosh-cli/blobstore"
-	fakeboshdavcli "github.com/cloudfoundry/bosh-davcli/client/fakes"
+	fakeblobstore "github.com/cloudfoundry/bosh-cli/blobstore/fakes"
+	boshcrypto "github.com/cloudfoundry/bosh-utils/crypto"
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
 	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
 	fakeuuid "github.com/cloudfoundry/bosh-utils/uuid/fakes"
 	. "github.com/onsi/ginkgo"
@@ -16,19 +21,24 @@ import (
 
 var _ = Describe("Blobstore", func() {
 	var (
-		fakeDavClient     *fakeboshdavcli.FakeClient
+		fakeDavClient     *fakeblobstore.FakeDavClient
 		fakeUUIDGenerator *fakeuuid.FakeGenerator
 		fs                *fakesys.FakeFileSystem
+		logger            boshlog.Logger
 		blobstore         Blobstore
 	)
 
+	type progressCall struct {
+		transferred, total int64
+	}
+
 	BeforeEach(func() {
-		fakeDavClient = fakeboshdavcli.NewFakeClient()
+		fakeDavClient = fakeblobstore.NewFakeDavClient()
 		fakeUUIDGenerator = fakeuuid.NewFakeGenerator()
 		fs = fakesys.NewFakeFileSystem()
-		logger := boshlog.NewLogger(boshlog.LevelNone)
+		logger = boshlog.NewLogger(boshlog.LevelNone)
 
-		blobstore = NewBlobstore(fakeDavClient, fakeUUIDGenerator, fs, logger)
+		blobstore = NewBlobstore(fakeDavClient, fakeUUIDGenerator, fs, Options{RetryPolicy: RetryPolicy{MaxAttempts: 3}}, logger)
 	})
 
 	Describe("Get", func() {
@@ -47,6 +57,17 @@ var _ = Describe("Blobstore", func() {
 			Expect(fakeDavClient.GetPath).To(Equal("fake-blob-id"))
 		})
 
+		It("returns local blob with content type reported by the blobstore", func() {
+			fakeDavClient.GetContents = ioutil.NopCloser(strings.NewReader("fake-content"))
+			fakeDavClient.GetContentType = "text/plain"
+
+			localBlob, err := blobstore.Get("fake-blob-id")
+			Expect(err).ToNot(HaveOccurred())
+			defer localBlob.DeleteSilently()
+
+			Expect(localBlob.ContentType()).To(Equal("text/plain"))
+		})
+
 		It("saves the blob to the destination path", func() {
 			fakeDavClient.GetContents = ioutil.NopCloser(strings.NewReader("fake-content"))
 
@@ -64,6 +85,26 @@ var _ = Describe("Blobstore", func() {
 			Expect(contents).To(Equal("fake-content"))
 		})
 
+		It("reports download progress with total from content length", func() {
+			var calls []progressCall
+
+			progressFunc := func(transferred, total int64) {
+				calls = append(calls, progressCall{transferred, total})
+			}
+
+			blobstore = NewBlobstore(fakeDavClient, fakeUUIDGenerator, fs, Options{RetryPolicy: RetryPolicy{MaxAttempts: 3}, ProgressFunc: progressFunc}, logger)
+
+			fakeDavClient.GetContents = ioutil.NopCloser(strings.NewReader("fake-content"))
+			fakeDavClient.GetContentLength = 12
+
+			localBlob, err := blobstore.Get("fake-blob-id")
+			Expect(err).ToNot(HaveOccurred())
+			defer localBlob.DeleteSilently()
+
+			Expect(calls).ToNot(BeEmpty())
+			Expect(calls[len(calls)-1]).To(Equal(progressCall{12, 12}))
+		})
+
 		Context("when getting from blobstore fails", func() {
 			It("returns an error", func() {
 				fakeDavClient.GetErr = errors.New("fake-get-error")
@@ -73,6 +114,270 @@ var _ = Describe("Blobstore", func() {
 				Expect(err.Error()).To(ContainSubstring("fake-get-error"))
 			})
 		})
+
+		Context("when getting from blobstore fails with a retryable error", func() {
+			It("retries and returns the blob", func() {
+				fakeDavClient.GetErrs = []error{
+					errors.New("Getting dav blob fake-blob-id: Wrong response code: 503; body: "),
+					errors.New("fake-connection-reset-error"),
+				}
+				fakeDavClient.GetContents = ioutil.NopCloser(strings.NewReader("fake-content"))
+
+				localBlob, err := blobstore.Get("fake-blob-id")
+				Expect(err).ToNot(HaveOccurred())
+				defer localBlob.DeleteSilently()
+
+				Expect(fakeDavClient.GetCallCount).To(Equal(3))
+
+				contents, err := fs.ReadFileString("fake-destination-path")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(contents).To(Equal("fake-content"))
+			})
+
+			It("returns an error after running out of attempts", func() {
+				fakeDavClient.GetErr = errors.New("Getting dav blob fake-blob-id: Wrong response code: 500; body: ")
+
+				_, err := blobstore.Get("fake-blob-id")
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("Wrong response code: 500"))
+
+				Expect(fakeDavClient.GetCallCount).To(Equal(3))
+			})
+		})
+
+		Context("when getting from blobstore fails with a non-retryable error", func() {
+			It("does not retry", func() {
+				fakeDavClient.GetErr = errors.New("Getting dav blob fake-blob-id: Wrong response code: 404; body: ")
+
+				_, err := blobstore.Get("fake-blob-id")
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("Wrong response code: 404"))
+
+				Expect(fakeDavClient.GetCallCount).To(Equal(1))
+			})
+		})
+
+		Context("when creating temp file fails", func() {
+			It("returns an error", func() {
+				fs.TempFileError = errors.New("fake-temp-file-error")
+
+				_, err := blobstore.Get("fake-blob-id")
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("fake-temp-file-error"))
+			})
+		})
+	})
+
+	Describe("resuming downloads", func() {
+		var (
+			realFS boshsys.FileSystem
+		)
+
+		BeforeEach(func() {
+			realFS = boshsys.NewOsFileSystem(logger)
+			blobstore = NewBlobstore(fakeDavClient, fakeUUIDGenerator, realFS, Options{RetryPolicy: RetryPolicy{MaxAttempts: 3}}, logger)
+
+			fakeDavClient.GetContents = ioutil.NopCloser(io.MultiReader(
+				strings.NewReader("fake-partial-blob-"), &failingReader{err: errors.New("fake-connection-reset-error")}))
+			fakeDavClient.GetContentLength = 25
+		})
+
+		It("requests only remaining contents and appends them when retrying", func() {
+			fakeDavClient.GetRangeContents = ioutil.NopCloser(strings.NewReader("content"))
+			fakeDavClient.GetRangeContentLength = 7
+			fakeDavClient.GetRangePartial = true
+
+			localBlob, err := blobstore.Get("fake-blob-id")
+			Expect(err).ToNot(HaveOccurred())
+			defer localBlob.DeleteSilently()
+
+			Expect(fakeDavClient.GetCallCount).To(Equal(1))
+			Expect(fakeDavClient.GetRangePath).To(Equal("fake-blob-id"))
+			Expect(fakeDavClient.GetRangeOffsets).To(Equal([]int64{18}))
+
+			contents, err := realFS.ReadFileString(localBlob.Path())
+			Expect(err).ToNot(HaveOccurred())
+			Expect(contents).To(Equal("fake-partial-blob-content"))
+		})
+
+		It("reports progress including previously downloaded contents", func() {
+			var calls []progressCall
+
+			progressFunc := func(transferred, total int64) {
+				calls = append(calls, progressCall{transferred, total})
+			}
+
+			blobstore = NewBlobstore(fakeDavClient, fakeUUIDGenerator, realFS, Options{RetryPolicy: RetryPolicy{MaxAttempts: 3}, ProgressFunc: progressFunc}, logger)
+
+			fakeDavClient.GetRangeContents = ioutil.NopCloser(strings.NewReader("content"))
+			fakeDavClient.GetRangeContentLength = 7
+			fakeDavClient.GetRangePartial = true
+
+			localBlob, err := blobstore.Get("fake-blob-id")
+			Expect(err).ToNot(HaveOccurred())
+			defer localBlob.DeleteSilently()
+
+			Expect(calls[len(calls)-1]).To(Equal(progressCall{25, 25}))
+		})
+
+		It("saves full contents if blobstore does not support range requests", func() {
+			fakeDavClient.GetRangeContents = ioutil.NopCloser(strings.NewReader("fake-partial-blob-content"))
+			fakeDavClient.GetRangeContentLength = 25
+			fakeDavClient.GetRangePartial = false
+
+			localBlob, err := blobstore.Get("fake-blob-id")
+			Expect(err).ToNot(HaveOccurred())
+			defer localBlob.DeleteSilently()
+
+			contents, err := realFS.ReadFileString(localBlob.Path())
+			Expect(err).ToNot(HaveOccurred())
+			Expect(contents).To(Equal("fake-partial-blob-content"))
+		})
+
+		It("returns an error if requesting remaining contents fails on all attempts", func() {
+			fakeDavClient.GetRangeErr = errors.New("fake-connection-reset-error")
+
+			_, err := blobstore.Get("fake-blob-id")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-connection-reset-error"))
+
+			Expect(fakeDavClient.GetRangeOffsets).To(Equal([]int64{18, 18}))
+		})
+
+		It("validates digest of resumed download", func() {
+			expectedDigest, err := boshcrypto.DigestAlgorithmSHA256.CreateDigest(
+				strings.NewReader("fake-partial-blob-content"))
+			Expect(err).ToNot(HaveOccurred())
+
+			fakeDavClient.GetRangeContents = ioutil.NopCloser(strings.NewReader("content"))
+			fakeDavClient.GetRangePartial = true
+
+			dstFile, err := realFS.TempFile("bosh-cli-blobstore-test")
+			Expect(err).ToNot(HaveOccurred())
+			dstFile.Close()
+			defer realFS.RemoveAll(dstFile.Name())
+
+			err = blobstore.GetWithDigest("fake-blob-id", dstFile.Name(), expectedDigest)
+			Expect(err).ToNot(HaveOccurred())
+
+			contents, err := realFS.ReadFileString(dstFile.Name())
+			Expect(err).ToNot(HaveOccurred())
+			Expect(contents).To(Equal("fake-partial-blob-content"))
+		})
+	})
+
+	Describe("GetTo", func() {
+		It("writes blob contents into writer without creating a file", func() {
+			fakeDavClient.GetContents = ioutil.NopCloser(strings.NewReader("fake-content"))
+			fakeDavClient.GetContentLength = 12
+
+			fs.TempFileError = errors.New("fake-temp-file-error")
+
+			var buf bytes.Buffer
+
+			err := blobstore.GetTo("fake-blob-id", &buf)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(fakeDavClient.GetPath).To(Equal("fake-blob-id"))
+			Expect(buf.String()).To(Equal("fake-content"))
+		})
+
+		It("retries if getting from blobstore fails before anything is written", func() {
+			fakeDavClient.GetErrs = []error{errors.New("fake-connection-reset-error")}
+			fakeDavClient.GetContents = ioutil.NopCloser(strings.NewReader("fake-content"))
+
+			var buf bytes.Buffer
+
+			err := blobstore.GetTo("fake-blob-id", &buf)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(buf.String()).To(Equal("fake-content"))
+
+			Expect(fakeDavClient.GetCallCount).To(Equal(2))
+		})
+
+		It("continues writing remaining contents when download is resumed", func() {
+			fakeDavClient.GetContents = ioutil.NopCloser(io.MultiReader(
+				strings.NewReader("fake-partial-blob-"), &failingReader{err: errors.New("fake-connection-reset-error")}))
+			fakeDavClient.GetRangeContents = ioutil.NopCloser(strings.NewReader("content"))
+			fakeDavClient.GetRangePartial = true
+
+			var buf bytes.Buffer
+
+			err := blobstore.GetTo("fake-blob-id", &buf)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(buf.String()).To(Equal("fake-partial-blob-content"))
+
+			Expect(fakeDavClient.GetRangeOffsets).To(Equal([]int64{18}))
+		})
+
+		It("returns an error if download cannot be resumed after contents were written", func() {
+			fakeDavClient.GetContents = ioutil.NopCloser(io.MultiReader(
+				strings.NewReader("fake-partial-blob-"), &failingReader{err: errors.New("fake-connection-reset-error")}))
+			fakeDavClient.GetRangeContents = ioutil.NopCloser(strings.NewReader("fake-partial-blob-content"))
+			fakeDavClient.GetRangePartial = false
+
+			var buf bytes.Buffer
+
+			err := blobstore.GetTo("fake-blob-id", &buf)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring(
+				"Cannot download blob again since 18 bytes were already written to destination writer"))
+
+			Expect(buf.String()).To(Equal("fake-partial-blob-"))
+		})
+	})
+
+	Describe("GetWithDigest", func() {
+		const expectedDigestValue = "9c87681ea7ba17d350f3cb62894935d8f77c0aacc678966d51638d584a6eaee0"
+
+		var (
+			expectedDigest boshcrypto.Digest
+		)
+
+		BeforeEach(func() {
+			fakeFile := fakesys.NewFakeFile("fake-temp-path", fs)
+			fs.ReturnTempFile = fakeFile
+
+			// sha256 of 'fake-content'
+			expectedDigest = boshcrypto.NewDigest(boshcrypto.DigestAlgorithmSHA256, expectedDigestValue)
+		})
+
+		It("moves blob to destination path if digest matches", func() {
+			fakeDavClient.GetContents = ioutil.NopCloser(strings.NewReader("fake-content"))
+
+			err := blobstore.GetWithDigest("fake-blob-id", "fake-destination-path", expectedDigest)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(fakeDavClient.GetPath).To(Equal("fake-blob-id"))
+
+			contents, err := fs.ReadFileString("fake-destination-path")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(contents).To(Equal("fake-content"))
+
+			Expect(fs.FileExists("fake-temp-path")).To(BeFalse())
+		})
+
+		It("returns an error and removes temp file if digest does not match", func() {
+			fakeDavClient.GetContents = ioutil.NopCloser(strings.NewReader("fake-truncated-cont"))
+
+			err := blobstore.GetWithDigest("fake-blob-id", "fake-destination-path", expectedDigest)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring(
+				"Expected blob fake-blob-id to have digest 'sha256:" + expectedDigestValue + "' but was 'sha256:"))
+
+			Expect(fs.FileExists("fake-destination-path")).To(BeFalse())
+			Expect(fs.FileExists("fake-temp-path")).To(BeFalse())
+		})
+
+		It("returns an error if getting from blobstore fails", func() {
+			fakeDavClient.GetErr = errors.New("fake-get-error")
+
+			err := blobstore.GetWithDigest("fake-blob-id", "fake-destination-path", expectedDigest)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-get-error"))
+
+			Expect(fs.FileExists("fake-temp-path")).To(BeFalse())
+		})
 	})
 
 	Describe("Add", func() {
@@ -85,11 +390,347 @@ var _ = Describe("Blobstore", func() {
 		It("adds file to blobstore and returns its blob ID", func() {
 			fakeUUIDGenerator.GeneratedUUID = "fake-blob-id"
 
-			blobID, err := blobstore.Add("fake-source-path")
+			blobID, err := blobstore.Add("fake-source-path", "")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(blobID).To(Equal("fake-blob-id"))
+			Expect(fakeDavClient.PutPath).To(Equal("fake-blob-id"))
+			Expect(fakeDavClient.PutContents).To(Equal("fake-contents"))
+		})
+
+		It("uploads blob with default content type if content type is not specified", func() {
+			_, err := blobstore.Add("fake-source-path", "")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(fakeDavClient.PutContentType).To(Equal("application/octet-stream"))
+		})
+
+		It("uploads blob with specified content type", func() {
+			_, err := blobstore.Add("fake-source-path", "text/plain")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(fakeDavClient.PutContentType).To(Equal("text/plain"))
+		})
+
+		It("reports upload progress with total from file size", func() {
+			var calls []progressCall
+
+			progressFunc := func(transferred, total int64) {
+				calls = append(calls, progressCall{transferred, total})
+			}
+
+			blobstore = NewBlobstore(fakeDavClient, fakeUUIDGenerator, fs, Options{RetryPolicy: RetryPolicy{MaxAttempts: 3}, ProgressFunc: progressFunc}, logger)
+
+			_, err := blobstore.Add("fake-source-path", "")
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(calls).ToNot(BeEmpty())
+			Expect(calls[len(calls)-1]).To(Equal(progressCall{13, 13}))
+		})
+
+		It("retries putting file if it fails with a retryable error", func() {
+			fakeUUIDGenerator.GeneratedUUID = "fake-blob-id"
+			fakeDavClient.PutErrs = []error{
+				errors.New("Putting dav blob fake-blob-id: Wrong response code: 502; body: "),
+			}
+
+			blobID, err := blobstore.Add("fake-source-path", "")
 			Expect(err).ToNot(HaveOccurred())
 			Expect(blobID).To(Equal("fake-blob-id"))
+
+			Expect(fakeDavClient.PutCallCount).To(Equal(2))
+			Expect(fakeDavClient.PutContents).To(Equal("fake-contents"))
+		})
+
+		It("does not retry putting file if it fails with a non-retryable error", func() {
+			fakeDavClient.PutErr = errors.New("Putting dav blob fake-blob-id: Wrong response code: 400; body: ")
+
+			_, err := blobstore.Add("fake-source-path", "")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Wrong response code: 400"))
+
+			Expect(fakeDavClient.PutCallCount).To(Equal(1))
+		})
+	})
+
+	Describe("AddWithDigest", func() {
+		var expectedDigest boshcrypto.MultipleDigest
+
+		BeforeEach(func() {
+			fs.RegisterOpenFile("fake-source-path", &fakesys.FakeFile{
+				Contents: []byte("fake-contents"),
+			})
+
+			sha1Digest, err := boshcrypto.DigestAlgorithmSHA1.CreateDigest(strings.NewReader("fake-contents"))
+			Expect(err).ToNot(HaveOccurred())
+
+			sha256Digest, err := boshcrypto.DigestAlgorithmSHA256.CreateDigest(strings.NewReader("fake-contents"))
+			Expect(err).ToNot(HaveOccurred())
+
+			expectedDigest = boshcrypto.MustNewMultipleDigest(sha1Digest, sha256Digest)
+		})
+
+		It("adds file to blobstore and returns its blob ID and digest", func() {
+			fakeUUIDGenerator.GeneratedUUID = "fake-blob-id"
+
+			blobID, digest, err := blobstore.AddWithDigest("fake-source-path", "")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(blobID).To(Equal("fake-blob-id"))
+			Expect(digest).To(Equal(expectedDigest))
+
 			Expect(fakeDavClient.PutPath).To(Equal("fake-blob-id"))
 			Expect(fakeDavClient.PutContents).To(Equal("fake-contents"))
 		})
+
+		It("returns digest of uploaded contents after retrying", func() {
+			fakeDavClient.PutErrs = []error{
+				errors.New("Putting dav blob fake-blob-id: Wrong response code: 502; body: "),
+			}
+
+			_, digest, err := blobstore.AddWithDigest("fake-source-path", "")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(digest).To(Equal(expectedDigest))
+
+			Expect(fakeDavClient.PutCallCount).To(Equal(2))
+		})
+
+		It("returns error if uploading fails", func() {
+			fakeDavClient.PutErr = errors.New("fake-put-err")
+
+			_, _, err := blobstore.AddWithDigest("fake-source-path", "")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-put-err"))
+		})
+	})
+
+	Describe("metrics", func() {
+		var (
+			sink *fakeblobstore.FakeMetricsSink
+		)
+
+		BeforeEach(func() {
+			sink = fakeblobstore.NewFakeMetricsSink()
+			blobstore = NewBlobstore(fakeDavClient, fakeUUIDGenerator, fs, Options{RetryPolicy: RetryPolicy{MaxAttempts: 3}, Metrics: sink}, logger)
+
+			fs.ReturnTempFile = fakesys.NewFakeFile("fake-destination-path", fs)
+			fs.RegisterOpenFile("fake-source-path", &fakesys.FakeFile{
+				Contents: []byte("fake-contents"),
+			})
+		})
+
+		It("records count, bytes and duration of gets", func() {
+			fakeDavClient.GetContents = ioutil.NopCloser(strings.NewReader("fake-content"))
+
+			localBlob, err := blobstore.Get("fake-blob-id")
+			Expect(err).ToNot(HaveOccurred())
+			defer localBlob.DeleteSilently()
+
+			Expect(sink.Counters).To(Equal(map[string]int64{
+				"blobstore.get.count": 1,
+				"blobstore.get.bytes": 12,
+			}))
+			Expect(sink.Histograms["blobstore.get.duration"]).To(HaveLen(1))
+		})
+
+		It("records errors of each get attempt by kind", func() {
+			fakeDavClient.GetErrs = []error{
+				errors.New("Getting dav blob fake-blob-id: Wrong response code: 503; body: "),
+				errors.New("Getting dav blob fake-blob-id: connection reset by peer"),
+			}
+			fakeDavClient.GetErr = errors.New("Getting dav blob fake-blob-id: Wrong response code: 404; body: ")
+
+			_, err := blobstore.Get("fake-blob-id")
+			Expect(err).To(HaveOccurred())
+
+			Expect(sink.Counters).To(Equal(map[string]int64{
+				"blobstore.get.count":            3,
+				"blobstore.get.errors.server":    1,
+				"blobstore.get.errors.network":   1,
+				"blobstore.get.errors.not_found": 1,
+			}))
+			Expect(sink.Histograms["blobstore.get.duration"]).To(HaveLen(3))
+		})
+
+		It("records count, bytes and duration of adds", func() {
+			_, err := blobstore.Add("fake-source-path", "")
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(sink.Counters).To(Equal(map[string]int64{
+				"blobstore.add.count": 1,
+				"blobstore.add.bytes": 13,
+			}))
+			Expect(sink.Histograms["blobstore.add.duration"]).To(HaveLen(1))
+		})
+
+		It("records add errors by kind", func() {
+			fakeDavClient.PutErr = errors.New("Putting dav blob fake-blob-id: Wrong response code: 400; body: ")
+
+			_, err := blobstore.Add("fake-source-path", "")
+			Expect(err).To(HaveOccurred())
+
+			Expect(sink.Counters).To(Equal(map[string]int64{
+				"blobstore.add.count":         1,
+				"blobstore.add.errors.client": 1,
+			}))
+		})
+
+		It("writes metrics into debug log when logger sink is used", func() {
+			logBuffer := bytes.NewBufferString("")
+			debugLogger := boshlog.NewWriterLogger(boshlog.LevelDebug, logBuffer, logBuffer)
+
+			blobstore = NewBlobstore(fakeDavClient, fakeUUIDGenerator, fs, Options{Metrics: NewLoggerMetricsSink(debugLogger)}, logger)
+
+			_, err := blobstore.Add("fake-source-path", "")
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(logBuffer.String()).To(ContainSubstring("blobstore.add.count +1"))
+			Expect(logBuffer.String()).To(ContainSubstring("blobstore.add.bytes +13"))
+			Expect(logBuffer.String()).To(ContainSubstring("blobstore.add.duration "))
+		})
+	})
+
+	Describe("Exists", func() {
+		It("returns true if blob exists", func() {
+			fakeDavClient.ExistsResult = true
+
+			exists, err := blobstore.Exists("fake-blob-id")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(exists).To(BeTrue())
+
+			Expect(fakeDavClient.ExistsPath).To(Equal("fake-blob-id"))
+		})
+
+		It("returns false if blob does not exist", func() {
+			fakeDavClient.ExistsResult = false
+
+			exists, err := blobstore.Exists("fake-blob-id")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(exists).To(BeFalse())
+		})
+
+		It("returns an error if checking blob fails", func() {
+			fakeDavClient.ExistsErr = errors.New("fake-exists-error")
+
+			_, err := blobstore.Exists("fake-blob-id")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-exists-error"))
+		})
+	})
+
+	Describe("Delete", func() {
+		It("deletes blob from blobstore", func() {
+			err := blobstore.Delete("fake-blob-id")
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(fakeDavClient.DeletePath).To(Equal("fake-blob-id"))
+		})
+
+		It("returns an error if deleting blob fails", func() {
+			fakeDavClient.DeleteErr = errors.New("fake-delete-error")
+
+			err := blobstore.Delete("fake-blob-id")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-delete-error"))
+		})
+	})
+
+	Describe("Sign", func() {
+		It("returns an error since dav blobstore does not support signing", func() {
+			_, err := blobstore.Sign("fake-blob-id", "PUT", time.Minute)
+			Expect(err).To(Equal(ErrSigningNotSupported))
+		})
+	})
+
+	Describe("List", func() {
+		It("returns an error since dav blobstore does not support listing", func() {
+			_, err := blobstore.List()
+			Expect(err).To(Equal(ErrListingNotSupported))
+		})
+	})
+
+	Describe("Copy", func() {
+		BeforeEach(func() {
+			fakeUUIDGenerator.GeneratedUUID = "fake-new-blob-id"
+		})
+
+		It("copies blob on the server under new blob ID", func() {
+			blobID, err := blobstore.Copy("fake-blob-id")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(blobID).To(Equal("fake-new-blob-id"))
+
+			Expect(fakeDavClient.CopySrcPath).To(Equal("fake-blob-id"))
+			Expect(fakeDavClient.CopyDstPath).To(Equal("fake-new-blob-id"))
+			Expect(fakeDavClient.GetPath).To(BeEmpty())
+			Expect(fakeDavClient.PutPath).To(BeEmpty())
+		})
+
+		It("downloads and uploads blob if server-side copy is not supported", func() {
+			fakeDavClient.CopyErr = ErrCopyNotSupported
+			fakeDavClient.GetContents = ioutil.NopCloser(strings.NewReader("fake-content"))
+
+			fakeFile := fakesys.NewFakeFile("fake-temp-path", fs)
+			fs.ReturnTempFile = fakeFile
+			fs.RegisterOpenFile("fake-temp-path", fakeFile)
+
+			blobID, err := blobstore.Copy("fake-blob-id")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(blobID).To(Equal("fake-new-blob-id"))
+
+			Expect(fakeDavClient.GetPath).To(Equal("fake-blob-id"))
+			Expect(fakeDavClient.PutPath).To(Equal("fake-new-blob-id"))
+			Expect(fakeDavClient.PutContents).To(Equal("fake-content"))
+
+			Expect(fs.FileExists("fake-temp-path")).To(BeFalse())
+		})
+
+		It("keeps content type of downloaded blob when uploading it again", func() {
+			fakeDavClient.CopyErr = ErrCopyNotSupported
+			fakeDavClient.GetContents = ioutil.NopCloser(strings.NewReader("fake-content"))
+			fakeDavClient.GetContentType = "text/plain"
+
+			fakeFile := fakesys.NewFakeFile("fake-temp-path", fs)
+			fs.ReturnTempFile = fakeFile
+			fs.RegisterOpenFile("fake-temp-path", fakeFile)
+
+			_, err := blobstore.Copy("fake-blob-id")
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(fakeDavClient.PutContentType).To(Equal("text/plain"))
+		})
+
+		It("returns an error if copying blob fails", func() {
+			fakeDavClient.CopyErr = errors.New("fake-copy-error")
+
+			_, err := blobstore.Copy("fake-blob-id")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-copy-error"))
+
+			Expect(fakeDavClient.GetPath).To(BeEmpty())
+		})
+
+		It("returns an error if downloading blob fails", func() {
+			fakeDavClient.CopyErr = ErrCopyNotSupported
+			fakeDavClient.GetErr = errors.New("fake-get-error")
+			fs.ReturnTempFile = fakesys.NewFakeFile("fake-temp-path", fs)
+
+			_, err := blobstore.Copy("fake-blob-id")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-get-error"))
+
+			Expect(fakeDavClient.PutPath).To(BeEmpty())
+		})
 	})
 })
+
+// failingReader fails first read with err and returns EOF afterwards
+type failingReader struct {
+	err    error
+	failed bool
+}
+
+func (r *failingReader) Read([]byte) (int, error) {
+	if r.failed {
+		return 0, io.EOF
+	}
+
+	r.failed = true
+
+	return 0, r.err
+}
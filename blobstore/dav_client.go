@@ -0,0 +1,265 @@
+package blobstore
+
+import (
+	"crypto/sha1"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+
+	boshdavcli "github.com/cloudfoundry/bosh-davcli/client"
+	boshdavcliconf "github.com/cloudfoundry/bosh-davcli/config"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshhttp "github.com/cloudfoundry/bosh-utils/http"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+)
+
+// DavClient extends bosh-davcli client with operations it does not provide
+type DavClient interface {
+	boshdavcli.Client
+
+	// GetWithInfo is like Get but also returns content length and type
+	GetWithInfo(path string) (io.ReadCloser, BlobInfo, error)
+
+	// GetRange is like GetWithLength but only asks for contents starting at offset;
+	// returned bool is false if server ignored range and returned full contents
+	GetRange(path string, offset int64) (io.ReadCloser, int64, bool, error)
+
+	// PutWithContentType is like Put but also sets Content-Type of stored blob
+	PutWithContentType(path string, content io.ReadCloser, contentLength int64, contentType string) error
+
+	Exists(path string) (bool, error)
+	Delete(path string) error
+
+	// Copy returns ErrCopyNotSupported if dav server does not implement COPY method
+	Copy(srcPath, dstPath string) error
+}
+
+// BlobInfo describes blob contents returned by the dav server
+type BlobInfo struct {
+	// ContentLength is -1 if unknown
+	ContentLength int64
+
+	// ContentType is empty if server did not specify it
+	ContentType string
+}
+
+var ErrCopyNotSupported = errors.New("Dav server does not support copying blobs")
+
+type davClient struct {
+	boshdavcli.Client
+
+	config     boshdavcliconf.Config
+	httpClient boshhttp.Client
+}
+
+func NewDavClient(config boshdavcliconf.Config, httpClient boshhttp.Client, logger boshlog.Logger) DavClient {
+	return davClient{
+		Client: boshdavcli.NewClient(config, httpClient, logger),
+
+		config:     config,
+		httpClient: httpClient,
+	}
+}
+
+func (c davClient) GetWithInfo(path string) (io.ReadCloser, BlobInfo, error) {
+	req, err := c.createReq("GET", path, nil)
+	if err != nil {
+		return nil, BlobInfo{}, bosherr.WrapErrorf(err, "Building request for dav blob %s", path)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, BlobInfo{}, bosherr.WrapErrorf(err, "Getting dav blob %s", path)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, BlobInfo{}, bosherr.Errorf("Getting dav blob %s: Wrong response code: %d", path, resp.StatusCode)
+	}
+
+	info := BlobInfo{
+		ContentLength: resp.ContentLength,
+		ContentType:   resp.Header.Get("Content-Type"),
+	}
+
+	return resp.Body, info, nil
+}
+
+func (c davClient) GetRange(path string, offset int64) (io.ReadCloser, int64, bool, error) {
+	req, err := c.createReq("GET", path, nil)
+	if err != nil {
+		return nil, 0, false, bosherr.WrapErrorf(err, "Building request for dav blob %s", path)
+	}
+
+	req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, 0, false, bosherr.WrapErrorf(err, "Getting dav blob %s", path)
+	}
+
+	switch resp.StatusCode {
+	case http.StatusPartialContent:
+		return resp.Body, resp.ContentLength, true, nil
+	case http.StatusOK:
+		return resp.Body, resp.ContentLength, false, nil
+	default:
+		resp.Body.Close()
+		return nil, 0, false, bosherr.Errorf("Getting dav blob %s: Wrong response code: %d", path, resp.StatusCode)
+	}
+}
+
+func (c davClient) PutWithContentType(path string, content io.ReadCloser, contentLength int64, contentType string) error {
+	defer content.Close()
+
+	req, err := c.createReq("PUT", path, content)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Building request for dav blob %s", path)
+	}
+
+	req.ContentLength = contentLength
+	req.Header.Set("Content-Type", contentType)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Putting dav blob %s", path)
+	}
+
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusCreated, http.StatusNoContent:
+		return nil
+	default:
+		// Same error format as bosh-davcli's Put so that callers can classify errors
+		return bosherr.Errorf("Putting dav blob %s: Wrong response code: %d; body: %s",
+			path, resp.StatusCode, readAndTruncateBody(resp))
+	}
+}
+
+func (c davClient) Exists(path string) (bool, error) {
+	req, err := c.createReq("HEAD", path, nil)
+	if err != nil {
+		return false, bosherr.WrapErrorf(err, "Building request for dav blob %s", path)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return false, bosherr.WrapErrorf(err, "Checking dav blob %s", path)
+	}
+
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return true, nil
+	case http.StatusNotFound:
+		return false, nil
+	default:
+		return false, bosherr.Errorf("Checking dav blob %s: Wrong response code: %d", path, resp.StatusCode)
+	}
+}
+
+func (c davClient) Delete(path string) error {
+	req, err := c.createReq("DELETE", path, nil)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Building request for dav blob %s", path)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Deleting dav blob %s", path)
+	}
+
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusAccepted, http.StatusNoContent, http.StatusNotFound:
+		return nil
+	default:
+		return bosherr.Errorf("Deleting dav blob %s: Wrong response code: %d", path, resp.StatusCode)
+	}
+}
+
+func (c davClient) Copy(srcPath, dstPath string) error {
+	req, err := c.createReq("COPY", srcPath, nil)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Building request for dav blob %s", srcPath)
+	}
+
+	dstURL, err := c.blobURL(dstPath)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Building URL for dav blob %s", dstPath)
+	}
+
+	req.Header.Set("Destination", dstURL.String())
+	req.Header.Set("Overwrite", "F")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Copying dav blob %s to %s", srcPath, dstPath)
+	}
+
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusCreated, http.StatusNoContent:
+		return nil
+	case http.StatusMethodNotAllowed, http.StatusNotImplemented:
+		return ErrCopyNotSupported
+	default:
+		return bosherr.Errorf("Copying dav blob %s to %s: Wrong response code: %d", srcPath, dstPath, resp.StatusCode)
+	}
+}
+
+func (c davClient) createReq(method, blobID string, body io.Reader) (*http.Request, error) {
+	blobURL, err := c.blobURL(blobID)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest(method, blobURL.String(), body)
+	if err != nil {
+		return nil, err
+	}
+
+	req.SetBasicAuth(c.config.User, c.config.Password)
+
+	return req, nil
+}
+
+// blobURL builds blob URL the same way bosh-davcli does
+// (blobs are placed into directories named after first byte of blob ID's SHA1)
+func (c davClient) blobURL(blobID string) (*url.URL, error) {
+	blobURL, err := url.Parse(c.config.Endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	digester := sha1.New()
+	digester.Write([]byte(blobID))
+	blobPrefix := fmt.Sprintf("%02x", digester.Sum(nil)[0])
+
+	newPath := path.Join(blobURL.Path, blobPrefix, blobID)
+	if !strings.HasPrefix(newPath, "/") {
+		newPath = "/" + newPath
+	}
+
+	blobURL.Path = newPath
+
+	return blobURL, nil
+}
+
+func readAndTruncateBody(resp *http.Response) string {
+	body, err := ioutil.ReadAll(io.LimitReader(resp.Body, 1024))
+	if err != nil {
+		return ""
+	}
+
+	return string(body)
+}
@@ -0,0 +1,239 @@
+package blobstore_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+
+	. "github.com/cloudfoundry/bosh-cli/blobstore"
+	boshdavcliconf "github.com/cloudfoundry/bosh-davcli/config"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+)
+
+var _ = Describe("DavClient", func() {
+	var (
+		server    *ghttp.Server
+		davClient DavClient
+	)
+
+	BeforeEach(func() {
+		server = ghttp.NewServer()
+
+		config := boshdavcliconf.Config{
+			Endpoint: server.URL() + "/blobs",
+			User:     "fake-user",
+			Password: "fake-password",
+		}
+
+		davClient = NewDavClient(config, http.DefaultClient, boshlog.NewLogger(boshlog.LevelNone))
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	Describe("GetWithInfo", func() {
+		It("returns blob contents, content length and type", func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/blobs/80/fake-blob-id"),
+					ghttp.VerifyBasicAuth("fake-user", "fake-password"),
+					ghttp.RespondWith(http.StatusOK, "fake-content", http.Header{"Content-Type": []string{"text/plain"}}),
+				),
+			)
+
+			content, info, err := davClient.GetWithInfo("fake-blob-id")
+			Expect(err).ToNot(HaveOccurred())
+			defer content.Close()
+
+			Expect(info).To(Equal(BlobInfo{ContentLength: 12, ContentType: "text/plain"}))
+			Expect(ioutil.ReadAll(content)).To(Equal([]byte("fake-content")))
+		})
+
+		It("returns an error for non-200 response codes", func() {
+			server.AppendHandlers(ghttp.RespondWith(http.StatusServiceUnavailable, nil))
+
+			_, _, err := davClient.GetWithInfo("fake-blob-id")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("Getting dav blob fake-blob-id: Wrong response code: 503"))
+		})
+	})
+
+	Describe("PutWithContentType", func() {
+		It("uploads blob contents with given content type", func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("PUT", "/blobs/80/fake-blob-id"),
+					ghttp.VerifyBasicAuth("fake-user", "fake-password"),
+					ghttp.VerifyContentType("text/plain"),
+					ghttp.VerifyBody([]byte("fake-content")),
+					ghttp.RespondWith(http.StatusCreated, nil),
+				),
+			)
+
+			content := ioutil.NopCloser(strings.NewReader("fake-content"))
+
+			err := davClient.PutWithContentType("fake-blob-id", content, 12, "text/plain")
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("returns an error including response body for other response codes", func() {
+			server.AppendHandlers(ghttp.RespondWith(http.StatusServiceUnavailable, "fake-body"))
+
+			content := ioutil.NopCloser(strings.NewReader("fake-content"))
+
+			err := davClient.PutWithContentType("fake-blob-id", content, 12, "text/plain")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("Putting dav blob fake-blob-id: Wrong response code: 503; body: fake-body"))
+		})
+	})
+
+	Describe("GetRange", func() {
+		It("returns partial blob contents starting at offset", func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/blobs/80/fake-blob-id"),
+					ghttp.VerifyBasicAuth("fake-user", "fake-password"),
+					ghttp.VerifyHeader(http.Header{"Range": []string{"bytes=5-"}}),
+					ghttp.RespondWith(http.StatusPartialContent, "content"),
+				),
+			)
+
+			content, length, partial, err := davClient.GetRange("fake-blob-id", 5)
+			Expect(err).ToNot(HaveOccurred())
+			defer content.Close()
+
+			Expect(length).To(Equal(int64(7)))
+			Expect(partial).To(BeTrue())
+			Expect(ioutil.ReadAll(content)).To(Equal([]byte("content")))
+		})
+
+		It("returns full blob contents if server does not support ranges", func() {
+			server.AppendHandlers(ghttp.RespondWith(http.StatusOK, "fake-content"))
+
+			content, length, partial, err := davClient.GetRange("fake-blob-id", 5)
+			Expect(err).ToNot(HaveOccurred())
+			defer content.Close()
+
+			Expect(length).To(Equal(int64(12)))
+			Expect(partial).To(BeFalse())
+			Expect(ioutil.ReadAll(content)).To(Equal([]byte("fake-content")))
+		})
+
+		It("returns an error for other response codes", func() {
+			server.AppendHandlers(ghttp.RespondWith(http.StatusRequestedRangeNotSatisfiable, nil))
+
+			_, _, _, err := davClient.GetRange("fake-blob-id", 5)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("Getting dav blob fake-blob-id: Wrong response code: 416"))
+		})
+	})
+
+	Describe("Exists", func() {
+		It("returns true if blob exists", func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					// 'fake-blob-id' is placed into '80' directory by its SHA1
+					ghttp.VerifyRequest("HEAD", "/blobs/80/fake-blob-id"),
+					ghttp.VerifyBasicAuth("fake-user", "fake-password"),
+					ghttp.RespondWith(http.StatusOK, nil),
+				),
+			)
+
+			exists, err := davClient.Exists("fake-blob-id")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(exists).To(BeTrue())
+		})
+
+		It("returns false if blob is not found", func() {
+			server.AppendHandlers(ghttp.RespondWith(http.StatusNotFound, nil))
+
+			exists, err := davClient.Exists("fake-blob-id")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(exists).To(BeFalse())
+		})
+
+		It("returns an error for other response codes", func() {
+			server.AppendHandlers(ghttp.RespondWith(http.StatusInternalServerError, nil))
+
+			_, err := davClient.Exists("fake-blob-id")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Wrong response code: 500"))
+		})
+	})
+
+	Describe("Delete", func() {
+		It("deletes blob", func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("DELETE", "/blobs/80/fake-blob-id"),
+					ghttp.VerifyBasicAuth("fake-user", "fake-password"),
+					ghttp.RespondWith(http.StatusNoContent, nil),
+				),
+			)
+
+			err := davClient.Delete("fake-blob-id")
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("does not return an error if blob is already absent", func() {
+			server.AppendHandlers(ghttp.RespondWith(http.StatusNotFound, nil))
+
+			err := davClient.Delete("fake-blob-id")
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("returns an error for other response codes", func() {
+			server.AppendHandlers(ghttp.RespondWith(http.StatusInternalServerError, nil))
+
+			err := davClient.Delete("fake-blob-id")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Wrong response code: 500"))
+		})
+	})
+
+	Describe("Copy", func() {
+		It("copies blob to destination blob ID", func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("COPY", "/blobs/80/fake-blob-id"),
+					ghttp.VerifyBasicAuth("fake-user", "fake-password"),
+					ghttp.VerifyHeader(http.Header{
+						"Destination": []string{server.URL() + "/blobs/1c/fake-new-blob-id"},
+						"Overwrite":   []string{"F"},
+					}),
+					ghttp.RespondWith(http.StatusCreated, nil),
+				),
+			)
+
+			err := davClient.Copy("fake-blob-id", "fake-new-blob-id")
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("returns copy not supported error if server does not allow COPY method", func() {
+			server.AppendHandlers(ghttp.RespondWith(http.StatusMethodNotAllowed, nil))
+
+			err := davClient.Copy("fake-blob-id", "fake-new-blob-id")
+			Expect(err).To(Equal(ErrCopyNotSupported))
+		})
+
+		It("returns copy not supported error if server does not implement COPY method", func() {
+			server.AppendHandlers(ghttp.RespondWith(http.StatusNotImplemented, nil))
+
+			err := davClient.Copy("fake-blob-id", "fake-new-blob-id")
+			Expect(err).To(Equal(ErrCopyNotSupported))
+		})
+
+		It("returns an error for other response codes", func() {
+			server.AppendHandlers(ghttp.RespondWith(http.StatusNotFound, nil))
+
+			err := davClient.Copy("fake-blob-id", "fake-new-blob-id")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("Copying dav blob fake-blob-id to fake-new-blob-id: Wrong response code: 404"))
+		})
+	})
+})
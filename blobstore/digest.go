@@ -0,0 +1,42 @@
+package blobstore
+
+import (
+	"crypto/sha1"
+	"crypto/sha256"
+	"fmt"
+	"hash"
+
+	boshcrypto "github.com/cloudfoundry/bosh-utils/crypto"
+)
+
+// multipleDigestWriter calculates SHA1 and SHA256 digests
+// of contents written to it in a single pass
+type multipleDigestWriter struct {
+	sha1   hash.Hash
+	sha256 hash.Hash
+}
+
+func newMultipleDigestWriter() *multipleDigestWriter {
+	return &multipleDigestWriter{
+		sha1:   sha1.New(),
+		sha256: sha256.New(),
+	}
+}
+
+func (w *multipleDigestWriter) Write(p []byte) (int, error) {
+	w.sha1.Write(p)
+	w.sha256.Write(p)
+	return len(p), nil
+}
+
+func (w *multipleDigestWriter) Reset() {
+	w.sha1.Reset()
+	w.sha256.Reset()
+}
+
+func (w *multipleDigestWriter) Digest() boshcrypto.MultipleDigest {
+	return boshcrypto.MustNewMultipleDigest(
+		boshcrypto.NewDigest(boshcrypto.DigestAlgorithmSHA1, fmt.Sprintf("%x", w.sha1.Sum(nil))),
+		boshcrypto.NewDigest(boshcrypto.DigestAlgorithmSHA256, fmt.Sprintf("%x", w.sha256.Sum(nil))),
+	)
+}
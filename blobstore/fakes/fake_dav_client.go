@@ -0,0 +1,127 @@
+package fakes
+
+import (
+	"io"
+
+	fakeboshdavcli "github.com/cloudfoundry/bosh-davcli/client/fakes"
+
+	"github.com/cloudfoundry/bosh-cli/blobstore"
+)
+
+type FakeDavClient struct {
+	*fakeboshdavcli.FakeClient
+
+	// GetErrs and PutErrs are returned (one per call) before falling back to GetErr and PutErr
+	GetErrs      []error
+	GetCallCount int
+
+	GetContentLength int64
+	GetContentType   string
+	PutErrs          []error
+	PutCallCount     int
+	PutContentType   string
+
+	GetRangePath          string
+	GetRangeOffsets       []int64
+	GetRangeContents      io.ReadCloser
+	GetRangeContentLength int64
+	GetRangePartial       bool
+	GetRangeErr           error
+
+	ExistsPath   string
+	ExistsResult bool
+	ExistsErr    error
+
+	DeletePath string
+	DeleteErr  error
+
+	CopySrcPath string
+	CopyDstPath string
+	CopyErr     error
+}
+
+func NewFakeDavClient() *FakeDavClient {
+	return &FakeDavClient{FakeClient: fakeboshdavcli.NewFakeClient()}
+}
+
+func (c *FakeDavClient) Get(path string) (io.ReadCloser, error) {
+	c.GetCallCount++
+
+	if len(c.GetErrs) > 0 {
+		err := c.GetErrs[0]
+		c.GetErrs = c.GetErrs[1:]
+
+		if err != nil {
+			c.GetPath = path
+			return nil, err
+		}
+	}
+
+	return c.FakeClient.Get(path)
+}
+
+func (c *FakeDavClient) GetWithInfo(path string) (io.ReadCloser, blobstore.BlobInfo, error) {
+	content, err := c.Get(path)
+	if err != nil {
+		return nil, blobstore.BlobInfo{}, err
+	}
+
+	info := blobstore.BlobInfo{
+		ContentLength: c.GetContentLength,
+		ContentType:   c.GetContentType,
+	}
+
+	return content, info, nil
+}
+
+func (c *FakeDavClient) GetRange(path string, offset int64) (io.ReadCloser, int64, bool, error) {
+	c.GetRangePath = path
+	c.GetRangeOffsets = append(c.GetRangeOffsets, offset)
+
+	if c.GetRangeErr != nil {
+		return nil, 0, false, c.GetRangeErr
+	}
+
+	return c.GetRangeContents, c.GetRangeContentLength, c.GetRangePartial, nil
+}
+
+func (c *FakeDavClient) Put(path string, content io.ReadCloser, contentLength int64) error {
+	c.PutCallCount++
+
+	if len(c.PutErrs) > 0 {
+		err := c.PutErrs[0]
+		c.PutErrs = c.PutErrs[1:]
+
+		if err != nil {
+			c.PutPath = path
+			return err
+		}
+	}
+
+	return c.FakeClient.Put(path, content, contentLength)
+}
+
+func (c *FakeDavClient) PutWithContentType(path string, content io.ReadCloser, contentLength int64, contentType string) error {
+	c.PutContentType = contentType
+
+	return c.Put(path, content, contentLength)
+}
+
+func (c *FakeDavClient) Exists(path string) (bool, error) {
+	c.ExistsPath = path
+
+	return c.ExistsResult, c.ExistsErr
+}
+
+func (c *FakeDavClient) Delete(path string) error {
+	c.DeletePath = path
+
+	return c.DeleteErr
+}
+
+func (c *FakeDavClient) Copy(srcPath, dstPath string) error {
+	c.CopySrcPath = srcPath
+	c.CopyDstPath = dstPath
+
+	return c.CopyErr
+}
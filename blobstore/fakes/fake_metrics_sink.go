@@ -0,0 +1,21 @@
+package fakes
+
+type FakeMetricsSink struct {
+	Counters   map[string]int64
+	Histograms map[string][]float64
+}
+
+func NewFakeMetricsSink() *FakeMetricsSink {
+	return &FakeMetricsSink{
+		Counters:   map[string]int64{},
+		Histograms: map[string][]float64{},
+	}
+}
+
+func (s *FakeMetricsSink) IncrementCounter(name string, delta int64) {
+	s.Counters[name] += delta
+}
+
+func (s *FakeMetricsSink) ObserveHistogram(name string, value float64) {
+	s.Histograms[name] = append(s.Histograms[name], value)
+}
@@ -0,0 +1,265 @@
+package blobstore
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	boshcrypto "github.com/cloudfoundry/bosh-utils/crypto"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+	boshuuid "github.com/cloudfoundry/bosh-utils/uuid"
+)
+
+// fsContentTypeSuffix names files that keep content type next to blob contents
+const fsContentTypeSuffix = ".content-type"
+
+// fsBlobstore keeps blobs as files in a directory on the local file system;
+// it's useful when director and CLI share a file system (e.g. in development)
+type fsBlobstore struct {
+	dirPath       string
+	uuidGenerator boshuuid.Generator
+	fs            boshsys.FileSystem
+	logger        boshlog.Logger
+	logTag        string
+}
+
+func NewFSBlobstore(dirPath string, uuidGenerator boshuuid.Generator, fs boshsys.FileSystem, logger boshlog.Logger) Blobstore {
+	return &fsBlobstore{
+		dirPath:       dirPath,
+		uuidGenerator: uuidGenerator,
+		fs:            fs,
+		logger:        logger,
+		logTag:        "fsBlobstore",
+	}
+}
+
+func (b *fsBlobstore) Get(blobID string) (LocalBlob, error) {
+	err := b.checkExists(blobID)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := b.fs.TempFile(LocalBlobTempFilePrefix)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Creating temp file for blob")
+	}
+
+	destinationPath := file.Name()
+
+	err = file.Close()
+	if err != nil {
+		return nil, bosherr.WrapErrorf(err, "Closing new temp file '%s'", destinationPath)
+	}
+
+	b.logger.Debug(b.logTag, "Copying blob %s to %s", blobID, destinationPath)
+
+	err = b.fs.CopyFile(b.blobPath(blobID), destinationPath)
+	if err != nil {
+		return nil, bosherr.WrapErrorf(err, "Saving blob to %s", destinationPath)
+	}
+
+	return NewLocalBlob(destinationPath, b.contentType(blobID), b.fs, b.logger), nil
+}
+
+func (b *fsBlobstore) GetTo(blobID string, dst io.Writer) error {
+	err := b.checkExists(blobID)
+	if err != nil {
+		return err
+	}
+
+	file, err := b.fs.OpenFile(b.blobPath(blobID), os.O_RDONLY, 0)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Opening blob %s", blobID)
+	}
+
+	defer file.Close()
+
+	_, err = io.Copy(dst, file)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Writing blob %s", blobID)
+	}
+
+	return nil
+}
+
+func (b *fsBlobstore) GetWithDigest(blobID, destinationPath string, expectedDigest boshcrypto.Digest) error {
+	err := b.checkExists(blobID)
+	if err != nil {
+		return err
+	}
+
+	file, err := b.fs.OpenFile(b.blobPath(blobID), os.O_RDONLY, 0)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Opening blob %s", blobID)
+	}
+
+	actualDigest, err := expectedDigest.Algorithm().CreateDigest(file)
+	file.Close()
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Calculating digest of blob %s", blobID)
+	}
+
+	if actualDigest.String() != expectedDigest.String() {
+		return bosherr.Errorf("Expected blob %s to have digest '%s' but was '%s'",
+			blobID, expectedDigest.String(), actualDigest.String())
+	}
+
+	err = b.fs.CopyFile(b.blobPath(blobID), destinationPath)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Saving blob to %s", destinationPath)
+	}
+
+	return nil
+}
+
+func (b *fsBlobstore) Add(sourcePath, contentType string) (string, error) {
+	blobID, _, err := b.AddWithDigest(sourcePath, contentType)
+	return blobID, err
+}
+
+func (b *fsBlobstore) AddWithDigest(sourcePath, contentType string) (string, boshcrypto.MultipleDigest, error) {
+	file, err := b.fs.OpenFile(sourcePath, os.O_RDONLY, 0)
+	if err != nil {
+		return "", boshcrypto.MultipleDigest{}, bosherr.WrapErrorf(err, "Reading file %s", sourcePath)
+	}
+
+	digestWriter := newMultipleDigestWriter()
+
+	_, err = io.Copy(digestWriter, file)
+	file.Close()
+	if err != nil {
+		return "", boshcrypto.MultipleDigest{}, bosherr.WrapErrorf(err, "Reading file %s", sourcePath)
+	}
+
+	blobID, err := b.uuidGenerator.Generate()
+	if err != nil {
+		return "", boshcrypto.MultipleDigest{}, bosherr.WrapError(err, "Generating Blob ID")
+	}
+
+	b.logger.Debug(b.logTag, "Adding blob %s from %s", blobID, sourcePath)
+
+	if len(contentType) == 0 {
+		contentType = DefaultContentType
+	}
+
+	err = b.store(blobID, sourcePath, contentType)
+	if err != nil {
+		return "", boshcrypto.MultipleDigest{}, err
+	}
+
+	return blobID, digestWriter.Digest(), nil
+}
+
+func (b *fsBlobstore) Exists(blobID string) (bool, error) {
+	return b.fs.FileExists(b.blobPath(blobID)), nil
+}
+
+// Delete removes blob from the blobstore; deleting already absent blob is not an error
+func (b *fsBlobstore) Delete(blobID string) error {
+	b.logger.Debug(b.logTag, "Deleting blob %s", blobID)
+
+	err := b.fs.RemoveAll(b.blobPath(blobID))
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Deleting blob %s", blobID)
+	}
+
+	err = b.fs.RemoveAll(b.blobPath(blobID) + fsContentTypeSuffix)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Deleting content type of blob %s", blobID)
+	}
+
+	return nil
+}
+
+func (b *fsBlobstore) Copy(srcBlobID string) (string, error) {
+	err := b.checkExists(srcBlobID)
+	if err != nil {
+		return "", err
+	}
+
+	dstBlobID, err := b.uuidGenerator.Generate()
+	if err != nil {
+		return "", bosherr.WrapError(err, "Generating Blob ID")
+	}
+
+	b.logger.Debug(b.logTag, "Copying blob %s to %s", srcBlobID, dstBlobID)
+
+	err = b.store(dstBlobID, b.blobPath(srcBlobID), b.contentType(srcBlobID))
+	if err != nil {
+		return "", err
+	}
+
+	return dstBlobID, nil
+}
+
+// Sign is not supported since blobs are only accessible via the file system
+func (b *fsBlobstore) Sign(blobID string, action string, expiry time.Duration) (string, error) {
+	return "", ErrSigningNotSupported
+}
+
+// List returns IDs of all stored blobs in sorted order
+func (b *fsBlobstore) List() ([]string, error) {
+	paths, err := b.fs.Glob(filepath.Join(b.dirPath, "*"))
+	if err != nil {
+		return nil, bosherr.WrapErrorf(err, "Listing blobs in '%s'", b.dirPath)
+	}
+
+	blobIDs := []string{}
+
+	for _, path := range paths {
+		if !strings.HasSuffix(path, fsContentTypeSuffix) {
+			blobIDs = append(blobIDs, filepath.Base(path))
+		}
+	}
+
+	sort.Strings(blobIDs)
+
+	return blobIDs, nil
+}
+
+func (b *fsBlobstore) store(blobID, sourcePath, contentType string) error {
+	err := b.fs.MkdirAll(b.dirPath, os.FileMode(0700))
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Creating blobstore directory '%s'", b.dirPath)
+	}
+
+	// Content type is written first so that blob is never found without it
+	err = b.fs.WriteFileString(b.blobPath(blobID)+fsContentTypeSuffix, contentType)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Saving content type of blob %s", blobID)
+	}
+
+	err = b.fs.CopyFile(sourcePath, b.blobPath(blobID))
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Saving blob %s", blobID)
+	}
+
+	return nil
+}
+
+func (b *fsBlobstore) checkExists(blobID string) error {
+	if !b.fs.FileExists(b.blobPath(blobID)) {
+		return bosherr.Errorf("Getting blob %s from blobstore: Blob not found", blobID)
+	}
+
+	return nil
+}
+
+// contentType falls back to default content type for blobs placed into directory directly
+func (b *fsBlobstore) contentType(blobID string) string {
+	contentType, err := b.fs.ReadFileString(b.blobPath(blobID) + fsContentTypeSuffix)
+	if err != nil || len(contentType) == 0 {
+		return DefaultContentType
+	}
+
+	return contentType
+}
+
+func (b *fsBlobstore) blobPath(blobID string) string {
+	return filepath.Join(b.dirPath, blobID)
+}
@@ -0,0 +1,241 @@
+package blobstore_test
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/cloudfoundry/bosh-cli/blobstore"
+	boshcrypto "github.com/cloudfoundry/bosh-utils/crypto"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+	fakeuuid "github.com/cloudfoundry/bosh-utils/uuid/fakes"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("fsBlobstore", func() {
+	var (
+		fakeUUIDGenerator *fakeuuid.FakeGenerator
+		fs                boshsys.FileSystem
+		tmpDir            string
+		dirPath           string
+		sourcePath        string
+		blobstore         Blobstore
+	)
+
+	BeforeEach(func() {
+		fakeUUIDGenerator = fakeuuid.NewFakeGenerator()
+		fakeUUIDGenerator.GeneratedUUID = "fake-blob-id"
+
+		logger := boshlog.NewLogger(boshlog.LevelNone)
+		fs = boshsys.NewOsFileSystem(logger)
+
+		var err error
+
+		tmpDir, err = ioutil.TempDir("", "fs-blobstore-test")
+		Expect(err).ToNot(HaveOccurred())
+
+		dirPath = filepath.Join(tmpDir, "blobs")
+
+		sourcePath = filepath.Join(tmpDir, "source")
+		Expect(fs.WriteFileString(sourcePath, "fake-contents")).To(Succeed())
+
+		blobstore = NewFSBlobstore(dirPath, fakeUUIDGenerator, fs, logger)
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(tmpDir)
+	})
+
+	Describe("Add", func() {
+		It("stores file contents under generated blob ID in the directory", func() {
+			blobID, err := blobstore.Add(sourcePath, "")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(blobID).To(Equal("fake-blob-id"))
+
+			Expect(fs.ReadFileString(filepath.Join(dirPath, "fake-blob-id"))).To(Equal("fake-contents"))
+			Expect(blobstore.Exists("fake-blob-id")).To(BeTrue())
+		})
+
+		It("returns error if file cannot be read", func() {
+			_, err := blobstore.Add("/missing-path", "")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Reading file /missing-path"))
+		})
+
+		It("returns error if generating blob ID fails", func() {
+			fakeUUIDGenerator.GenerateError = errors.New("fake-generate-err")
+
+			_, err := blobstore.Add(sourcePath, "")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-generate-err"))
+		})
+	})
+
+	Describe("AddWithDigest", func() {
+		It("returns digest of file contents", func() {
+			_, digest, err := blobstore.AddWithDigest(sourcePath, "")
+			Expect(err).ToNot(HaveOccurred())
+
+			sha1Digest, err := digest.DigestFor(boshcrypto.DigestAlgorithmSHA1)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(sha1Digest.String()).To(Equal("978ad524a02039f261773fe93d94973ae7de6470"))
+		})
+	})
+
+	Describe("Get", func() {
+		It("returns local blob with stored contents and content type", func() {
+			_, err := blobstore.Add(sourcePath, "application/x-gzip")
+			Expect(err).ToNot(HaveOccurred())
+
+			localBlob, err := blobstore.Get("fake-blob-id")
+			Expect(err).ToNot(HaveOccurred())
+
+			defer localBlob.Delete()
+
+			Expect(fs.ReadFileString(localBlob.Path())).To(Equal("fake-contents"))
+			Expect(localBlob.ContentType()).To(Equal("application/x-gzip"))
+		})
+
+		It("returns default content type for blobs placed into directory directly", func() {
+			Expect(fs.MkdirAll(dirPath, os.FileMode(0700))).To(Succeed())
+			Expect(fs.WriteFileString(filepath.Join(dirPath, "placed-blob-id"), "placed-contents")).To(Succeed())
+
+			localBlob, err := blobstore.Get("placed-blob-id")
+			Expect(err).ToNot(HaveOccurred())
+
+			defer localBlob.Delete()
+
+			Expect(localBlob.ContentType()).To(Equal(DefaultContentType))
+		})
+
+		It("returns error if blob is not found", func() {
+			_, err := blobstore.Get("missing-blob-id")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Blob not found"))
+		})
+	})
+
+	Describe("GetTo", func() {
+		It("writes stored contents into writer", func() {
+			_, err := blobstore.Add(sourcePath, "")
+			Expect(err).ToNot(HaveOccurred())
+
+			var buf bytes.Buffer
+
+			err = blobstore.GetTo("fake-blob-id", &buf)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(buf.String()).To(Equal("fake-contents"))
+		})
+
+		It("returns error if blob is not found", func() {
+			err := blobstore.GetTo("missing-blob-id", &bytes.Buffer{})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Blob not found"))
+		})
+	})
+
+	Describe("GetWithDigest", func() {
+		var destinationPath string
+
+		BeforeEach(func() {
+			destinationPath = filepath.Join(tmpDir, "destination")
+
+			_, err := blobstore.Add(sourcePath, "")
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("saves blob to destination path if digest matches", func() {
+			digest := boshcrypto.NewDigest(boshcrypto.DigestAlgorithmSHA1, "978ad524a02039f261773fe93d94973ae7de6470")
+
+			err := blobstore.GetWithDigest("fake-blob-id", destinationPath, digest)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(fs.ReadFileString(destinationPath)).To(Equal("fake-contents"))
+		})
+
+		It("returns error if digest does not match", func() {
+			digest := boshcrypto.NewDigest(boshcrypto.DigestAlgorithmSHA1, "wrong-sha1")
+
+			err := blobstore.GetWithDigest("fake-blob-id", destinationPath, digest)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Expected blob fake-blob-id to have digest 'wrong-sha1'"))
+
+			Expect(fs.FileExists(destinationPath)).To(BeFalse())
+		})
+	})
+
+	Describe("Copy", func() {
+		It("stores copy of blob under new blob ID", func() {
+			_, err := blobstore.Add(sourcePath, "application/x-gzip")
+			Expect(err).ToNot(HaveOccurred())
+
+			fakeUUIDGenerator.GeneratedUUID = "fake-copy-blob-id"
+
+			dstBlobID, err := blobstore.Copy("fake-blob-id")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(dstBlobID).To(Equal("fake-copy-blob-id"))
+
+			localBlob, err := blobstore.Get("fake-copy-blob-id")
+			Expect(err).ToNot(HaveOccurred())
+
+			defer localBlob.Delete()
+
+			Expect(fs.ReadFileString(localBlob.Path())).To(Equal("fake-contents"))
+			Expect(localBlob.ContentType()).To(Equal("application/x-gzip"))
+		})
+
+		It("returns error if source blob is not found", func() {
+			_, err := blobstore.Copy("missing-blob-id")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Blob not found"))
+		})
+	})
+
+	Describe("Delete", func() {
+		It("removes blob and its content type", func() {
+			_, err := blobstore.Add(sourcePath, "")
+			Expect(err).ToNot(HaveOccurred())
+
+			err = blobstore.Delete("fake-blob-id")
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(blobstore.Exists("fake-blob-id")).To(BeFalse())
+			Expect(blobstore.List()).To(BeEmpty())
+			Expect(fs.FileExists(filepath.Join(dirPath, "fake-blob-id.content-type"))).To(BeFalse())
+		})
+
+		It("does not return error if blob is already absent", func() {
+			err := blobstore.Delete("missing-blob-id")
+			Expect(err).ToNot(HaveOccurred())
+		})
+	})
+
+	Describe("List", func() {
+		It("returns sorted IDs of stored blobs", func() {
+			for _, blobID := range []string{"blob-b", "blob-a"} {
+				fakeUUIDGenerator.GeneratedUUID = blobID
+
+				_, err := blobstore.Add(sourcePath, "")
+				Expect(err).ToNot(HaveOccurred())
+			}
+
+			Expect(blobstore.List()).To(Equal([]string{"blob-a", "blob-b"}))
+		})
+
+		It("returns empty list if directory does not exist yet", func() {
+			Expect(blobstore.List()).To(BeEmpty())
+		})
+	})
+
+	Describe("Sign", func() {
+		It("returns not supported error", func() {
+			_, err := blobstore.Sign("fake-blob-id", "get", time.Minute)
+			Expect(err).To(Equal(ErrSigningNotSupported))
+		})
+	})
+})
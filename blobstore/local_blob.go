@@ -12,6 +12,8 @@ import (
 type LocalBlob interface {
 	// Path returns the path to the local copy of the blob
 	Path() string
+	// ContentType returns content type reported by the blobstore (empty if unknown)
+	ContentType() string
 	// Delete removes the local copy of the blob (does not effect the blobstore)
 	Delete() error
 	// DeleteSilently removes the local copy of the blob (does not effect the blobstore), logging instead of returning an error.
@@ -19,18 +21,20 @@ type LocalBlob interface {
 }
 
 type localBlob struct {
-	path   string
-	fs     boshsys.FileSystem
-	logger boshlog.Logger
-	logTag string
+	path        string
+	contentType string
+	fs          boshsys.FileSystem
+	logger      boshlog.Logger
+	logTag      string
 }
 
-func NewLocalBlob(path string, fs boshsys.FileSystem, logger boshlog.Logger) LocalBlob {
+func NewLocalBlob(path, contentType string, fs boshsys.FileSystem, logger boshlog.Logger) LocalBlob {
 	return &localBlob{
-		path:   path,
-		fs:     fs,
-		logger: logger,
-		logTag: "localBlob",
+		path:        path,
+		contentType: contentType,
+		fs:          fs,
+		logger:      logger,
+		logTag:      "localBlob",
 	}
 }
 
@@ -38,6 +42,10 @@ func (b *localBlob) Path() string {
 	return b.path
 }
 
+func (b *localBlob) ContentType() string {
+	return b.contentType
+}
+
 func (b *localBlob) Delete() error {
 	err := b.fs.RemoveAll(b.path)
 	if err != nil {
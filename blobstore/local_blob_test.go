@@ -34,7 +34,7 @@ var _ = Describe("LocalBlobstore", func() {
 
 		localBlobPath = "fake-local-blob-path"
 
-		localBlob = NewLocalBlob(localBlobPath, fs, logger)
+		localBlob = NewLocalBlob(localBlobPath, "fake-content-type", fs, logger)
 	})
 
 	Describe("Path", func() {
@@ -43,6 +43,12 @@ var _ = Describe("LocalBlobstore", func() {
 		})
 	})
 
+	Describe("ContentType", func() {
+		It("returns content type reported by the blobstore", func() {
+			Expect(localBlob.ContentType()).To(Equal("fake-content-type"))
+		})
+	})
+
 	Describe("Delete", func() {
 		It("deletes the local blob from the file system", func() {
 			err := fs.WriteFileString(localBlobPath, "fake-local-blob-content")
@@ -0,0 +1,218 @@
+package blobstore
+
+import (
+	"bytes"
+	"io"
+	"sort"
+	"sync"
+	"time"
+
+	boshcrypto "github.com/cloudfoundry/bosh-utils/crypto"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+	boshuuid "github.com/cloudfoundry/bosh-utils/uuid"
+)
+
+// memoryBlobstore keeps blobs in memory; it is useful for tests
+// and when blobs do not need to outlive the current process
+type memoryBlobstore struct {
+	uuidGenerator boshuuid.Generator
+	fs            boshsys.FileSystem
+	logger        boshlog.Logger
+	logTag        string
+
+	blobs     map[string]memoryBlob
+	blobsLock sync.RWMutex
+}
+
+type memoryBlob struct {
+	contents    []byte
+	contentType string
+}
+
+func NewMemoryBlobstore(uuidGenerator boshuuid.Generator, fs boshsys.FileSystem, logger boshlog.Logger) Blobstore {
+	return &memoryBlobstore{
+		uuidGenerator: uuidGenerator,
+		fs:            fs,
+		logger:        logger,
+		logTag:        "memoryBlobstore",
+		blobs:         map[string]memoryBlob{},
+	}
+}
+
+func (b *memoryBlobstore) Get(blobID string) (LocalBlob, error) {
+	blob, err := b.find(blobID)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := b.fs.TempFile(LocalBlobTempFilePrefix)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Creating temp file for blob")
+	}
+
+	destinationPath := file.Name()
+
+	err = file.Close()
+	if err != nil {
+		return nil, bosherr.WrapErrorf(err, "Closing new temp file '%s'", destinationPath)
+	}
+
+	b.logger.Debug(b.logTag, "Writing blob %s to %s", blobID, destinationPath)
+
+	err = b.fs.WriteFile(destinationPath, blob.contents)
+	if err != nil {
+		return nil, bosherr.WrapErrorf(err, "Saving blob to %s", destinationPath)
+	}
+
+	return NewLocalBlob(destinationPath, blob.contentType, b.fs, b.logger), nil
+}
+
+func (b *memoryBlobstore) GetTo(blobID string, dst io.Writer) error {
+	blob, err := b.find(blobID)
+	if err != nil {
+		return err
+	}
+
+	_, err = dst.Write(blob.contents)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Writing blob %s", blobID)
+	}
+
+	return nil
+}
+
+func (b *memoryBlobstore) GetWithDigest(blobID, destinationPath string, expectedDigest boshcrypto.Digest) error {
+	blob, err := b.find(blobID)
+	if err != nil {
+		return err
+	}
+
+	actualDigest, err := expectedDigest.Algorithm().CreateDigest(bytes.NewReader(blob.contents))
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Calculating digest of blob %s", blobID)
+	}
+
+	if actualDigest.String() != expectedDigest.String() {
+		return bosherr.Errorf("Expected blob %s to have digest '%s' but was '%s'",
+			blobID, expectedDigest.String(), actualDigest.String())
+	}
+
+	err = b.fs.WriteFile(destinationPath, blob.contents)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Saving blob to %s", destinationPath)
+	}
+
+	return nil
+}
+
+func (b *memoryBlobstore) Add(sourcePath, contentType string) (string, error) {
+	blobID, _, err := b.AddWithDigest(sourcePath, contentType)
+	return blobID, err
+}
+
+func (b *memoryBlobstore) AddWithDigest(sourcePath, contentType string) (string, boshcrypto.MultipleDigest, error) {
+	contents, err := b.fs.ReadFile(sourcePath)
+	if err != nil {
+		return "", boshcrypto.MultipleDigest{}, bosherr.WrapErrorf(err, "Reading file %s", sourcePath)
+	}
+
+	blobID, err := b.uuidGenerator.Generate()
+	if err != nil {
+		return "", boshcrypto.MultipleDigest{}, bosherr.WrapError(err, "Generating Blob ID")
+	}
+
+	b.logger.Debug(b.logTag, "Adding blob %s from %s", blobID, sourcePath)
+
+	if len(contentType) == 0 {
+		contentType = DefaultContentType
+	}
+
+	b.store(blobID, memoryBlob{contents: contents, contentType: contentType})
+
+	digestWriter := newMultipleDigestWriter()
+	digestWriter.Write(contents)
+
+	return blobID, digestWriter.Digest(), nil
+}
+
+func (b *memoryBlobstore) Exists(blobID string) (bool, error) {
+	b.blobsLock.RLock()
+	defer b.blobsLock.RUnlock()
+
+	_, found := b.blobs[blobID]
+
+	return found, nil
+}
+
+// Delete removes blob from the blobstore; deleting already absent blob is not an error
+func (b *memoryBlobstore) Delete(blobID string) error {
+	b.blobsLock.Lock()
+	defer b.blobsLock.Unlock()
+
+	delete(b.blobs, blobID)
+
+	return nil
+}
+
+func (b *memoryBlobstore) Copy(srcBlobID string) (string, error) {
+	blob, err := b.find(srcBlobID)
+	if err != nil {
+		return "", err
+	}
+
+	dstBlobID, err := b.uuidGenerator.Generate()
+	if err != nil {
+		return "", bosherr.WrapError(err, "Generating Blob ID")
+	}
+
+	b.logger.Debug(b.logTag, "Copying blob %s to %s", srcBlobID, dstBlobID)
+
+	b.store(dstBlobID, blob)
+
+	return dstBlobID, nil
+}
+
+// Sign is not supported since blobs are only accessible within the process
+func (b *memoryBlobstore) Sign(blobID string, action string, expiry time.Duration) (string, error) {
+	return "", ErrSigningNotSupported
+}
+
+// List returns IDs of all stored blobs in sorted order
+func (b *memoryBlobstore) List() ([]string, error) {
+	b.blobsLock.RLock()
+	defer b.blobsLock.RUnlock()
+
+	blobIDs := []string{}
+
+	for blobID := range b.blobs {
+		blobIDs = append(blobIDs, blobID)
+	}
+
+	sort.Strings(blobIDs)
+
+	return blobIDs, nil
+}
+
+func (b *memoryBlobstore) find(blobID string) (memoryBlob, error) {
+	b.blobsLock.RLock()
+	defer b.blobsLock.RUnlock()
+
+	blob, found := b.blobs[blobID]
+	if !found {
+		return memoryBlob{}, bosherr.Errorf("Getting blob %s from blobstore: Blob not found", blobID)
+	}
+
+	return blob, nil
+}
+
+func (b *memoryBlobstore) store(blobID string, blob memoryBlob) {
+	b.blobsLock.Lock()
+	defer b.blobsLock.Unlock()
+
+	// Keep a private copy so that callers cannot modify stored blob
+	blob.contents = append([]byte(nil), blob.contents...)
+
+	b.blobs[blobID] = blob
+}
@@ -0,0 +1,233 @@
+package blobstore_test
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"time"
+
+	. "github.com/cloudfoundry/bosh-cli/blobstore"
+	boshcrypto "github.com/cloudfoundry/bosh-utils/crypto"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+	fakeuuid "github.com/cloudfoundry/bosh-utils/uuid/fakes"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("memoryBlobstore", func() {
+	var (
+		fakeUUIDGenerator *fakeuuid.FakeGenerator
+		fs                *fakesys.FakeFileSystem
+		blobstore         Blobstore
+	)
+
+	BeforeEach(func() {
+		fakeUUIDGenerator = fakeuuid.NewFakeGenerator()
+		fakeUUIDGenerator.GeneratedUUID = "fake-blob-id"
+
+		fs = fakesys.NewFakeFileSystem()
+		fs.WriteFileString("/fake-source-path", "fake-contents")
+
+		logger := boshlog.NewLogger(boshlog.LevelNone)
+		blobstore = NewMemoryBlobstore(fakeUUIDGenerator, fs, logger)
+	})
+
+	Describe("Add", func() {
+		It("stores file contents under generated blob ID", func() {
+			blobID, err := blobstore.Add("/fake-source-path", "")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(blobID).To(Equal("fake-blob-id"))
+
+			Expect(blobstore.Exists("fake-blob-id")).To(BeTrue())
+		})
+
+		It("returns error if file cannot be read", func() {
+			_, err := blobstore.Add("/missing-path", "")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Reading file /missing-path"))
+		})
+
+		It("returns error if generating blob ID fails", func() {
+			fakeUUIDGenerator.GenerateError = errors.New("fake-generate-err")
+
+			_, err := blobstore.Add("/fake-source-path", "")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-generate-err"))
+		})
+	})
+
+	Describe("AddWithDigest", func() {
+		It("returns digest of file contents", func() {
+			sha1Digest, err := boshcrypto.DigestAlgorithmSHA1.CreateDigest(strings.NewReader("fake-contents"))
+			Expect(err).ToNot(HaveOccurred())
+
+			sha256Digest, err := boshcrypto.DigestAlgorithmSHA256.CreateDigest(strings.NewReader("fake-contents"))
+			Expect(err).ToNot(HaveOccurred())
+
+			_, digest, err := blobstore.AddWithDigest("/fake-source-path", "")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(digest).To(Equal(boshcrypto.MustNewMultipleDigest(sha1Digest, sha256Digest)))
+		})
+	})
+
+	Describe("Get", func() {
+		BeforeEach(func() {
+			fs.ReturnTempFile = fakesys.NewFakeFile("/fake-destination-path", fs)
+		})
+
+		It("returns local blob with content type specified when adding", func() {
+			_, err := blobstore.Add("/fake-source-path", "text/plain")
+			Expect(err).ToNot(HaveOccurred())
+
+			localBlob, err := blobstore.Get("fake-blob-id")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(localBlob.ContentType()).To(Equal("text/plain"))
+		})
+
+		It("returns local blob with default content type if content type was not specified", func() {
+			_, err := blobstore.Add("/fake-source-path", "")
+			Expect(err).ToNot(HaveOccurred())
+
+			localBlob, err := blobstore.Get("fake-blob-id")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(localBlob.ContentType()).To(Equal("application/octet-stream"))
+		})
+
+		It("writes stored contents into a local blob", func() {
+			_, err := blobstore.Add("/fake-source-path", "")
+			Expect(err).ToNot(HaveOccurred())
+
+			localBlob, err := blobstore.Get("fake-blob-id")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(localBlob.Path()).To(Equal("/fake-destination-path"))
+
+			Expect(fs.ReadFileString("/fake-destination-path")).To(Equal("fake-contents"))
+		})
+
+		It("returns error if blob does not exist", func() {
+			_, err := blobstore.Get("unknown-blob-id")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("Getting blob unknown-blob-id from blobstore: Blob not found"))
+		})
+	})
+
+	Describe("GetTo", func() {
+		It("writes stored contents into writer", func() {
+			_, err := blobstore.Add("/fake-source-path", "")
+			Expect(err).ToNot(HaveOccurred())
+
+			var buf bytes.Buffer
+
+			err = blobstore.GetTo("fake-blob-id", &buf)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(buf.String()).To(Equal("fake-contents"))
+		})
+
+		It("returns error if blob does not exist", func() {
+			err := blobstore.GetTo("unknown-blob-id", &bytes.Buffer{})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("Getting blob unknown-blob-id from blobstore: Blob not found"))
+		})
+	})
+
+	Describe("GetWithDigest", func() {
+		BeforeEach(func() {
+			_, err := blobstore.Add("/fake-source-path", "")
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("writes stored contents to destination path if digest matches", func() {
+			digest, err := boshcrypto.DigestAlgorithmSHA1.CreateDigest(strings.NewReader("fake-contents"))
+			Expect(err).ToNot(HaveOccurred())
+
+			err = blobstore.GetWithDigest("fake-blob-id", "/fake-destination-path", digest)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(fs.ReadFileString("/fake-destination-path")).To(Equal("fake-contents"))
+		})
+
+		It("returns error and does not write destination path if digest does not match", func() {
+			digest := boshcrypto.NewDigest(boshcrypto.DigestAlgorithmSHA1, "wrong-sha1")
+
+			err := blobstore.GetWithDigest("fake-blob-id", "/fake-destination-path", digest)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Expected blob fake-blob-id to have digest 'wrong-sha1'"))
+
+			Expect(fs.FileExists("/fake-destination-path")).To(BeFalse())
+		})
+
+		It("returns error if blob does not exist", func() {
+			digest := boshcrypto.NewDigest(boshcrypto.DigestAlgorithmSHA1, "sha1")
+
+			err := blobstore.GetWithDigest("unknown-blob-id", "/fake-destination-path", digest)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Blob not found"))
+		})
+	})
+
+	Describe("Delete", func() {
+		It("removes blob", func() {
+			_, err := blobstore.Add("/fake-source-path", "")
+			Expect(err).ToNot(HaveOccurred())
+
+			err = blobstore.Delete("fake-blob-id")
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(blobstore.Exists("fake-blob-id")).To(BeFalse())
+		})
+
+		It("does not return error if blob does not exist", func() {
+			Expect(blobstore.Delete("unknown-blob-id")).To(Succeed())
+		})
+	})
+
+	Describe("Sign", func() {
+		It("returns an error since signing is not supported", func() {
+			_, err := blobstore.Sign("fake-blob-id", "GET", time.Minute)
+			Expect(err).To(Equal(ErrSigningNotSupported))
+		})
+	})
+
+	Describe("List", func() {
+		It("returns IDs of stored blobs in sorted order", func() {
+			fakeUUIDGenerator.GeneratedUUID = "fake-blob-id-2"
+			_, err := blobstore.Add("/fake-source-path", "")
+			Expect(err).ToNot(HaveOccurred())
+
+			fakeUUIDGenerator.GeneratedUUID = "fake-blob-id-1"
+			_, err = blobstore.Add("/fake-source-path", "")
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(blobstore.List()).To(Equal([]string{"fake-blob-id-1", "fake-blob-id-2"}))
+		})
+
+		It("returns an empty list if there are no blobs", func() {
+			Expect(blobstore.List()).To(BeEmpty())
+		})
+	})
+
+	Describe("Copy", func() {
+		It("stores contents under a new blob ID", func() {
+			_, err := blobstore.Add("/fake-source-path", "")
+			Expect(err).ToNot(HaveOccurred())
+
+			fakeUUIDGenerator.GeneratedUUID = "fake-new-blob-id"
+
+			dstBlobID, err := blobstore.Copy("fake-blob-id")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(dstBlobID).To(Equal("fake-new-blob-id"))
+
+			err = blobstore.Delete("fake-blob-id")
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(blobstore.Exists("fake-new-blob-id")).To(BeTrue())
+		})
+
+		It("returns error if source blob does not exist", func() {
+			_, err := blobstore.Copy("unknown-blob-id")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Blob not found"))
+		})
+	})
+})
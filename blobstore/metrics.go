@@ -0,0 +1,97 @@
+package blobstore
+
+import (
+	"strconv"
+	"time"
+
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+)
+
+// MetricsSink receives counters and latencies of blobstore operations
+// so that they can be forwarded to telemetry systems
+type MetricsSink interface {
+	IncrementCounter(name string, delta int64)
+	ObserveHistogram(name string, value float64)
+}
+
+const (
+	MetricGetCount    = "blobstore.get.count"
+	MetricGetBytes    = "blobstore.get.bytes"
+	MetricGetDuration = "blobstore.get.duration"
+
+	MetricAddCount    = "blobstore.add.count"
+	MetricAddBytes    = "blobstore.add.bytes"
+	MetricAddDuration = "blobstore.add.duration"
+
+	// Error counters are suffixed with one of the error kinds,
+	// e.g. 'blobstore.get.errors.not_found'
+	MetricGetErrorsPrefix = "blobstore.get.errors."
+	MetricAddErrorsPrefix = "blobstore.add.errors."
+
+	ErrorKindNetwork  = "network"
+	ErrorKindNotFound = "not_found"
+	ErrorKindClient   = "client"
+	ErrorKindServer   = "server"
+)
+
+type loggerMetricsSink struct {
+	logger boshlog.Logger
+	logTag string
+}
+
+// NewLoggerMetricsSink returns sink that writes metrics into debug log
+func NewLoggerMetricsSink(logger boshlog.Logger) MetricsSink {
+	return loggerMetricsSink{logger: logger, logTag: "blobstoreMetrics"}
+}
+
+func (s loggerMetricsSink) IncrementCounter(name string, delta int64) {
+	s.logger.Debug(s.logTag, "%s +%d", name, delta)
+}
+
+func (s loggerMetricsSink) ObserveHistogram(name string, value float64) {
+	s.logger.Debug(s.logTag, "%s %f", name, value)
+}
+
+// blobstoreMetrics records metrics into optional sink;
+// durations are observed in seconds
+type blobstoreMetrics struct {
+	sink MetricsSink
+}
+
+func (m blobstoreMetrics) count(name string, delta int64) {
+	if m.sink != nil {
+		m.sink.IncrementCounter(name, delta)
+	}
+}
+
+func (m blobstoreMetrics) since(name string, start time.Time) {
+	if m.sink != nil {
+		m.sink.ObserveHistogram(name, time.Since(start).Seconds())
+	}
+}
+
+func (m blobstoreMetrics) davErr(prefix string, err error) {
+	m.count(prefix+davErrKind(err), 1)
+}
+
+// davErrKind classifies dav client errors based on response code
+// included in their messages; errors without one are network errors
+func davErrKind(err error) string {
+	matches := davResponseCodeRegexp.FindStringSubmatch(err.Error())
+	if len(matches) == 0 {
+		return ErrorKindNetwork
+	}
+
+	code, convErr := strconv.Atoi(matches[1])
+
+	switch {
+	case convErr != nil:
+		return ErrorKindNetwork
+	case code == 404:
+		return ErrorKindNotFound
+	case code >= 500:
+		return ErrorKindServer
+	default:
+		return ErrorKindClient
+	}
+}
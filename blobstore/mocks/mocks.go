@@ -5,8 +5,11 @@ package mocks
 
 import (
 	blobstore "github.com/cloudfoundry/bosh-cli/blobstore"
+	crypto "github.com/cloudfoundry/bosh-utils/crypto"
 	gomock "github.com/golang/mock/gomock"
+	io "io"
 	http "net/http"
+	time "time"
 )
 
 // Mock of Factory interface
@@ -62,15 +65,69 @@ func (_m *MockBlobstore) EXPECT() *_MockBlobstoreRecorder {
 	return _m.recorder
 }
 
-func (_m *MockBlobstore) Add(_param0 string) (string, error) {
-	ret := _m.ctrl.Call(_m, "Add", _param0)
+func (_m *MockBlobstore) Add(_param0 string, _param1 string) (string, error) {
+	ret := _m.ctrl.Call(_m, "Add", _param0, _param1)
 	ret0, _ := ret[0].(string)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-func (_mr *_MockBlobstoreRecorder) Add(arg0 interface{}) *gomock.Call {
-	return _mr.mock.ctrl.RecordCall(_mr.mock, "Add", arg0)
+func (_mr *_MockBlobstoreRecorder) Add(arg0, arg1 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "Add", arg0, arg1)
+}
+
+func (_m *MockBlobstore) AddWithDigest(_param0 string, _param1 string) (string, crypto.MultipleDigest, error) {
+	ret := _m.ctrl.Call(_m, "AddWithDigest", _param0, _param1)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(crypto.MultipleDigest)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+func (_mr *_MockBlobstoreRecorder) AddWithDigest(arg0, arg1 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "AddWithDigest", arg0, arg1)
+}
+
+func (_m *MockBlobstore) Copy(_param0 string) (string, error) {
+	ret := _m.ctrl.Call(_m, "Copy", _param0)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+func (_mr *_MockBlobstoreRecorder) Copy(arg0 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "Copy", arg0)
+}
+
+func (_m *MockBlobstore) GetWithDigest(_param0 string, _param1 string, _param2 crypto.Digest) error {
+	ret := _m.ctrl.Call(_m, "GetWithDigest", _param0, _param1, _param2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+func (_mr *_MockBlobstoreRecorder) GetWithDigest(arg0, arg1, arg2 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "GetWithDigest", arg0, arg1, arg2)
+}
+
+func (_m *MockBlobstore) Delete(_param0 string) error {
+	ret := _m.ctrl.Call(_m, "Delete", _param0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+func (_mr *_MockBlobstoreRecorder) Delete(arg0 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "Delete", arg0)
+}
+
+func (_m *MockBlobstore) Exists(_param0 string) (bool, error) {
+	ret := _m.ctrl.Call(_m, "Exists", _param0)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+func (_mr *_MockBlobstoreRecorder) Exists(arg0 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "Exists", arg0)
 }
 
 func (_m *MockBlobstore) Get(_param0 string) (blobstore.LocalBlob, error) {
@@ -83,3 +140,35 @@ func (_m *MockBlobstore) Get(_param0 string) (blobstore.LocalBlob, error) {
 func (_mr *_MockBlobstoreRecorder) Get(arg0 interface{}) *gomock.Call {
 	return _mr.mock.ctrl.RecordCall(_mr.mock, "Get", arg0)
 }
+
+func (_m *MockBlobstore) GetTo(_param0 string, _param1 io.Writer) error {
+	ret := _m.ctrl.Call(_m, "GetTo", _param0, _param1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+func (_mr *_MockBlobstoreRecorder) GetTo(arg0, arg1 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "GetTo", arg0, arg1)
+}
+
+func (_m *MockBlobstore) List() ([]string, error) {
+	ret := _m.ctrl.Call(_m, "List")
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+func (_mr *_MockBlobstoreRecorder) List() *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "List")
+}
+
+func (_m *MockBlobstore) Sign(_param0 string, _param1 string, _param2 time.Duration) (string, error) {
+	ret := _m.ctrl.Call(_m, "Sign", _param0, _param1, _param2)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+func (_mr *_MockBlobstoreRecorder) Sign(arg0, arg1, arg2 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "Sign", arg0, arg1, arg2)
+}
@@ -0,0 +1,47 @@
+package blobstore
+
+import (
+	"io"
+)
+
+// ProgressFunc is called as blob contents are transferred with number of bytes
+// transferred so far and total number of bytes (-1 if total is not known)
+type ProgressFunc func(transferred, total int64)
+
+type progressReadCloser struct {
+	io.ReadCloser
+
+	transferred  int64
+	total        int64
+	progressFunc ProgressFunc
+}
+
+func newProgressReadCloser(readCloser io.ReadCloser, total int64, progressFunc ProgressFunc) io.ReadCloser {
+	return newResumedProgressReadCloser(readCloser, 0, total, progressFunc)
+}
+
+// newResumedProgressReadCloser reports progress counting bytes
+// that were already transferred before readCloser was opened
+func newResumedProgressReadCloser(readCloser io.ReadCloser, transferred, total int64, progressFunc ProgressFunc) io.ReadCloser {
+	if progressFunc == nil {
+		return readCloser
+	}
+
+	return &progressReadCloser{
+		ReadCloser:   readCloser,
+		transferred:  transferred,
+		total:        total,
+		progressFunc: progressFunc,
+	}
+}
+
+func (r *progressReadCloser) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+
+	if n > 0 {
+		r.transferred += int64(n)
+		r.progressFunc(r.transferred, r.total)
+	}
+
+	return n, err
+}
@@ -0,0 +1,90 @@
+package blobstore
+
+import (
+	"math/rand"
+	"regexp"
+	"strconv"
+	"time"
+
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshretry "github.com/cloudfoundry/bosh-utils/retrystrategy"
+)
+
+// RetryPolicy configures retries of blobstore requests;
+// delay between attempts doubles after each attempt and includes random jitter
+type RetryPolicy struct {
+	MaxAttempts int
+	BaseDelay   time.Duration
+}
+
+var DefaultRetryPolicy = RetryPolicy{MaxAttempts: 3, BaseDelay: 1 * time.Second}
+
+type backoffRetryStrategy struct {
+	policy    RetryPolicy
+	retryable boshretry.Retryable
+	logger    boshlog.Logger
+	logTag    string
+}
+
+func newBackoffRetryStrategy(policy RetryPolicy, retryable boshretry.Retryable, logger boshlog.Logger) boshretry.RetryStrategy {
+	return backoffRetryStrategy{
+		policy:    policy,
+		retryable: retryable,
+		logger:    logger,
+		logTag:    "blobstoreRetryStrategy",
+	}
+}
+
+func (s backoffRetryStrategy) Try() error {
+	maxAttempts := s.policy.MaxAttempts
+	if maxAttempts < 1 {
+		maxAttempts = 1
+	}
+
+	var err error
+	var isRetryable bool
+
+	for i := 1; i <= maxAttempts; i++ {
+		isRetryable, err = s.retryable.Attempt()
+		if err == nil || !isRetryable || i == maxAttempts {
+			return err
+		}
+
+		delay := s.delay(i)
+
+		s.logger.Debug(s.logTag, "Attempt #%d of %d failed, retrying in %s: %s", i, maxAttempts, delay, err.Error())
+
+		time.Sleep(delay)
+	}
+
+	return err
+}
+
+func (s backoffRetryStrategy) delay(attempt int) time.Duration {
+	if s.policy.BaseDelay <= 0 {
+		return 0
+	}
+
+	backoff := s.policy.BaseDelay * time.Duration(1<<uint(attempt-1))
+	jitter := time.Duration(rand.Int63n(int64(s.policy.BaseDelay)))
+
+	return backoff + jitter
+}
+
+// bosh-davcli reports unexpected response codes only as part of the error message
+var davResponseCodeRegexp = regexp.MustCompile(`Wrong response code: (\d+)`)
+
+// isRetryableDavErr returns true for network errors and 5xx responses
+func isRetryableDavErr(err error) bool {
+	matches := davResponseCodeRegexp.FindStringSubmatch(err.Error())
+	if len(matches) == 0 {
+		return true
+	}
+
+	code, convErr := strconv.Atoi(matches[1])
+	if convErr != nil {
+		return true
+	}
+
+	return code >= 500
+}
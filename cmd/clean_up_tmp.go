@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"path/filepath"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+	"github.com/pivotal-golang/clock"
+
+	biblobstore "github.com/cloudfoundry/bosh-cli/blobstore"
+	boshui "github.com/cloudfoundry/bosh-cli/ui"
+)
+
+type CleanUpTmpCmd struct {
+	tmpDirPath  string
+	fs          boshsys.FileSystem
+	timeService clock.Clock
+	ui          boshui.UI
+}
+
+func NewCleanUpTmpCmd(tmpDirPath string, fs boshsys.FileSystem, timeService clock.Clock, ui boshui.UI) CleanUpTmpCmd {
+	return CleanUpTmpCmd{tmpDirPath: tmpDirPath, fs: fs, timeService: timeService, ui: ui}
+}
+
+// Run only deletes files that were not modified recently since other bosh processes
+// may still be downloading into them or keep them to resume interrupted downloads
+func (c CleanUpTmpCmd) Run(opts CleanUpTmpOpts) error {
+	pattern := filepath.Join(c.tmpDirPath, biblobstore.LocalBlobTempFilePrefix+"*")
+
+	paths, err := c.fs.Glob(pattern)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Finding temporary files matching '%s'", pattern)
+	}
+
+	var deleted int
+
+	for _, path := range paths {
+		info, err := c.fs.Stat(path)
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Checking temporary file '%s'", path)
+		}
+
+		if c.timeService.Now().Sub(info.ModTime()) < opts.OlderThan {
+			c.ui.PrintLinef("Kept temporary file '%s' modified less than %s ago", path, opts.OlderThan)
+			continue
+		}
+
+		err = c.fs.RemoveAll(path)
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Deleting temporary file '%s'", path)
+		}
+
+		c.ui.PrintLinef("Deleted temporary file '%s'", path)
+
+		deleted++
+	}
+
+	if deleted == 0 {
+		c.ui.PrintLinef("No temporary files to clean up")
+	}
+
+	return nil
+}
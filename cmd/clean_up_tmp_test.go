@@ -0,0 +1,170 @@
+package cmd_test
+
+import (
+	"errors"
+	"os"
+	"time"
+
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/pivotal-golang/clock/fakeclock"
+
+	. "github.com/cloudfoundry/bosh-cli/cmd"
+	fakeui "github.com/cloudfoundry/bosh-cli/ui/fakes"
+)
+
+var _ = Describe("CleanUpTmpCmd", func() {
+	var (
+		fs          *modTimeFileSystem
+		timeService *fakeclock.FakeClock
+		ui          *fakeui.FakeUI
+		command     CleanUpTmpCmd
+	)
+
+	BeforeEach(func() {
+		fs = &modTimeFileSystem{FakeFileSystem: fakesys.NewFakeFileSystem(), modTimes: map[string]time.Time{}}
+		timeService = fakeclock.NewFakeClock(time.Date(2017, time.January, 2, 3, 4, 5, 0, time.UTC))
+		ui = &fakeui.FakeUI{}
+		command = NewCleanUpTmpCmd("/tmp-dir", fs, timeService, ui)
+	})
+
+	Describe("Run", func() {
+		var (
+			opts CleanUpTmpOpts
+		)
+
+		BeforeEach(func() {
+			opts = CleanUpTmpOpts{OlderThan: time.Hour}
+		})
+
+		act := func() error { return command.Run(opts) }
+
+		writeFile := func(path string, age time.Duration) {
+			fs.WriteFileString(path, "content")
+			fs.modTimes[path] = timeService.Now().Add(-age)
+		}
+
+		It("deletes leftover downloaded blob files", func() {
+			writeFile("/tmp-dir/bosh-init-local-blob123", 2*time.Hour)
+			writeFile("/tmp-dir/bosh-init-local-blob456", 3*time.Hour)
+			writeFile("/tmp-dir/other-file", 2*time.Hour)
+
+			fs.SetGlob("/tmp-dir/bosh-init-local-blob*", []string{
+				"/tmp-dir/bosh-init-local-blob123",
+				"/tmp-dir/bosh-init-local-blob456",
+			})
+
+			err := act()
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(fs.FileExists("/tmp-dir/bosh-init-local-blob123")).To(BeFalse())
+			Expect(fs.FileExists("/tmp-dir/bosh-init-local-blob456")).To(BeFalse())
+			Expect(fs.FileExists("/tmp-dir/other-file")).To(BeTrue())
+
+			Expect(ui.Said).To(Equal([]string{
+				"Deleted temporary file '/tmp-dir/bosh-init-local-blob123'",
+				"Deleted temporary file '/tmp-dir/bosh-init-local-blob456'",
+			}))
+		})
+
+		It("keeps files modified recently since they may be still downloaded into", func() {
+			writeFile("/tmp-dir/bosh-init-local-blob123", 2*time.Hour)
+			writeFile("/tmp-dir/bosh-init-local-blob456", 30*time.Minute)
+
+			fs.SetGlob("/tmp-dir/bosh-init-local-blob*", []string{
+				"/tmp-dir/bosh-init-local-blob123",
+				"/tmp-dir/bosh-init-local-blob456",
+			})
+
+			err := act()
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(fs.FileExists("/tmp-dir/bosh-init-local-blob123")).To(BeFalse())
+			Expect(fs.FileExists("/tmp-dir/bosh-init-local-blob456")).To(BeTrue())
+
+			Expect(ui.Said).To(Equal([]string{
+				"Deleted temporary file '/tmp-dir/bosh-init-local-blob123'",
+				"Kept temporary file '/tmp-dir/bosh-init-local-blob456' modified less than 1h0m0s ago",
+			}))
+		})
+
+		It("reports that there is nothing to clean up if all files were modified recently", func() {
+			writeFile("/tmp-dir/bosh-init-local-blob123", time.Minute)
+
+			fs.SetGlob("/tmp-dir/bosh-init-local-blob*", []string{"/tmp-dir/bosh-init-local-blob123"})
+
+			err := act()
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(fs.FileExists("/tmp-dir/bosh-init-local-blob123")).To(BeTrue())
+
+			Expect(ui.Said).To(ContainElement("No temporary files to clean up"))
+		})
+
+		It("reports that there is nothing to clean up", func() {
+			err := act()
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(ui.Said).To(Equal([]string{"No temporary files to clean up"}))
+		})
+
+		It("returns error if finding files fails", func() {
+			fs.GlobErr = errors.New("fake-err")
+
+			err := act()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-err"))
+		})
+
+		It("returns error if checking file fails", func() {
+			fs.SetGlob("/tmp-dir/bosh-init-local-blob*", []string{"/tmp-dir/bosh-init-local-blob123"})
+			fs.statErr = errors.New("fake-err")
+
+			err := act()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Checking temporary file '/tmp-dir/bosh-init-local-blob123'"))
+			Expect(err.Error()).To(ContainSubstring("fake-err"))
+		})
+
+		It("returns error if deleting file fails", func() {
+			writeFile("/tmp-dir/bosh-init-local-blob123", 2*time.Hour)
+
+			fs.SetGlob("/tmp-dir/bosh-init-local-blob*", []string{"/tmp-dir/bosh-init-local-blob123"})
+			fs.RemoveAllStub = func(_ string) error { return errors.New("fake-err") }
+
+			err := act()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-err"))
+		})
+	})
+})
+
+// modTimeFileSystem reports configured modification times
+// since fake file system does not keep track of them
+type modTimeFileSystem struct {
+	*fakesys.FakeFileSystem
+
+	modTimes map[string]time.Time
+	statErr  error
+}
+
+func (fs *modTimeFileSystem) Stat(path string) (os.FileInfo, error) {
+	if fs.statErr != nil {
+		return nil, fs.statErr
+	}
+
+	info, err := fs.FakeFileSystem.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return modTimeFileInfo{FileInfo: info, modTime: fs.modTimes[path]}, nil
+}
+
+type modTimeFileInfo struct {
+	os.FileInfo
+	modTime time.Time
+}
+
+func (fi modTimeFileInfo) ModTime() time.Time { return fi.modTime }
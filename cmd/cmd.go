@@ -2,17 +2,25 @@ package cmd
 
 import (
 	"fmt"
+	"os"
+	"os/signal"
+	gopath "path"
+	"time"
 
 	"github.com/cppforlife/go-patch/patch"
 
 	cmdconf "github.com/cloudfoundry/bosh-cli/cmd/config"
+	biconfig "github.com/cloudfoundry/bosh-cli/config"
 	boshdir "github.com/cloudfoundry/bosh-cli/director"
 	boshtpl "github.com/cloudfoundry/bosh-cli/director/template"
+	bitarball "github.com/cloudfoundry/bosh-cli/installation/tarball"
 	boshrel "github.com/cloudfoundry/bosh-cli/release"
 	boshreldir "github.com/cloudfoundry/bosh-cli/releasedir"
 	boshssh "github.com/cloudfoundry/bosh-cli/ssh"
 	boshui "github.com/cloudfoundry/bosh-cli/ui"
 	boshuit "github.com/cloudfoundry/bosh-cli/ui/task"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	bihttpclient "github.com/cloudfoundry/bosh-utils/httpclient"
 )
 
 type Cmd struct {
@@ -59,21 +67,43 @@ func (c Cmd) Execute() (cmdErr error) {
 		return NewEnvironmentsCmd(c.config(), deps.UI).Run()
 
 	case *CreateEnvOpts:
+		factories := &envFactories{}
+		defer factories.Close()
+
 		envProvider := func(manifestPath string, statePath string, vars boshtpl.Variables, op patch.Op) DeploymentPreparer {
-			return NewEnvFactory(deps, manifestPath, statePath, vars, op).Preparer()
+			return factories.New(deps, manifestPath, statePath, vars, op).Preparer()
 		}
 
 		stage := boshui.NewStage(deps.UI, deps.Time, deps.Logger)
 		return NewCreateEnvCmd(deps.UI, envProvider).Run(stage, *opts)
 
 	case *DeleteEnvOpts:
+		factories := &envFactories{}
+		defer factories.Close()
+
 		envProvider := func(manifestPath string, statePath string, vars boshtpl.Variables, op patch.Op) DeploymentDeleter {
-			return NewEnvFactory(deps, manifestPath, statePath, vars, op).Deleter()
+			return factories.New(deps, manifestPath, statePath, vars, op).Deleter()
 		}
 
 		stage := boshui.NewStage(deps.UI, deps.Time, deps.Logger)
 		return NewDeleteCmd(deps.UI, envProvider).Run(stage, *opts)
 
+	case *EnvDisksOpts:
+		diskRepoProvider := func(statePath string) (biconfig.DiskRepo, error) {
+			stateService := biconfig.NewFileSystemDeploymentStateService(
+				deps.FS, deps.UUIDGen, deps.Logger, statePath, biconfig.DefaultDeploymentStateBackups)
+
+			// Loading missing state would initialize and save it
+			if !stateService.Exists() {
+				return nil, bosherr.Errorf("Expected state file '%s' to exist", statePath)
+			}
+
+			return biconfig.NewDiskRepo(
+				stateService, biconfig.DefaultDeploymentName, deps.UUIDGen, deps.Time, nil, deps.Logger), nil
+		}
+
+		return NewEnvDisksCmd(deps.UI, diskRepoProvider).Run(*opts)
+
 	case *AliasEnvOpts:
 		sessionFactory := func(config cmdconf.Config) Session {
 			return NewSessionFromOpts(c.BoshOpts, config, deps.UI, true, false, deps.FS, deps.Logger)
@@ -250,9 +280,47 @@ func (c Cmd) Execute() (cmdErr error) {
 		return NewUnignoreCmd(c.deployment()).Run(*opts)
 
 	case *DeployOpts:
-		director, deployment := c.directorAndDeployment()
-		releaseManager := c.releaseManager(director)
-		return NewDeployCmd(deps.UI, deployment, releaseManager).Run(*opts)
+		if len(opts.LogFile) > 0 {
+			logFile, err := deps.FS.OpenFile(opts.LogFile, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+			if err != nil {
+				return bosherr.WrapErrorf(err, "Opening log file '%s'", opts.LogFile)
+			}
+
+			defer logFile.Close()
+
+			deps.UI.EnableLogFile(logFile, deps.Time)
+		}
+
+		director, deployment := c.directorAndOptionalDeployment()
+
+		releaseUploaderFactory := func(director boshdir.Director) ReleaseUploader {
+			return c.releaseManager(director)
+		}
+
+		if c.BoshOpts.NoColorOpt && opts.Color == boshui.ColorModeAuto {
+			opts.Color = boshui.ColorModeNever
+		}
+
+		configFunc := func() (cmdconf.Config, error) {
+			return cmdconf.NewFSConfigFromPath(c.BoshOpts.ConfigPathOpt, deps.FS)
+		}
+
+		deployedManifests := NewConfigDeployedManifests(c.session().Environment(), configFunc)
+
+		return NewDeployCmd(deps.UI, director, deployment, releaseUploaderFactory, NewHTTPManifestFetcher(), signal.Notify, nil, deployedManifests).Run(*opts)
+
+	case *DeployDiffOpts:
+		if c.BoshOpts.NoColorOpt && opts.Color == boshui.ColorModeAuto {
+			opts.Color = boshui.ColorModeNever
+		}
+
+		configFunc := func() (cmdconf.Config, error) {
+			return cmdconf.NewFSConfigFromPath(c.BoshOpts.ConfigPathOpt, deps.FS)
+		}
+
+		deployedManifests := NewConfigDeployedManifests(c.session().Environment(), configFunc)
+
+		return NewDeployDiffCmd(deps.UI, c.BoshOpts.DeploymentOpt, deployedManifests).Run(*opts)
 
 	case *StartOpts:
 		return NewStartCmd(deps.UI, c.deployment()).Run(*opts)
@@ -272,6 +340,9 @@ func (c Cmd) Execute() (cmdErr error) {
 	case *CleanUpOpts:
 		return NewCleanUpCmd(deps.UI, c.director()).Run(*opts)
 
+	case *CleanUpTmpOpts:
+		return NewCleanUpTmpCmd(c.tmpDirPath(), deps.FS, deps.Time, deps.UI).Run(*opts)
+
 	case *LogsOpts:
 		director, deployment := c.directorAndDeployment()
 		downloader := NewUIDownloader(director, deps.Time, deps.FS, deps.UI)
@@ -370,11 +441,15 @@ func (c Cmd) configureUI() {
 }
 
 func (c Cmd) configureFS() {
-	tmpDirPath, err := c.deps.FS.ExpandPath("~/.bosh/tmp")
+	err := c.deps.FS.ChangeTempRoot(c.tmpDirPath())
 	c.panicIfErr(err)
+}
 
-	err = c.deps.FS.ChangeTempRoot(tmpDirPath)
+func (c Cmd) tmpDirPath() string {
+	tmpDirPath, err := c.deps.FS.ExpandPath("~/.bosh/tmp")
 	c.panicIfErr(err)
+
+	return tmpDirPath
 }
 
 func (c Cmd) config() cmdconf.Config {
@@ -414,6 +489,16 @@ func (c Cmd) directorAndDeployment() (boshdir.Director, boshdir.Deployment) {
 	return director, deployment
 }
 
+// directorAndOptionalDeployment returns nil deployment if deployment was not specified
+// so that command could find deployment itself (e.g. based on the manifest)
+func (c Cmd) directorAndOptionalDeployment() (boshdir.Director, boshdir.Deployment) {
+	if len(c.BoshOpts.DeploymentOpt) == 0 {
+		return c.director(), nil
+	}
+
+	return c.directorAndDeployment()
+}
+
 func (c Cmd) releaseProviders() (boshrel.Provider, boshreldir.Provider) {
 	indexReporter := boshui.NewIndexReporter(c.deps.UI)
 	blobsReporter := boshui.NewBlobsReporter(c.deps.UI)
@@ -440,16 +525,35 @@ func (c Cmd) releaseManager(director boshdir.Director) ReleaseManager {
 
 	releaseWriter := relProv.NewArchiveWriter()
 
-	createReleaseCmd := NewCreateReleaseCmd(releaseDirFactory, releaseWriter, c.deps.FS, c.deps.UI)
+	createReleaseCmd := func(ui boshui.UI) ReleaseCreatingCmd {
+		return NewCreateReleaseCmd(releaseDirFactory, releaseWriter, c.deps.FS, ui)
+	}
 
 	releaseArchiveFactory := func(path string) boshdir.ReleaseArchive {
 		return boshdir.NewFSReleaseArchive(path, c.deps.FS)
 	}
 
-	uploadReleaseCmd := NewUploadReleaseCmd(
-		releaseDirFactory, releaseWriter, director, releaseArchiveFactory, c.deps.CmdRunner, c.deps.FS, c.deps.UI)
+	uploadReleaseCmd := func(ui boshui.UI) ReleaseUploadingCmd {
+		return NewUploadReleaseCmd(
+			releaseDirFactory, releaseWriter, director, releaseArchiveFactory, c.deps.CmdRunner, c.deps.FS, ui)
+	}
+
+	// Releases verified before upload share download cache with create-env
+	tarballCache := bitarball.NewCache(gopath.Join(os.Getenv("HOME"), ".bosh", "downloads"), c.deps.FS, c.deps.Logger)
+	httpClient := bihttpclient.NewHTTPClient(bitarball.HTTPClient, c.deps.Logger)
+	releaseTarballProvider := bitarball.NewProvider(
+		tarballCache, c.deps.FS, httpClient, 3, 500*time.Millisecond, c.deps.Logger)
+
+	stemcellArchiveFactory := func(path string) boshdir.StemcellArchive {
+		return boshdir.NewFSStemcellArchive(path, c.deps.FS)
+	}
+
+	uploadStemcellCmd := NewUploadStemcellCmd(director, stemcellArchiveFactory, c.deps.UI)
+
+	stage := boshui.NewStage(c.deps.UI, c.deps.Time, c.deps.Logger)
 
-	return NewReleaseManager(createReleaseCmd, uploadReleaseCmd)
+	return NewReleaseManager(
+		createReleaseCmd, uploadReleaseCmd, uploadStemcellCmd, releaseTarballProvider, director, stage, c.deps.UI, c.deps.FS)
 }
 
 func (c Cmd) blobsDir(dir DirOrCWDArg) boshreldir.BlobsDir {
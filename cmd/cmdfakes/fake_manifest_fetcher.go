@@ -0,0 +1,80 @@
+// This file was generated by counterfeiter
+package cmdfakes
+
+import (
+	"sync"
+	"time"
+
+	"github.com/cloudfoundry/bosh-cli/cmd"
+)
+
+type FakeManifestFetcher struct {
+	FetchStub        func(url string, timeout time.Duration) ([]byte, error)
+	fetchMutex       sync.RWMutex
+	fetchArgsForCall []struct {
+		url     string
+		timeout time.Duration
+	}
+	fetchReturns struct {
+		result1 []byte
+		result2 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeManifestFetcher) Fetch(url string, timeout time.Duration) ([]byte, error) {
+	fake.fetchMutex.Lock()
+	fake.fetchArgsForCall = append(fake.fetchArgsForCall, struct {
+		url     string
+		timeout time.Duration
+	}{url, timeout})
+	fake.recordInvocation("Fetch", []interface{}{url, timeout})
+	fake.fetchMutex.Unlock()
+	if fake.FetchStub != nil {
+		return fake.FetchStub(url, timeout)
+	}
+	return fake.fetchReturns.result1, fake.fetchReturns.result2
+}
+
+func (fake *FakeManifestFetcher) FetchCallCount() int {
+	fake.fetchMutex.RLock()
+	defer fake.fetchMutex.RUnlock()
+	return len(fake.fetchArgsForCall)
+}
+
+func (fake *FakeManifestFetcher) FetchArgsForCall(i int) (string, time.Duration) {
+	fake.fetchMutex.RLock()
+	defer fake.fetchMutex.RUnlock()
+	return fake.fetchArgsForCall[i].url, fake.fetchArgsForCall[i].timeout
+}
+
+func (fake *FakeManifestFetcher) FetchReturns(result1 []byte, result2 error) {
+	fake.FetchStub = nil
+	fake.fetchReturns = struct {
+		result1 []byte
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeManifestFetcher) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.fetchMutex.RLock()
+	defer fake.fetchMutex.RUnlock()
+	return fake.invocations
+}
+
+func (fake *FakeManifestFetcher) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ cmd.ManifestFetcher = new(FakeManifestFetcher)
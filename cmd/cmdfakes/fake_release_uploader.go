@@ -8,20 +8,22 @@ import (
 )
 
 type FakeReleaseUploader struct {
-	UploadReleasesStub        func([]byte) ([]byte, error)
+	UploadReleasesStub        func([]byte, cmd.UploadReleasesOpts) ([]byte, []cmd.ReleaseUploadResult, error)
 	uploadReleasesMutex       sync.RWMutex
 	uploadReleasesArgsForCall []struct {
 		arg1 []byte
+		arg2 cmd.UploadReleasesOpts
 	}
 	uploadReleasesReturns struct {
 		result1 []byte
-		result2 error
+		result2 []cmd.ReleaseUploadResult
+		result3 error
 	}
 	invocations      map[string][][]interface{}
 	invocationsMutex sync.RWMutex
 }
 
-func (fake *FakeReleaseUploader) UploadReleases(arg1 []byte) ([]byte, error) {
+func (fake *FakeReleaseUploader) UploadReleases(arg1 []byte, arg2 cmd.UploadReleasesOpts) ([]byte, []cmd.ReleaseUploadResult, error) {
 	var arg1Copy []byte
 	if arg1 != nil {
 		arg1Copy = make([]byte, len(arg1))
@@ -30,13 +32,14 @@ func (fake *FakeReleaseUploader) UploadReleases(arg1 []byte) ([]byte, error) {
 	fake.uploadReleasesMutex.Lock()
 	fake.uploadReleasesArgsForCall = append(fake.uploadReleasesArgsForCall, struct {
 		arg1 []byte
-	}{arg1Copy})
-	fake.recordInvocation("UploadReleases", []interface{}{arg1Copy})
+		arg2 cmd.UploadReleasesOpts
+	}{arg1Copy, arg2})
+	fake.recordInvocation("UploadReleases", []interface{}{arg1Copy, arg2})
 	fake.uploadReleasesMutex.Unlock()
 	if fake.UploadReleasesStub != nil {
-		return fake.UploadReleasesStub(arg1)
+		return fake.UploadReleasesStub(arg1, arg2)
 	}
-	return fake.uploadReleasesReturns.result1, fake.uploadReleasesReturns.result2
+	return fake.uploadReleasesReturns.result1, fake.uploadReleasesReturns.result2, fake.uploadReleasesReturns.result3
 }
 
 func (fake *FakeReleaseUploader) UploadReleasesCallCount() int {
@@ -45,18 +48,19 @@ func (fake *FakeReleaseUploader) UploadReleasesCallCount() int {
 	return len(fake.uploadReleasesArgsForCall)
 }
 
-func (fake *FakeReleaseUploader) UploadReleasesArgsForCall(i int) []byte {
+func (fake *FakeReleaseUploader) UploadReleasesArgsForCall(i int) ([]byte, cmd.UploadReleasesOpts) {
 	fake.uploadReleasesMutex.RLock()
 	defer fake.uploadReleasesMutex.RUnlock()
-	return fake.uploadReleasesArgsForCall[i].arg1
+	return fake.uploadReleasesArgsForCall[i].arg1, fake.uploadReleasesArgsForCall[i].arg2
 }
 
-func (fake *FakeReleaseUploader) UploadReleasesReturns(result1 []byte, result2 error) {
+func (fake *FakeReleaseUploader) UploadReleasesReturns(result1 []byte, result2 []cmd.ReleaseUploadResult, result3 error) {
 	fake.UploadReleasesStub = nil
 	fake.uploadReleasesReturns = struct {
 		result1 []byte
-		result2 error
-	}{result1, result2}
+		result2 []cmd.ReleaseUploadResult
+		result3 error
+	}{result1, result2, result3}
 }
 
 func (fake *FakeReleaseUploader) Invocations() map[string][][]interface{} {
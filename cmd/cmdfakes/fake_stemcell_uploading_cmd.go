@@ -0,0 +1,75 @@
+// This file was generated by counterfeiter
+package cmdfakes
+
+import (
+	"sync"
+
+	"github.com/cloudfoundry/bosh-cli/cmd"
+)
+
+type FakeStemcellUploadingCmd struct {
+	RunStub        func(cmd.UploadStemcellOpts) error
+	runMutex       sync.RWMutex
+	runArgsForCall []struct {
+		arg1 cmd.UploadStemcellOpts
+	}
+	runReturns struct {
+		result1 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeStemcellUploadingCmd) Run(arg1 cmd.UploadStemcellOpts) error {
+	fake.runMutex.Lock()
+	fake.runArgsForCall = append(fake.runArgsForCall, struct {
+		arg1 cmd.UploadStemcellOpts
+	}{arg1})
+	fake.recordInvocation("Run", []interface{}{arg1})
+	fake.runMutex.Unlock()
+	if fake.RunStub != nil {
+		return fake.RunStub(arg1)
+	}
+	return fake.runReturns.result1
+}
+
+func (fake *FakeStemcellUploadingCmd) RunCallCount() int {
+	fake.runMutex.RLock()
+	defer fake.runMutex.RUnlock()
+	return len(fake.runArgsForCall)
+}
+
+func (fake *FakeStemcellUploadingCmd) RunArgsForCall(i int) cmd.UploadStemcellOpts {
+	fake.runMutex.RLock()
+	defer fake.runMutex.RUnlock()
+	return fake.runArgsForCall[i].arg1
+}
+
+func (fake *FakeStemcellUploadingCmd) RunReturns(result1 error) {
+	fake.RunStub = nil
+	fake.runReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeStemcellUploadingCmd) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.runMutex.RLock()
+	defer fake.runMutex.RUnlock()
+	return fake.invocations
+}
+
+func (fake *FakeStemcellUploadingCmd) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ cmd.StemcellUploadingCmd = new(FakeStemcellUploadingCmd)
@@ -66,6 +66,26 @@ type FakeConfig struct {
 	unsetCredentialsReturns struct {
 		result1 config.Config
 	}
+	DeployedManifestStub        func(url, deployment string) (config.DeployedManifest, bool)
+	deployedManifestMutex       sync.RWMutex
+	deployedManifestArgsForCall []struct {
+		url        string
+		deployment string
+	}
+	deployedManifestReturns struct {
+		result1 config.DeployedManifest
+		result2 bool
+	}
+	SetDeployedManifestStub        func(url, deployment string, manifest config.DeployedManifest) config.Config
+	setDeployedManifestMutex       sync.RWMutex
+	setDeployedManifestArgsForCall []struct {
+		url        string
+		deployment string
+		manifest   config.DeployedManifest
+	}
+	setDeployedManifestReturns struct {
+		result1 config.Config
+	}
 	SaveStub        func() error
 	saveMutex       sync.RWMutex
 	saveArgsForCall []struct{}
@@ -296,6 +316,74 @@ func (fake *FakeConfig) UnsetCredentialsReturns(result1 config.Config) {
 	}{result1}
 }
 
+func (fake *FakeConfig) DeployedManifest(url string, deployment string) (config.DeployedManifest, bool) {
+	fake.deployedManifestMutex.Lock()
+	fake.deployedManifestArgsForCall = append(fake.deployedManifestArgsForCall, struct {
+		url        string
+		deployment string
+	}{url, deployment})
+	fake.recordInvocation("DeployedManifest", []interface{}{url, deployment})
+	fake.deployedManifestMutex.Unlock()
+	if fake.DeployedManifestStub != nil {
+		return fake.DeployedManifestStub(url, deployment)
+	}
+	return fake.deployedManifestReturns.result1, fake.deployedManifestReturns.result2
+}
+
+func (fake *FakeConfig) DeployedManifestCallCount() int {
+	fake.deployedManifestMutex.RLock()
+	defer fake.deployedManifestMutex.RUnlock()
+	return len(fake.deployedManifestArgsForCall)
+}
+
+func (fake *FakeConfig) DeployedManifestArgsForCall(i int) (string, string) {
+	fake.deployedManifestMutex.RLock()
+	defer fake.deployedManifestMutex.RUnlock()
+	return fake.deployedManifestArgsForCall[i].url, fake.deployedManifestArgsForCall[i].deployment
+}
+
+func (fake *FakeConfig) DeployedManifestReturns(result1 config.DeployedManifest, result2 bool) {
+	fake.DeployedManifestStub = nil
+	fake.deployedManifestReturns = struct {
+		result1 config.DeployedManifest
+		result2 bool
+	}{result1, result2}
+}
+
+func (fake *FakeConfig) SetDeployedManifest(url string, deployment string, manifest config.DeployedManifest) config.Config {
+	fake.setDeployedManifestMutex.Lock()
+	fake.setDeployedManifestArgsForCall = append(fake.setDeployedManifestArgsForCall, struct {
+		url        string
+		deployment string
+		manifest   config.DeployedManifest
+	}{url, deployment, manifest})
+	fake.recordInvocation("SetDeployedManifest", []interface{}{url, deployment, manifest})
+	fake.setDeployedManifestMutex.Unlock()
+	if fake.SetDeployedManifestStub != nil {
+		return fake.SetDeployedManifestStub(url, deployment, manifest)
+	}
+	return fake.setDeployedManifestReturns.result1
+}
+
+func (fake *FakeConfig) SetDeployedManifestCallCount() int {
+	fake.setDeployedManifestMutex.RLock()
+	defer fake.setDeployedManifestMutex.RUnlock()
+	return len(fake.setDeployedManifestArgsForCall)
+}
+
+func (fake *FakeConfig) SetDeployedManifestArgsForCall(i int) (string, string, config.DeployedManifest) {
+	fake.setDeployedManifestMutex.RLock()
+	defer fake.setDeployedManifestMutex.RUnlock()
+	return fake.setDeployedManifestArgsForCall[i].url, fake.setDeployedManifestArgsForCall[i].deployment, fake.setDeployedManifestArgsForCall[i].manifest
+}
+
+func (fake *FakeConfig) SetDeployedManifestReturns(result1 config.Config) {
+	fake.SetDeployedManifestStub = nil
+	fake.setDeployedManifestReturns = struct {
+		result1 config.Config
+	}{result1}
+}
+
 func (fake *FakeConfig) Save() error {
 	fake.saveMutex.Lock()
 	fake.saveArgsForCall = append(fake.saveArgsForCall, struct{}{})
@@ -337,6 +425,10 @@ func (fake *FakeConfig) Invocations() map[string][][]interface{} {
 	defer fake.setCredentialsMutex.RUnlock()
 	fake.unsetCredentialsMutex.RLock()
 	defer fake.unsetCredentialsMutex.RUnlock()
+	fake.deployedManifestMutex.RLock()
+	defer fake.deployedManifestMutex.RUnlock()
+	fake.setDeployedManifestMutex.RLock()
+	defer fake.setDeployedManifestMutex.RUnlock()
 	fake.saveMutex.RLock()
 	defer fake.saveMutex.RUnlock()
 	return fake.invocations
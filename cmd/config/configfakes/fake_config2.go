@@ -72,6 +72,14 @@ func (f *FakeConfig2) SetDeployment(environment string, nameOrPath string) confi
 	panic("Not implemented")
 }
 
+func (f *FakeConfig2) DeployedManifest(environment, deployment string) (config.DeployedManifest, bool) {
+	panic("Not implemented")
+}
+
+func (f *FakeConfig2) SetDeployedManifest(environment, deployment string, manifest config.DeployedManifest) config.Config {
+	panic("Not implemented")
+}
+
 func (f *FakeConfig2) Save() error {
 	f.Saved.EnvironmentURL = f.Existing.EnvironmentURL
 	f.Saved.EnvironmentAlias = f.Existing.EnvironmentAlias
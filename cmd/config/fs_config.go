@@ -1,6 +1,8 @@
 package config
 
 import (
+	"time"
+
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 	boshsys "github.com/cloudfoundry/bosh-utils/system"
 	"gopkg.in/yaml.v2"
@@ -12,6 +14,11 @@ environments:
   ca_cert: |...
   username: admin
   password: admin
+  deployments:
+  - name: cf
+    manifest_sha1: 8f2a...
+    deployed_at: 2017-01-01T00:00:00Z
+    manifest: |...
 */
 
 type FSConfig struct {
@@ -35,6 +42,17 @@ type fsConfigSchema_Environment struct {
 	Username     string `yaml:"username,omitempty"`
 	Password     string `yaml:"password,omitempty"`
 	RefreshToken string `yaml:"refresh_token,omitempty"`
+
+	Deployments []fsConfigSchema_Deployment `yaml:"deployments,omitempty"`
+}
+
+type fsConfigSchema_Deployment struct {
+	Name string `yaml:"name"`
+
+	// Last successfully deployed manifest
+	ManifestSHA1 string `yaml:"manifest_sha1"`
+	DeployedAt   string `yaml:"deployed_at"` // in RFC3339 format
+	Manifest     string `yaml:"manifest,omitempty"`
 }
 
 func NewFSConfigFromPath(path string, fs boshsys.FileSystem) (FSConfig, error) {
@@ -137,6 +155,52 @@ func (c FSConfig) UnsetCredentials(urlOrAlias string) Config {
 	return config
 }
 
+func (c FSConfig) DeployedManifest(urlOrAlias, deployment string) (DeployedManifest, bool) {
+	_, tg := c.findOrCreateEnvironment(urlOrAlias)
+
+	for _, dep := range tg.Deployments {
+		if dep.Name == deployment {
+			// Unparseable time is left as zero since SHA1 is still usable
+			deployedAt, _ := time.Parse(time.RFC3339, dep.DeployedAt)
+
+			return DeployedManifest{SHA1: dep.ManifestSHA1, DeployedAt: deployedAt, Manifest: dep.Manifest}, true
+		}
+	}
+
+	return DeployedManifest{}, false
+}
+
+func (c FSConfig) SetDeployedManifest(urlOrAlias, deployment string, manifest DeployedManifest) Config {
+	config := c.deepCopy()
+
+	i, tg := config.findOrCreateEnvironment(urlOrAlias)
+
+	dep := fsConfigSchema_Deployment{
+		Name:         deployment,
+		ManifestSHA1: manifest.SHA1,
+		DeployedAt:   manifest.DeployedAt.UTC().Format(time.RFC3339),
+		Manifest:     manifest.Manifest,
+	}
+
+	found := false
+
+	for j, existingDep := range tg.Deployments {
+		if existingDep.Name == deployment {
+			tg.Deployments[j] = dep
+			found = true
+			break
+		}
+	}
+
+	if !found {
+		tg.Deployments = append(tg.Deployments, dep)
+	}
+
+	config.schema.Environments[i] = tg
+
+	return config
+}
+
 func (c FSConfig) Save() error {
 	bytes, err := yaml.Marshal(c.schema)
 	if err != nil {
@@ -2,6 +2,7 @@ package config_test
 
 import (
 	"errors"
+	"time"
 
 	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
 	. "github.com/onsi/ginkgo"
@@ -388,6 +389,70 @@ var _ = Describe("FSConfig", func() {
 		})
 	})
 
+	Describe("SetDeployedManifest/DeployedManifest", func() {
+		deployedAt := time.Date(2017, time.January, 2, 3, 4, 5, 0, time.UTC)
+
+		It("returns not found if environment is not found", func() {
+			_, found := config.DeployedManifest("url", "dep")
+			Expect(found).To(BeFalse())
+		})
+
+		It("returns deployed manifest if it was set for deployment", func() {
+			updatedConfig, err := config.AliasEnvironment("url", "alias", "")
+			Expect(err).ToNot(HaveOccurred())
+
+			updatedConfig = updatedConfig.SetDeployedManifest("alias", "dep", DeployedManifest{SHA1: "sha1", DeployedAt: deployedAt})
+
+			manifest, found := updatedConfig.DeployedManifest("url", "dep")
+			Expect(found).To(BeTrue())
+			Expect(manifest).To(Equal(DeployedManifest{SHA1: "sha1", DeployedAt: deployedAt}))
+
+			_, found = updatedConfig.DeployedManifest("url", "other-dep")
+			Expect(found).To(BeFalse())
+
+			err = updatedConfig.Save()
+			Expect(err).ToNot(HaveOccurred())
+
+			reloadedConfig := readConfig()
+
+			manifest, found = reloadedConfig.DeployedManifest("alias", "dep")
+			Expect(found).To(BeTrue())
+			Expect(manifest).To(Equal(DeployedManifest{SHA1: "sha1", DeployedAt: deployedAt}))
+		})
+
+		It("keeps deployed manifest contents", func() {
+			deployed := DeployedManifest{SHA1: "sha1", DeployedAt: deployedAt, Manifest: "name: dep\n"}
+
+			err := config.SetDeployedManifest("url", "dep", deployed).Save()
+			Expect(err).ToNot(HaveOccurred())
+
+			manifest, found := readConfig().DeployedManifest("url", "dep")
+			Expect(found).To(BeTrue())
+			Expect(manifest).To(Equal(deployed))
+		})
+
+		It("replaces previously deployed manifest of the same deployment", func() {
+			updatedConfig := config.SetDeployedManifest("url", "dep", DeployedManifest{SHA1: "sha1", DeployedAt: deployedAt})
+			updatedConfig = updatedConfig.SetDeployedManifest("url", "dep2", DeployedManifest{SHA1: "sha2", DeployedAt: deployedAt})
+			updatedConfig = updatedConfig.SetDeployedManifest("url", "dep", DeployedManifest{SHA1: "sha3", DeployedAt: deployedAt})
+
+			manifest, found := updatedConfig.DeployedManifest("url", "dep")
+			Expect(found).To(BeTrue())
+			Expect(manifest.SHA1).To(Equal("sha3"))
+
+			manifest, found = updatedConfig.DeployedManifest("url", "dep2")
+			Expect(found).To(BeTrue())
+			Expect(manifest.SHA1).To(Equal("sha2"))
+		})
+
+		It("does not update existing config when deployed manifest is set", func() {
+			config.SetDeployedManifest("url", "dep", DeployedManifest{SHA1: "sha1", DeployedAt: deployedAt})
+
+			_, found := config.DeployedManifest("url", "dep")
+			Expect(found).To(BeFalse())
+		})
+	})
+
 	Describe("Save", func() {
 		It("returns error if writing file fails", func() {
 			fs.WriteFileError = errors.New("fake-err")
@@ -1,5 +1,9 @@
 package config
 
+import (
+	"time"
+)
+
 //go:generate counterfeiter . Config
 
 type Config interface {
@@ -13,6 +17,11 @@ type Config interface {
 	SetCredentials(url string, creds Creds) Config
 	UnsetCredentials(url string) Config
 
+	// DeployedManifest returns record of the manifest last successfully
+	// deployed to the named deployment in the environment
+	DeployedManifest(url, deployment string) (DeployedManifest, bool)
+	SetDeployedManifest(url, deployment string, manifest DeployedManifest) Config
+
 	Save() error
 }
 
@@ -20,3 +29,12 @@ type Environment struct {
 	URL   string
 	Alias string
 }
+
+type DeployedManifest struct {
+	SHA1       string
+	DeployedAt time.Time
+
+	// Manifest is interpolated manifest contents used by deploy-diff;
+	// it's empty for manifests deployed by older CLI versions
+	Manifest string
+}
@@ -197,7 +197,7 @@ var _ = Describe("CreateEnvCmd", func() {
 
 			configUUIDGenerator = &fakeuuid.FakeGenerator{}
 			configUUIDGenerator.GeneratedUUID = directorID
-			setupDeploymentStateService = biconfig.NewFileSystemDeploymentStateService(fs, configUUIDGenerator, logger, biconfig.DeploymentStatePath(deploymentManifestPath, ""))
+			setupDeploymentStateService = biconfig.NewFileSystemDeploymentStateService(fs, configUUIDGenerator, logger, biconfig.DeploymentStatePath(deploymentManifestPath, ""), 0)
 
 			fakeDeploymentValidator = fakebideplval.NewFakeValidator()
 
@@ -316,7 +316,7 @@ var _ = Describe("CreateEnvCmd", func() {
 
 		JustBeforeEach(func() {
 			doGet := func(deploymentManifestPath string, statePath string, deploymentVars boshtpl.Variables, deploymentOp patch.Op) bicmd.DeploymentPreparer {
-				deploymentStateService := biconfig.NewFileSystemDeploymentStateService(fs, configUUIDGenerator, logger, biconfig.DeploymentStatePath(deploymentManifestPath, statePath))
+				deploymentStateService := biconfig.NewFileSystemDeploymentStateService(fs, configUUIDGenerator, logger, biconfig.DeploymentStatePath(deploymentManifestPath, statePath), 0)
 				deploymentRepo := biconfig.NewDeploymentRepo(deploymentStateService)
 				releaseRepo := biconfig.NewReleaseRepo(deploymentStateService, fakeUUIDGenerator)
 				stemcellRepo := biconfig.NewStemcellRepo(deploymentStateService, fakeUUIDGenerator)
@@ -495,6 +495,12 @@ var _ = Describe("CreateEnvCmd", func() {
 			Expect(stdOut).To(gbytes.Say("Migrated legacy deployments file: '/path/to/bosh-deployments.yml'"))
 		})
 
+		It("releases the deployment state lock after deploying", func() {
+			err := command.Run(fakeStage, defaultCreateEnvOpts)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(fs.FileExists(deploymentStatePath + ".lock")).To(BeFalse())
+		})
+
 		It("sets the temp root", func() {
 			err := command.Run(fakeStage, defaultCreateEnvOpts)
 			Expect(err).NotTo(HaveOccurred())
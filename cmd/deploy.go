@@ -1,75 +1,675 @@
 package cmd
 
 import (
+	"context"
+	"crypto/sha1"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	"github.com/cppforlife/go-patch/patch"
 
+	cmdconf "github.com/cloudfoundry/bosh-cli/cmd/config"
 	boshdir "github.com/cloudfoundry/bosh-cli/director"
 	boshtpl "github.com/cloudfoundry/bosh-cli/director/template"
 	boshui "github.com/cloudfoundry/bosh-cli/ui"
+	boshtbl "github.com/cloudfoundry/bosh-cli/ui/table"
 )
 
 type DeployCmd struct {
 	ui              boshui.UI
+	director        boshdir.Director
 	deployment      boshdir.Deployment
-	releaseUploader ReleaseUploader
+	manifestFetcher ManifestFetcher
+
+	// releaseUploaderFactory builds release uploader for given director
+	// so that uploading releases is bound to --timeout
+	releaseUploaderFactory func(boshdir.Director) ReleaseUploader
+
+	signalNotifyFunc func(chan<- os.Signal, ...os.Signal)
+
+	// variableProvider is optional; it's consulted for variables
+	// not found in vars given via flags
+	variableProvider boshtpl.VariableProvider
+
+	// deployedManifests is optional; it records hash of the last
+	// successfully deployed manifest used by --skip-if-unchanged
+	deployedManifests DeployedManifests
 }
 
+// ErrDeployCancelled is returned when deploy task was cancelled by an interrupt
+var ErrDeployCancelled = errors.New("Deploy was cancelled")
+
+// deployLockRetryInitialDelay and deployLockRetryMaxDelay bound backoff
+// between update attempts while deployment is locked (see --wait-for-lock)
+const (
+	deployLockRetryInitialDelay = 5 * time.Second
+	deployLockRetryMaxDelay     = time.Minute
+)
+
+// ErrDeployTimedOut is returned when deploy did not finish within --timeout;
+// director requests are aborted and running deploy task is cancelled
+var ErrDeployTimedOut = errors.New("Deploy timed out")
+
 type ReleaseUploader interface {
-	UploadReleases([]byte) ([]byte, error)
+	// UploadReleases returns manifest updated with created release versions
+	// and results of releases processed so far even if uploading failed
+	UploadReleases([]byte, UploadReleasesOpts) ([]byte, []ReleaseUploadResult, error)
+}
+
+// ReleaseUploadStatus describes what happened to a manifest release while uploading releases
+type ReleaseUploadStatus string
+
+const (
+	ReleaseUploadStatusUploaded      ReleaseUploadStatus = "uploaded"
+	ReleaseUploadStatusAlreadyExists ReleaseUploadStatus = "already exists"
+	ReleaseUploadStatusNoURL         ReleaseUploadStatus = "skipped (no url)"
+	ReleaseUploadStatusFailed        ReleaseUploadStatus = "failed"
+)
+
+// ReleaseUploadResult keeps version of created release instead of 'create'
+type ReleaseUploadResult struct {
+	Name    string
+	Version string
+	Status  ReleaseUploadStatus
+}
+
+type UploadReleasesOpts struct {
+	// Order lists release names that should be uploaded first (in given order);
+	// remaining releases are uploaded in manifest order
+	Order []string
+
+	// Parallelism is a max number of releases processed at the same time
+	Parallelism int
+
+	// URLReplacements rewrite release URLs before uploading;
+	// first matching replacement is used
+	URLReplacements []URLReplaceArg
+
+	// VerifySHA1s downloads remote releases and verifies their SHA1s
+	// before uploading any of them
+	VerifySHA1s bool
+
+	// ForceUpload uploads remote releases even if the Director already has them
+	ForceUpload bool
 }
 
 func NewDeployCmd(
 	ui boshui.UI,
+	director boshdir.Director,
 	deployment boshdir.Deployment,
-	releaseUploader ReleaseUploader,
+	releaseUploaderFactory func(boshdir.Director) ReleaseUploader,
+	manifestFetcher ManifestFetcher,
+	signalNotifyFunc func(chan<- os.Signal, ...os.Signal),
+	variableProvider boshtpl.VariableProvider,
+	deployedManifests DeployedManifests,
 ) DeployCmd {
-	return DeployCmd{ui, deployment, releaseUploader}
+	return DeployCmd{ui, director, deployment, manifestFetcher, releaseUploaderFactory, signalNotifyFunc, variableProvider, deployedManifests}
 }
 
 func (c DeployCmd) Run(opts DeployOpts) error {
-	tpl := boshtpl.NewTemplate(opts.Args.Manifest.Bytes)
+	if opts.DiffContext != nil && *opts.DiffContext < 0 {
+		return bosherr.Errorf("Expected --diff-context to be a non-negative number of lines but was '%d'", *opts.DiffContext)
+	}
+
+	if opts.Recreate && opts.RecreateOnlyChanged {
+		return bosherr.Error("Expected only one of --recreate or --recreate-only-changed to be specified")
+	}
+
+	ctx := context.Background()
 
-	bytes, err := tpl.Evaluate(opts.VarFlags.AsVariables(), opts.OpsFlags.AsOp(), boshtpl.EvaluateOpts{})
+	if opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.Timeout)
+		defer cancel()
+	}
+
+	manifestBytes, err := c.manifestBytes(opts)
+	if err != nil {
+		return err
+	}
+
+	tpl := boshtpl.NewTemplate(manifestBytes)
+
+	evalOpts := boshtpl.EvaluateOpts{
+		ExpectAllKeys:     opts.VarErrors,
+		ExpectAllVarsUsed: opts.VarErrorsUnused,
+		PreserveKeyOrder:  true,
+
+		InterpolationPasses: opts.InterpolationPasses,
+
+		// Providers resolve variables named by their paths (e.g. '((secret/path/key))')
+		SlashedVariableNames: c.variableProvider != nil,
+	}
+
+	if opts.InterpolateOnly {
+		return c.interpolate(tpl, evalOpts, opts)
+	} else if opts.Path.IsSet() {
+		return bosherr.Error("Expected --path to be used with --interpolate-only")
+	}
+
+	bytes, err := tpl.Evaluate(c.variables(opts), opts.OpsFlags.AsOp(), evalOpts)
 	if err != nil {
 		return bosherr.WrapErrorf(err, "Evaluating manifest")
 	}
 
-	err = c.checkDeploymentName(bytes)
+	// Deployment name is taken from the manifest when it's not specified explicitly
+	if c.deployment == nil {
+		c.deployment, err = c.inferDeployment(bytes)
+	} else {
+		err = c.checkDeploymentName(bytes)
+	}
 	if err != nil {
 		return err
 	}
 
-	bytes, err = c.releaseUploader.UploadReleases(bytes)
+	err = c.checkDuplicateReleases(bytes)
 	if err != nil {
 		return err
 	}
 
-	deploymentDiff, err := c.deployment.Diff(bytes, opts.NoRedact)
+	// Errors reference lines of interpolated manifest since it's what gets deployed
+	err = validateManifest(bytes)
 	if err != nil {
 		return err
 	}
 
+	if len(opts.ExpectDirectorUUID) > 0 {
+		err = c.checkDirectorUUID(opts.ExpectDirectorUUID)
+		if err != nil {
+			return err
+		}
+	}
+
+	if opts.Force {
+		err = c.checkForceOpts(opts)
+		if err != nil {
+			return err
+		}
+	}
+
+	if opts.Preview {
+		return c.preview(bytes, opts)
+	}
+
+	// Confirmation prompt would compete with manifest for stdin
+	if c.manifestFromStdin(opts) && c.ui.IsInteractive() && !opts.Force {
+		return bosherr.Error("Expected --non-interactive to be used when reading manifest from stdin")
+	}
+
+	// Typed name confirmation is stricter than --non-interactive auto-confirmation
+	if opts.ConfirmName && !c.ui.IsInteractive() {
+		return bosherr.Error("Expected --non-interactive not to be used with --confirm-name " +
+			"since typed deployment name confirmation takes precedence and cannot be auto-confirmed")
+	}
+
+	// Hash is taken before uploading releases since uploading may update manifest
+	interpolatedBytes := bytes
+	manifestSHA1 := fmt.Sprintf("%x", sha1.Sum(bytes))
+
+	if opts.SkipIfUnchanged {
+		unchanged, err := c.manifestUnchanged(manifestSHA1)
+		if err != nil || unchanged {
+			return err
+		}
+	}
+
+	uploadOpts := UploadReleasesOpts{
+		Order:       opts.ReleaseUploadOrder,
+		Parallelism: opts.UploadParallelism,
+
+		URLReplacements: opts.ReleaseURLReplace,
+		VerifySHA1s:     opts.VerifyReleaseSHA1s,
+		ForceUpload:     opts.ForceUpload,
+	}
+
+	deployment, releaseUploader, err := c.withRequestContext(ctx, opts)
+	if err != nil {
+		return err
+	}
+
+	bytes, uploadResults, err := releaseUploader.UploadReleases(bytes, uploadOpts)
+	if err != nil && ctx.Err() != nil {
+		return ErrDeployTimedOut
+	} else if err != nil {
+		c.printReleasesSummary(uploadResults)
+		return NewReleaseUploadError(err)
+	}
+
+	var deploymentDiff boshdir.DeploymentDiff
+
+	if opts.Force {
+		c.ui.PrintLinef("Skipping manifest diff and confirmation since --force was given")
+	} else {
+		var proceed bool
+
+		deploymentDiff, proceed, err = c.diffAndConfirm(deployment, bytes, opts)
+		if err != nil && ctx.Err() != nil {
+			return ErrDeployTimedOut
+		} else if err != nil || !proceed {
+			return err
+		}
+	}
+
+	updateOpts := boshdir.UpdateOpts{
+		Recreate:                opts.Recreate,
+		RecreatePersistentDisks: opts.RecreatePersistentDisks,
+		Fix:                     opts.Fix,
+		SkipDrain:               opts.SkipDrain,
+		DryRun:                  opts.DryRun,
+		Canaries:                string(opts.Canaries),
+		MaxInFlight:             string(opts.MaxInFlight),
+		Diff:                    deploymentDiff,
+	}
+
+	if opts.RecreateOnlyChanged {
+		updateOpts.RecreateInstanceGroups = c.changedInstanceGroups(deploymentDiff)
+	}
+
+	err = c.update(ctx, deployment, bytes, updateOpts, opts.WaitForLock)
+	if err != nil {
+		return err
+	}
+
+	if !opts.DryRun {
+		c.saveDeployedManifest(manifestSHA1, interpolatedBytes)
+	}
+
+	c.printReleasesSummary(uploadResults)
+
+	if len(opts.RunErrand) > 0 {
+		return c.runErrand(opts.RunErrand)
+	}
+
+	return nil
+}
+
+// manifestUnchanged returns true if manifest has the same hash as the last successfully deployed one
+func (c DeployCmd) manifestUnchanged(manifestSHA1 string) (bool, error) {
+	if c.deployedManifests == nil {
+		return false, bosherr.Error("Expected last deployed manifest to be tracked to use --skip-if-unchanged")
+	}
+
+	deployed, found, err := c.deployedManifests.Find(c.deployment.Name())
+	if err != nil {
+		return false, bosherr.WrapError(err, "Finding last deployed manifest")
+	}
+
+	if !found || deployed.SHA1 != manifestSHA1 {
+		return false, nil
+	}
+
+	c.ui.PrintLinef("Manifest has not changed since last successful deploy at %s, skipping deploy",
+		deployed.DeployedAt.Format(time.RFC3339))
+
+	return true, nil
+}
+
+// saveDeployedManifest does not fail deploy since deployment is already updated;
+// manifest contents are kept for comparing against them with deploy-diff
+func (c DeployCmd) saveDeployedManifest(manifestSHA1 string, manifest []byte) {
+	if c.deployedManifests == nil {
+		return
+	}
+
+	deployed := cmdconf.DeployedManifest{
+		SHA1:       manifestSHA1,
+		DeployedAt: time.Now(),
+		Manifest:   string(manifest),
+	}
+
+	err := c.deployedManifests.Save(c.deployment.Name(), deployed)
+	if err != nil {
+		c.ui.ErrorLinef("Failed to save last deployed manifest: %s", err)
+	}
+}
+
+// changedInstanceGroups returns instance groups to recreate based on manifest diff;
+// nothing is recreated if diff has no instance group changes
+func (c DeployCmd) changedInstanceGroups(diff boshdir.DeploymentDiff) []string {
+	groups := diff.Summary().InstanceGroups
+
+	if len(groups) == 0 {
+		c.ui.PrintLinef("No instance groups changed, skipping recreate")
+	} else {
+		c.ui.PrintLinef("Recreating only changed instance groups: %s", strings.Join(groups, ", "))
+	}
+
+	return groups
+}
+
+// checkForceOpts rejects options that rely on manifest diff or confirmation
+// since --force skips both
+func (c DeployCmd) checkForceOpts(opts DeployOpts) error {
+	conflicts := []struct {
+		set  bool
+		name string
+	}{
+		{opts.Preview, "--preview"},
+		{opts.ConfirmName, "--confirm-name"},
+		{opts.SkipIfNoChanges, "--skip-if-no-changes"},
+		{opts.DiffOut.IsSet(), "--diff-out"},
+		{opts.RecreateOnlyChanged, "--recreate-only-changed"},
+	}
+
+	for _, conflict := range conflicts {
+		if conflict.set {
+			return bosherr.Errorf("Expected --force not to be used with %s since it skips manifest diff and confirmation", conflict.name)
+		}
+	}
+
+	return nil
+}
+
+// withRequestContext returns deployment and release uploader
+// which abort director requests once ctx is done
+func (c DeployCmd) withRequestContext(ctx context.Context, opts DeployOpts) (boshdir.Deployment, ReleaseUploader, error) {
+	if opts.Timeout <= 0 {
+		return c.deployment, c.releaseUploaderFactory(c.director), nil
+	}
+
+	director := c.director.WithRequestContext(ctx)
+
+	deployment, err := director.FindDeployment(c.deployment.Name())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return deployment, c.releaseUploaderFactory(director), nil
+}
+
+// diffAndConfirm shows manifest diff and asks for confirmation;
+// returned bool is false if deploy should be skipped without an error
+func (c DeployCmd) diffAndConfirm(deployment boshdir.Deployment, bytes []byte, opts DeployOpts) (boshdir.DeploymentDiff, bool, error) {
+	deploymentDiff, err := deployment.Diff(bytes, opts.NoRedact)
+	if err != nil {
+		return deploymentDiff, false, err
+	}
+
 	err = c.printManifestDiff(deploymentDiff, bytes, opts)
 	if err != nil {
-		return bosherr.WrapError(err, "Diffing manifest")
+		return deploymentDiff, false, bosherr.WrapError(err, "Diffing manifest")
+	}
+
+	// Diff is written even if it has no changes so that reviewers see that
+	if opts.DiffOut.IsSet() {
+		err = c.writeManifestDiff(deploymentDiff, opts)
+		if err != nil {
+			return deploymentDiff, false, err
+		}
+	}
+
+	// Releases are uploaded above even if manifest has not changed
+	if opts.SkipIfNoChanges && !deploymentDiff.Summary().HasChanges() {
+		c.ui.PrintLinef("No changes, skipping deploy")
+		return deploymentDiff, false, nil
+	}
+
+	if opts.ConfirmName {
+		err = c.ui.AskForConfirmationWithLabel(c.deployment.Name())
+	} else {
+		err = c.ui.AskForConfirmation()
+	}
+	if err != nil {
+		return deploymentDiff, false, err
+	}
+
+	return deploymentDiff, true, nil
+}
+
+// update cancels running deployment tasks on interrupt or once ctx is done
+// instead of leaving them running on the director; if waitForLock is set
+// update is retried with backoff while deployment is locked by another task
+func (c DeployCmd) update(ctx context.Context, deployment boshdir.Deployment, bytes []byte, updateOpts boshdir.UpdateOpts, waitForLock time.Duration) error {
+	signalCh := make(chan os.Signal, 1)
+	c.signalNotifyFunc(signalCh, os.Interrupt)
+	defer signal.Stop(signalCh)
+
+	lockDeadline := time.Now().Add(waitForLock)
+	lockRetryDelay := deployLockRetryInitialDelay
+
+	for {
+		errCh := make(chan error, 1)
+
+		go func() {
+			errCh <- deployment.Update(bytes, updateOpts)
+		}()
+
+		var err error
+
+		select {
+		case err = <-errCh:
+			if err == nil {
+				return nil
+			}
+
+		case <-signalCh:
+			c.cancelTasks()
+			<-errCh
+			return ErrDeployCancelled
+		}
+
+		// Update is aborted once ctx is done; tasks are cancelled
+		// via director which is not bound to ctx
+		if ctx.Err() != nil {
+			c.cancelTasks()
+			return ErrDeployTimedOut
+		}
+
+		if waitForLock <= 0 || !boshdir.IsDeploymentLockedError(err) {
+			return NewUpdateError(err)
+		}
+
+		remaining := lockDeadline.Sub(time.Now())
+		if remaining <= 0 {
+			return NewUpdateError(bosherr.WrapErrorf(err, "Waiting %s for deployment lock", waitForLock))
+		}
+
+		if lockRetryDelay > remaining {
+			lockRetryDelay = remaining
+		}
+
+		c.ui.PrintLinef("Deployment is locked by another task, retrying in %s", lockRetryDelay)
+
+		select {
+		case <-time.After(lockRetryDelay):
+		case <-signalCh:
+			return ErrDeployCancelled
+		case <-ctx.Done():
+			return ErrDeployTimedOut
+		}
+
+		lockRetryDelay *= 2
+
+		if lockRetryDelay > deployLockRetryMaxDelay {
+			lockRetryDelay = deployLockRetryMaxDelay
+		}
 	}
+}
 
-	err = c.ui.AskForConfirmation()
+func (c DeployCmd) cancelTasks() {
+	tasks, err := c.director.CurrentTasks(boshdir.TasksFilter{Deployment: c.deployment.Name()})
+	if err != nil {
+		c.ui.ErrorLinef("Failed to find running deploy task: %s", err)
+		return
+	}
+
+	for _, task := range tasks {
+		err := task.Cancel()
+		if err != nil {
+			c.ui.ErrorLinef("Failed to cancel task '%d': %s", task.ID(), err)
+			continue
+		}
+
+		c.ui.PrintLinef("Cancelled task '%d'", task.ID())
+	}
+}
+
+func (c DeployCmd) manifestBytes(opts DeployOpts) ([]byte, error) {
+	bytes := opts.Args.Manifest.Bytes
+
+	if len(opts.ManifestURL) > 0 {
+		if len(opts.Args.Manifest.Bytes) > 0 {
+			return nil, bosherr.Error("Expected either manifest path or --manifest-url but not both")
+		}
+
+		var err error
+
+		bytes, err = c.manifestFetcher.Fetch(opts.ManifestURL, opts.ManifestURLTimeout)
+		if err != nil {
+			return nil, err
+		}
+	} else if len(bytes) == 0 && len(opts.Manifests) == 0 {
+		return nil, bosherr.Error("Expected manifest path, --manifest or --manifest-url to be specified")
+	}
+
+	if len(opts.ManifestSHA1) > 0 {
+		if len(bytes) == 0 {
+			return nil, bosherr.Error("Expected --manifest-sha1 to be used with manifest path or --manifest-url")
+		}
+
+		err := verifyManifestSHA1(bytes, opts.ManifestSHA1)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if len(opts.Manifests) == 0 {
+		return bytes, nil
+	}
+
+	var fragments [][]byte
+
+	if len(bytes) > 0 {
+		fragments = append(fragments, bytes)
+	}
+
+	for _, manifest := range opts.Manifests {
+		fragments = append(fragments, manifest.Bytes)
+	}
+
+	// Single manifest is used as is to keep its formatting
+	if len(fragments) == 1 {
+		return fragments[0], nil
+	}
+
+	return mergeManifests(fragments, opts.ManifestMergeOverride)
+}
+
+// manifestFromStdin returns true if manifest or any of its fragments is read from stdin
+func (c DeployCmd) manifestFromStdin(opts DeployOpts) bool {
+	if opts.Args.Manifest.FromStdin {
+		return true
+	}
+
+	for _, manifest := range opts.Manifests {
+		if manifest.FromStdin {
+			return true
+		}
+	}
+
+	return false
+}
+
+// variables consults variable provider only for variables not found in vars given via flags
+func (c DeployCmd) variables(opts DeployOpts) boshtpl.Variables {
+	vars := opts.VarFlags.AsVariables()
+
+	if c.variableProvider == nil {
+		return vars
+	}
+
+	return boshtpl.NewMultiVars([]boshtpl.Variables{vars, boshtpl.NewProviderVars(c.variableProvider)})
+}
+
+// interpolate prints evaluated manifest or a value found at given path
+// without checking, diffing or deploying it
+func (c DeployCmd) interpolate(tpl boshtpl.Template, evalOpts boshtpl.EvaluateOpts, opts DeployOpts) error {
+	if opts.Path.IsSet() {
+		evalOpts.PostVarSubstitutionOp = patch.FindOp{Path: opts.Path}
+
+		// Printing YAML indented multiline strings (eg SSH key) is not useful
+		evalOpts.UnescapedMultiline = true
+	}
+
+	bytes, err := tpl.Evaluate(c.variables(opts), opts.OpsFlags.AsOp(), evalOpts)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Evaluating manifest")
+	}
+
+	c.ui.PrintBlock(string(bytes))
+
+	return nil
+}
+
+// preview shows manifest diff and remote releases that would be uploaded
+// without uploading releases or updating deployment
+func (c DeployCmd) preview(bytes []byte, opts DeployOpts) error {
+	manifest, err := boshdir.NewManifestFromBytes(bytes)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Parsing manifest")
+	}
+
+	for _, rel := range manifest.Releases {
+		if len(rel.URL) > 0 {
+			c.ui.PrintLinef("Would upload release '%s/%s' from '%s'", rel.Name, rel.Version, rel.URL)
+		}
+	}
+
+	deploymentDiff, err := c.deployment.Diff(bytes, opts.NoRedact)
 	if err != nil {
 		return err
 	}
 
-	updateOpts := boshdir.UpdateOpts{
-		Recreate:    opts.Recreate,
-		Fix:         opts.Fix,
-		SkipDrain:   opts.SkipDrain,
-		DryRun:      opts.DryRun,
-		Canaries:    opts.Canaries,
-		MaxInFlight: opts.MaxInFlight,
-		Diff:        deploymentDiff,
+	err = c.printManifestDiff(deploymentDiff, bytes, opts)
+	if err != nil {
+		return bosherr.WrapError(err, "Diffing manifest")
+	}
+
+	if opts.DiffOut.IsSet() {
+		return c.writeManifestDiff(deploymentDiff, opts)
+	}
+
+	return nil
+}
+
+// printReleasesSummary lists what happened to each manifest release while uploading releases
+func (c DeployCmd) printReleasesSummary(results []ReleaseUploadResult) {
+	if len(results) == 0 {
+		return
+	}
+
+	table := boshtbl.Table{
+		Content: "releases",
+
+		Header: []string{"Name", "Version", "Upload"},
+	}
+
+	for _, result := range results {
+		table.Rows = append(table.Rows, []boshtbl.Value{
+			boshtbl.NewValueString(result.Name),
+			boshtbl.NewValueString(result.Version),
+			boshtbl.NewValueString(string(result.Status)),
+		})
+	}
+
+	c.ui.PrintTable(table)
+}
+
+func (c DeployCmd) runErrand(name string) error {
+	errandOpts := RunErrandOpts{Args: RunErrandArgs{Name: name}}
+
+	err := NewRunErrandCmd(c.deployment, nil, c.ui).Run(errandOpts)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Deploy succeeded but running errand '%s' failed", name)
 	}
 
-	return c.deployment.Update(bytes, updateOpts)
+	return nil
 }
 
 func (c DeployCmd) checkDeploymentName(bytes []byte) error {
@@ -79,25 +679,290 @@ func (c DeployCmd) checkDeploymentName(bytes []byte) error {
 	}
 
 	if manifest.Name != c.deployment.Name() {
-		errMsg := "Expected manifest to specify deployment name '%s' but was '%s'"
-		return bosherr.Errorf(errMsg, c.deployment.Name(), manifest.Name)
+		return NewNameMismatchError(c.deployment.Name(), manifest.Name)
+	}
+
+	return nil
+}
+
+func (c DeployCmd) inferDeployment(bytes []byte) (boshdir.Deployment, error) {
+	manifest, err := boshdir.NewManifestFromBytes(bytes)
+	if err != nil {
+		return nil, bosherr.WrapErrorf(err, "Parsing manifest")
+	}
+
+	if len(manifest.Name) == 0 {
+		return nil, bosherr.Error("Expected manifest to specify deployment name " +
+			"since deployment was not specified via --deployment")
+	}
+
+	deployment, err := c.director.FindDeployment(manifest.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	c.ui.PrintLinef("Using deployment '%s'", manifest.Name)
+
+	return deployment, nil
+}
+
+func (c DeployCmd) checkDuplicateReleases(bytes []byte) error {
+	manifest, err := boshdir.NewManifestFromBytes(bytes)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Parsing manifest")
+	}
+
+	var names []string
+
+	versionsByName := map[string][]string{}
+
+	for _, rel := range manifest.Releases {
+		if _, found := versionsByName[rel.Name]; !found {
+			names = append(names, rel.Name)
+		}
+
+		versionsByName[rel.Name] = append(versionsByName[rel.Name], fmt.Sprintf("'%s'", rel.Version))
+	}
+
+	var errs []error
+
+	for _, name := range names {
+		versions := versionsByName[name]
+
+		if len(versions) > 1 {
+			errMsg := "Expected release '%s' to be specified once but was specified %d times with versions %s"
+			errs = append(errs, bosherr.Errorf(errMsg, name, len(versions), strings.Join(versions, ", ")))
+		}
+	}
+
+	if len(errs) > 0 {
+		return bosherr.NewMultiError(errs...)
+	}
+
+	return nil
+}
+
+type deployDiffLine struct {
+	Line   string `json:"line"`
+	Change string `json:"change"`
+}
+
+type deployDiffDocument struct {
+	Deployment   string `json:"deployment"`
+	DirectorUUID string `json:"director_uuid"`
+	User         string `json:"user"`
+	CreatedAt    string `json:"created_at"`
+
+	// Redacted is false when diff was requested with --no-redact
+	Redacted bool `json:"redacted"`
+
+	HasChanges bool             `json:"has_changes"`
+	Summary    string           `json:"summary"`
+	Lines      []deployDiffLine `json:"lines"`
+}
+
+func (c DeployCmd) checkDirectorUUID(expectedUUID string) error {
+	info, err := c.director.Info()
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Fetching director info")
+	}
+
+	if info.UUID != expectedUUID {
+		return bosherr.Errorf("Expected director UUID to be '%s' but was '%s'", expectedUUID, info.UUID)
 	}
 
 	return nil
 }
 
 func (c DeployCmd) printManifestDiff(diff boshdir.DeploymentDiff, bytes []byte, opts DeployOpts) error {
-	for _, line := range diff.Diff {
+	diffOpts := manifestDiffOpts{
+		NoRedact:       opts.NoRedact,
+		JSONDiff:       opts.JSONDiff,
+		Color:          opts.Color,
+		RedactPatterns: opts.RedactPatterns,
+		DiffContext:    opts.DiffContext,
+	}
+
+	return printManifestDiff(c.ui, diff, diffOpts)
+}
+
+// manifestDiffOpts configure how manifest diff is shown by deploy and deploy-diff
+type manifestDiffOpts struct {
+	NoRedact       bool
+	JSONDiff       bool
+	Color          string
+	RedactPatterns []RegexpArg
+	DiffContext    *int
+}
+
+func printManifestDiff(ui boshui.UI, diff boshdir.DeploymentDiff, opts manifestDiffOpts) error {
+	if opts.NoRedact {
+		ui.ErrorLinef("Warning: Showing non-redacted manifest diff; it may include secrets")
+	}
+
+	lines := redactDiffLines(diff.Diff, opts.RedactPatterns)
+
+	if opts.JSONDiff {
+		return printManifestDiffJSON(ui, lines)
+	}
+
+	if opts.DiffContext != nil {
+		lines = collapseDiffLines(lines, *opts.DiffContext)
+	}
+
+	colors := boshui.NewDiffColors(opts.Color)
+
+	for _, line := range lines {
 		lineMod, _ := line[1].(string)
 
 		if lineMod == "added" {
-			c.ui.BeginLinef("+ %s\n", line[0])
+			ui.BeginLinef("%s\n", colors.Added(fmt.Sprintf("+ %s", line[0])))
 		} else if lineMod == "removed" {
-			c.ui.BeginLinef("- %s\n", line[0])
+			ui.BeginLinef("%s\n", colors.Removed(fmt.Sprintf("- %s", line[0])))
 		} else {
-			c.ui.BeginLinef("  %s\n", line[0])
+			ui.BeginLinef("%s\n", colors.Unchanged(fmt.Sprintf("  %s", line[0])))
 		}
 	}
 
+	ui.PrintLinef("Summary: %s", diff.Summary().Description())
+
+	return nil
+}
+
+func printManifestDiffJSON(ui boshui.UI, diffLines [][]interface{}) error {
+	bytes, err := json.MarshalIndent(newDeployDiffLines(diffLines), "", "  ")
+	if err != nil {
+		return bosherr.WrapError(err, "Marshaling diff")
+	}
+
+	ui.PrintBlock(string(bytes))
+
+	return nil
+}
+
+// writeManifestDiff writes diff with deployment and director details
+// for reviewing it before deploy is confirmed
+func (c DeployCmd) writeManifestDiff(diff boshdir.DeploymentDiff, opts DeployOpts) error {
+	info, err := c.director.Info()
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Fetching director info")
+	}
+
+	doc := deployDiffDocument{
+		Deployment:   c.deployment.Name(),
+		DirectorUUID: info.UUID,
+		User:         info.User,
+		CreatedAt:    time.Now().UTC().Format(time.RFC3339),
+		Redacted:     !opts.NoRedact,
+		HasChanges:   diff.Summary().HasChanges(),
+		Summary:      diff.Summary().Description(),
+		Lines:        newDeployDiffLines(redactDiffLines(diff.Diff, opts.RedactPatterns)),
+	}
+
+	bytes, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return bosherr.WrapError(err, "Marshaling diff")
+	}
+
+	err = opts.DiffOut.Write(append(bytes, '\n'))
+	if err != nil {
+		return bosherr.WrapError(err, "Writing manifest diff")
+	}
+
+	c.ui.PrintLinef("Wrote manifest diff to '%s'", opts.DiffOut.Path)
+
 	return nil
 }
+
+func newDeployDiffLines(diffLines [][]interface{}) []deployDiffLine {
+	lines := []deployDiffLine{}
+
+	for _, line := range diffLines {
+		text, _ := line[0].(string)
+		lineMod, _ := line[1].(string)
+
+		change := "unchanged"
+
+		if lineMod == "added" || lineMod == "removed" {
+			change = lineMod
+		}
+
+		lines = append(lines, deployDiffLine{Line: text, Change: change})
+	}
+
+	return lines
+}
+
+// redactDiffLines replaces matches of patterns in diff lines with '<redacted>';
+// original diff lines are left untouched since diff is later sent back to the Director
+func redactDiffLines(diffLines [][]interface{}, patterns []RegexpArg) [][]interface{} {
+	if len(patterns) == 0 {
+		return diffLines
+	}
+
+	var redactedLines [][]interface{}
+
+	for _, line := range diffLines {
+		redactedLine := append([]interface{}{}, line...)
+
+		if text, ok := line[0].(string); ok {
+			for _, pattern := range patterns {
+				text = pattern.ReplaceAllString(text, "<redacted>")
+			}
+
+			redactedLine[0] = text
+		}
+
+		redactedLines = append(redactedLines, redactedLine)
+	}
+
+	return redactedLines
+}
+
+// collapseDiffLines replaces runs of unchanged lines further than context lines
+// away from added or removed lines with a single '... (k unchanged lines) ...' line
+func collapseDiffLines(diffLines [][]interface{}, context int) [][]interface{} {
+	keep := make([]bool, len(diffLines))
+
+	for i, line := range diffLines {
+		lineMod, _ := line[1].(string)
+
+		if lineMod != "added" && lineMod != "removed" {
+			continue
+		}
+
+		for j := i - context; j <= i+context; j++ {
+			if j >= 0 && j < len(diffLines) {
+				keep[j] = true
+			}
+		}
+	}
+
+	var collapsedLines [][]interface{}
+
+	skipped := 0
+
+	for i, line := range diffLines {
+		if !keep[i] {
+			skipped++
+			continue
+		}
+
+		if skipped > 0 {
+			collapsedLines = append(collapsedLines, collapsedDiffLine(skipped))
+			skipped = 0
+		}
+
+		collapsedLines = append(collapsedLines, line)
+	}
+
+	if skipped > 0 {
+		collapsedLines = append(collapsedLines, collapsedDiffLine(skipped))
+	}
+
+	return collapsedLines
+}
+
+func collapsedDiffLine(count int) []interface{} {
+	return []interface{}{fmt.Sprintf("... (%d unchanged lines) ...", count), ""}
+}
@@ -0,0 +1,97 @@
+package cmd
+
+import (
+	"time"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+
+	boshdir "github.com/cloudfoundry/bosh-cli/director"
+	boshtpl "github.com/cloudfoundry/bosh-cli/director/template"
+	boshui "github.com/cloudfoundry/bosh-cli/ui"
+)
+
+// DeployDiffCmd compares manifest against the one last successfully deployed
+// from this machine; it does not contact the Director so it works offline
+type DeployDiffCmd struct {
+	ui                boshui.UI
+	deployment        string
+	deployedManifests DeployedManifests
+}
+
+func NewDeployDiffCmd(ui boshui.UI, deployment string, deployedManifests DeployedManifests) DeployDiffCmd {
+	return DeployDiffCmd{ui: ui, deployment: deployment, deployedManifests: deployedManifests}
+}
+
+func (c DeployDiffCmd) Run(opts DeployDiffOpts) error {
+	tpl := boshtpl.NewTemplate(opts.Args.Manifest.Bytes)
+
+	evalOpts := boshtpl.EvaluateOpts{
+		ExpectAllKeys:     opts.VarErrors,
+		ExpectAllVarsUsed: opts.VarErrorsUnused,
+		PreserveKeyOrder:  true,
+
+		InterpolationPasses: opts.InterpolationPasses,
+	}
+
+	bytes, err := tpl.Evaluate(opts.VarFlags.AsVariables(), opts.OpsFlags.AsOp(), evalOpts)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Evaluating manifest")
+	}
+
+	deploymentName, err := c.deploymentName(bytes)
+	if err != nil {
+		return err
+	}
+
+	deployed, found, err := c.deployedManifests.Find(deploymentName)
+	if err != nil {
+		return bosherr.WrapError(err, "Finding last deployed manifest")
+	}
+
+	if !found {
+		return bosherr.Errorf("No manifest was recorded as last deployed to deployment '%s' "+
+			"from this machine; deploy it at least once to compare against it", deploymentName)
+	}
+
+	// Older versions only recorded hash of deployed manifest
+	if len(deployed.Manifest) == 0 {
+		return bosherr.Errorf("Last deployed manifest of deployment '%s' was recorded without "+
+			"its contents; deploy it again to compare against it", deploymentName)
+	}
+
+	diff, err := boshdir.NewLocalDeploymentDiff([]byte(deployed.Manifest), bytes, !opts.NoRedact)
+	if err != nil {
+		return bosherr.WrapError(err, "Diffing manifest")
+	}
+
+	c.ui.PrintLinef("Comparing against manifest deployed to '%s' at %s",
+		deploymentName, deployed.DeployedAt.Format(time.RFC3339))
+
+	diffOpts := manifestDiffOpts{
+		NoRedact:       opts.NoRedact,
+		JSONDiff:       opts.JSONDiff,
+		Color:          opts.Color,
+		RedactPatterns: opts.RedactPatterns,
+		DiffContext:    opts.DiffContext,
+	}
+
+	return printManifestDiff(c.ui, diff, diffOpts)
+}
+
+// deploymentName prefers explicitly specified deployment over manifest name
+func (c DeployDiffCmd) deploymentName(bytes []byte) (string, error) {
+	if len(c.deployment) > 0 {
+		return c.deployment, nil
+	}
+
+	manifest, err := boshdir.NewManifestFromBytes(bytes)
+	if err != nil {
+		return "", bosherr.WrapErrorf(err, "Parsing manifest")
+	}
+
+	if len(manifest.Name) == 0 {
+		return "", bosherr.Error("Expected deployment to be specified via --deployment or manifest 'name'")
+	}
+
+	return manifest.Name, nil
+}
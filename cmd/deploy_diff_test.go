@@ -0,0 +1,161 @@
+package cmd_test
+
+import (
+	"errors"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-cli/cmd"
+	cmdconf "github.com/cloudfoundry/bosh-cli/cmd/config"
+	boshtpl "github.com/cloudfoundry/bosh-cli/director/template"
+	fakeui "github.com/cloudfoundry/bosh-cli/ui/fakes"
+)
+
+var _ = Describe("DeployDiffCmd", func() {
+	var (
+		ui                *fakeui.FakeUI
+		deployedManifests *fakeDeployedManifests
+		deploymentName    string
+	)
+
+	BeforeEach(func() {
+		ui = &fakeui.FakeUI{}
+		deployedManifests = &fakeDeployedManifests{manifests: map[string]cmdconf.DeployedManifest{}}
+		deploymentName = ""
+	})
+
+	Describe("Run", func() {
+		var (
+			opts DeployDiffOpts
+		)
+
+		BeforeEach(func() {
+			opts = DeployDiffOpts{
+				Args: DeployArgs{
+					Manifest: FileBytesArg{
+						Bytes: []byte("name: dep\ninstance_groups:\n- name: web\n  instances: ((instances))\n"),
+					},
+				},
+			}
+
+			opts.VarKVs = []boshtpl.VarKV{{Name: "instances", Value: 2}}
+
+			deployedManifests.manifests["dep"] = cmdconf.DeployedManifest{
+				SHA1:       "sha1",
+				DeployedAt: time.Date(2017, time.January, 2, 3, 4, 5, 0, time.UTC),
+				Manifest:   "name: dep\ninstance_groups:\n- name: web\n  instances: 1\n",
+			}
+		})
+
+		act := func() error {
+			return NewDeployDiffCmd(ui, deploymentName, deployedManifests).Run(opts)
+		}
+
+		It("shows diff between interpolated manifest and last deployed manifest", func() {
+			err := act()
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(ui.Said).To(Equal([]string{
+				"Comparing against manifest deployed to 'dep' at 2017-01-02T03:04:05Z",
+				"  name: dep\n",
+				"  instance_groups:\n",
+				"  - name: web\n",
+				"-   instances: 1\n",
+				"+   instances: 2\n",
+				"Summary: 2 changes across 1 instance group",
+			}))
+		})
+
+		It("shows diff as JSON if requested", func() {
+			opts.JSONDiff = true
+
+			err := act()
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(ui.Blocks).To(HaveLen(1))
+			Expect(ui.Blocks[0]).To(ContainSubstring(`"line": "  instances: 2",`))
+			Expect(ui.Blocks[0]).To(ContainSubstring(`"change": "added"`))
+		})
+
+		It("redacts properties unless no-redact is specified", func() {
+			opts.Args.Manifest.Bytes = []byte("name: dep\nproperties:\n  password: secret2\n")
+			deployedManifests.manifests["dep"] = cmdconf.DeployedManifest{
+				Manifest: "name: dep\nproperties:\n  password: secret1\n",
+			}
+
+			err := act()
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(ui.Said).To(ContainElement("    password: <redacted>\n"))
+			Expect(ui.Said).To(ContainElement("Summary: no changes"))
+
+			ui.Said = nil
+			opts.NoRedact = true
+
+			err = act()
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(ui.Said).To(ContainElement("-   password: secret1\n"))
+			Expect(ui.Said).To(ContainElement("+   password: secret2\n"))
+			Expect(ui.Errors).To(ContainElement("Warning: Showing non-redacted manifest diff; it may include secrets"))
+		})
+
+		It("uses explicitly specified deployment instead of manifest name", func() {
+			deploymentName = "other-dep"
+			deployedManifests.manifests["other-dep"] = deployedManifests.manifests["dep"]
+			delete(deployedManifests.manifests, "dep")
+
+			err := act()
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(ui.Said).To(ContainElement("Comparing against manifest deployed to 'other-dep' at 2017-01-02T03:04:05Z"))
+		})
+
+		It("returns error if deployment name cannot be determined", func() {
+			opts.Args.Manifest.Bytes = []byte("instance_groups: []\n")
+
+			err := act()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("Expected deployment to be specified via --deployment or manifest 'name'"))
+		})
+
+		It("returns error if no manifest was recorded as deployed", func() {
+			delete(deployedManifests.manifests, "dep")
+
+			err := act()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("No manifest was recorded as last deployed to deployment 'dep' " +
+				"from this machine; deploy it at least once to compare against it"))
+		})
+
+		It("returns error if last deployed manifest was recorded without its contents", func() {
+			deployedManifests.manifests["dep"] = cmdconf.DeployedManifest{SHA1: "sha1"}
+
+			err := act()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("Last deployed manifest of deployment 'dep' was recorded without " +
+				"its contents; deploy it again to compare against it"))
+		})
+
+		It("returns error if finding last deployed manifest fails", func() {
+			deployedManifests.findErr = errors.New("fake-find-err")
+
+			err := act()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Finding last deployed manifest"))
+			Expect(err.Error()).To(ContainSubstring("fake-find-err"))
+		})
+
+		It("returns error if evaluating manifest fails", func() {
+			opts.VarKVs = nil
+			opts.VarErrors = true
+
+			err := act()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Evaluating manifest"))
+			Expect(err.Error()).To(ContainSubstring("Expected to find variables: instances"))
+		})
+	})
+})
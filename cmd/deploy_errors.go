@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"fmt"
+)
+
+// NameMismatchError is returned by deploy when manifest specifies
+// a deployment name different from the targeted deployment
+type NameMismatchError struct {
+	expectedName string
+	actualName   string
+}
+
+func NewNameMismatchError(expectedName, actualName string) NameMismatchError {
+	return NameMismatchError{
+		expectedName: expectedName,
+		actualName:   actualName,
+	}
+}
+
+func (e NameMismatchError) Error() string {
+	return fmt.Sprintf("Expected manifest to specify deployment name '%s' but was '%s'", e.expectedName, e.actualName)
+}
+
+func (e NameMismatchError) ExpectedName() string {
+	return e.expectedName
+}
+
+func (e NameMismatchError) ActualName() string {
+	return e.actualName
+}
+
+// ReleaseUploadError is returned by deploy when creating or uploading
+// releases referenced by the manifest fails
+type ReleaseUploadError struct {
+	cause error
+}
+
+func NewReleaseUploadError(cause error) ReleaseUploadError {
+	return ReleaseUploadError{cause: cause}
+}
+
+func (e ReleaseUploadError) Error() string {
+	return e.cause.Error()
+}
+
+func (e ReleaseUploadError) Cause() error {
+	return e.cause
+}
+
+// UpdateError is returned by deploy when updating deployment fails
+type UpdateError struct {
+	cause error
+}
+
+func NewUpdateError(cause error) UpdateError {
+	return UpdateError{cause: cause}
+}
+
+func (e UpdateError) Error() string {
+	return e.cause.Error()
+}
+
+func (e UpdateError) Cause() error {
+	return e.cause
+}
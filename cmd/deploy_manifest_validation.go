@@ -0,0 +1,225 @@
+package cmd
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	"gopkg.in/yaml.v2"
+)
+
+// validateManifest checks that manifest has sections every deployment needs
+// so that malformed manifests fail before diffing instead of on the Director.
+// Checks are intentionally shallow: only missing or contradicting keys are reported.
+func validateManifest(bytes []byte) error {
+	var manifest map[interface{}]interface{}
+
+	err := yaml.Unmarshal(bytes, &manifest)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Parsing manifest")
+	}
+
+	lines := newManifestLines(bytes)
+
+	var errs []error
+
+	if _, found := manifest["name"]; !found {
+		errs = append(errs, bosherr.Error("Expected manifest to specify 'name'"))
+	}
+
+	_, hasInstanceGroups := manifest["instance_groups"]
+	_, hasJobs := manifest["jobs"]
+
+	if !hasInstanceGroups && !hasJobs {
+		errs = append(errs, bosherr.Error("Expected manifest to specify 'instance_groups' or 'jobs'"))
+	}
+
+	// Manifests with 'jobs' may still use resource pools instead of stemcells
+	if _, found := manifest["stemcells"]; !found && !hasJobs {
+		errs = append(errs, bosherr.Error("Expected manifest to specify 'stemcells'"))
+	}
+
+	if rels, found := manifest["releases"]; found {
+		relErrs := validateManifestReleases(rels, lines)
+		errs = append(errs, relErrs...)
+
+		// Declared release names are only known if releases are valid
+		if len(relErrs) == 0 {
+			errs = append(errs, validateManifestJobReleases(manifest, rels, lines)...)
+		}
+	} else {
+		errs = append(errs, bosherr.Error("Expected manifest to specify 'releases'"))
+	}
+
+	if len(errs) > 0 {
+		return bosherr.WrapError(bosherr.NewMultiError(errs...), "Validating manifest")
+	}
+
+	return nil
+}
+
+func validateManifestReleases(rels interface{}, lines manifestLines) []error {
+	relsSlice, ok := rels.([]interface{})
+	if !ok {
+		return []error{bosherr.Errorf("Expected 'releases'%s to be a list", lines.keyRef("releases"))}
+	}
+
+	var errs []error
+
+	for i, rel := range relsSlice {
+		relPath := fmt.Sprintf("releases[%d]", i)
+		lineRef := lines.itemRef("releases", i)
+
+		relMap, ok := rel.(map[interface{}]interface{})
+		if !ok {
+			errs = append(errs, bosherr.Errorf("Expected '%s'%s to be a hash", relPath, lineRef))
+			continue
+		}
+
+		if name, _ := relMap["name"].(string); len(name) == 0 {
+			errs = append(errs, bosherr.Errorf("Expected '%s'%s to specify 'name'", relPath, lineRef))
+		}
+
+		url, _ := relMap["url"].(string)
+		_, hasSHA1 := relMap["sha1"]
+		_, hasVersion := relMap["version"]
+
+		switch {
+		case URLArg(url).IsRemote():
+			if !hasSHA1 || !hasVersion {
+				errs = append(errs, bosherr.Errorf(
+					"Expected '%s'%s to specify 'sha1' and 'version' since it specifies remote 'url'", relPath, lineRef))
+			}
+
+		case len(url) == 0 && hasSHA1:
+			errs = append(errs, bosherr.Errorf(
+				"Expected '%s'%s to specify 'url' since it specifies 'sha1'", relPath, lineRef))
+		}
+	}
+
+	return errs
+}
+
+// validateManifestJobReleases checks that releases referenced by jobs are declared in 'releases'.
+// Jobs without 'release' (v1 manifests with a single release) and references
+// with unresolved variables are skipped since the Director resolves them.
+func validateManifestJobReleases(manifest map[interface{}]interface{}, rels interface{}, lines manifestLines) []error {
+	declared := map[string]struct{}{}
+
+	for _, rel := range rels.([]interface{}) {
+		name, _ := rel.(map[interface{}]interface{})["name"].(string)
+		declared[name] = struct{}{}
+	}
+
+	var errs []error
+
+	checkRef := func(jobMap map[interface{}]interface{}, jobPath, lineRef string) {
+		relName, _ := jobMap["release"].(string)
+		if len(relName) == 0 || strings.Contains(relName, "((") {
+			return
+		}
+
+		if _, found := declared[relName]; !found {
+			errs = append(errs, bosherr.Errorf(
+				"Expected release '%s' referenced by '%s'%s to be declared in 'releases'", relName, jobPath, lineRef))
+		}
+	}
+
+	for _, key := range []string{"instance_groups", "jobs", "addons"} {
+		groups, _ := manifest[key].([]interface{})
+
+		for i, group := range groups {
+			groupMap, ok := group.(map[interface{}]interface{})
+			if !ok {
+				continue
+			}
+
+			groupPath := fmt.Sprintf("%s[%d]", key, i)
+			lineRef := lines.itemRef(key, i)
+
+			// v1 jobs may specify release for all of their templates
+			checkRef(groupMap, groupPath, lineRef)
+
+			for _, jobsKey := range []string{"jobs", "templates"} {
+				jobs, _ := groupMap[jobsKey].([]interface{})
+
+				for j, job := range jobs {
+					if jobMap, ok := job.(map[interface{}]interface{}); ok {
+						checkRef(jobMap, fmt.Sprintf("%s.%s[%d]", groupPath, jobsKey, j), lineRef)
+					}
+				}
+			}
+		}
+	}
+
+	return errs
+}
+
+var (
+	manifestTopLevelKeyRegexp = regexp.MustCompile(`^([^\s#-][^:]*):`)
+	manifestListItemRegexp    = regexp.MustCompile(`^(\s*)- `)
+)
+
+// manifestLines remembers where top level keys and their list items start.
+// Interpolated manifests are always marshalled by the same YAML library
+// so that simple line matching is enough to find them. Line numbers refer
+// to the interpolated manifest (as shown by --interpolate-only) since ops files,
+// variables and manifest fragments may move keys around in the original one.
+type manifestLines struct {
+	keys  map[string]int
+	items map[string][]int
+}
+
+func newManifestLines(bytes []byte) manifestLines {
+	lines := manifestLines{
+		keys:  map[string]int{},
+		items: map[string][]int{},
+	}
+
+	var currKey, itemIndent string
+	var inItems bool
+
+	for i, line := range strings.Split(string(bytes), "\n") {
+		if m := manifestTopLevelKeyRegexp.FindStringSubmatch(line); m != nil {
+			currKey = m[1]
+			lines.keys[currKey] = i + 1
+			inItems = false
+			continue
+		}
+
+		if len(currKey) == 0 {
+			continue
+		}
+
+		if m := manifestListItemRegexp.FindStringSubmatch(line); m != nil {
+			// Only items at the first seen indentation belong to the top level key
+			if !inItems {
+				itemIndent = m[1]
+				inItems = true
+			}
+
+			if m[1] == itemIndent {
+				lines.items[currKey] = append(lines.items[currKey], i+1)
+			}
+		}
+	}
+
+	return lines
+}
+
+func (l manifestLines) keyRef(key string) string {
+	if line, found := l.keys[key]; found {
+		return fmt.Sprintf(" (line %d of interpolated manifest)", line)
+	}
+
+	return ""
+}
+
+func (l manifestLines) itemRef(key string, i int) string {
+	if items := l.items[key]; i < len(items) {
+		return fmt.Sprintf(" (line %d of interpolated manifest)", items[i])
+	}
+
+	return ""
+}
@@ -1,42 +1,84 @@
 package cmd_test
 
 import (
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"time"
 
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
 	"github.com/cppforlife/go-patch/patch"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
+	"github.com/pivotal-golang/clock/fakeclock"
 
 	. "github.com/cloudfoundry/bosh-cli/cmd"
 	fakecmd "github.com/cloudfoundry/bosh-cli/cmd/cmdfakes"
+	cmdconf "github.com/cloudfoundry/bosh-cli/cmd/config"
 	boshdir "github.com/cloudfoundry/bosh-cli/director"
 	fakedir "github.com/cloudfoundry/bosh-cli/director/directorfakes"
 	boshtpl "github.com/cloudfoundry/bosh-cli/director/template"
+	boshui "github.com/cloudfoundry/bosh-cli/ui"
 	fakeui "github.com/cloudfoundry/bosh-cli/ui/fakes"
+	boshtbl "github.com/cloudfoundry/bosh-cli/ui/table"
 )
 
 var _ = Describe("DeployCmd", func() {
 	var (
-		ui              *fakeui.FakeUI
-		deployment      *fakedir.FakeDeployment
-		releaseUploader *fakecmd.FakeReleaseUploader
-		command         DeployCmd
+		ui                     *fakeui.FakeUI
+		director               *fakedir.FakeDirector
+		deployment             *fakedir.FakeDeployment
+		releaseUploader        *fakecmd.FakeReleaseUploader
+		releaseUploaderFactory func(boshdir.Director) ReleaseUploader
+		uploaderDirector       boshdir.Director
+		manifestFetcher        *fakecmd.FakeManifestFetcher
+		signalCh               chan<- os.Signal
+		deployedManifests      *fakeDeployedManifests
+		command                DeployCmd
 	)
 
 	BeforeEach(func() {
 		ui = &fakeui.FakeUI{}
+		director = &fakedir.FakeDirector{}
 		deployment = &fakedir.FakeDeployment{
 			NameStub: func() string { return "dep" },
 		}
 
 		releaseUploader = &fakecmd.FakeReleaseUploader{
-			UploadReleasesStub: func(bytes []byte) ([]byte, error) { return bytes, nil },
+			UploadReleasesStub: func(bytes []byte, _ UploadReleasesOpts) ([]byte, []ReleaseUploadResult, error) {
+				return bytes, nil, nil
+			},
 		}
 
-		command = NewDeployCmd(ui, deployment, releaseUploader)
+		manifestFetcher = &fakecmd.FakeManifestFetcher{}
+
+		signalNotifyFunc := func(ch chan<- os.Signal, s ...os.Signal) { signalCh = ch }
+
+		deployedManifests = &fakeDeployedManifests{manifests: map[string]cmdconf.DeployedManifest{}}
+
+		releaseUploaderFactory = func(d boshdir.Director) ReleaseUploader {
+			uploaderDirector = d
+			return releaseUploader
+		}
+
+		command = NewDeployCmd(ui, director, deployment, releaseUploaderFactory, manifestFetcher, signalNotifyFunc, nil, deployedManifests)
 	})
 
 	Describe("Run", func() {
+		const (
+			// validSections make manifests pass validation
+			validSections = "instance_groups: []\nreleases: []\nstemcells: []\n"
+
+			validManifest          = "name: dep\n" + validSections
+			evaluatedValidManifest = "name: dep\ninstance_groups: []\nreleases: []\nstemcells: []\n"
+		)
+
 		var (
 			opts DeployOpts
 		)
@@ -44,7 +86,7 @@ var _ = Describe("DeployCmd", func() {
 		BeforeEach(func() {
 			opts = DeployOpts{
 				Args: DeployArgs{
-					Manifest: FileBytesArg{Bytes: []byte("name: dep")},
+					Manifest: FileBytesArg{Bytes: []byte(validManifest)},
 				},
 			}
 		})
@@ -58,7 +100,7 @@ var _ = Describe("DeployCmd", func() {
 			Expect(deployment.UpdateCallCount()).To(Equal(1))
 
 			bytes, updateOpts := deployment.UpdateArgsForCall(0)
-			Expect(bytes).To(Equal([]byte("name: dep\n")))
+			Expect(bytes).To(Equal([]byte(evaluatedValidManifest)))
 			Expect(updateOpts).To(Equal(boshdir.UpdateOpts{}))
 		})
 
@@ -73,7 +115,7 @@ var _ = Describe("DeployCmd", func() {
 			Expect(deployment.UpdateCallCount()).To(Equal(1))
 
 			bytes, updateOpts := deployment.UpdateArgsForCall(0)
-			Expect(bytes).To(Equal([]byte("name: dep\n")))
+			Expect(bytes).To(Equal([]byte(evaluatedValidManifest)))
 			Expect(updateOpts).To(Equal(boshdir.UpdateOpts{
 				Recreate:  true,
 				Fix:       true,
@@ -81,6 +123,310 @@ var _ = Describe("DeployCmd", func() {
 			}))
 		})
 
+		It("deploys manifest allowing to fix unresponsive instances without recreating all VMs", func() {
+			opts.Fix = true
+
+			err := act()
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(deployment.UpdateCallCount()).To(Equal(1))
+
+			_, updateOpts := deployment.UpdateArgsForCall(0)
+			Expect(updateOpts).To(Equal(boshdir.UpdateOpts{Fix: true}))
+			Expect(updateOpts.Recreate).To(BeFalse())
+		})
+
+		It("does not fix unresponsive instances by default", func() {
+			err := act()
+			Expect(err).ToNot(HaveOccurred())
+
+			_, updateOpts := deployment.UpdateArgsForCall(0)
+			Expect(updateOpts.Fix).To(BeFalse())
+		})
+
+		It("deploys manifest overriding canaries and max in flight", func() {
+			opts.Canaries = InstanceCountArg("2")
+			opts.MaxInFlight = InstanceCountArg("25%")
+
+			err := act()
+			Expect(err).ToNot(HaveOccurred())
+
+			_, updateOpts := deployment.UpdateArgsForCall(0)
+			Expect(updateOpts).To(Equal(boshdir.UpdateOpts{
+				Canaries:    "2",
+				MaxInFlight: "25%",
+			}))
+		})
+
+		It("deploys manifest allowing to recreate persistent disks independently of VMs", func() {
+			opts.RecreatePersistentDisks = true
+
+			err := act()
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(deployment.UpdateCallCount()).To(Equal(1))
+
+			_, updateOpts := deployment.UpdateArgsForCall(0)
+			Expect(updateOpts).To(Equal(boshdir.UpdateOpts{
+				RecreatePersistentDisks: true,
+			}))
+		})
+
+		Context("when recreating only changed instance groups", func() {
+			BeforeEach(func() {
+				opts.RecreateOnlyChanged = true
+			})
+
+			It("deploys manifest recreating instance groups changed according to diff", func() {
+				diff := [][]interface{}{
+					[]interface{}{"instance_groups:", nil},
+					[]interface{}{"- name: router", nil},
+					[]interface{}{"  instances: 2", "added"},
+					[]interface{}{"- name: api", nil},
+					[]interface{}{"  instances: 1", nil},
+					[]interface{}{"- name: diego-cell", nil},
+					[]interface{}{"  vm_type: large", "removed"},
+				}
+
+				deployment.DiffReturns(boshdir.NewDeploymentDiff(diff, nil), nil)
+
+				err := act()
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(deployment.UpdateCallCount()).To(Equal(1))
+
+				_, updateOpts := deployment.UpdateArgsForCall(0)
+				Expect(updateOpts.Recreate).To(BeFalse())
+				Expect(updateOpts.RecreateInstanceGroups).To(Equal([]string{"router", "diego-cell"}))
+
+				Expect(ui.Said).To(ContainElement("Recreating only changed instance groups: router, diego-cell"))
+			})
+
+			It("deploys manifest without recreating anything if diff has no instance group changes", func() {
+				diff := [][]interface{}{
+					[]interface{}{"update:", nil},
+					[]interface{}{"  canaries: 2", "added"},
+				}
+
+				deployment.DiffReturns(boshdir.NewDeploymentDiff(diff, nil), nil)
+
+				err := act()
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(deployment.UpdateCallCount()).To(Equal(1))
+
+				_, updateOpts := deployment.UpdateArgsForCall(0)
+				Expect(updateOpts.Recreate).To(BeFalse())
+				Expect(updateOpts.RecreateInstanceGroups).To(BeEmpty())
+
+				Expect(ui.Said).To(ContainElement("No instance groups changed, skipping recreate"))
+			})
+
+			It("returns error and does not deploy if used with --recreate", func() {
+				opts.Recreate = true
+
+				err := act()
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(Equal("Expected only one of --recreate or --recreate-only-changed to be specified"))
+
+				Expect(releaseUploader.UploadReleasesCallCount()).To(Equal(0))
+				Expect(deployment.UpdateCallCount()).To(Equal(0))
+			})
+
+			It("returns error and does not deploy if used with --force", func() {
+				opts.Force = true
+
+				err := act()
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(Equal(
+					"Expected --force not to be used with --recreate-only-changed since it skips manifest diff and confirmation"))
+
+				Expect(releaseUploader.UploadReleasesCallCount()).To(Equal(0))
+				Expect(deployment.UpdateCallCount()).To(Equal(0))
+			})
+		})
+
+		It("deploys manifest skipping drain only for specified instance groups", func() {
+			skipDrains := boshdir.SkipDrains{
+				boshdir.SkipDrain{Slug: boshdir.NewInstanceGroupOrInstanceSlug("router", "")},
+				boshdir.SkipDrain{Slug: boshdir.NewInstanceGroupOrInstanceSlug("diego-cell", "")},
+			}
+
+			opts.SkipDrain = skipDrains
+
+			err := act()
+			Expect(err).ToNot(HaveOccurred())
+
+			_, updateOpts := deployment.UpdateArgsForCall(0)
+			Expect(updateOpts.SkipDrain).To(Equal(skipDrains))
+			Expect(updateOpts.SkipDrain.AsQueryValue()).To(Equal("router,diego-cell"))
+		})
+
+		Context("when interrupted while deploying", func() {
+			var (
+				task *fakedir.FakeTask
+			)
+
+			BeforeEach(func() {
+				task = &fakedir.FakeTask{}
+				task.IDReturns(42)
+
+				director.CurrentTasksReturns([]boshdir.Task{task}, nil)
+
+				deployment.UpdateStub = func(_ []byte, _ boshdir.UpdateOpts) error {
+					signalCh <- os.Interrupt
+					Eventually(task.CancelCallCount).Should(Equal(1))
+					return errors.New("fake-task-cancelled-err")
+				}
+			})
+
+			It("cancels running deployment tasks and returns cancelled error", func() {
+				err := act()
+				Expect(err).To(Equal(ErrDeployCancelled))
+
+				Expect(director.CurrentTasksArgsForCall(0)).To(Equal(boshdir.TasksFilter{Deployment: "dep"}))
+				Expect(ui.Said).To(ContainElement("Cancelled task '42'"))
+			})
+
+			It("reports error if cancelling task fails", func() {
+				task.CancelReturns(errors.New("fake-err"))
+
+				err := act()
+				Expect(err).To(Equal(ErrDeployCancelled))
+
+				Expect(ui.Errors).To(ContainElement("Failed to cancel task '42': fake-err"))
+			})
+
+			It("does not run errand after cancellation", func() {
+				opts.RunErrand = "smoke-tests"
+
+				err := act()
+				Expect(err).To(Equal(ErrDeployCancelled))
+
+				Expect(deployment.RunErrandCallCount()).To(Equal(0))
+			})
+		})
+
+		Context("when timeout is specified", func() {
+			var (
+				task *fakedir.FakeTask
+
+				boundDirector   *fakedir.FakeDirector
+				boundDeployment *fakedir.FakeDeployment
+				requestCtx      context.Context
+			)
+
+			BeforeEach(func() {
+				task = &fakedir.FakeTask{}
+				task.IDReturns(42)
+
+				director.CurrentTasksReturns([]boshdir.Task{task}, nil)
+
+				boundDeployment = &fakedir.FakeDeployment{
+					NameStub: func() string { return "dep" },
+				}
+
+				boundDirector = &fakedir.FakeDirector{}
+				boundDirector.FindDeploymentReturns(boundDeployment, nil)
+
+				director.WithRequestContextStub = func(ctx context.Context) boshdir.Director {
+					requestCtx = ctx
+					return boundDirector
+				}
+
+				opts.Timeout = 10 * time.Millisecond
+			})
+
+			It("uploads releases, diffs and updates via director bound to request context", func() {
+				opts.Timeout = time.Minute
+
+				err := act()
+				Expect(err).ToNot(HaveOccurred())
+
+				deadline, ok := requestCtx.Deadline()
+				Expect(ok).To(BeTrue())
+				Expect(deadline).To(BeTemporally("~", time.Now().Add(time.Minute), time.Second))
+
+				Expect(boundDirector.FindDeploymentArgsForCall(0)).To(Equal("dep"))
+				Expect(uploaderDirector).To(Equal(boundDirector))
+
+				Expect(boundDeployment.DiffCallCount()).To(Equal(1))
+				Expect(boundDeployment.UpdateCallCount()).To(Equal(1))
+				Expect(deployment.UpdateCallCount()).To(Equal(0))
+
+				Expect(director.CurrentTasksCallCount()).To(Equal(0))
+			})
+
+			It("cancels running deployment tasks and returns timeout error if updating takes too long", func() {
+				boundDeployment.UpdateStub = func(_ []byte, _ boshdir.UpdateOpts) error {
+					<-requestCtx.Done()
+					return requestCtx.Err()
+				}
+
+				err := act()
+				Expect(err).To(Equal(ErrDeployTimedOut))
+
+				Expect(director.CurrentTasksArgsForCall(0)).To(Equal(boshdir.TasksFilter{Deployment: "dep"}))
+				Expect(task.CancelCallCount()).To(Equal(1))
+				Expect(ui.Said).To(ContainElement("Cancelled task '42'"))
+			})
+
+			It("returns timeout error without updating if uploading releases takes too long", func() {
+				releaseUploader.UploadReleasesStub = func(bytes []byte, _ UploadReleasesOpts) ([]byte, []ReleaseUploadResult, error) {
+					<-requestCtx.Done()
+					return nil, nil, requestCtx.Err()
+				}
+
+				err := act()
+				Expect(err).To(Equal(ErrDeployTimedOut))
+
+				Expect(boundDeployment.UpdateCallCount()).To(Equal(0))
+			})
+
+			It("returns timeout error without updating if diffing takes too long", func() {
+				boundDeployment.DiffStub = func(_ []byte, _ bool) (boshdir.DeploymentDiff, error) {
+					<-requestCtx.Done()
+					return boshdir.DeploymentDiff{}, requestCtx.Err()
+				}
+
+				err := act()
+				Expect(err).To(Equal(ErrDeployTimedOut))
+
+				Expect(boundDeployment.UpdateCallCount()).To(Equal(0))
+			})
+
+			It("returns timeout error if deployment is still locked once timeout passes", func() {
+				opts.WaitForLock = time.Minute
+
+				boundDeployment.UpdateReturns(boshdir.TaskError{
+					ID:     123,
+					State:  "error",
+					Result: "Failed to acquire lock for lock:deployment:dep uid: fake-uid",
+				})
+
+				err := act()
+				Expect(err).To(Equal(ErrDeployTimedOut))
+
+				Expect(boundDeployment.UpdateCallCount()).To(Equal(1))
+			})
+		})
+
+		It("does not bind director to request context if timeout is not specified", func() {
+			err := act()
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(director.WithRequestContextCallCount()).To(Equal(0))
+			Expect(uploaderDirector).To(Equal(director))
+			Expect(deployment.UpdateCallCount()).To(Equal(1))
+		})
+
+		It("does not cancel tasks if not interrupted", func() {
+			err := act()
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(director.CurrentTasksCallCount()).To(Equal(0))
+		})
+
 		It("deploys manifest allowing to dry_run", func() {
 			opts.DryRun = true
 
@@ -90,7 +436,7 @@ var _ = Describe("DeployCmd", func() {
 			Expect(deployment.UpdateCallCount()).To(Equal(1))
 
 			bytes, updateOpts := deployment.UpdateArgsForCall(0)
-			Expect(bytes).To(Equal([]byte("name: dep\n")))
+			Expect(bytes).To(Equal([]byte(evaluatedValidManifest)))
 			Expect(updateOpts).To(Equal(boshdir.UpdateOpts{
 				DryRun: true,
 			}))
@@ -98,7 +444,7 @@ var _ = Describe("DeployCmd", func() {
 
 		It("deploys templated manifest", func() {
 			opts.Args.Manifest = FileBytesArg{
-				Bytes: []byte("name: dep\nname1: ((name1))\nname2: ((name2))\n"),
+				Bytes: []byte("name: dep\nname1: ((name1))\nname2: ((name2))\n" + validSections),
 			}
 
 			opts.VarKVs = []boshtpl.VarKV{
@@ -124,134 +470,1935 @@ var _ = Describe("DeployCmd", func() {
 			Expect(deployment.UpdateCallCount()).To(Equal(1))
 
 			bytes, _ := deployment.UpdateArgsForCall(0)
-			Expect(bytes).To(Equal([]byte("name: dep\nname1: val1-from-kv\nname2: val2-from-file\nxyz: val\n")))
+			Expect(bytes).To(Equal([]byte("name: dep\nname1: val1-from-kv\nname2: val2-from-file\ninstance_groups: []\nreleases: []\nstemcells: []\nxyz: val\n")))
 		})
 
-		It("does not deploy if name specified in the manifest does not match deployment's name", func() {
+		Context("when variable provider is given", func() {
+			var (
+				provider *fakeVariableProvider
+			)
+
+			BeforeEach(func() {
+				provider = &fakeVariableProvider{
+					vars: map[string]interface{}{
+						"secret/path/key": "val-from-provider",
+						"name1":           "val1-from-provider",
+					},
+				}
+
+				command = NewDeployCmd(ui, director, deployment, releaseUploaderFactory, manifestFetcher, func(chan<- os.Signal, ...os.Signal) {}, provider, nil)
+			})
+
+			It("deploys manifest with variables not given via flags resolved by the provider", func() {
+				opts.Args.Manifest = FileBytesArg{
+					Bytes: []byte("name: dep\nname1: ((name1))\nname2: ((secret/path/key))\n" + validSections),
+				}
+
+				opts.VarKVs = []boshtpl.VarKV{
+					{Name: "name1", Value: "val1-from-kv"},
+				}
+
+				err := act()
+				Expect(err).ToNot(HaveOccurred())
+
+				bytes, _ := deployment.UpdateArgsForCall(0)
+				Expect(bytes).To(Equal([]byte("name: dep\nname1: val1-from-kv\nname2: val-from-provider\n" + validSections)))
+
+				Expect(provider.names).To(Equal([]string{"secret/path/key"}))
+			})
+
+			It("returns error if variables are not found by the provider and var-errs is specified", func() {
+				opts.Args.Manifest = FileBytesArg{
+					Bytes: []byte("name: dep\nname1: ((secret/missing))\n" + validSections),
+				}
+
+				opts.VarErrors = true
+
+				err := act()
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("Expected to find variables: secret/missing"))
+
+				Expect(deployment.UpdateCallCount()).To(Equal(0))
+			})
+
+			It("returns error if provider fails", func() {
+				opts.Args.Manifest = FileBytesArg{
+					Bytes: []byte("name: dep\nname1: ((secret/path/key))\n" + validSections),
+				}
+
+				provider.err = errors.New("fake-provider-err")
+
+				err := act()
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("fake-provider-err"))
+
+				Expect(deployment.UpdateCallCount()).To(Equal(0))
+			})
+		})
+
+		It("does not treat references with slashes as variables if variable provider is not given", func() {
 			opts.Args.Manifest = FileBytesArg{
-				Bytes: []byte("name: other-name"),
+				Bytes: []byte("name: dep\nname1: ((secret/path/key))\n" + validSections),
 			}
 
+			opts.VarErrors = true
+
 			err := act()
-			Expect(err).To(HaveOccurred())
-			Expect(err.Error()).To(Equal(
-				"Expected manifest to specify deployment name 'dep' but was 'other-name'"))
+			Expect(err).ToNot(HaveOccurred())
 
-			Expect(deployment.UpdateCallCount()).To(Equal(0))
+			bytes, _ := deployment.UpdateArgsForCall(0)
+			Expect(bytes).To(Equal([]byte("name: dep\nname1: ((secret/path/key))\n" + validSections)))
 		})
 
-		It("uploads releases provided in the manifest after manifest has been interpolated", func() {
+		It("returns error listing all missing variables if var-errs is specified", func() {
 			opts.Args.Manifest = FileBytesArg{
-				Bytes: []byte("name: dep\nbefore-upload-manifest: ((key))"),
+				Bytes: []byte("name: dep\nname1: ((name1))\nname2: ((name2))\nname3: ((name3))\n"),
 			}
 
 			opts.VarKVs = []boshtpl.VarKV{
-				{Name: "key", Value: "key-val"},
+				{Name: "name2", Value: "val2"},
 			}
 
-			releaseUploader.UploadReleasesReturns([]byte("after-upload-manifest"), nil)
+			opts.VarErrors = true
 
 			err := act()
-			Expect(err).ToNot(HaveOccurred())
-
-			bytes := releaseUploader.UploadReleasesArgsForCall(0)
-			Expect(bytes).To(Equal([]byte("before-upload-manifest: key-val\nname: dep\n")))
-
-			Expect(deployment.UpdateCallCount()).To(Equal(1))
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Expected to find variables: name1\nname3"))
 
-			bytes, _ = deployment.UpdateArgsForCall(0)
-			Expect(bytes).To(Equal([]byte("after-upload-manifest")))
+			Expect(deployment.UpdateCallCount()).To(Equal(0))
 		})
 
-		It("returns error and does not deploy if uploading releases fails", func() {
+		It("returns error listing all unused variables if var-errs-unused is specified", func() {
 			opts.Args.Manifest = FileBytesArg{
-				Bytes: []byte(`
-name: dep
-releases:
-- name: capi
-  sha1: capi-sha1
-  url: https://capi-url
-  version: 1+capi
-`),
+				Bytes: []byte("name: dep\nname1: ((name1))\n"),
+			}
+
+			opts.VarKVs = []boshtpl.VarKV{
+				{Name: "name1", Value: "val1"},
+				{Name: "nam2", Value: "val2"},
+				{Name: "nam3", Value: "val3"},
 			}
 
-			releaseUploader.UploadReleasesReturns(nil, errors.New("fake-err"))
+			opts.VarErrorsUnused = true
 
 			err := act()
 			Expect(err).To(HaveOccurred())
-			Expect(err.Error()).To(ContainSubstring("fake-err"))
+			Expect(err.Error()).To(ContainSubstring("Expected to use variables: nam2\nnam3"))
 
 			Expect(deployment.UpdateCallCount()).To(Equal(0))
 		})
 
-		It("uploads releases but does not deploy if confirmation is rejected", func() {
+		It("resolves variable references found in values of variables within interpolation passes", func() {
 			opts.Args.Manifest = FileBytesArg{
-				Bytes: []byte(`
-name: dep
-releases:
-- name: capi
-  sha1: capi-sha1
-  url: /capi-url
-  version: create
-`),
+				Bytes: []byte("name: dep\nurl: ((url))\n" + validSections),
 			}
 
-			ui.AskedConfirmationErr = errors.New("stop")
+			opts.VarKVs = []boshtpl.VarKV{
+				{Name: "url", Value: "https://((host))"},
+				{Name: "host", Value: "((domain))"},
+				{Name: "domain", Value: "example.com"},
+			}
+
+			opts.InterpolationPasses = 3
 
 			err := act()
-			Expect(err).To(HaveOccurred())
-			Expect(err.Error()).To(ContainSubstring("stop"))
+			Expect(err).ToNot(HaveOccurred())
 
-			Expect(releaseUploader.UploadReleasesCallCount()).To(Equal(1))
-			Expect(deployment.UpdateCallCount()).To(Equal(0))
+			bytes, _ := deployment.UpdateArgsForCall(0)
+			Expect(bytes).To(Equal([]byte("name: dep\nurl: https://example.com\n" + validSections)))
 		})
 
-		It("returns an error if diffing failed", func() {
-			deployment.DiffReturns(boshdir.DeploymentDiff{}, errors.New("Fetching diff result"))
+		It("returns error if variable references found in values of variables form a cycle", func() {
+			opts.Args.Manifest = FileBytesArg{
+				Bytes: []byte("name: dep\nname1: ((name1))\n" + validSections),
+			}
+
+			opts.VarKVs = []boshtpl.VarKV{
+				{Name: "name1", Value: "((name2))"},
+				{Name: "name2", Value: "((name1))"},
+			}
+
+			opts.InterpolationPasses = 3
 
 			err := act()
 			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Detected cycle in variable references: name1 -> name2 -> name1"))
+
+			Expect(deployment.UpdateCallCount()).To(Equal(0))
 		})
 
-		It("gets the diff from the deployment", func() {
-			diff := [][]interface{}{
-				[]interface{}{"some line that stayed", nil},
-				[]interface{}{"some line that was added", "added"},
-				[]interface{}{"some line that was removed", "removed"},
+		It("ignores unused variables if var-errs-unused is not specified", func() {
+			opts.Args.Manifest = FileBytesArg{
+				Bytes: []byte("name: dep\nname1: ((name1))\n" + validSections),
+			}
+
+			opts.VarKVs = []boshtpl.VarKV{
+				{Name: "name1", Value: "val1"},
+				{Name: "nam2", Value: "val2"},
 			}
 
-			expectedDiff := boshdir.NewDeploymentDiff(diff, nil)
-			deployment.DiffReturns(expectedDiff, nil)
 			err := act()
 			Expect(err).ToNot(HaveOccurred())
-			Expect(deployment.DiffCallCount()).To(Equal(1))
-			Expect(ui.Said).To(ContainElement("  some line that stayed\n"))
-			Expect(ui.Said).To(ContainElement("+ some line that was added\n"))
-			Expect(ui.Said).To(ContainElement("- some line that was removed\n"))
+
+			Expect(deployment.UpdateCallCount()).To(Equal(1))
 		})
 
-		It("deploys manifest with diff context", func() {
-			context := map[string]interface{}{
-				"cloud_config_id":   2,
-				"runtime_config_id": 3,
-			}
-			expectedDiff := boshdir.NewDeploymentDiff(nil, context)
+		Context("when only interpolating", func() {
+			BeforeEach(func() {
+				opts.InterpolateOnly = true
 
-			deployment.DiffReturns(expectedDiff, nil)
-			err := act()
-			Expect(err).ToNot(HaveOccurred())
-			Expect(deployment.DiffCallCount()).To(Equal(1))
+				opts.Args.Manifest = FileBytesArg{
+					Bytes: []byte("name: dep\ninstance_groups:\n- name: router\n  instances: ((instances))\n"),
+				}
 
-			_, updateOptions := deployment.UpdateArgsForCall(0)
-			Expect(updateOptions.Diff).To(Equal(expectedDiff))
+				opts.VarKVs = []boshtpl.VarKV{
+					{Name: "instances", Value: 2},
+				}
+			})
+
+			It("prints evaluated manifest without uploading releases, diffing or deploying", func() {
+				err := act()
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(ui.Blocks).To(Equal([]string{"name: dep\ninstance_groups:\n- name: router\n  instances: 2\n"}))
+
+				Expect(releaseUploader.UploadReleasesCallCount()).To(Equal(0))
+				Expect(deployment.DiffCallCount()).To(Equal(0))
+				Expect(deployment.UpdateCallCount()).To(Equal(0))
+			})
+
+			It("prints value found at given path", func() {
+				opts.Path = patch.MustNewPointerFromString("/instance_groups/name=router/instances")
+
+				err := act()
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(ui.Blocks).To(Equal([]string{"2\n"}))
+				Expect(deployment.UpdateCallCount()).To(Equal(0))
+			})
+
+			It("returns error naming missing path segment", func() {
+				opts.Path = patch.MustNewPointerFromString("/instance_groups/name=web/instances")
+
+				err := act()
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("Evaluating manifest"))
+				Expect(err.Error()).To(ContainSubstring("name=web"))
+
+				Expect(ui.Blocks).To(BeEmpty())
+			})
 		})
 
-		It("returns error if deploying failed", func() {
-			deployment.UpdateReturns(errors.New("fake-err"))
+		It("returns error if path is given without interpolate-only", func() {
+			opts.Path = patch.MustNewPointerFromString("/name")
 
 			err := act()
 			Expect(err).To(HaveOccurred())
-			Expect(err.Error()).To(ContainSubstring("fake-err"))
+			Expect(err.Error()).To(Equal("Expected --path to be used with --interpolate-only"))
+
+			Expect(deployment.UpdateCallCount()).To(Equal(0))
 		})
-	})
-})
+
+		Context("when manifest is read from stdin", func() {
+			BeforeEach(func() {
+				opts.Args.Manifest.FromStdin = true
+			})
+
+			It("returns error and does not upload releases or deploy if ui is interactive", func() {
+				ui.Interactive = true
+
+				err := act()
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(Equal("Expected --non-interactive to be used when reading manifest from stdin"))
+
+				Expect(releaseUploader.UploadReleasesCallCount()).To(Equal(0))
+				Expect(deployment.UpdateCallCount()).To(Equal(0))
+			})
+
+			It("deploys manifest if ui is non-interactive", func() {
+				err := act()
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(deployment.UpdateCallCount()).To(Equal(1))
+
+				bytes, _ := deployment.UpdateArgsForCall(0)
+				Expect(bytes).To(Equal([]byte(evaluatedValidManifest)))
+			})
+		})
+
+		It("checks deployment name against manifest with ops files applied", func() {
+			opts.Args.Manifest = FileBytesArg{
+				Bytes: []byte("name: other-name\n" + validSections),
+			}
+
+			opts.OpsFiles = []OpsFileArg{
+				{
+					Ops: patch.Ops([]patch.Op{
+						patch.ReplaceOp{Path: patch.MustNewPointerFromString("/name"), Value: "dep"},
+					}),
+				},
+			}
+
+			err := act()
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(deployment.UpdateCallCount()).To(Equal(1))
+
+			bytes, _ := deployment.UpdateArgsForCall(0)
+			Expect(bytes).To(Equal([]byte(evaluatedValidManifest)))
+		})
+
+		It("returns error and does not deploy if ops file cannot be applied", func() {
+			opts.OpsFiles = []OpsFileArg{
+				{
+					Ops: patch.Ops([]patch.Op{
+						patch.ReplaceOp{Path: patch.MustNewPointerFromString("/missing-key/nested"), Value: "val"},
+					}),
+				},
+			}
+
+			err := act()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Evaluating manifest"))
+			Expect(err.Error()).To(ContainSubstring("missing-key"))
+
+			Expect(deployment.UpdateCallCount()).To(Equal(0))
+		})
+
+		It("does not deploy if name specified in the manifest does not match deployment's name", func() {
+			opts.Args.Manifest = FileBytesArg{
+				Bytes: []byte("name: other-name"),
+			}
+
+			err := act()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal(
+				"Expected manifest to specify deployment name 'dep' but was 'other-name'"))
+			Expect(err).To(Equal(NewNameMismatchError("dep", "other-name")))
+
+			Expect(deployment.UpdateCallCount()).To(Equal(0))
+		})
+
+		Context("when deployment is not specified", func() {
+			BeforeEach(func() {
+				command = NewDeployCmd(ui, director, nil, releaseUploaderFactory, manifestFetcher, func(chan<- os.Signal, ...os.Signal) {}, nil, nil)
+				director.FindDeploymentReturns(deployment, nil)
+			})
+
+			It("deploys deployment named in the manifest", func() {
+				opts.Args.Manifest = FileBytesArg{Bytes: []byte(validManifest)}
+
+				err := act()
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(director.FindDeploymentCallCount()).To(Equal(1))
+				Expect(director.FindDeploymentArgsForCall(0)).To(Equal("dep"))
+				Expect(ui.Said).To(ContainElement("Using deployment 'dep'"))
+
+				Expect(deployment.UpdateCallCount()).To(Equal(1))
+			})
+
+			It("does not check name mismatch since name comes from the manifest", func() {
+				opts.Args.Manifest = FileBytesArg{Bytes: []byte("name: other-name\n" + validSections)}
+
+				err := act()
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(director.FindDeploymentArgsForCall(0)).To(Equal("other-name"))
+				Expect(deployment.UpdateCallCount()).To(Equal(1))
+			})
+
+			It("returns error if manifest does not specify a name", func() {
+				opts.Args.Manifest = FileBytesArg{Bytes: []byte(validSections)}
+
+				err := act()
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(Equal("Expected manifest to specify deployment name " +
+					"since deployment was not specified via --deployment"))
+
+				Expect(director.FindDeploymentCallCount()).To(Equal(0))
+			})
+
+			It("returns error if finding deployment fails", func() {
+				opts.Args.Manifest = FileBytesArg{Bytes: []byte(validManifest)}
+				director.FindDeploymentReturns(nil, errors.New("fake-err"))
+
+				err := act()
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(Equal("fake-err"))
+
+				Expect(deployment.UpdateCallCount()).To(Equal(0))
+			})
+		})
+
+		It("does not upload releases or deploy if manifest specifies same release multiple times", func() {
+			opts.Args.Manifest = FileBytesArg{
+				Bytes: []byte(`
+name: dep
+releases:
+- name: capi
+  version: 1
+- name: ((consul_name))
+  version: 1
+- name: capi
+  version: 2
+- name: consul
+  version: 2
+- name: diego
+  version: 1
+`),
+			}
+
+			opts.VarKVs = []boshtpl.VarKV{
+				{Name: "consul_name", Value: "consul"},
+			}
+
+			err := act()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal(
+				"Expected release 'capi' to be specified once but was specified 2 times with versions '1', '2'\n" +
+					"Expected release 'consul' to be specified once but was specified 2 times with versions '1', '2'"))
+
+			Expect(releaseUploader.UploadReleasesCallCount()).To(Equal(0))
+			Expect(deployment.UpdateCallCount()).To(Equal(0))
+		})
+
+		It("does not upload releases, diff or deploy if manifest is missing required sections", func() {
+			opts.Args.Manifest = FileBytesArg{Bytes: []byte("name: dep")}
+
+			err := act()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("Validating manifest: " +
+				"Expected manifest to specify 'instance_groups' or 'jobs'\n" +
+				"Expected manifest to specify 'stemcells'\n" +
+				"Expected manifest to specify 'releases'"))
+
+			Expect(releaseUploader.UploadReleasesCallCount()).To(Equal(0))
+			Expect(deployment.DiffCallCount()).To(Equal(0))
+			Expect(deployment.UpdateCallCount()).To(Equal(0))
+		})
+
+		It("returns error referencing lines of invalid releases after manifest has been interpolated", func() {
+			opts.Args.Manifest = FileBytesArg{
+				Bytes: []byte(`
+name: dep
+releases:
+- name: capi
+  url: ((capi_url))
+  version: 1
+- version: 1
+- name: consul
+  sha1: consul-sha1
+- name: local
+  url: file:///local-dir
+  version: create
+instance_groups: []
+stemcells: []
+`),
+			}
+
+			opts.VarKVs = []boshtpl.VarKV{
+				{Name: "capi_url", Value: "https://capi-url"},
+			}
+
+			err := act()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("Validating manifest: " +
+				"Expected 'releases[0]' (line 3 of interpolated manifest) to specify 'sha1' and 'version' since it specifies remote 'url'\n" +
+				"Expected 'releases[1]' (line 6 of interpolated manifest) to specify 'name'\n" +
+				"Expected 'releases[2]' (line 7 of interpolated manifest) to specify 'url' since it specifies 'sha1'"))
+
+			Expect(releaseUploader.UploadReleasesCallCount()).To(Equal(0))
+			Expect(deployment.UpdateCallCount()).To(Equal(0))
+		})
+
+		It("returns error listing releases referenced by jobs that are not declared in releases", func() {
+			opts.Args.Manifest = FileBytesArg{
+				Bytes: []byte(`
+name: dep
+releases:
+- name: capi
+  version: 1
+instance_groups:
+- name: api
+  jobs:
+  - name: cloud_controller_ng
+    release: capi
+  - name: consul_agent
+    release: ((consul_name))
+- name: router
+  jobs:
+  - name: gorouter
+    release: routing
+addons:
+- name: monitoring
+  jobs:
+  - name: node_exporter
+    release: node-exporter
+stemcells: []
+`),
+			}
+
+			opts.VarKVs = []boshtpl.VarKV{
+				{Name: "consul_name", Value: "consul"},
+			}
+
+			err := act()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("Validating manifest: " +
+				"Expected release 'consul' referenced by 'instance_groups[0].jobs[1]' (line 6 of interpolated manifest) to be declared in 'releases'\n" +
+				"Expected release 'routing' referenced by 'instance_groups[1].jobs[0]' (line 12 of interpolated manifest) to be declared in 'releases'\n" +
+				"Expected release 'node-exporter' referenced by 'addons[0].jobs[0]' (line 17 of interpolated manifest) to be declared in 'releases'"))
+
+			Expect(releaseUploader.UploadReleasesCallCount()).To(Equal(0))
+			Expect(deployment.DiffCallCount()).To(Equal(0))
+			Expect(deployment.UpdateCallCount()).To(Equal(0))
+		})
+
+		It("deploys v1 manifest with templates that reference declared release or omit it", func() {
+			opts.Args.Manifest = FileBytesArg{
+				Bytes: []byte(`
+name: dep
+releases:
+- name: capi
+  version: latest
+jobs:
+- name: api
+  release: capi
+  templates:
+  - name: cloud_controller_ng
+- name: worker
+  templates:
+  - name: cloud_controller_worker
+    release: capi
+  - name: metron_agent
+resource_pools: []
+`),
+			}
+
+			err := act()
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(deployment.UpdateCallCount()).To(Equal(1))
+		})
+
+		It("deploys manifest with jobs instead of instance groups without requiring stemcells", func() {
+			opts.Args.Manifest = FileBytesArg{Bytes: []byte("name: dep\njobs: []\nreleases: []\nresource_pools: []\n")}
+
+			err := act()
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(deployment.UpdateCallCount()).To(Equal(1))
+		})
+
+		It("uploads releases provided in the manifest after manifest has been interpolated", func() {
+			opts.Args.Manifest = FileBytesArg{
+				Bytes: []byte("name: dep\nbefore-upload-manifest: ((key))\n" + validSections),
+			}
+
+			opts.VarKVs = []boshtpl.VarKV{
+				{Name: "key", Value: "key-val"},
+			}
+
+			releaseUploader.UploadReleasesReturns([]byte("after-upload-manifest"), nil, nil)
+
+			err := act()
+			Expect(err).ToNot(HaveOccurred())
+
+			bytes, _ := releaseUploader.UploadReleasesArgsForCall(0)
+			Expect(bytes).To(Equal([]byte("name: dep\nbefore-upload-manifest: key-val\n" + validSections)))
+
+			Expect(deployment.UpdateCallCount()).To(Equal(1))
+
+			bytes, _ = deployment.UpdateArgsForCall(0)
+			Expect(bytes).To(Equal([]byte("after-upload-manifest")))
+		})
+
+		It("uploads releases with url, sha1 and version resolved from variables", func() {
+			opts.Args.Manifest = FileBytesArg{
+				Bytes: []byte(`name: dep
+releases:
+- name: capi
+  url: https://((host))/capi-((capi_version)).tgz
+  sha1: ((capi_sha1))
+  version: ((capi_version))
+instance_groups: []
+stemcells: []
+`),
+			}
+
+			opts.VarKVs = []boshtpl.VarKV{
+				{Name: "host", Value: "releases.example.com"},
+				{Name: "capi_sha1", Value: "capi-sha1"},
+				{Name: "capi_version", Value: "1.2"},
+			}
+
+			err := act()
+			Expect(err).ToNot(HaveOccurred())
+
+			bytes, _ := releaseUploader.UploadReleasesArgsForCall(0)
+
+			manifest, err := boshdir.NewManifestFromBytes(bytes)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(manifest.Releases).To(Equal([]boshdir.ManifestRelease{{
+				Name:    "capi",
+				Version: "1.2",
+				URL:     "https://releases.example.com/capi-1.2.tgz",
+				SHA1:    "capi-sha1",
+			}}))
+		})
+
+		It("prints summary of what happened to each release after deploying", func() {
+			releaseUploader.UploadReleasesReturns([]byte("name: dep\n"), []ReleaseUploadResult{
+				{Name: "capi", Version: "1+capi", Status: ReleaseUploadStatusUploaded},
+				{Name: "consul", Version: "1+consul", Status: ReleaseUploadStatusAlreadyExists},
+				{Name: "rel-without-upload", Version: "1+rel", Status: ReleaseUploadStatusNoURL},
+				{Name: "local", Version: "local-created-ver", Status: ReleaseUploadStatusUploaded},
+			}, nil)
+
+			err := act()
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(deployment.UpdateCallCount()).To(Equal(1))
+
+			Expect(ui.Tables).To(Equal([]boshtbl.Table{
+				{
+					Content: "releases",
+
+					Header: []string{"Name", "Version", "Upload"},
+
+					Rows: [][]boshtbl.Value{
+						{
+							boshtbl.NewValueString("capi"),
+							boshtbl.NewValueString("1+capi"),
+							boshtbl.NewValueString("uploaded"),
+						},
+						{
+							boshtbl.NewValueString("consul"),
+							boshtbl.NewValueString("1+consul"),
+							boshtbl.NewValueString("already exists"),
+						},
+						{
+							boshtbl.NewValueString("rel-without-upload"),
+							boshtbl.NewValueString("1+rel"),
+							boshtbl.NewValueString("skipped (no url)"),
+						},
+						{
+							boshtbl.NewValueString("local"),
+							boshtbl.NewValueString("local-created-ver"),
+							boshtbl.NewValueString("uploaded"),
+						},
+					},
+				},
+			}))
+		})
+
+		It("does not print releases summary if deploying fails", func() {
+			releaseUploader.UploadReleasesReturns([]byte("name: dep\n"), []ReleaseUploadResult{
+				{Name: "capi", Version: "1", Status: ReleaseUploadStatusNoURL},
+			}, nil)
+
+			deployment.UpdateReturns(errors.New("fake-err"))
+
+			err := act()
+			Expect(err).To(HaveOccurred())
+
+			Expect(ui.Tables).To(BeEmpty())
+		})
+
+		It("uploads releases in specified order", func() {
+			opts.ReleaseUploadOrder = []string{"consul", "capi"}
+
+			err := act()
+			Expect(err).ToNot(HaveOccurred())
+
+			_, uploadOpts := releaseUploader.UploadReleasesArgsForCall(0)
+			Expect(uploadOpts).To(Equal(UploadReleasesOpts{Order: []string{"consul", "capi"}}))
+		})
+
+		It("uploads releases with specified parallelism", func() {
+			opts.UploadParallelism = 3
+
+			err := act()
+			Expect(err).ToNot(HaveOccurred())
+
+			_, uploadOpts := releaseUploader.UploadReleasesArgsForCall(0)
+			Expect(uploadOpts).To(Equal(UploadReleasesOpts{Parallelism: 3}))
+		})
+
+		It("uploads releases with specified URL replacements", func() {
+			opts.ReleaseURLReplace = []URLReplaceArg{{Old: "https://public", New: "https://mirror"}}
+
+			err := act()
+			Expect(err).ToNot(HaveOccurred())
+
+			_, uploadOpts := releaseUploader.UploadReleasesArgsForCall(0)
+			Expect(uploadOpts).To(Equal(UploadReleasesOpts{
+				URLReplacements: []URLReplaceArg{{Old: "https://public", New: "https://mirror"}},
+			}))
+		})
+
+		It("uploads releases verifying their SHA1s if requested", func() {
+			opts.VerifyReleaseSHA1s = true
+
+			err := act()
+			Expect(err).ToNot(HaveOccurred())
+
+			_, uploadOpts := releaseUploader.UploadReleasesArgsForCall(0)
+			Expect(uploadOpts).To(Equal(UploadReleasesOpts{VerifySHA1s: true}))
+		})
+
+		It("returns error and does not deploy if uploading releases fails", func() {
+			opts.Args.Manifest = FileBytesArg{
+				Bytes: []byte(`
+name: dep
+releases:
+- name: capi
+  sha1: capi-sha1
+  url: https://capi-url
+  version: 1+capi
+instance_groups: []
+stemcells: []
+`),
+			}
+
+			releaseUploader.UploadReleasesReturns(nil, nil, errors.New("fake-err"))
+
+			err := act()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-err"))
+			Expect(err).To(BeAssignableToTypeOf(ReleaseUploadError{}))
+			Expect(err.(ReleaseUploadError).Cause()).To(Equal(errors.New("fake-err")))
+
+			Expect(deployment.UpdateCallCount()).To(Equal(0))
+		})
+
+		It("prints summary of releases processed before uploading releases failed", func() {
+			releaseUploader.UploadReleasesReturns(nil, []ReleaseUploadResult{
+				{Name: "capi", Version: "1+capi", Status: ReleaseUploadStatusUploaded},
+				{Name: "consul", Version: "1+consul", Status: ReleaseUploadStatusFailed},
+			}, errors.New("fake-err"))
+
+			err := act()
+			Expect(err).To(HaveOccurred())
+
+			Expect(ui.Tables).To(HaveLen(1))
+			Expect(ui.Tables[0].Rows).To(Equal([][]boshtbl.Value{
+				{
+					boshtbl.NewValueString("capi"),
+					boshtbl.NewValueString("1+capi"),
+					boshtbl.NewValueString("uploaded"),
+				},
+				{
+					boshtbl.NewValueString("consul"),
+					boshtbl.NewValueString("1+consul"),
+					boshtbl.NewValueString("failed"),
+				},
+			}))
+		})
+
+		It("uploads releases but does not deploy if confirmation is rejected", func() {
+			opts.Args.Manifest = FileBytesArg{
+				Bytes: []byte(`
+name: dep
+releases:
+- name: capi
+  sha1: capi-sha1
+  url: /capi-url
+  version: create
+instance_groups: []
+stemcells: []
+`),
+			}
+
+			ui.AskedConfirmationErr = errors.New("stop")
+
+			err := act()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("stop"))
+
+			Expect(releaseUploader.UploadReleasesCallCount()).To(Equal(1))
+			Expect(deployment.UpdateCallCount()).To(Equal(0))
+		})
+
+		Context("when skipping deploy if there are no changes", func() {
+			BeforeEach(func() {
+				opts.SkipIfNoChanges = true
+			})
+
+			It("uploads releases but does not ask for confirmation or deploy if diff is empty", func() {
+				diff := [][]interface{}{
+					[]interface{}{"some line that stayed", nil},
+				}
+
+				deployment.DiffReturns(boshdir.NewDeploymentDiff(diff, nil), nil)
+
+				err := act()
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(releaseUploader.UploadReleasesCallCount()).To(Equal(1))
+				Expect(ui.Said).To(ContainElement("No changes, skipping deploy"))
+				Expect(ui.AskedConfirmationCalled).To(BeFalse())
+				Expect(deployment.UpdateCallCount()).To(Equal(0))
+			})
+
+			It("deploys if diff has changes", func() {
+				diff := [][]interface{}{
+					[]interface{}{"some line that was added", "added"},
+				}
+
+				deployment.DiffReturns(boshdir.NewDeploymentDiff(diff, nil), nil)
+
+				err := act()
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(ui.Said).ToNot(ContainElement("No changes, skipping deploy"))
+				Expect(deployment.UpdateCallCount()).To(Equal(1))
+			})
+		})
+
+		It("deploys even if diff is empty when not asked to skip", func() {
+			deployment.DiffReturns(boshdir.NewDeploymentDiff([][]interface{}{}, nil), nil)
+
+			err := act()
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(deployment.UpdateCallCount()).To(Equal(1))
+		})
+
+		It("asks to type deployment name to confirm if requested", func() {
+			opts.ConfirmName = true
+			ui.Interactive = true
+
+			err := act()
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(ui.AskedConfirmationLabels).To(Equal([]string{"dep"}))
+			Expect(deployment.UpdateCallCount()).To(Equal(1))
+		})
+
+		It("does not deploy if typed deployment name does not match", func() {
+			opts.ConfirmName = true
+			ui.Interactive = true
+			ui.AskedConfirmationErr = errors.New("Stopped: expected 'dep' to be typed but was 'other'")
+
+			err := act()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("expected 'dep' to be typed"))
+
+			Expect(deployment.UpdateCallCount()).To(Equal(0))
+		})
+
+		It("returns error and does not upload releases or deploy if name confirmation is requested in non-interactive mode", func() {
+			opts.ConfirmName = true
+			ui.Interactive = false
+
+			err := act()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Expected --non-interactive not to be used with --confirm-name"))
+
+			Expect(ui.AskedConfirmationLabels).To(BeEmpty())
+			Expect(releaseUploader.UploadReleasesCallCount()).To(Equal(0))
+			Expect(deployment.UpdateCallCount()).To(Equal(0))
+		})
+
+		Context("when forced", func() {
+			BeforeEach(func() {
+				opts.Force = true
+				ui.Interactive = true
+			})
+
+			It("uploads releases and deploys without diffing manifest or asking for confirmation", func() {
+				err := act()
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(deployment.DiffCallCount()).To(Equal(0))
+				Expect(ui.AskedConfirmationCalled).To(BeFalse())
+				Expect(ui.Said).To(ContainElement("Skipping manifest diff and confirmation since --force was given"))
+
+				Expect(releaseUploader.UploadReleasesCallCount()).To(Equal(1))
+				Expect(deployment.UpdateCallCount()).To(Equal(1))
+
+				bytes, updateOpts := deployment.UpdateArgsForCall(0)
+				Expect(bytes).To(Equal([]byte(evaluatedValidManifest)))
+				Expect(updateOpts.Diff).To(Equal(boshdir.DeploymentDiff{}))
+			})
+
+			It("still validates deployment name", func() {
+				opts.Args.Manifest = FileBytesArg{
+					Bytes: []byte("name: other-name"),
+				}
+
+				err := act()
+				Expect(err).To(Equal(NewNameMismatchError("dep", "other-name")))
+
+				Expect(deployment.UpdateCallCount()).To(Equal(0))
+			})
+
+			It("deploys manifest read from stdin since there is no confirmation prompt", func() {
+				opts.Args.Manifest.FromStdin = true
+
+				err := act()
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(deployment.UpdateCallCount()).To(Equal(1))
+			})
+
+			It("does not deploy if update fails", func() {
+				deployment.UpdateReturns(errors.New("fake-err"))
+
+				err := act()
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("fake-err"))
+			})
+
+			It("returns error and does not deploy if used with --preview", func() {
+				opts.Preview = true
+
+				err := act()
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(Equal(
+					"Expected --force not to be used with --preview since it skips manifest diff and confirmation"))
+
+				Expect(releaseUploader.UploadReleasesCallCount()).To(Equal(0))
+				Expect(deployment.UpdateCallCount()).To(Equal(0))
+			})
+
+			It("returns error and does not deploy if used with --confirm-name", func() {
+				opts.ConfirmName = true
+
+				err := act()
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(Equal(
+					"Expected --force not to be used with --confirm-name since it skips manifest diff and confirmation"))
+
+				Expect(releaseUploader.UploadReleasesCallCount()).To(Equal(0))
+				Expect(deployment.UpdateCallCount()).To(Equal(0))
+			})
+
+			It("returns error and does not deploy if used with --skip-if-no-changes", func() {
+				opts.SkipIfNoChanges = true
+
+				err := act()
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(Equal(
+					"Expected --force not to be used with --skip-if-no-changes since it skips manifest diff and confirmation"))
+
+				Expect(releaseUploader.UploadReleasesCallCount()).To(Equal(0))
+				Expect(deployment.UpdateCallCount()).To(Equal(0))
+			})
+		})
+
+		It("returns an error if diffing failed", func() {
+			deployment.DiffReturns(boshdir.DeploymentDiff{}, errors.New("Fetching diff result"))
+
+			err := act()
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("gets the diff from the deployment", func() {
+			diff := [][]interface{}{
+				[]interface{}{"some line that stayed", nil},
+				[]interface{}{"some line that was added", "added"},
+				[]interface{}{"some line that was removed", "removed"},
+			}
+
+			expectedDiff := boshdir.NewDeploymentDiff(diff, nil)
+			deployment.DiffReturns(expectedDiff, nil)
+			err := act()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(deployment.DiffCallCount()).To(Equal(1))
+			Expect(ui.Said).To(ContainElement("  some line that stayed\n"))
+			Expect(ui.Said).To(ContainElement("+ some line that was added\n"))
+			Expect(ui.Said).To(ContainElement("- some line that was removed\n"))
+		})
+
+		It("colorizes diff lines keeping their prefixes if color is always enabled", func() {
+			opts.Color = "always"
+
+			diff := [][]interface{}{
+				[]interface{}{"some line that stayed", nil},
+				[]interface{}{"some line that was added", "added"},
+				[]interface{}{"some line that was removed", "removed"},
+			}
+
+			deployment.DiffReturns(boshdir.NewDeploymentDiff(diff, nil), nil)
+
+			err := act()
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(ui.Said).To(ContainElement("  some line that stayed\n"))
+			Expect(ui.Said).To(ContainElement("\x1b[32m+ some line that was added\x1b[0m\n"))
+			Expect(ui.Said).To(ContainElement("\x1b[31m- some line that was removed\x1b[0m\n"))
+		})
+
+		It("writes diff lines without colors into log file as soon as they are printed", func() {
+			opts.Color = "always"
+
+			diff := [][]interface{}{
+				[]interface{}{"some line that stayed", nil},
+				[]interface{}{"some line that was added", "added"},
+			}
+
+			deployment.DiffReturns(boshdir.NewDeploymentDiff(diff, nil), nil)
+
+			logBuf := bytes.NewBufferString("")
+			timeService := fakeclock.NewFakeClock(time.Date(2017, time.January, 2, 3, 4, 5, 0, time.UTC))
+			logFileUI := boshui.NewLogFileUI(ui, logBuf, timeService, boshlog.NewLogger(boshlog.LevelNone))
+
+			signalNotifyFunc := func(chan<- os.Signal, ...os.Signal) {}
+
+			command = NewDeployCmd(logFileUI, director, deployment, releaseUploaderFactory, manifestFetcher, signalNotifyFunc, nil, deployedManifests)
+
+			err := act()
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(logBuf.String()).To(ContainSubstring("[2017-01-02T03:04:05Z]   some line that stayed\n"))
+			Expect(logBuf.String()).To(ContainSubstring("[2017-01-02T03:04:05Z] + some line that was added\n"))
+		})
+
+		It("does not colorize diff lines if color is never enabled", func() {
+			opts.Color = "never"
+
+			diff := [][]interface{}{
+				[]interface{}{"some line that was added", "added"},
+			}
+
+			deployment.DiffReturns(boshdir.NewDeploymentDiff(diff, nil), nil)
+
+			err := act()
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(ui.Said).To(ContainElement("+ some line that was added\n"))
+		})
+
+		It("prints summary of changes after the diff before asking for confirmation", func() {
+			diff := [][]interface{}{
+				[]interface{}{"instance_groups:", nil},
+				[]interface{}{"- name: web", nil},
+				[]interface{}{"  instances: 1", "removed"},
+				[]interface{}{"  instances: 2", "added"},
+				[]interface{}{"- name: worker", "added"},
+			}
+
+			deployment.DiffReturns(boshdir.NewDeploymentDiff(diff, nil), nil)
+
+			ui.AskedConfirmationErr = errors.New("stop")
+
+			err := act()
+			Expect(err).To(HaveOccurred())
+
+			Expect(ui.Said[len(ui.Said)-1]).To(Equal("Summary: 3 changes across 2 instance groups"))
+		})
+
+		It("prints no changes summary if diff is empty", func() {
+			err := act()
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(ui.Said).To(Equal([]string{"Summary: no changes"}))
+		})
+
+		It("requests non-redacted diff and warns that secrets may be shown", func() {
+			opts.NoRedact = true
+
+			err := act()
+			Expect(err).ToNot(HaveOccurred())
+
+			_, noRedact := deployment.DiffArgsForCall(0)
+			Expect(noRedact).To(BeTrue())
+
+			Expect(ui.Errors).To(ContainElement("Warning: Showing non-redacted manifest diff; it may include secrets"))
+		})
+
+		It("requests redacted diff and does not warn by default", func() {
+			err := act()
+			Expect(err).ToNot(HaveOccurred())
+
+			_, noRedact := deployment.DiffArgsForCall(0)
+			Expect(noRedact).To(BeFalse())
+
+			Expect(ui.Errors).To(BeEmpty())
+		})
+
+		It("prints the diff as JSON if requested", func() {
+			opts.JSONDiff = true
+
+			diff := [][]interface{}{
+				[]interface{}{"some line that stayed", nil},
+				[]interface{}{"some line that was added", "added"},
+				[]interface{}{"some line that was removed", "removed"},
+			}
+
+			deployment.DiffReturns(boshdir.NewDeploymentDiff(diff, nil), nil)
+
+			err := act()
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(ui.Said).To(BeEmpty())
+			Expect(ui.Blocks).To(Equal([]string{`[
+  {
+    "line": "some line that stayed",
+    "change": "unchanged"
+  },
+  {
+    "line": "some line that was added",
+    "change": "added"
+  },
+  {
+    "line": "some line that was removed",
+    "change": "removed"
+  }
+]`}))
+		})
+
+		Context("when redact patterns are given", func() {
+			var (
+				diff [][]interface{}
+			)
+
+			BeforeEach(func() {
+				opts.Color = "never"
+				opts.RedactPatterns = []RegexpArg{
+					{regexp.MustCompile(`token: .+`)},
+					{regexp.MustCompile(`secret-[a-z]+`)},
+				}
+
+				diff = [][]interface{}{
+					[]interface{}{"api_token: abc123", "added"},
+					[]interface{}{"password: secret-value", "removed"},
+					[]interface{}{"name: web", nil},
+				}
+
+				deployment.DiffReturns(boshdir.NewDeploymentDiff(diff, nil), nil)
+			})
+
+			It("redacts matching values in diff lines even if non-redacted diff was requested", func() {
+				opts.NoRedact = true
+
+				err := act()
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(ui.Said).To(ContainElement("+ api_<redacted>\n"))
+				Expect(ui.Said).To(ContainElement("- password: <redacted>\n"))
+				Expect(ui.Said).To(ContainElement("  name: web\n"))
+			})
+
+			It("redacts matching values in JSON diff", func() {
+				opts.JSONDiff = true
+
+				err := act()
+				Expect(err).ToNot(HaveOccurred())
+
+				var lines []map[string]string
+
+				err = json.Unmarshal([]byte(ui.Blocks[0]), &lines)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(lines[0]["line"]).To(Equal("api_<redacted>"))
+				Expect(lines[1]["line"]).To(Equal("password: <redacted>"))
+			})
+
+			It("deploys with original diff", func() {
+				err := act()
+				Expect(err).ToNot(HaveOccurred())
+
+				_, updateOpts := deployment.UpdateArgsForCall(0)
+				Expect(updateOpts.Diff).To(Equal(boshdir.NewDeploymentDiff([][]interface{}{
+					[]interface{}{"api_token: abc123", "added"},
+					[]interface{}{"password: secret-value", "removed"},
+					[]interface{}{"name: web", nil},
+				}, nil)))
+			})
+		})
+
+		Context("when diff context is given", func() {
+			BeforeEach(func() {
+				opts.Color = "never"
+
+				diff := [][]interface{}{
+					[]interface{}{"name: dep", ""},
+					[]interface{}{"instance_groups:", ""},
+					[]interface{}{"- name: web", ""},
+					[]interface{}{"  instances: 1", "removed"},
+					[]interface{}{"  instances: 2", "added"},
+					[]interface{}{"  azs: [z1]", ""},
+					[]interface{}{"  networks: []", ""},
+					[]interface{}{"  jobs: []", ""},
+					[]interface{}{"- name: db", ""},
+					[]interface{}{"  instances: 1", ""},
+				}
+
+				deployment.DiffReturns(boshdir.NewDeploymentDiff(diff, nil), nil)
+			})
+
+			It("collapses unchanged lines further away from changes", func() {
+				diffContext := 1
+				opts.DiffContext = &diffContext
+
+				err := act()
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(ui.Said).To(Equal([]string{
+					"  ... (2 unchanged lines) ...\n",
+					"  - name: web\n",
+					"-   instances: 1\n",
+					"+   instances: 2\n",
+					"    azs: [z1]\n",
+					"  ... (4 unchanged lines) ...\n",
+					"Summary: 2 changes across 1 instance group",
+				}))
+			})
+
+			It("shows only changed lines if diff context is 0", func() {
+				diffContext := 0
+				opts.DiffContext = &diffContext
+
+				err := act()
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(ui.Said).To(Equal([]string{
+					"  ... (3 unchanged lines) ...\n",
+					"-   instances: 1\n",
+					"+   instances: 2\n",
+					"  ... (5 unchanged lines) ...\n",
+					"Summary: 2 changes across 1 instance group",
+				}))
+			})
+
+			It("shows all lines if diff context is not given", func() {
+				err := act()
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(ui.Said).To(HaveLen(11))
+			})
+
+			It("does not collapse JSON diff", func() {
+				diffContext := 0
+				opts.DiffContext = &diffContext
+				opts.JSONDiff = true
+
+				err := act()
+				Expect(err).ToNot(HaveOccurred())
+
+				var lines []map[string]string
+
+				err = json.Unmarshal([]byte(ui.Blocks[0]), &lines)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(lines).To(HaveLen(10))
+			})
+
+			It("returns an error if diff context is negative", func() {
+				diffContext := -1
+				opts.DiffContext = &diffContext
+
+				err := act()
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(Equal("Expected --diff-context to be a non-negative number of lines but was '-1'"))
+
+				Expect(deployment.UpdateCallCount()).To(Equal(0))
+			})
+		})
+
+		It("deploys manifest with diff context", func() {
+			context := map[string]interface{}{
+				"cloud_config_id":   2,
+				"runtime_config_id": 3,
+			}
+			expectedDiff := boshdir.NewDeploymentDiff(nil, context)
+
+			deployment.DiffReturns(expectedDiff, nil)
+			err := act()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(deployment.DiffCallCount()).To(Equal(1))
+
+			_, updateOptions := deployment.UpdateArgsForCall(0)
+			Expect(updateOptions.Diff).To(Equal(expectedDiff))
+		})
+
+		It("returns error if deploying failed", func() {
+			deployment.UpdateReturns(errors.New("fake-err"))
+
+			err := act()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-err"))
+			Expect(err).To(BeAssignableToTypeOf(UpdateError{}))
+			Expect(err.(UpdateError).Cause()).To(Equal(errors.New("fake-err")))
+		})
+
+		Context("when director UUID is expected", func() {
+			BeforeEach(func() {
+				opts.ExpectDirectorUUID = "director-uuid"
+			})
+
+			It("deploys manifest if director UUID matches", func() {
+				director.InfoReturns(boshdir.Info{UUID: "director-uuid"}, nil)
+
+				err := act()
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(deployment.UpdateCallCount()).To(Equal(1))
+			})
+
+			It("returns error and does not upload releases if director UUID does not match", func() {
+				director.InfoReturns(boshdir.Info{UUID: "other-uuid"}, nil)
+
+				err := act()
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(Equal("Expected director UUID to be 'director-uuid' but was 'other-uuid'"))
+
+				Expect(releaseUploader.UploadReleasesCallCount()).To(Equal(0))
+				Expect(deployment.UpdateCallCount()).To(Equal(0))
+			})
+
+			It("returns error if fetching director info fails", func() {
+				director.InfoReturns(boshdir.Info{}, errors.New("fake-err"))
+
+				err := act()
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("fake-err"))
+
+				Expect(releaseUploader.UploadReleasesCallCount()).To(Equal(0))
+			})
+		})
+
+		It("does not fetch director info if director UUID is not expected", func() {
+			err := act()
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(director.InfoCallCount()).To(Equal(0))
+		})
+
+		Context("when deployment is locked by another task", func() {
+			var (
+				lockedErr error
+			)
+
+			BeforeEach(func() {
+				lockedErr = boshdir.TaskError{
+					ID:     123,
+					State:  "error",
+					Result: "Failed to acquire lock for lock:deployment:dep uid: fake-uid",
+				}
+			})
+
+			It("returns error without retrying if not asked to wait for lock", func() {
+				deployment.UpdateReturns(lockedErr)
+
+				err := act()
+				Expect(err).To(Equal(NewUpdateError(lockedErr)))
+
+				Expect(deployment.UpdateCallCount()).To(Equal(1))
+			})
+
+			It("retries deploy until lock frees if asked to wait for lock", func() {
+				opts.WaitForLock = 100 * time.Millisecond
+
+				deployment.UpdateStub = func(_ []byte, _ boshdir.UpdateOpts) error {
+					if deployment.UpdateCallCount() < 2 {
+						return lockedErr
+					}
+					return nil
+				}
+
+				err := act()
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(deployment.UpdateCallCount()).To(Equal(2))
+				Expect(ui.Said).To(ContainElement(ContainSubstring("Deployment is locked by another task, retrying in")))
+			})
+
+			It("returns error once waiting for lock times out", func() {
+				opts.WaitForLock = 50 * time.Millisecond
+
+				deployment.UpdateReturns(lockedErr)
+
+				err := act()
+				Expect(err).To(HaveOccurred())
+				Expect(err).To(BeAssignableToTypeOf(UpdateError{}))
+				Expect(err.Error()).To(ContainSubstring("Waiting 50ms for deployment lock"))
+				Expect(err.Error()).To(ContainSubstring("Expected task '123' to succeed"))
+
+				Expect(deployment.UpdateCallCount()).To(Equal(2))
+			})
+
+			It("does not retry other errors", func() {
+				opts.WaitForLock = time.Second
+
+				deployment.UpdateReturns(errors.New("fake-err"))
+
+				err := act()
+				Expect(err).To(Equal(NewUpdateError(errors.New("fake-err"))))
+
+				Expect(deployment.UpdateCallCount()).To(Equal(1))
+			})
+		})
+
+		Context("when diff is requested to be written to a file", func() {
+			var (
+				fs *fakesys.FakeFileSystem
+			)
+
+			readDiffDoc := func() map[string]interface{} {
+				var doc map[string]interface{}
+
+				bytes, err := fs.ReadFile("/diff.json")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(json.Unmarshal(bytes, &doc)).To(Succeed())
+
+				return doc
+			}
+
+			BeforeEach(func() {
+				fs = fakesys.NewFakeFileSystem()
+				opts.DiffOut = OutputFileArg{FS: fs, Path: "/diff.json"}
+
+				director.InfoReturns(boshdir.Info{UUID: "director-uuid", User: "admin"}, nil)
+
+				diff := [][]interface{}{
+					[]interface{}{"name: dep", ""},
+					[]interface{}{"password: secret", "added"},
+				}
+
+				deployment.DiffReturns(boshdir.NewDeploymentDiff(diff, nil), nil)
+			})
+
+			It("writes diff with deployment and director details before asking for confirmation", func() {
+				ui.AskedConfirmationErr = errors.New("stop")
+
+				before := time.Now().Add(-time.Second)
+
+				err := act()
+				Expect(err).To(HaveOccurred())
+
+				doc := readDiffDoc()
+				Expect(doc["deployment"]).To(Equal("dep"))
+				Expect(doc["director_uuid"]).To(Equal("director-uuid"))
+				Expect(doc["user"]).To(Equal("admin"))
+				Expect(doc["redacted"]).To(BeTrue())
+				Expect(doc["has_changes"]).To(BeTrue())
+				Expect(doc["summary"]).To(Equal("1 change"))
+				Expect(doc["lines"]).To(Equal([]interface{}{
+					map[string]interface{}{"line": "name: dep", "change": "unchanged"},
+					map[string]interface{}{"line": "password: secret", "change": "added"},
+				}))
+
+				createdAt, err := time.Parse(time.RFC3339, doc["created_at"].(string))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(createdAt).To(BeTemporally(">=", before))
+
+				Expect(ui.Said).To(ContainElement("Wrote manifest diff to '/diff.json'"))
+				Expect(deployment.UpdateCallCount()).To(Equal(0))
+			})
+
+			It("writes diff redacted with given patterns and marks it non-redacted if requested", func() {
+				opts.NoRedact = true
+				opts.RedactPatterns = []RegexpArg{{regexp.MustCompile("secret")}}
+
+				err := act()
+				Expect(err).ToNot(HaveOccurred())
+
+				doc := readDiffDoc()
+				Expect(doc["redacted"]).To(BeFalse())
+				Expect(doc["lines"]).To(ContainElement(
+					map[string]interface{}{"line": "password: <redacted>", "change": "added"}))
+			})
+
+			It("writes diff recording no changes if diff is empty", func() {
+				deployment.DiffReturns(boshdir.NewDeploymentDiff([][]interface{}{}, nil), nil)
+				opts.SkipIfNoChanges = true
+
+				err := act()
+				Expect(err).ToNot(HaveOccurred())
+
+				doc := readDiffDoc()
+				Expect(doc["has_changes"]).To(BeFalse())
+				Expect(doc["summary"]).To(Equal("no changes"))
+				Expect(doc["lines"]).To(BeEmpty())
+
+				Expect(deployment.UpdateCallCount()).To(Equal(0))
+			})
+
+			It("writes diff when previewing", func() {
+				opts.Preview = true
+
+				err := act()
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(readDiffDoc()["has_changes"]).To(BeTrue())
+				Expect(deployment.UpdateCallCount()).To(Equal(0))
+			})
+
+			It("returns error and does not deploy if writing diff fails", func() {
+				fs.WriteFileError = errors.New("fake-err")
+
+				err := act()
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("Writing manifest diff"))
+				Expect(err.Error()).To(ContainSubstring("fake-err"))
+
+				Expect(ui.AskedConfirmationCalled).To(BeFalse())
+				Expect(deployment.UpdateCallCount()).To(Equal(0))
+			})
+
+			It("returns error and does not deploy if fetching director info fails", func() {
+				director.InfoReturns(boshdir.Info{}, errors.New("fake-err"))
+
+				err := act()
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("Fetching director info"))
+
+				Expect(deployment.UpdateCallCount()).To(Equal(0))
+			})
+
+			It("returns error if used with --force since there is no diff", func() {
+				opts.Force = true
+
+				err := act()
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(Equal(
+					"Expected --force not to be used with --diff-out since it skips manifest diff and confirmation"))
+
+				Expect(deployment.UpdateCallCount()).To(Equal(0))
+			})
+		})
+
+		Context("when previewing", func() {
+			BeforeEach(func() {
+				opts.Preview = true
+				opts.Args.Manifest = FileBytesArg{Bytes: []byte(`
+name: dep
+releases:
+- name: capi
+  version: 1+capi
+  url: https://capi-url
+  sha1: capi-sha1
+- name: local
+  version: 1
+instance_groups: []
+stemcells: []
+`)}
+
+				deployment.DiffReturns(boshdir.NewDeploymentDiff([][]interface{}{
+					[]interface{}{"some line that stayed", nil},
+					[]interface{}{"some line that was added", "added"},
+				}, nil), nil)
+			})
+
+			It("shows diff and releases to be uploaded without uploading releases or deploying", func() {
+				err := act()
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(ui.Said).To(ContainElement("Would upload release 'capi/1+capi' from 'https://capi-url'"))
+				Expect(ui.Said).To(ContainElement("+ some line that was added\n"))
+
+				Expect(deployment.DiffCallCount()).To(Equal(1))
+				Expect(releaseUploader.UploadReleasesCallCount()).To(Equal(0))
+				Expect(deployment.UpdateCallCount()).To(Equal(0))
+				Expect(ui.AskedConfirmationCalled).To(BeFalse())
+			})
+
+			It("returns error if diffing failed", func() {
+				deployment.DiffReturns(boshdir.DeploymentDiff{}, errors.New("fake-err"))
+
+				err := act()
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("fake-err"))
+			})
+
+			It("still checks deployment name", func() {
+				opts.Args.Manifest = FileBytesArg{Bytes: []byte("name: other-name")}
+
+				err := act()
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("Expected manifest to specify deployment name 'dep' but was 'other-name'"))
+
+				Expect(deployment.DiffCallCount()).To(Equal(0))
+			})
+		})
+
+		Context("when manifest is fetched from URL", func() {
+			BeforeEach(func() {
+				opts.Args.Manifest = FileBytesArg{}
+				opts.ManifestURL = "https://example.com/manifest.yml"
+				opts.ManifestURLTimeout = 10 * time.Second
+
+				manifestFetcher.FetchReturns([]byte(validManifest), nil)
+			})
+
+			It("deploys fetched manifest", func() {
+				err := act()
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(manifestFetcher.FetchCallCount()).To(Equal(1))
+
+				url, timeout := manifestFetcher.FetchArgsForCall(0)
+				Expect(url).To(Equal("https://example.com/manifest.yml"))
+				Expect(timeout).To(Equal(10 * time.Second))
+
+				Expect(deployment.UpdateCallCount()).To(Equal(1))
+
+				bytes, _ := deployment.UpdateArgsForCall(0)
+				Expect(bytes).To(Equal([]byte(evaluatedValidManifest)))
+			})
+
+			It("deploys fetched manifest if it matches expected SHA1", func() {
+				opts.ManifestSHA1 = "6ee6b13a9ca37369a4068046e2056aae6bb5a9c5"
+
+				err := act()
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(deployment.UpdateCallCount()).To(Equal(1))
+			})
+
+			It("returns error if fetched manifest does not match expected SHA1", func() {
+				opts.ManifestSHA1 = "fake-sha1"
+
+				err := act()
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("Expected manifest SHA1 to be 'fake-sha1' but was"))
+
+				Expect(deployment.UpdateCallCount()).To(Equal(0))
+			})
+
+			It("returns error if fetching manifest fails", func() {
+				manifestFetcher.FetchReturns(nil, errors.New("fake-err"))
+
+				err := act()
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("fake-err"))
+
+				Expect(deployment.UpdateCallCount()).To(Equal(0))
+			})
+
+			It("returns error if manifest path is also given", func() {
+				opts.Args.Manifest = FileBytesArg{Bytes: []byte(validManifest)}
+
+				err := act()
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(Equal("Expected either manifest path or --manifest-url but not both"))
+
+				Expect(manifestFetcher.FetchCallCount()).To(Equal(0))
+			})
+		})
+
+		It("returns error if neither manifest path nor URL is given", func() {
+			opts.Args.Manifest = FileBytesArg{}
+
+			err := act()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("Expected manifest path, --manifest or --manifest-url to be specified"))
+		})
+
+		Context("when manifest fragments are given", func() {
+			BeforeEach(func() {
+				opts.Args.Manifest = FileBytesArg{Bytes: []byte("name: dep\nreleases:\n- name: rel\n  version: 1\n")}
+				opts.Manifests = []FileBytesArg{
+					{Bytes: []byte("stemcells: []\ninstance_groups:\n- name: ig\n  instances: 1\n")},
+					{Bytes: []byte("instance_groups:\n- name: ig\n  azs: [z1]\n- name: ig2\n")},
+				}
+			})
+
+			It("deploys manifest merged from manifest and fragments in order", func() {
+				err := act()
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(deployment.UpdateCallCount()).To(Equal(1))
+
+				bytes, _ := deployment.UpdateArgsForCall(0)
+				Expect(string(bytes)).To(Equal(`name: dep
+releases:
+- name: rel
+  version: 1
+stemcells: []
+instance_groups:
+- name: ig
+  instances: 1
+  azs:
+  - z1
+- name: ig2
+`))
+			})
+
+			It("deploys manifest merged only from fragments if manifest path is not given", func() {
+				opts.Args.Manifest = FileBytesArg{}
+				opts.Manifests = []FileBytesArg{
+					{Bytes: []byte("name: dep\n")},
+					{Bytes: []byte(validSections)},
+				}
+
+				err := act()
+				Expect(err).ToNot(HaveOccurred())
+
+				bytes, _ := deployment.UpdateArgsForCall(0)
+				Expect(bytes).To(Equal([]byte(evaluatedValidManifest)))
+			})
+
+			It("checks deployment name of merged manifest", func() {
+				opts.Manifests = append(opts.Manifests, FileBytesArg{Bytes: []byte("name: other-name\n")})
+				opts.ManifestMergeOverride = true
+
+				err := act()
+				Expect(err).To(Equal(NewNameMismatchError("dep", "other-name")))
+
+				Expect(deployment.UpdateCallCount()).To(Equal(0))
+			})
+
+			It("returns error and does not deploy if fragments set conflicting values", func() {
+				opts.Manifests = append(opts.Manifests, FileBytesArg{Bytes: []byte("instance_groups:\n- name: ig\n  instances: 2\n")})
+
+				err := act()
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("Merging manifest fragment 4"))
+				Expect(err.Error()).To(ContainSubstring(
+					"Expected manifest fragments not to set conflicting values at '/instance_groups/name=ig/instances'"))
+
+				Expect(deployment.UpdateCallCount()).To(Equal(0))
+			})
+
+			It("lets later fragments override conflicting values if requested", func() {
+				opts.Manifests = append(opts.Manifests, FileBytesArg{Bytes: []byte("instance_groups:\n- name: ig\n  instances: 2\n")})
+				opts.ManifestMergeOverride = true
+
+				err := act()
+				Expect(err).ToNot(HaveOccurred())
+
+				bytes, _ := deployment.UpdateArgsForCall(0)
+				Expect(string(bytes)).To(ContainSubstring("- name: ig\n  instances: 2\n"))
+			})
+
+			It("returns error if fragment is not a valid manifest", func() {
+				opts.Manifests = append(opts.Manifests, FileBytesArg{Bytes: []byte("- not-a-map")})
+
+				err := act()
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("Deserializing manifest fragment 4"))
+
+				Expect(deployment.UpdateCallCount()).To(Equal(0))
+			})
+
+			It("returns error if manifest SHA1 is expected without manifest path", func() {
+				opts.Args.Manifest = FileBytesArg{}
+				opts.ManifestSHA1 = "fake-sha1"
+
+				err := act()
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(Equal("Expected --manifest-sha1 to be used with manifest path or --manifest-url"))
+			})
+
+			It("returns error if fragment is read from stdin and ui is interactive", func() {
+				opts.Manifests[0].FromStdin = true
+				ui.Interactive = true
+
+				err := act()
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(Equal("Expected --non-interactive to be used when reading manifest from stdin"))
+
+				Expect(deployment.UpdateCallCount()).To(Equal(0))
+			})
+		})
+
+		Context("when tracking last successfully deployed manifest", func() {
+			var manifestSHA1 string
+
+			BeforeEach(func() {
+				manifestSHA1 = fmt.Sprintf("%x", sha1.Sum([]byte(evaluatedValidManifest)))
+			})
+
+			It("saves hash of deployed manifest after successful deploy", func() {
+				before := time.Now()
+
+				err := act()
+				Expect(err).ToNot(HaveOccurred())
+
+				deployed, found := deployedManifests.manifests["dep"]
+				Expect(found).To(BeTrue())
+				Expect(deployed.SHA1).To(Equal(manifestSHA1))
+				Expect(deployed.DeployedAt).To(BeTemporally(">=", before))
+				Expect(deployed.Manifest).To(Equal(evaluatedValidManifest))
+			})
+
+			It("does not save hash if deploy fails", func() {
+				deployment.UpdateReturns(errors.New("fake-err"))
+
+				err := act()
+				Expect(err).To(HaveOccurred())
+
+				Expect(deployedManifests.manifests).To(BeEmpty())
+			})
+
+			It("does not save hash for dry runs", func() {
+				opts.DryRun = true
+
+				err := act()
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(deployedManifests.manifests).To(BeEmpty())
+			})
+
+			It("succeeds but shows error if saving hash fails", func() {
+				deployedManifests.saveErr = errors.New("fake-save-err")
+
+				err := act()
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(ui.Errors).To(ContainElement("Failed to save last deployed manifest: fake-save-err"))
+			})
+
+			Context("when asked to skip if unchanged", func() {
+				BeforeEach(func() {
+					opts.SkipIfUnchanged = true
+				})
+
+				It("skips uploading releases and deploying if manifest has the same hash", func() {
+					deployedManifests.manifests["dep"] = cmdconf.DeployedManifest{
+						SHA1:       manifestSHA1,
+						DeployedAt: time.Date(2017, time.January, 2, 3, 4, 5, 0, time.UTC),
+					}
+
+					err := act()
+					Expect(err).ToNot(HaveOccurred())
+
+					Expect(ui.Said).To(ContainElement(
+						"Manifest has not changed since last successful deploy at 2017-01-02T03:04:05Z, skipping deploy"))
+
+					Expect(releaseUploader.UploadReleasesCallCount()).To(Equal(0))
+					Expect(deployment.DiffCallCount()).To(Equal(0))
+					Expect(deployment.UpdateCallCount()).To(Equal(0))
+				})
+
+				It("deploys if manifest has different hash", func() {
+					deployedManifests.manifests["dep"] = cmdconf.DeployedManifest{SHA1: "other-sha1"}
+
+					err := act()
+					Expect(err).ToNot(HaveOccurred())
+
+					Expect(deployment.UpdateCallCount()).To(Equal(1))
+					Expect(deployedManifests.manifests["dep"].SHA1).To(Equal(manifestSHA1))
+				})
+
+				It("deploys if manifest was not deployed before", func() {
+					err := act()
+					Expect(err).ToNot(HaveOccurred())
+
+					Expect(deployment.UpdateCallCount()).To(Equal(1))
+				})
+
+				It("returns error if finding last deployed manifest fails", func() {
+					deployedManifests.findErr = errors.New("fake-find-err")
+
+					err := act()
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(ContainSubstring("Finding last deployed manifest"))
+					Expect(err.Error()).To(ContainSubstring("fake-find-err"))
+
+					Expect(deployment.UpdateCallCount()).To(Equal(0))
+				})
+
+				It("returns error if last deployed manifests are not tracked", func() {
+					command = NewDeployCmd(ui, director, deployment, releaseUploaderFactory, manifestFetcher, func(chan<- os.Signal, ...os.Signal) {}, nil, nil)
+
+					err := act()
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(Equal("Expected last deployed manifest to be tracked to use --skip-if-unchanged"))
+
+					Expect(deployment.UpdateCallCount()).To(Equal(0))
+				})
+			})
+		})
+
+		Context("when errand is requested to run after deploy", func() {
+			BeforeEach(func() {
+				opts.RunErrand = "smoke-tests"
+			})
+
+			It("runs errand after deploying and shows its result", func() {
+				deployment.RunErrandReturns([]boshdir.ErrandResult{{ExitCode: 0, Stdout: "smoke-stdout"}}, nil)
+
+				err := act()
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(deployment.UpdateCallCount()).To(Equal(1))
+				Expect(deployment.RunErrandCallCount()).To(Equal(1))
+
+				name, keepAlive, whenChanged := deployment.RunErrandArgsForCall(0)
+				Expect(name).To(Equal("smoke-tests"))
+				Expect(keepAlive).To(BeFalse())
+				Expect(whenChanged).To(BeFalse())
+
+				Expect(ui.Table.Content).To(Equal("errands"))
+			})
+
+			It("returns errand specific error if errand fails", func() {
+				deployment.RunErrandReturns([]boshdir.ErrandResult{{ExitCode: 1}}, nil)
+
+				err := act()
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("Deploy succeeded but running errand 'smoke-tests' failed"))
+				Expect(err.Error()).To(ContainSubstring("Errand 'smoke-tests' completed with error (exit code 1)"))
+			})
+
+			It("returns errand specific error if running errand fails", func() {
+				deployment.RunErrandReturns(nil, errors.New("fake-err"))
+
+				err := act()
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("Deploy succeeded but running errand 'smoke-tests' failed"))
+				Expect(err.Error()).To(ContainSubstring("fake-err"))
+			})
+
+			It("does not run errand if deploying failed", func() {
+				deployment.UpdateReturns(errors.New("fake-err"))
+
+				err := act()
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(Equal("fake-err"))
+
+				Expect(deployment.RunErrandCallCount()).To(Equal(0))
+			})
+		})
+	})
+})
+
+type fakeVariableProvider struct {
+	vars  map[string]interface{}
+	err   error
+	names []string
+}
+
+func (p *fakeVariableProvider) Get(name string) (interface{}, bool, error) {
+	p.names = append(p.names, name)
+
+	if p.err != nil {
+		return nil, false, p.err
+	}
+
+	val, found := p.vars[name]
+
+	return val, found, nil
+}
+
+type fakeDeployedManifests struct {
+	manifests map[string]cmdconf.DeployedManifest
+	findErr   error
+	saveErr   error
+}
+
+func (m *fakeDeployedManifests) Find(deployment string) (cmdconf.DeployedManifest, bool, error) {
+	if m.findErr != nil {
+		return cmdconf.DeployedManifest{}, false, m.findErr
+	}
+
+	manifest, found := m.manifests[deployment]
+
+	return manifest, found, nil
+}
+
+func (m *fakeDeployedManifests) Save(deployment string, manifest cmdconf.DeployedManifest) error {
+	if m.saveErr != nil {
+		return m.saveErr
+	}
+
+	m.manifests[deployment] = manifest
+
+	return nil
+}
@@ -0,0 +1,49 @@
+package cmd
+
+import (
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+
+	cmdconf "github.com/cloudfoundry/bosh-cli/cmd/config"
+)
+
+// DeployedManifests keeps track of manifests last successfully deployed to deployments
+type DeployedManifests interface {
+	Find(deployment string) (cmdconf.DeployedManifest, bool, error)
+	Save(deployment string, manifest cmdconf.DeployedManifest) error
+}
+
+// ConfigDeployedManifests keeps deployed manifests in CLI config under the environment;
+// config is loaded each time so that changes made to it during long deploys are not lost
+type ConfigDeployedManifests struct {
+	environment string
+	configFunc  func() (cmdconf.Config, error)
+}
+
+func NewConfigDeployedManifests(environment string, configFunc func() (cmdconf.Config, error)) ConfigDeployedManifests {
+	return ConfigDeployedManifests{environment: environment, configFunc: configFunc}
+}
+
+func (m ConfigDeployedManifests) Find(deployment string) (cmdconf.DeployedManifest, bool, error) {
+	config, err := m.configFunc()
+	if err != nil {
+		return cmdconf.DeployedManifest{}, false, bosherr.WrapError(err, "Loading config")
+	}
+
+	manifest, found := config.DeployedManifest(m.environment, deployment)
+
+	return manifest, found, nil
+}
+
+func (m ConfigDeployedManifests) Save(deployment string, manifest cmdconf.DeployedManifest) error {
+	config, err := m.configFunc()
+	if err != nil {
+		return bosherr.WrapError(err, "Loading config")
+	}
+
+	err = config.SetDeployedManifest(m.environment, deployment, manifest).Save()
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Saving deployed manifest of deployment '%s'", deployment)
+	}
+
+	return nil
+}
@@ -0,0 +1,111 @@
+package cmd_test
+
+import (
+	"errors"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-cli/cmd"
+	cmdconf "github.com/cloudfoundry/bosh-cli/cmd/config"
+	fakecmdconf "github.com/cloudfoundry/bosh-cli/cmd/config/configfakes"
+)
+
+var _ = Describe("ConfigDeployedManifests", func() {
+	var (
+		config        *fakecmdconf.FakeConfig
+		updatedConfig *fakecmdconf.FakeConfig
+		configErr     error
+		configLoads   int
+		manifests     ConfigDeployedManifests
+	)
+
+	BeforeEach(func() {
+		config = &fakecmdconf.FakeConfig{}
+		updatedConfig = &fakecmdconf.FakeConfig{}
+		configErr = nil
+		configLoads = 0
+
+		config.SetDeployedManifestReturns(updatedConfig)
+
+		configFunc := func() (cmdconf.Config, error) {
+			configLoads++
+			return config, configErr
+		}
+
+		manifests = NewConfigDeployedManifests("env", configFunc)
+	})
+
+	deployed := cmdconf.DeployedManifest{
+		SHA1:       "sha1",
+		DeployedAt: time.Date(2017, time.January, 2, 3, 4, 5, 0, time.UTC),
+	}
+
+	Describe("Find", func() {
+		It("returns deployed manifest for deployment in environment", func() {
+			config.DeployedManifestReturns(deployed, true)
+
+			manifest, found, err := manifests.Find("dep")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(found).To(BeTrue())
+			Expect(manifest).To(Equal(deployed))
+
+			env, deployment := config.DeployedManifestArgsForCall(0)
+			Expect(env).To(Equal("env"))
+			Expect(deployment).To(Equal("dep"))
+		})
+
+		It("returns not found if manifest was not deployed", func() {
+			_, found, err := manifests.Find("dep")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(found).To(BeFalse())
+		})
+
+		It("returns error if loading config fails", func() {
+			configErr = errors.New("fake-err")
+
+			_, _, err := manifests.Find("dep")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-err"))
+		})
+	})
+
+	Describe("Save", func() {
+		It("saves deployed manifest into freshly loaded config", func() {
+			_, _, err := manifests.Find("dep")
+			Expect(err).ToNot(HaveOccurred())
+
+			err = manifests.Save("dep", deployed)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(configLoads).To(Equal(2))
+
+			env, deployment, manifest := config.SetDeployedManifestArgsForCall(0)
+			Expect(env).To(Equal("env"))
+			Expect(deployment).To(Equal("dep"))
+			Expect(manifest).To(Equal(deployed))
+
+			Expect(updatedConfig.SaveCallCount()).To(Equal(1))
+		})
+
+		It("returns error if saving config fails", func() {
+			updatedConfig.SaveReturns(errors.New("fake-err"))
+
+			err := manifests.Save("dep", deployed)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Saving deployed manifest of deployment 'dep'"))
+			Expect(err.Error()).To(ContainSubstring("fake-err"))
+		})
+
+		It("returns error if loading config fails", func() {
+			configErr = errors.New("fake-err")
+
+			err := manifests.Save("dep", deployed)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-err"))
+
+			Expect(config.SetDeployedManifestCallCount()).To(Equal(0))
+		})
+	})
+})
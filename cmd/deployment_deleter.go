@@ -90,6 +90,18 @@ type deploymentDeleter struct {
 func (c *deploymentDeleter) DeleteDeployment(stage biui.Stage) (err error) {
 	c.ui.BeginLinef("Deployment state: '%s'\n", c.deploymentStateService.Path())
 
+	err = c.deploymentStateService.Lock(deploymentStateLockTimeout)
+	if err != nil {
+		return bosherr.WrapError(err, "Locking deployment state")
+	}
+
+	defer func() {
+		unlockErr := c.deploymentStateService.Unlock()
+		if unlockErr != nil {
+			c.logger.Warn(c.logTag, "Unlocking deployment state: %s", unlockErr.Error())
+		}
+	}()
+
 	if !c.deploymentStateService.Exists() {
 		c.ui.BeginLinef("No deployment state file found.\n")
 		return nil
@@ -168,7 +168,7 @@ cloud_provider:
 			fakeHTTPClient := fakebihttpclient.NewFakeHTTPClient()
 			tarballCache := bitarball.NewCache("fake-base-path", fs, logger)
 			tarballProvider := bitarball.NewProvider(tarballCache, fs, fakeHTTPClient, 1, 0, logger)
-			deploymentStateService := biconfig.NewFileSystemDeploymentStateService(fs, fakeUUIDGenerator, logger, biconfig.DeploymentStatePath(deploymentManifestPath, ""))
+			deploymentStateService := biconfig.NewFileSystemDeploymentStateService(fs, fakeUUIDGenerator, logger, biconfig.DeploymentStatePath(deploymentManifestPath, ""), 0)
 
 			cpiInstaller := bicpirel.CpiInstaller{
 				ReleaseManager:   releaseManager,
@@ -278,7 +278,7 @@ cloud_provider:
 			logger = boshlog.NewLogger(boshlog.LevelNone)
 			fakeUUIDGenerator = fakeuuid.NewFakeGenerator()
 			deploymentStatePath = biconfig.DeploymentStatePath(deploymentManifestPath, "")
-			setupDeploymentStateService = biconfig.NewFileSystemDeploymentStateService(fs, fakeUUIDGenerator, logger, deploymentStatePath)
+			setupDeploymentStateService = biconfig.NewFileSystemDeploymentStateService(fs, fakeUUIDGenerator, logger, deploymentStatePath, 0)
 			setupDeploymentStateService.Load()
 
 			fakeUI = &fakeui.FakeUI{}
@@ -422,6 +422,15 @@ cloud_provider:
 					Expect(fs.FileExists(deploymentStatePath)).To(BeFalse())
 				})
 
+				It("releases the deployment state lock", func() {
+					expectDeleteAndCleanup(true)
+
+					err := newDeploymentDeleter().DeleteDeployment(fakeStage)
+					Expect(err).ToNot(HaveOccurred())
+
+					Expect(fs.FileExists(deploymentStatePath + ".lock")).To(BeFalse())
+				})
+
 			})
 
 			Context("when nothing has been deployed", func() {
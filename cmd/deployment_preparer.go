@@ -1,6 +1,8 @@
 package cmd
 
 import (
+	"time"
+
 	bihttpagent "github.com/cloudfoundry/bosh-agent/agentclient/http"
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 	bihttpclient "github.com/cloudfoundry/bosh-utils/httpclient"
@@ -23,6 +25,10 @@ import (
 	biui "github.com/cloudfoundry/bosh-cli/ui"
 )
 
+// deploymentStateLockTimeout is how long create-env and delete-env wait
+// for another process using the same deployment state to finish
+const deploymentStateLockTimeout = 30 * time.Second
+
 func NewDeploymentPreparer(
 	ui biui.UI,
 	logger boshlog.Logger,
@@ -104,6 +110,18 @@ type DeploymentPreparer struct {
 func (c *DeploymentPreparer) PrepareDeployment(stage biui.Stage) (err error) {
 	c.ui.BeginLinef("Deployment state: '%s'\n", c.deploymentStateService.Path())
 
+	err = c.deploymentStateService.Lock(deploymentStateLockTimeout)
+	if err != nil {
+		return bosherr.WrapError(err, "Locking deployment state")
+	}
+
+	defer func() {
+		unlockErr := c.deploymentStateService.Unlock()
+		if unlockErr != nil {
+			c.logger.Warn(c.logTag, "Unlocking deployment state: %s", unlockErr.Error())
+		}
+	}()
+
 	if !c.deploymentStateService.Exists() {
 		migrated, err := c.legacyDeploymentStateMigrator.MigrateIfExists(biconfig.LegacyDeploymentStatePath(c.deploymentManifestPath))
 		if err != nil {
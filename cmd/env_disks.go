@@ -0,0 +1,67 @@
+package cmd
+
+import (
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+
+	biconfig "github.com/cloudfoundry/bosh-cli/config"
+	boshui "github.com/cloudfoundry/bosh-cli/ui"
+	boshtbl "github.com/cloudfoundry/bosh-cli/ui/table"
+)
+
+// EnvDiskRepoProvider returns disk repo backed by the state file at given path
+type EnvDiskRepoProvider func(statePath string) (biconfig.DiskRepo, error)
+
+// EnvDisksCmd lists persistent disks recorded in the state file of an environment
+// created with create-env; table can be printed as JSON with global --json flag
+type EnvDisksCmd struct {
+	ui               boshui.UI
+	diskRepoProvider EnvDiskRepoProvider
+}
+
+func NewEnvDisksCmd(ui boshui.UI, diskRepoProvider EnvDiskRepoProvider) EnvDisksCmd {
+	return EnvDisksCmd{ui: ui, diskRepoProvider: diskRepoProvider}
+}
+
+func (c EnvDisksCmd) Run(opts EnvDisksOpts) error {
+	if len(opts.Args.Manifest) == 0 && len(opts.StatePath) == 0 {
+		return bosherr.Error("Expected manifest path or --state to be specified")
+	}
+
+	statePath := biconfig.DeploymentStatePath(opts.Args.Manifest, opts.StatePath)
+
+	diskRepo, err := c.diskRepoProvider(statePath)
+	if err != nil {
+		return err
+	}
+
+	defer diskRepo.Close()
+
+	records, err := diskRepo.All()
+	if err != nil {
+		return bosherr.WrapError(err, "Finding disk records")
+	}
+
+	currentRecord, foundCurrent, err := diskRepo.FindCurrent()
+	if err != nil {
+		return bosherr.WrapError(err, "Finding current disk record")
+	}
+
+	table := boshtbl.Table{
+		Content: "disks",
+		Header:  []string{"ID", "Disk CID", "Size", "Current", "Cloud Properties"},
+	}
+
+	for _, record := range records {
+		table.Rows = append(table.Rows, []boshtbl.Value{
+			boshtbl.NewValueString(record.ID),
+			boshtbl.NewValueString(record.CID),
+			boshtbl.NewValueMegaBytes(uint64(record.Size)),
+			boshtbl.NewValueBool(foundCurrent && record.ID == currentRecord.ID),
+			boshtbl.NewValueInterface(record.CloudProperties),
+		})
+	}
+
+	c.ui.PrintTable(table)
+
+	return nil
+}
@@ -0,0 +1,144 @@
+package cmd_test
+
+import (
+	"errors"
+
+	biproperty "github.com/cloudfoundry/bosh-utils/property"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-cli/cmd"
+	biconfig "github.com/cloudfoundry/bosh-cli/config"
+	fakebiconfig "github.com/cloudfoundry/bosh-cli/config/fakes"
+	fakeui "github.com/cloudfoundry/bosh-cli/ui/fakes"
+	boshtbl "github.com/cloudfoundry/bosh-cli/ui/table"
+)
+
+var _ = Describe("EnvDisksCmd", func() {
+	var (
+		ui           *fakeui.FakeUI
+		diskRepo     *fakebiconfig.FakeDiskRepo
+		statePaths   []string
+		diskRepoErr  error
+		command      EnvDisksCmd
+		opts         EnvDisksOpts
+		firstRecord  biconfig.DiskRecord
+		secondRecord biconfig.DiskRecord
+	)
+
+	BeforeEach(func() {
+		ui = &fakeui.FakeUI{}
+		diskRepo = fakebiconfig.NewFakeDiskRepo()
+		statePaths = nil
+		diskRepoErr = nil
+
+		diskRepoProvider := func(statePath string) (biconfig.DiskRepo, error) {
+			statePaths = append(statePaths, statePath)
+			return diskRepo, diskRepoErr
+		}
+
+		command = NewEnvDisksCmd(ui, diskRepoProvider)
+		opts = EnvDisksOpts{Args: EnvDisksArgs{Manifest: "/dir/manifest.yml"}}
+
+		firstRecord = biconfig.DiskRecord{
+			ID:              "fake-id-1",
+			CID:             "fake-cid-1",
+			Size:            1024,
+			CloudProperties: biproperty.Map{"type": "ssd"},
+		}
+
+		secondRecord = biconfig.DiskRecord{
+			ID:   "fake-id-2",
+			CID:  "fake-cid-2",
+			Size: 2048,
+		}
+	})
+
+	act := func() error { return command.Run(opts) }
+
+	It("lists disk records marking the current disk", func() {
+		diskRepo.SetAllBehavior([]biconfig.DiskRecord{firstRecord, secondRecord}, nil)
+		diskRepo.SetFindCurrentBehavior(secondRecord, true, nil)
+
+		err := act()
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(statePaths).To(Equal([]string{"/dir/manifest-state.json"}))
+
+		Expect(ui.Table).To(Equal(boshtbl.Table{
+			Content: "disks",
+
+			Header: []string{"ID", "Disk CID", "Size", "Current", "Cloud Properties"},
+
+			Rows: [][]boshtbl.Value{
+				{
+					boshtbl.NewValueString("fake-id-1"),
+					boshtbl.NewValueString("fake-cid-1"),
+					boshtbl.NewValueMegaBytes(1024),
+					boshtbl.NewValueBool(false),
+					boshtbl.NewValueInterface(biproperty.Map{"type": "ssd"}),
+				},
+				{
+					boshtbl.NewValueString("fake-id-2"),
+					boshtbl.NewValueString("fake-cid-2"),
+					boshtbl.NewValueMegaBytes(2048),
+					boshtbl.NewValueBool(true),
+					boshtbl.NewValueInterface(biproperty.Map(nil)),
+				},
+			},
+		}))
+
+		Expect(diskRepo.Closed).To(BeTrue())
+	})
+
+	It("does not mark any disk as current if there is no current disk", func() {
+		diskRepo.SetAllBehavior([]biconfig.DiskRecord{firstRecord}, nil)
+		diskRepo.SetFindCurrentBehavior(biconfig.DiskRecord{}, false, nil)
+
+		err := act()
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(ui.Table.Rows[0][3]).To(Equal(boshtbl.NewValueBool(false)))
+	})
+
+	It("uses state path if specified", func() {
+		opts = EnvDisksOpts{StatePath: "/other/state.json"}
+
+		err := act()
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(statePaths).To(Equal([]string{"/other/state.json"}))
+	})
+
+	It("returns error if neither manifest nor state path is specified", func() {
+		opts = EnvDisksOpts{}
+
+		err := act()
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(Equal("Expected manifest path or --state to be specified"))
+	})
+
+	It("returns error if disk repo cannot be provided", func() {
+		diskRepoErr = errors.New("fake-err")
+
+		err := act()
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(Equal("fake-err"))
+	})
+
+	It("returns error if finding disk records fails", func() {
+		diskRepo.SetAllBehavior(nil, errors.New("fake-err"))
+
+		err := act()
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(Equal("Finding disk records: fake-err"))
+	})
+
+	It("returns error if finding current disk record fails", func() {
+		diskRepo.SetFindCurrentBehavior(biconfig.DiskRecord{}, false, errors.New("fake-err"))
+
+		err := act()
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(Equal("Finding current disk record: fake-err"))
+	})
+})
@@ -33,6 +33,7 @@ import (
 	bistemcell "github.com/cloudfoundry/bosh-cli/stemcell"
 	bitemplate "github.com/cloudfoundry/bosh-cli/templatescompiler"
 	bitemplateerb "github.com/cloudfoundry/bosh-cli/templatescompiler/erbrenderer"
+	boshui "github.com/cloudfoundry/bosh-cli/ui"
 	bihttpclient "github.com/cloudfoundry/bosh-utils/httpclient"
 )
 
@@ -53,6 +54,7 @@ type envFactory struct {
 	targetProvider boshinst.TargetProvider
 	cloudFactory   bicloud.Factory
 
+	diskRepo               biconfig.DiskRepo
 	diskManagerFactory     bidisk.ManagerFactory
 	vmManagerFactory       bivm.ManagerFactory
 	stemcellManagerFactory bistemcell.ManagerFactory
@@ -106,7 +108,8 @@ func NewEnvFactory(deps BasicDeps, manifestPath string, statePath string, manife
 	}
 
 	f.deploymentStateService = biconfig.NewFileSystemDeploymentStateService(
-		deps.FS, deps.UUIDGen, deps.Logger, biconfig.DeploymentStatePath(manifestPath, statePath))
+		deps.FS, deps.UUIDGen, deps.Logger, biconfig.DeploymentStatePath(manifestPath, statePath),
+		biconfig.DefaultDeploymentStateBackups)
 
 	{
 		registryServer := biregistry.NewServerManager(deps.Logger)
@@ -125,12 +128,12 @@ func NewEnvFactory(deps BasicDeps, manifestPath string, statePath string, manife
 		f.deploymentStateService, deps.UUIDGen, gopath.Join(workspaceRootPath, "installations"))
 
 	{
-		diskRepo := biconfig.NewDiskRepo(f.deploymentStateService, deps.UUIDGen)
+		f.diskRepo = biconfig.NewDiskRepo(f.deploymentStateService, biconfig.DefaultDeploymentName, deps.UUIDGen, deps.Time, nil, deps.Logger)
 		stemcellRepo := biconfig.NewStemcellRepo(f.deploymentStateService, deps.UUIDGen)
 		vmRepo := biconfig.NewVMRepo(f.deploymentStateService)
 
-		f.diskManagerFactory = bidisk.NewManagerFactory(diskRepo, deps.Logger)
-		diskDeployer := bivm.NewDiskDeployer(f.diskManagerFactory, diskRepo, deps.Logger)
+		f.diskManagerFactory = bidisk.NewManagerFactory(f.diskRepo, deps.Logger)
+		diskDeployer := bivm.NewDiskDeployer(f.diskManagerFactory, f.diskRepo, deps.Logger)
 
 		f.stemcellManagerFactory = bistemcell.NewManagerFactory(stemcellRepo)
 		f.vmManagerFactory = bivm.NewManagerFactory(
@@ -142,7 +145,13 @@ func NewEnvFactory(deps BasicDeps, manifestPath string, statePath string, manife
 	}
 
 	{
-		f.blobstoreFactory = biblobstore.NewBlobstoreFactory(deps.UUIDGen, deps.FS, deps.Logger)
+		blobstoreOpts := biblobstore.Options{
+			RetryPolicy:  biblobstore.DefaultRetryPolicy,
+			ProgressFunc: boshui.NewTransferReporter(deps.UI).Track,
+			Metrics:      biblobstore.NewLoggerMetricsSink(deps.Logger),
+		}
+
+		f.blobstoreFactory = biblobstore.NewBlobstoreFactory(deps.UUIDGen, deps.FS, blobstoreOpts, deps.Logger)
 		f.deploymentFactory = bidepl.NewFactory(10*time.Second, 500*time.Millisecond)
 		f.agentClientFactory = bihttpagent.NewAgentClientFactory(1*time.Second, deps.Logger)
 		f.cloudFactory = bicloud.NewFactory(deps.FS, deps.CmdRunner, deps.Logger)
@@ -183,6 +192,29 @@ func NewEnvFactory(deps BasicDeps, manifestPath string, statePath string, manife
 	return &f
 }
 
+// Close waits for pending disk repo notifications before command exits
+func (f *envFactory) Close() {
+	f.diskRepo.Close()
+}
+
+// envFactories keeps factories created while running a command
+// so that all of them are closed once command finishes
+type envFactories struct {
+	factories []*envFactory
+}
+
+func (fs *envFactories) New(deps BasicDeps, manifestPath string, statePath string, manifestVars boshtpl.Variables, manifestOp patch.Op) *envFactory {
+	f := NewEnvFactory(deps, manifestPath, statePath, manifestVars, manifestOp)
+	fs.factories = append(fs.factories, f)
+	return f
+}
+
+func (fs *envFactories) Close() {
+	for _, f := range fs.factories {
+		f.Close()
+	}
+}
+
 func (f *envFactory) Preparer() DeploymentPreparer {
 	return NewDeploymentPreparer(
 		f.deps.UI,
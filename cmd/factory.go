@@ -11,6 +11,10 @@ import (
 	goflags "github.com/jessevdk/go-flags"
 )
 
+type varsMergeValidator interface {
+	ValidateVarsMerge() error
+}
+
 type Factory struct {
 	deps BasicDeps
 }
@@ -62,6 +66,13 @@ func (f Factory) New(args []string) (Cmd, error) {
 			opts.Deployment = boshOpts.DeploymentOpt
 		}
 
+		if opts, ok := command.(varsMergeValidator); ok {
+			err := opts.ValidateVarsMerge()
+			if err != nil {
+				return err
+			}
+		}
+
 		if len(extraArgs) > 0 {
 			errMsg := "Command '%T' does not support extra arguments: %s"
 			return fmt.Errorf(errMsg, command, strings.Join(extraArgs, ", "))
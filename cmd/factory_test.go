@@ -11,6 +11,7 @@ import (
 
 	. "github.com/cloudfoundry/bosh-cli/cmd"
 	boshdir "github.com/cloudfoundry/bosh-cli/director"
+	boshtpl "github.com/cloudfoundry/bosh-cli/director/template"
 	boshui "github.com/cloudfoundry/bosh-cli/ui"
 )
 
@@ -223,6 +224,47 @@ var _ = Describe("Factory", func() {
 				{All: true},
 			}))
 		})
+
+		It("parses --canaries and --max-in-flight as numbers or percentages", func() {
+			cmd, err := factory.New([]string{"deploy", "--canaries=2", "--max-in-flight=25%", "/file"})
+			Expect(err).ToNot(HaveOccurred())
+
+			opts := cmd.Opts.(*DeployOpts)
+			Expect(opts.Canaries).To(Equal(InstanceCountArg("2")))
+			Expect(opts.MaxInFlight).To(Equal(InstanceCountArg("25%")))
+		})
+
+		It("errors when --max-in-flight is not a positive number or percentage", func() {
+			_, err := factory.New([]string{"deploy", "--max-in-flight=0", "/file"})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring(
+				"Expected '0' to be a positive integer or a percentage between 1% and 100%"))
+		})
+	})
+
+	Describe("commands that use vars files", func() {
+		BeforeEach(func() {
+			err := fs.WriteFileString("/vars", "key: val1\n---\nkey: val2\n")
+			Expect(err).ToNot(HaveOccurred())
+
+			err = fs.WriteFileString("/file", "")
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("returns error if vars file redefines variables across documents", func() {
+			_, err := factory.New([]string{"interpolate", "-l", "/vars", "/file"})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal(
+				"Expected variables file '/vars' to not redefine variables across documents: key (use --vars-merge=override to allow)"))
+		})
+
+		It("allows redefined variables if --vars-merge=override is given", func() {
+			cmd, err := factory.New([]string{"interpolate", "-l", "/vars", "--vars-merge=override", "/file"})
+			Expect(err).ToNot(HaveOccurred())
+
+			opts := cmd.Opts.(*InterpolateOpts)
+			Expect(opts.VarsFiles[0].Vars).To(Equal(boshtpl.StaticVariables{"key": "val2"}))
+		})
 	})
 
 	Describe("create-env command (command that uses FileBytesArg)", func() {
@@ -350,6 +392,9 @@ var _ = Describe("Factory", func() {
 			boshOpts.InitRelease = InitReleaseOpts{}
 			boshOpts.ResetRelease = ResetReleaseOpts{}
 			boshOpts.GenerateJob = GenerateJobOpts{}
+			boshOpts.Deploy = DeployOpts{}
+			boshOpts.DeployDiff = DeployDiffOpts{}
+			boshOpts.CleanUpTmp = CleanUpTmpOpts{}
 			boshOpts.GeneratePackage = GeneratePackageOpts{}
 			boshOpts.CreateRelease = CreateReleaseOpts{}
 			boshOpts.FinalizeRelease = FinalizeReleaseOpts{}
@@ -12,6 +12,9 @@ type FileBytesArg struct {
 	FS boshsys.FileSystem
 
 	Bytes []byte
+
+	// FromStdin is set when bytes were read from stdin ("-")
+	FromStdin bool
 }
 
 func (a *FileBytesArg) UnmarshalFlag(data string) error {
@@ -26,6 +29,7 @@ func (a *FileBytesArg) UnmarshalFlag(data string) error {
 		}
 
 		(*a).Bytes = bs
+		(*a).FromStdin = true
 
 		return nil
 	}
@@ -39,6 +39,7 @@ var _ = Describe("FileBytesArg", func() {
 				err = (&arg).UnmarshalFlag("-")
 				Expect(err).ToNot(HaveOccurred())
 				Expect(arg.Bytes).To(Equal([]byte("content")))
+				Expect(arg.FromStdin).To(BeTrue())
 			})
 
 			It("returns error if reading from stdin fails", func() {
@@ -57,6 +58,7 @@ var _ = Describe("FileBytesArg", func() {
 				err := (&arg).UnmarshalFlag("/some/path")
 				Expect(err).ToNot(HaveOccurred())
 				Expect(arg.Bytes).To(Equal([]byte("content")))
+				Expect(arg.FromStdin).To(BeFalse())
 			})
 
 			It("returns an error if expanding path fails", func() {
@@ -0,0 +1,27 @@
+package cmd
+
+import (
+	"strconv"
+	"strings"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+)
+
+// InstanceCountArg is a number of instances specified either
+// as a positive integer (e.g. '2') or as a percentage (e.g. '25%')
+// in the same format as the Director accepts for canaries and max_in_flight
+type InstanceCountArg string
+
+func (a *InstanceCountArg) UnmarshalFlag(data string) error {
+	num := strings.TrimSuffix(data, "%")
+	isPercentage := num != data
+
+	val, err := strconv.Atoi(num)
+	if err != nil || val < 1 || (isPercentage && val > 100) {
+		return bosherr.Errorf("Expected '%s' to be a positive integer or a percentage between 1%% and 100%%", data)
+	}
+
+	*a = InstanceCountArg(data)
+
+	return nil
+}
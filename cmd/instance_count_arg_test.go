@@ -0,0 +1,45 @@
+package cmd_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-cli/cmd"
+)
+
+var _ = Describe("InstanceCountArg", func() {
+	Describe("UnmarshalFlag", func() {
+		var (
+			arg InstanceCountArg
+		)
+
+		BeforeEach(func() {
+			arg = InstanceCountArg("")
+		})
+
+		It("accepts positive integers", func() {
+			err := (&arg).UnmarshalFlag("3")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(arg).To(Equal(InstanceCountArg("3")))
+		})
+
+		It("accepts percentages", func() {
+			err := (&arg).UnmarshalFlag("25%")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(arg).To(Equal(InstanceCountArg("25%")))
+
+			err = (&arg).UnmarshalFlag("100%")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(arg).To(Equal(InstanceCountArg("100%")))
+		})
+
+		It("returns error if value is not a positive integer or a percentage", func() {
+			for _, val := range []string{"", "0", "-1", "0%", "101%", "%", "1.5", "abc", "25 %"} {
+				err := (&arg).UnmarshalFlag(val)
+				Expect(err).To(HaveOccurred(), val)
+				Expect(err.Error()).To(Equal("Expected '" + val +
+					"' to be a positive integer or a percentage between 1% and 100%"))
+			}
+		})
+	})
+})
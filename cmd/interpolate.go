@@ -1,6 +1,10 @@
 package cmd
 
 import (
+	"fmt"
+	"sort"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 	"github.com/cppforlife/go-patch/patch"
 
 	boshtpl "github.com/cloudfoundry/bosh-cli/director/template"
@@ -23,6 +27,7 @@ func (c InterpolateCmd) Run(opts InterpolateOpts) error {
 	evalOpts := boshtpl.EvaluateOpts{
 		ExpectAllKeys:     opts.VarErrors,
 		ExpectAllVarsUsed: opts.VarErrorsUnused,
+		PreserveKeyOrder:  true,
 	}
 
 	if opts.Path.IsSet() {
@@ -32,12 +37,77 @@ func (c InterpolateCmd) Run(opts InterpolateOpts) error {
 		evalOpts.UnescapedMultiline = true
 	}
 
-	bytes, err := tpl.Evaluate(vars, op, evalOpts)
+	usedVars := newUsedVariables(vars)
+
+	bytes, err := tpl.Evaluate(usedVars, op, evalOpts)
 	if err != nil {
 		return err
 	}
 
+	if len(opts.VarsFilesUnused) > 0 {
+		err = c.checkVarsFilesUsed(opts.VarsFiles, usedVars, opts.VarsFilesUnused == "error")
+		if err != nil {
+			return err
+		}
+	}
+
 	c.ui.PrintBlock(string(bytes))
 
 	return nil
 }
+
+func (c InterpolateCmd) checkVarsFilesUsed(varsFiles []boshtpl.VarsFileArg, usedVars usedVariables, failOnUnused bool) error {
+	var errs []error
+
+	for _, varsFile := range varsFiles {
+		var names []string
+
+		for name := range varsFile.Vars {
+			if !usedVars.IsUsed(name) {
+				names = append(names, name)
+			}
+		}
+
+		sort.Strings(names)
+
+		for _, name := range names {
+			msg := fmt.Sprintf("Variable '%s' from vars file '%s' is not used", name, varsFile.Path)
+
+			if failOnUnused {
+				errs = append(errs, bosherr.Error(msg))
+			} else {
+				c.ui.ErrorLinef("Warning: %s", msg)
+			}
+		}
+	}
+
+	if len(errs) > 0 {
+		return bosherr.WrapError(bosherr.NewMultiError(errs...), "Checking vars files")
+	}
+
+	return nil
+}
+
+// usedVariables records names of variables looked up during template evaluation
+type usedVariables struct {
+	vars boshtpl.Variables
+	used map[string]struct{}
+}
+
+func newUsedVariables(vars boshtpl.Variables) usedVariables {
+	return usedVariables{vars: vars, used: map[string]struct{}{}}
+}
+
+func (v usedVariables) Get(varDef boshtpl.VariableDefinition) (interface{}, bool, error) {
+	v.used[varDef.Name] = struct{}{}
+	return v.vars.Get(varDef)
+}
+
+func (v usedVariables) List() ([]boshtpl.VariableDefinition, error) {
+	return v.vars.List()
+}
+
+func (v usedVariables) IsUsed(name string) bool {
+	_, found := v.used[name]
+	return found
+}
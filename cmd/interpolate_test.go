@@ -61,6 +61,22 @@ var _ = Describe("InterpolateCmd", func() {
 			Expect(ui.Blocks).To(Equal([]string{bytes}))
 		})
 
+		It("shows templated manifest keeping keys in the order of the template", func() {
+			opts.Args.Manifest = FileBytesArg{
+				Bytes: []byte("name: dep\nreleases:\n- version: ((version))\n  name: rel\ninstance_groups: []\n"),
+			}
+
+			opts.VarKVs = []boshtpl.VarKV{
+				{Name: "version", Value: "1"},
+			}
+
+			err := act()
+			Expect(err).ToNot(HaveOccurred())
+
+			bytes := "name: dep\nreleases:\n- version: \"1\"\n  name: rel\ninstance_groups: []\n"
+			Expect(ui.Blocks).To(Equal([]string{bytes}))
+		})
+
 		It("returns portion of the template after it's interpolated if path is given", func() {
 			opts.Args.Manifest = FileBytesArg{
 				Bytes: []byte("name1: ((name1))\nname2: ((name2))"),
@@ -148,5 +164,55 @@ var _ = Describe("InterpolateCmd", func() {
 			Expect(err).To(HaveOccurred())
 			Expect(err.Error()).To(ContainSubstring("Expected to use variables: name3"))
 		})
+
+		Context("when checking vars files for unused variables", func() {
+			BeforeEach(func() {
+				opts.Args.Manifest = FileBytesArg{
+					Bytes: []byte("name1: ((name1))\nname2: ((name2.key))"),
+				}
+
+				opts.VarsFiles = []boshtpl.VarsFileArg{
+					{
+						Path: "/vars1.yml",
+						Vars: boshtpl.StaticVariables{"name1": "val1", "unused2": "val", "unused1": "val"},
+					},
+					{
+						Path: "/vars2.yml",
+						Vars: boshtpl.StaticVariables{"name1": "val1", "name2": map[interface{}]interface{}{"key": "val2"}},
+					},
+				}
+			})
+
+			It("warns about each unused variable with its vars file", func() {
+				opts.VarsFilesUnused = "warn"
+
+				err := act()
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(ui.Errors).To(Equal([]string{
+					"Warning: Variable 'unused1' from vars file '/vars1.yml' is not used",
+					"Warning: Variable 'unused2' from vars file '/vars1.yml' is not used",
+				}))
+				Expect(ui.Blocks).To(Equal([]string{"name1: val1\nname2: val2\n"}))
+			})
+
+			It("returns error listing each unused variable with its vars file", func() {
+				opts.VarsFilesUnused = "error"
+
+				err := act()
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("Variable 'unused1' from vars file '/vars1.yml' is not used"))
+				Expect(err.Error()).To(ContainSubstring("Variable 'unused2' from vars file '/vars1.yml' is not used"))
+
+				Expect(ui.Errors).To(BeEmpty())
+				Expect(ui.Blocks).To(BeEmpty())
+			})
+
+			It("does not check vars files if not requested", func() {
+				err := act()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(ui.Errors).To(BeEmpty())
+			})
+		})
 	})
 })
@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"crypto/sha1"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+)
+
+//go:generate counterfeiter . ManifestFetcher
+
+type ManifestFetcher interface {
+	Fetch(url string, timeout time.Duration) ([]byte, error)
+}
+
+type HTTPManifestFetcher struct{}
+
+func NewHTTPManifestFetcher() HTTPManifestFetcher {
+	return HTTPManifestFetcher{}
+}
+
+// Fetch downloads manifest at given URL following redirects;
+// whole request (including reading body) must complete within timeout
+func (f HTTPManifestFetcher) Fetch(url string, timeout time.Duration) ([]byte, error) {
+	client := &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			Proxy: http.ProxyFromEnvironment,
+		},
+	}
+
+	resp, err := client.Get(url)
+	if err != nil {
+		return nil, bosherr.WrapErrorf(err, "Fetching manifest from '%s'", url)
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, bosherr.Errorf(
+			"Fetching manifest from '%s': expected response status 2xx but was '%d'", url, resp.StatusCode)
+	}
+
+	bytes, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, bosherr.WrapErrorf(err, "Reading manifest from '%s'", url)
+	}
+
+	return bytes, nil
+}
+
+func verifyManifestSHA1(bytes []byte, expectedSHA1 string) error {
+	actualSHA1 := fmt.Sprintf("%x", sha1.Sum(bytes))
+
+	if actualSHA1 != expectedSHA1 {
+		return bosherr.Errorf("Expected manifest SHA1 to be '%s' but was '%s'", expectedSHA1, actualSHA1)
+	}
+
+	return nil
+}
@@ -0,0 +1,78 @@
+package cmd_test
+
+import (
+	"net/http"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+
+	. "github.com/cloudfoundry/bosh-cli/cmd"
+)
+
+var _ = Describe("HTTPManifestFetcher", func() {
+	var (
+		server  *ghttp.Server
+		fetcher HTTPManifestFetcher
+	)
+
+	BeforeEach(func() {
+		server = ghttp.NewServer()
+		fetcher = NewHTTPManifestFetcher()
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	Describe("Fetch", func() {
+		It("returns manifest bytes", func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/manifest.yml"),
+					ghttp.RespondWith(http.StatusOK, "name: dep"),
+				),
+			)
+
+			bytes, err := fetcher.Fetch(server.URL()+"/manifest.yml", time.Minute)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(bytes).To(Equal([]byte("name: dep")))
+		})
+
+		It("follows redirects", func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/manifest.yml"),
+					ghttp.RespondWith(http.StatusFound, nil, http.Header{"Location": []string{"/other.yml"}}),
+				),
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/other.yml"),
+					ghttp.RespondWith(http.StatusOK, "name: other"),
+				),
+			)
+
+			bytes, err := fetcher.Fetch(server.URL()+"/manifest.yml", time.Minute)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(bytes).To(Equal([]byte("name: other")))
+		})
+
+		It("returns error if response status is not successful", func() {
+			server.AppendHandlers(ghttp.RespondWith(http.StatusNotFound, "not found"))
+
+			_, err := fetcher.Fetch(server.URL()+"/manifest.yml", time.Minute)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("expected response status 2xx but was '404'"))
+		})
+
+		It("returns error if request does not complete within timeout", func() {
+			server.AppendHandlers(func(w http.ResponseWriter, r *http.Request) {
+				time.Sleep(200 * time.Millisecond)
+			})
+
+			_, err := fetcher.Fetch(server.URL()+"/manifest.yml", 50*time.Millisecond)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Fetching manifest from"))
+		})
+	})
+})
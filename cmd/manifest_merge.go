@@ -0,0 +1,160 @@
+package cmd
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	"gopkg.in/yaml.v2"
+)
+
+// mergeManifests deep-merges manifest fragments in order; maps are merged by key,
+// arrays of named items (e.g. instance groups) are merged by item name and
+// any other differing values are considered to be conflicting unless override is set
+func mergeManifests(fragments [][]byte, override bool) ([]byte, error) {
+	var merged interface{}
+
+	for i, fragment := range fragments {
+		var node yaml.MapSlice
+
+		err := yaml.Unmarshal(fragment, &node)
+		if err != nil {
+			return nil, bosherr.WrapErrorf(err, "Deserializing manifest fragment %d", i+1)
+		}
+
+		if i == 0 {
+			merged = node
+			continue
+		}
+
+		merged, err = mergeManifestNodes(merged, node, "", override)
+		if err != nil {
+			return nil, bosherr.WrapErrorf(err, "Merging manifest fragment %d", i+1)
+		}
+	}
+
+	bytes, err := yaml.Marshal(merged)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Serializing merged manifest")
+	}
+
+	return bytes, nil
+}
+
+func mergeManifestNodes(dst, src interface{}, path string, override bool) (interface{}, error) {
+	switch typedSrc := src.(type) {
+	case yaml.MapSlice:
+		if typedDst, ok := dst.(yaml.MapSlice); ok {
+			return mergeManifestMaps(typedDst, typedSrc, path, override)
+		}
+
+	case []interface{}:
+		if typedDst, ok := dst.([]interface{}); ok {
+			if manifestItemsNamed(typedDst) && manifestItemsNamed(typedSrc) {
+				return mergeManifestNamedItems(typedDst, typedSrc, path, override)
+			}
+		}
+	}
+
+	if reflect.DeepEqual(dst, src) || override {
+		return src, nil
+	}
+
+	if len(path) == 0 {
+		path = "/"
+	}
+
+	return nil, bosherr.Errorf("Expected manifest fragments not to set conflicting values at '%s' "+
+		"(use --manifest-merge-override to let later fragments override earlier ones)", path)
+}
+
+func mergeManifestMaps(dst, src yaml.MapSlice, path string, override bool) (yaml.MapSlice, error) {
+	merged := append(yaml.MapSlice{}, dst...)
+
+	for _, srcItem := range src {
+		found := false
+
+		for i, dstItem := range merged {
+			if dstItem.Key != srcItem.Key {
+				continue
+			}
+
+			val, err := mergeManifestNodes(dstItem.Value, srcItem.Value, manifestPath(path, fmt.Sprintf("%v", srcItem.Key)), override)
+			if err != nil {
+				return nil, err
+			}
+
+			merged[i].Value = val
+			found = true
+			break
+		}
+
+		if !found {
+			merged = append(merged, srcItem)
+		}
+	}
+
+	return merged, nil
+}
+
+func mergeManifestNamedItems(dst, src []interface{}, path string, override bool) ([]interface{}, error) {
+	merged := append([]interface{}{}, dst...)
+
+	for _, srcItem := range src {
+		srcName := manifestItemName(srcItem)
+		found := false
+
+		for i, dstItem := range merged {
+			if manifestItemName(dstItem) != srcName {
+				continue
+			}
+
+			val, err := mergeManifestNodes(dstItem, srcItem, manifestPath(path, "name="+srcName), override)
+			if err != nil {
+				return nil, err
+			}
+
+			merged[i] = val
+			found = true
+			break
+		}
+
+		if !found {
+			merged = append(merged, srcItem)
+		}
+	}
+
+	return merged, nil
+}
+
+func manifestItemsNamed(items []interface{}) bool {
+	for _, item := range items {
+		if len(manifestItemName(item)) == 0 {
+			return false
+		}
+	}
+
+	return true
+}
+
+func manifestItemName(item interface{}) string {
+	if itemMap, ok := item.(yaml.MapSlice); ok {
+		for _, mapItem := range itemMap {
+			if mapItem.Key == "name" {
+				name, _ := mapItem.Value.(string)
+				return name
+			}
+		}
+	}
+
+	return ""
+}
+
+func manifestPath(path, token string) string {
+	// Escape tokens similarly to go-patch pointers
+	token = strings.Replace(token, "~", "~0", -1)
+	token = strings.Replace(token, "/", "~1", -1)
+
+	return path + "/" + token
+}
@@ -1,6 +1,8 @@
 package cmd
 
 import (
+	"time"
+
 	boshuuid "github.com/cloudfoundry/bosh-utils/uuid"
 	"github.com/cppforlife/go-patch/patch"
 
@@ -43,6 +45,7 @@ type BoshOpts struct {
 	Environments EnvironmentsOpts `command:"environments" alias:"envs" description:"List environments"`
 	CreateEnv    CreateEnvOpts    `command:"create-env"                description:"Create or update BOSH environment"`
 	DeleteEnv    DeleteEnvOpts    `command:"delete-env"                description:"Delete BOSH environment"`
+	EnvDisks     EnvDisksOpts     `command:"env-disks"                 description:"List persistent disks recorded in environment state file"`
 	AliasEnv     AliasEnvOpts     `command:"alias-env"                 description:"Alias environment to save URL and CA certificate"`
 
 	// Authentication
@@ -55,9 +58,10 @@ type BoshOpts struct {
 	CancelTask CancelTaskOpts `command:"cancel-task" alias:"ct" description:"Cancel task at its next checkpoint"`
 
 	// Misc
-	Locks   LocksOpts   `command:"locks"    description:"List current locks"`
-	CleanUp CleanUpOpts `command:"clean-up" description:"Clean up releases, stemcells, disks, etc."`
-	BackUp  BackUpOpts  `command:"back-up"  description:"Back up the Director database to a tarball"`
+	Locks      LocksOpts      `command:"locks"        description:"List current locks"`
+	CleanUp    CleanUpOpts    `command:"clean-up"     description:"Clean up releases, stemcells, disks, etc."`
+	CleanUpTmp CleanUpTmpOpts `command:"clean-up-tmp" description:"Clean up temporary files left behind by failed downloads"`
+	BackUp     BackUpOpts     `command:"back-up"      description:"Back up the Director database to a tarball"`
 
 	// Disks
 	AttachDisk AttachDiskOpts `command:"attach-disk" description:"Attaches an disk to an instance and replaces the current disk"`
@@ -79,8 +83,9 @@ type BoshOpts struct {
 	Deployments      DeploymentsOpts      `command:"deployments"       alias:"ds" alias:"deps" description:"List deployments"`
 	DeleteDeployment DeleteDeploymentOpts `command:"delete-deployment" alias:"deld"            description:"Delete deployment"`
 
-	Deploy   DeployOpts   `command:"deploy"   alias:"d"                                       description:"Deploy according to the currently selected deployment manifest"`
-	Manifest ManifestOpts `command:"manifest" alias:"m" alias:"man" alias:"download-manifest" description:"Download deployment manifest locally"`
+	Deploy     DeployOpts     `command:"deploy"      alias:"d"                                       description:"Deploy according to the currently selected deployment manifest"`
+	DeployDiff DeployDiffOpts `command:"deploy-diff"                                                 description:"Show manifest diff against manifest last successfully deployed from this machine without contacting the Director"`
+	Manifest   ManifestOpts   `command:"manifest"    alias:"m" alias:"man" alias:"download-manifest" description:"Download deployment manifest locally"`
 
 	Interpolate InterpolateOpts `command:"interpolate" alias:"int" description:"Interpolates variables into a manifest"`
 
@@ -182,6 +187,16 @@ type DeleteEnvArgs struct {
 	Manifest FileBytesWithPathArg `positional-arg-name:"PATH" description:"Path to a manifest file"`
 }
 
+type EnvDisksOpts struct {
+	Args      EnvDisksArgs `positional-args:"true"`
+	StatePath string       `long:"state" value-name:"PATH" description:"State file path"`
+	cmd
+}
+
+type EnvDisksArgs struct {
+	Manifest string `positional-arg-name:"PATH" description:"Path to a manifest file used to find state file"`
+}
+
 // Environment
 type EnvironmentOpts struct {
 	cmd
@@ -254,6 +269,12 @@ type CleanUpOpts struct {
 	cmd
 }
 
+type CleanUpTmpOpts struct {
+	OlderThan time.Duration `long:"older-than" value-name:"DURATION" description:"Only delete files not modified for given duration so that files of downloads in progress are kept" default:"24h"`
+
+	cmd
+}
+
 type AttachDiskOpts struct {
 	Args AttachDiskArgs `positional-args:"true" required:"true"`
 
@@ -286,6 +307,7 @@ type InterpolateOpts struct {
 	Path            patch.Pointer `long:"path" value-name:"OP-PATH" description:"Extract value out of template (e.g.: /private_key)"`
 	VarErrors       bool          `long:"var-errs"                  description:"Expect all variables to be found, otherwise error"`
 	VarErrorsUnused bool          `long:"var-errs-unused"           description:"Expect all variables to be used, otherwise error"`
+	VarsFilesUnused string        `long:"vars-files-unused" value-name:"warn|error" description:"Report variables in vars files that are not used by the template" choice:"warn" choice:"error"`
 
 	cmd
 }
@@ -351,21 +373,74 @@ type DeploymentsOpts struct {
 }
 
 type DeployOpts struct {
-	Args DeployArgs `positional-args:"true" required:"true"`
+	Args DeployArgs `positional-args:"true"`
+
+	ManifestURL        string        `long:"manifest-url"         value-name:"URL"      description:"Fetch manifest from URL instead of a local path"`
+	ManifestSHA1       string        `long:"manifest-sha1"        value-name:"SHA1"     description:"Verify manifest against expected SHA1"`
+	ManifestURLTimeout time.Duration `long:"manifest-url-timeout" value-name:"DURATION" description:"Timeout for fetching manifest from URL" default:"30s"`
+
+	Manifests             []FileBytesArg `long:"manifest"                value-name:"PATH" description:"Path to a manifest fragment deep-merged in order into manifest (can be specified multiple times)"`
+	ManifestMergeOverride bool           `long:"manifest-merge-override"                  description:"Let later manifest fragments override conflicting values instead of failing"`
+
+	ExpectDirectorUUID string `long:"expect-director-uuid" value-name:"UUID" description:"Fail if targeted director's UUID does not match"`
 
 	VarFlags
 	OpsFlags
 
-	NoRedact bool `long:"no-redact" description:"Show non-redacted manifest diff"`
+	VarErrors       bool `long:"var-errs" description:"Expect all variables to be found, otherwise error"`
+	VarErrorsUnused bool `long:"var-errs-unused" description:"Expect all variables to be used, otherwise error"`
+
+	InterpolationPasses int `long:"interpolation-passes" value-name:"N" description:"Max number of passes resolving variable references found in values of variables" default:"3"`
+
+	NoRedact bool   `long:"no-redact" description:"Show non-redacted manifest diff"`
+	JSONDiff bool   `long:"json-diff" description:"Show manifest diff as JSON"`
+	Color    string `long:"color" value-name:"auto|always|never" description:"Colorize manifest diff (auto colorizes only if stdout is a TTY)" choice:"auto" choice:"always" choice:"never" default:"auto"`
+
+	RedactPatterns []RegexpArg `long:"redact-pattern" value-name:"REGEX" description:"Redact values matching regular expression in manifest diff (can be specified multiple times)"`
+	DiffContext    *int        `long:"diff-context"   value-name:"N"     description:"Collapse unchanged manifest diff lines further than N lines away from changes (not applied with --json-diff)"`
+
+	DiffOut OutputFileArg `long:"diff-out" value-name:"PATH" description:"Write manifest diff with deployment and director details as JSON to a file for review before confirmation"`
+
+	ConfirmName bool `long:"confirm-name" description:"Require typing deployment name to confirm deploy"`
+
+	Force bool `long:"force" description:"Skip manifest diff and confirmation; trades safety for speed (manifest and deployment name are still validated)"`
 
 	Recreate  bool                `long:"recreate"                          description:"Recreate all VMs in deployment"`
 	Fix       bool                `long:"fix"                               description:"Recreate unresponsive instances"`
 	SkipDrain []boshdir.SkipDrain `long:"skip-drain" value-name:"INSTANCE-GROUP"  description:"Skip running drain scripts for specific instance groups" optional:"true" optional-value:"*"`
 
-	Canaries    string `long:"canaries" description:"Override manifest values for canaries"`
-	MaxInFlight string `long:"max-in-flight" description:"Override manifest values for max_in_flight"`
+	RecreateOnlyChanged bool `long:"recreate-only-changed" description:"Recreate VMs only in instance groups changed according to manifest diff"`
 
-	DryRun bool `long:"dry-run" description:"Renders job templates without altering deployment"`
+	RecreatePersistentDisks bool `long:"recreate-persistent-disks" description:"Recreate all persistent disks in deployment"`
+
+	Canaries    InstanceCountArg `long:"canaries" description:"Override manifest values for canaries"`
+	MaxInFlight InstanceCountArg `long:"max-in-flight" description:"Override manifest values for max_in_flight"`
+
+	DryRun  bool `long:"dry-run" description:"Renders job templates without altering deployment"`
+	Preview bool `long:"preview" description:"Show manifest diff and releases to be uploaded without deploying"`
+
+	SkipIfNoChanges bool `long:"skip-if-no-changes" description:"Upload releases but skip updating deployment if manifest diff has no changes"`
+	SkipIfUnchanged bool `long:"skip-if-unchanged"  description:"Skip uploading releases and deploying if manifest is the same as the last one successfully deployed from this machine"`
+
+	Timeout time.Duration `long:"timeout" value-name:"DURATION" description:"Fail and cancel deploy task if deploy does not finish in time (e.g. 30m)"`
+
+	WaitForLock time.Duration `long:"wait-for-lock" value-name:"DURATION" description:"Retry deploy while deployment is locked by another task until lock frees or duration elapses (e.g. 10m)"`
+
+	InterpolateOnly bool          `long:"interpolate-only" description:"Print evaluated manifest without diffing or deploying"`
+	Path            patch.Pointer `long:"path" value-name:"OP-PATH" description:"Extract value out of evaluated manifest with --interpolate-only (e.g.: /instance_groups/name=router/instances)"`
+
+	RunErrand string `long:"run-errand" value-name:"NAME" description:"Run errand after successful deploy (e.g. smoke-tests)"`
+
+	ReleaseUploadOrder []string `long:"release-upload-order" value-name:"NAME" description:"Upload specified release before others (can be specified multiple times)"`
+	UploadParallelism  int      `long:"upload-parallelism"   value-name:"N"    description:"Max number of releases to upload in parallel" default:"1"`
+
+	ReleaseURLReplace []URLReplaceArg `long:"release-url-replace" value-name:"OLD=NEW" description:"Replace URL prefix of releases uploaded from the manifest (can be specified multiple times)"`
+
+	VerifyReleaseSHA1s bool `long:"verify-release-sha1s" description:"Download remote releases and verify their SHA1s before uploading any of them"`
+
+	ForceUpload bool `long:"force-upload" description:"Upload remote releases even if the Director already has them"`
+
+	LogFile string `long:"log-file" value-name:"PATH" description:"Append deploy output with timestamps to file"`
 
 	cmd
 }
@@ -374,6 +449,27 @@ type DeployArgs struct {
 	Manifest FileBytesArg `positional-arg-name:"PATH" description:"Path to a manifest file"`
 }
 
+type DeployDiffOpts struct {
+	Args DeployArgs `positional-args:"true" required:"true"`
+
+	VarFlags
+	OpsFlags
+
+	VarErrors       bool `long:"var-errs" description:"Expect all variables to be found, otherwise error"`
+	VarErrorsUnused bool `long:"var-errs-unused" description:"Expect all variables to be used, otherwise error"`
+
+	InterpolationPasses int `long:"interpolation-passes" value-name:"N" description:"Max number of passes resolving variable references found in values of variables" default:"3"`
+
+	NoRedact bool   `long:"no-redact" description:"Show non-redacted manifest diff"`
+	JSONDiff bool   `long:"json-diff" description:"Show manifest diff as JSON"`
+	Color    string `long:"color" value-name:"auto|always|never" description:"Colorize manifest diff (auto colorizes only if stdout is a TTY)" choice:"auto" choice:"always" choice:"never" default:"auto"`
+
+	RedactPatterns []RegexpArg `long:"redact-pattern" value-name:"REGEX" description:"Redact values matching regular expression in manifest diff (can be specified multiple times)"`
+	DiffContext    *int        `long:"diff-context"   value-name:"N"     description:"Collapse unchanged manifest diff lines further than N lines away from changes (not applied with --json-diff)"`
+
+	cmd
+}
+
 type ManifestOpts struct {
 	cmd
 }
@@ -453,6 +549,8 @@ type UploadReleaseOpts struct {
 	SHA1 string `long:"sha1" description:"SHA1 of the remote release (is not used with local files)"`
 
 	Release boshrel.Release
+	Latest  bool // version is resolved from the release source; skips existence check
+	Force   bool // release is uploaded again even if it exists; skips existence check
 
 	cmd
 }
@@ -208,6 +208,14 @@ var _ = Describe("Opts", func() {
 			})
 		})
 
+		Describe("EnvDisks", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("EnvDisks", opts)).To(Equal(
+					`command:"env-disks" description:"List persistent disks recorded in environment state file"`,
+				))
+			})
+		})
+
 		Describe("Environment", func() {
 			It("contains desired values", func() {
 				Expect(getStructTagForName("Environment", opts)).To(Equal(
@@ -280,6 +288,14 @@ var _ = Describe("Opts", func() {
 			})
 		})
 
+		Describe("CleanUpTmp", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("CleanUpTmp", opts)).To(Equal(
+					`command:"clean-up-tmp" description:"Clean up temporary files left behind by failed downloads"`,
+				))
+			})
+		})
+
 		Describe("BackUp", func() {
 			It("contains desired values", func() {
 				Expect(getStructTagForName("BackUp", opts)).To(Equal(
@@ -376,6 +392,14 @@ var _ = Describe("Opts", func() {
 			})
 		})
 
+		Describe("DeployDiff", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("DeployDiff", opts)).To(Equal(
+					`command:"deploy-diff" description:"Show manifest diff against manifest last successfully deployed from this machine without contacting the Director"`,
+				))
+			})
+		})
+
 		Describe("Manifest", func() {
 			It("contains desired values", func() {
 				Expect(getStructTagForName("Manifest", opts)).To(Equal(
@@ -793,6 +817,42 @@ var _ = Describe("Opts", func() {
 		})
 	})
 
+	Describe("EnvDisksOpts", func() {
+		var opts *EnvDisksOpts
+
+		BeforeEach(func() {
+			opts = &EnvDisksOpts{}
+		})
+
+		Describe("Args", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("Args", opts)).To(Equal(`positional-args:"true"`))
+			})
+		})
+
+		It("has --state", func() {
+			Expect(getStructTagForName("StatePath", opts)).To(Equal(
+				`long:"state" value-name:"PATH" description:"State file path"`,
+			))
+		})
+	})
+
+	Describe("EnvDisksArgs", func() {
+		var args *EnvDisksArgs
+
+		BeforeEach(func() {
+			args = &EnvDisksArgs{}
+		})
+
+		Describe("Manifest", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("Manifest", args)).To(Equal(
+					`positional-arg-name:"PATH" description:"Path to a manifest file used to find state file"`,
+				))
+			})
+		})
+	})
+
 	Describe("AliasEnvOpts", func() {
 		var opts *AliasEnvOpts
 
@@ -969,6 +1029,22 @@ var _ = Describe("Opts", func() {
 		})
 	})
 
+	Describe("CleanUpTmpOpts", func() {
+		var opts *CleanUpTmpOpts
+
+		BeforeEach(func() {
+			opts = &CleanUpTmpOpts{}
+		})
+
+		Describe("OlderThan", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("OlderThan", opts)).To(Equal(
+					`long:"older-than" value-name:"DURATION" description:"Only delete files not modified for given duration so that files of downloads in progress are kept" default:"24h"`,
+				))
+			})
+		})
+	})
+
 	Describe("AttachDiskOpts", func() {
 		var opts *AttachDiskOpts
 
@@ -1036,6 +1112,12 @@ var _ = Describe("Opts", func() {
 				`long:"var-errs-unused" description:"Expect all variables to be used, otherwise error"`,
 			))
 		})
+
+		It("has VarsFilesUnused", func() {
+			Expect(getStructTagForName("VarsFilesUnused", &opts)).To(Equal(
+				`long:"vars-files-unused" value-name:"warn|error" description:"Report variables in vars files that are not used by the template" choice:"warn" choice:"error"`,
+			))
+		})
 	})
 
 	Describe("InterpolateArgs", func() {
@@ -1123,7 +1205,111 @@ var _ = Describe("Opts", func() {
 
 		Describe("Args", func() {
 			It("contains desired values", func() {
-				Expect(getStructTagForName("Args", opts)).To(Equal(`positional-args:"true" required:"true"`))
+				Expect(getStructTagForName("Args", opts)).To(Equal(`positional-args:"true"`))
+			})
+		})
+
+		Describe("DiffOut", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("DiffOut", opts)).To(Equal(
+					`long:"diff-out" value-name:"PATH" description:"Write manifest diff with deployment and director details as JSON to a file for review before confirmation"`,
+				))
+			})
+		})
+
+		Describe("ConfirmName", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("ConfirmName", opts)).To(Equal(
+					`long:"confirm-name" description:"Require typing deployment name to confirm deploy"`,
+				))
+			})
+		})
+
+		Describe("Force", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("Force", opts)).To(Equal(
+					`long:"force" description:"Skip manifest diff and confirmation; trades safety for speed (manifest and deployment name are still validated)"`,
+				))
+			})
+		})
+
+		Describe("ExpectDirectorUUID", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("ExpectDirectorUUID", opts)).To(Equal(
+					`long:"expect-director-uuid" value-name:"UUID" description:"Fail if targeted director's UUID does not match"`,
+				))
+			})
+		})
+
+		Describe("VarErrors", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("VarErrors", opts)).To(Equal(
+					`long:"var-errs" description:"Expect all variables to be found, otherwise error"`,
+				))
+			})
+		})
+
+		Describe("VarErrorsUnused", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("VarErrorsUnused", opts)).To(Equal(
+					`long:"var-errs-unused" description:"Expect all variables to be used, otherwise error"`,
+				))
+			})
+		})
+
+		Describe("InterpolationPasses", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("InterpolationPasses", opts)).To(Equal(
+					`long:"interpolation-passes" value-name:"N" description:"Max number of passes resolving variable references found in values of variables" default:"3"`,
+				))
+			})
+		})
+
+		Describe("JSONDiff", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("JSONDiff", opts)).To(Equal(
+					`long:"json-diff" description:"Show manifest diff as JSON"`,
+				))
+			})
+		})
+
+		Describe("ManifestURL", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("ManifestURL", opts)).To(Equal(
+					`long:"manifest-url" value-name:"URL" description:"Fetch manifest from URL instead of a local path"`,
+				))
+			})
+		})
+
+		Describe("ManifestSHA1", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("ManifestSHA1", opts)).To(Equal(
+					`long:"manifest-sha1" value-name:"SHA1" description:"Verify manifest against expected SHA1"`,
+				))
+			})
+		})
+
+		Describe("ManifestURLTimeout", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("ManifestURLTimeout", opts)).To(Equal(
+					`long:"manifest-url-timeout" value-name:"DURATION" description:"Timeout for fetching manifest from URL" default:"30s"`,
+				))
+			})
+		})
+
+		Describe("Manifests", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("Manifests", opts)).To(Equal(
+					`long:"manifest" value-name:"PATH" description:"Path to a manifest fragment deep-merged in order into manifest (can be specified multiple times)"`,
+				))
+			})
+		})
+
+		Describe("ManifestMergeOverride", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("ManifestMergeOverride", opts)).To(Equal(
+					`long:"manifest-merge-override" description:"Let later manifest fragments override conflicting values instead of failing"`,
+				))
 			})
 		})
 
@@ -1135,6 +1321,46 @@ var _ = Describe("Opts", func() {
 			})
 		})
 
+		Describe("Fix", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("Fix", opts)).To(Equal(
+					`long:"fix" description:"Recreate unresponsive instances"`,
+				))
+			})
+		})
+
+		Describe("Timeout", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("Timeout", opts)).To(Equal(
+					`long:"timeout" value-name:"DURATION" description:"Fail and cancel deploy task if deploy does not finish in time (e.g. 30m)"`,
+				))
+			})
+		})
+
+		Describe("WaitForLock", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("WaitForLock", opts)).To(Equal(
+					`long:"wait-for-lock" value-name:"DURATION" description:"Retry deploy while deployment is locked by another task until lock frees or duration elapses (e.g. 10m)"`,
+				))
+			})
+		})
+
+		Describe("RecreateOnlyChanged", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("RecreateOnlyChanged", opts)).To(Equal(
+					`long:"recreate-only-changed" description:"Recreate VMs only in instance groups changed according to manifest diff"`,
+				))
+			})
+		})
+
+		Describe("RecreatePersistentDisks", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("RecreatePersistentDisks", opts)).To(Equal(
+					`long:"recreate-persistent-disks" description:"Recreate all persistent disks in deployment"`,
+				))
+			})
+		})
+
 		Describe("NoRedact", func() {
 			It("contains desired values", func() {
 				Expect(getStructTagForName("NoRedact", opts)).To(Equal(
@@ -1143,6 +1369,22 @@ var _ = Describe("Opts", func() {
 			})
 		})
 
+		Describe("RedactPatterns", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("RedactPatterns", opts)).To(Equal(
+					`long:"redact-pattern" value-name:"REGEX" description:"Redact values matching regular expression in manifest diff (can be specified multiple times)"`,
+				))
+			})
+		})
+
+		Describe("DiffContext", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("DiffContext", opts)).To(Equal(
+					`long:"diff-context" value-name:"N" description:"Collapse unchanged manifest diff lines further than N lines away from changes (not applied with --json-diff)"`,
+				))
+			})
+		})
+
 		Describe("SkipDrain", func() {
 			It("contains desired values", func() {
 				Expect(getStructTagForName("SkipDrain", opts)).To(Equal(
@@ -1174,6 +1416,110 @@ var _ = Describe("Opts", func() {
 				))
 			})
 		})
+
+		Describe("InterpolateOnly", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("InterpolateOnly", opts)).To(Equal(
+					`long:"interpolate-only" description:"Print evaluated manifest without diffing or deploying"`,
+				))
+			})
+		})
+
+		Describe("Path", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("Path", opts)).To(Equal(
+					`long:"path" value-name:"OP-PATH" description:"Extract value out of evaluated manifest with --interpolate-only (e.g.: /instance_groups/name=router/instances)"`,
+				))
+			})
+		})
+
+		Describe("SkipIfNoChanges", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("SkipIfNoChanges", opts)).To(Equal(
+					`long:"skip-if-no-changes" description:"Upload releases but skip updating deployment if manifest diff has no changes"`,
+				))
+			})
+		})
+
+		Describe("SkipIfUnchanged", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("SkipIfUnchanged", opts)).To(Equal(
+					`long:"skip-if-unchanged" description:"Skip uploading releases and deploying if manifest is the same as the last one successfully deployed from this machine"`,
+				))
+			})
+		})
+
+		Describe("Preview", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("Preview", opts)).To(Equal(
+					`long:"preview" description:"Show manifest diff and releases to be uploaded without deploying"`,
+				))
+			})
+		})
+
+		Describe("RunErrand", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("RunErrand", opts)).To(Equal(
+					`long:"run-errand" value-name:"NAME" description:"Run errand after successful deploy (e.g. smoke-tests)"`,
+				))
+			})
+		})
+
+		Describe("ReleaseUploadOrder", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("ReleaseUploadOrder", opts)).To(Equal(
+					`long:"release-upload-order" value-name:"NAME" description:"Upload specified release before others (can be specified multiple times)"`,
+				))
+			})
+		})
+
+		Describe("UploadParallelism", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("UploadParallelism", opts)).To(Equal(
+					`long:"upload-parallelism" value-name:"N" description:"Max number of releases to upload in parallel" default:"1"`,
+				))
+			})
+		})
+
+		Describe("Color", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("Color", opts)).To(Equal(
+					`long:"color" value-name:"auto|always|never" description:"Colorize manifest diff (auto colorizes only if stdout is a TTY)" choice:"auto" choice:"always" choice:"never" default:"auto"`,
+				))
+			})
+		})
+
+		Describe("ReleaseURLReplace", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("ReleaseURLReplace", opts)).To(Equal(
+					`long:"release-url-replace" value-name:"OLD=NEW" description:"Replace URL prefix of releases uploaded from the manifest (can be specified multiple times)"`,
+				))
+			})
+		})
+
+		Describe("VerifyReleaseSHA1s", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("VerifyReleaseSHA1s", opts)).To(Equal(
+					`long:"verify-release-sha1s" description:"Download remote releases and verify their SHA1s before uploading any of them"`,
+				))
+			})
+		})
+
+		Describe("ForceUpload", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("ForceUpload", opts)).To(Equal(
+					`long:"force-upload" description:"Upload remote releases even if the Director already has them"`,
+				))
+			})
+		})
+
+		Describe("LogFile", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("LogFile", opts)).To(Equal(
+					`long:"log-file" value-name:"PATH" description:"Append deploy output with timestamps to file"`,
+				))
+			})
+		})
 	})
 
 	Describe("DeployArgs", func() {
@@ -1192,6 +1538,86 @@ var _ = Describe("Opts", func() {
 		})
 	})
 
+	Describe("DeployDiffOpts", func() {
+		var opts *DeployDiffOpts
+
+		BeforeEach(func() {
+			opts = &DeployDiffOpts{}
+		})
+
+		Describe("Args", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("Args", opts)).To(Equal(
+					`positional-args:"true" required:"true"`,
+				))
+			})
+		})
+
+		Describe("VarErrors", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("VarErrors", opts)).To(Equal(
+					`long:"var-errs" description:"Expect all variables to be found, otherwise error"`,
+				))
+			})
+		})
+
+		Describe("VarErrorsUnused", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("VarErrorsUnused", opts)).To(Equal(
+					`long:"var-errs-unused" description:"Expect all variables to be used, otherwise error"`,
+				))
+			})
+		})
+
+		Describe("InterpolationPasses", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("InterpolationPasses", opts)).To(Equal(
+					`long:"interpolation-passes" value-name:"N" description:"Max number of passes resolving variable references found in values of variables" default:"3"`,
+				))
+			})
+		})
+
+		Describe("NoRedact", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("NoRedact", opts)).To(Equal(
+					`long:"no-redact" description:"Show non-redacted manifest diff"`,
+				))
+			})
+		})
+
+		Describe("JSONDiff", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("JSONDiff", opts)).To(Equal(
+					`long:"json-diff" description:"Show manifest diff as JSON"`,
+				))
+			})
+		})
+
+		Describe("Color", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("Color", opts)).To(Equal(
+					`long:"color" value-name:"auto|always|never" description:"Colorize manifest diff (auto colorizes only if stdout is a TTY)" choice:"auto" choice:"always" choice:"never" default:"auto"`,
+				))
+			})
+		})
+
+		Describe("RedactPatterns", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("RedactPatterns", opts)).To(Equal(
+					`long:"redact-pattern" value-name:"REGEX" description:"Redact values matching regular expression in manifest diff (can be specified multiple times)"`,
+				))
+			})
+		})
+
+		Describe("DiffContext", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("DiffContext", opts)).To(Equal(
+					`long:"diff-context" value-name:"N" description:"Collapse unchanged manifest diff lines further than N lines away from changes (not applied with --json-diff)"`,
+				))
+			})
+		})
+	})
+
 	Describe("DeleteDeploymentOpts", func() {
 		var opts *DeleteDeploymentOpts
 
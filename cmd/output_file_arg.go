@@ -0,0 +1,39 @@
+package cmd
+
+import (
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+)
+
+// OutputFileArg is a path to a file that command writes its output to
+type OutputFileArg struct {
+	FS boshsys.FileSystem
+
+	Path string
+}
+
+func (a *OutputFileArg) UnmarshalFlag(data string) error {
+	if len(data) == 0 {
+		return bosherr.Errorf("Expected file path to be non-empty")
+	}
+
+	absPath, err := a.FS.ExpandPath(data)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Getting absolute path '%s'", data)
+	}
+
+	(*a).Path = absPath
+
+	return nil
+}
+
+func (a OutputFileArg) IsSet() bool { return len(a.Path) > 0 }
+
+func (a OutputFileArg) Write(bytes []byte) error {
+	err := a.FS.WriteFile(a.Path, bytes)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Writing file '%s'", a.Path)
+	}
+
+	return nil
+}
@@ -0,0 +1,76 @@
+package cmd_test
+
+import (
+	"errors"
+
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-cli/cmd"
+)
+
+var _ = Describe("OutputFileArg", func() {
+	var (
+		fs  *fakesys.FakeFileSystem
+		arg OutputFileArg
+	)
+
+	BeforeEach(func() {
+		fs = fakesys.NewFakeFileSystem()
+		arg = OutputFileArg{FS: fs}
+	})
+
+	Describe("UnmarshalFlag", func() {
+		It("sets expanded path without creating the file", func() {
+			fs.ExpandPathExpanded = "/expanded/path"
+
+			err := (&arg).UnmarshalFlag("~/path")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(arg.Path).To(Equal("/expanded/path"))
+			Expect(arg.IsSet()).To(BeTrue())
+
+			Expect(fs.FileExists("/expanded/path")).To(BeFalse())
+		})
+
+		It("returns an error if expanding path fails", func() {
+			fs.ExpandPathErr = errors.New("fake-err")
+
+			err := (&arg).UnmarshalFlag("/some/path")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-err"))
+		})
+
+		It("returns an error when it is empty", func() {
+			err := (&arg).UnmarshalFlag("")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("Expected file path to be non-empty"))
+		})
+	})
+
+	Describe("Write", func() {
+		It("writes bytes to the file", func() {
+			arg.Path = "/some/path"
+
+			err := arg.Write([]byte("content"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(fs.ReadFileString("/some/path")).To(Equal("content"))
+		})
+
+		It("returns an error if writing fails", func() {
+			arg.Path = "/some/path"
+			fs.WriteFileError = errors.New("fake-err")
+
+			err := arg.Write([]byte("content"))
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Writing file '/some/path'"))
+			Expect(err.Error()).To(ContainSubstring("fake-err"))
+		})
+	})
+
+	Describe("IsSet", func() {
+		It("returns false when path is not set", func() {
+			Expect(arg.IsSet()).To(BeFalse())
+		})
+	})
+})
@@ -0,0 +1,27 @@
+package cmd
+
+import (
+	"regexp"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+)
+
+// RegexpArg is a regular expression compiled when flag is parsed
+type RegexpArg struct {
+	*regexp.Regexp
+}
+
+func (a *RegexpArg) UnmarshalFlag(data string) error {
+	if len(data) == 0 {
+		return bosherr.Error("Expected regular expression to be non-empty")
+	}
+
+	re, err := regexp.Compile(data)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Compiling regular expression '%s'", data)
+	}
+
+	*a = RegexpArg{re}
+
+	return nil
+}
@@ -0,0 +1,39 @@
+package cmd_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-cli/cmd"
+)
+
+var _ = Describe("RegexpArg", func() {
+	Describe("UnmarshalFlag", func() {
+		var (
+			arg RegexpArg
+		)
+
+		BeforeEach(func() {
+			arg = RegexpArg{}
+		})
+
+		It("compiles regular expression", func() {
+			err := (&arg).UnmarshalFlag("pass(word)?: .+")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(arg.String()).To(Equal("pass(word)?: .+"))
+			Expect(arg.MatchString("password: secret")).To(BeTrue())
+		})
+
+		It("returns error if regular expression is empty", func() {
+			err := (&arg).UnmarshalFlag("")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("Expected regular expression to be non-empty"))
+		})
+
+		It("returns error if regular expression cannot be compiled", func() {
+			err := (&arg).UnmarshalFlag("pass(")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Compiling regular expression 'pass('"))
+		})
+	})
+})
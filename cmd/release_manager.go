@@ -1,80 +1,689 @@
 package cmd
 
 import (
+	"sort"
+	"strings"
+
+	boshcrypto "github.com/cloudfoundry/bosh-utils/crypto"
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
 	"github.com/cppforlife/go-patch/patch"
 	semver "github.com/cppforlife/go-semi-semantic/version"
 
 	boshdir "github.com/cloudfoundry/bosh-cli/director"
 	boshtpl "github.com/cloudfoundry/bosh-cli/director/template"
+	bitarball "github.com/cloudfoundry/bosh-cli/installation/tarball"
 	boshrel "github.com/cloudfoundry/bosh-cli/release"
+	birelmanifest "github.com/cloudfoundry/bosh-cli/release/manifest"
+	boshui "github.com/cloudfoundry/bosh-cli/ui"
 )
 
+// latestReleaseVersion can be used as a release version to upload
+// whichever version is available at the release URL
+const latestReleaseVersion = "latest"
+
 type ReleaseManager struct {
-	createReleaseCmd ReleaseCreatingCmd
-	uploadReleaseCmd ReleaseUploadingCmd
+	// createReleaseCmd and uploadReleaseCmd are built for given UI
+	// so that output of releases processed in parallel can be buffered
+	createReleaseCmd func(boshui.UI) ReleaseCreatingCmd
+	uploadReleaseCmd func(boshui.UI) ReleaseUploadingCmd
+
+	uploadStemcellCmd StemcellUploadingCmd
+
+	releaseTarballProvider bitarball.Provider
+	releaseLister          ReleaseLister
+	stage                  boshui.Stage
+	ui                     boshui.UI
+
+	fs boshsys.FileSystem
+}
+
+// ReleaseLister finds releases already uploaded to the Director
+type ReleaseLister interface {
+	Releases() ([]boshdir.Release, error)
 }
 
 type ReleaseUploadingCmd interface {
 	Run(UploadReleaseOpts) error
 }
 
+type StemcellUploadingCmd interface {
+	Run(UploadStemcellOpts) error
+}
+
 type ReleaseCreatingCmd interface {
 	Run(CreateReleaseOpts) (boshrel.Release, error)
 }
 
 func NewReleaseManager(
-	createReleaseCmd ReleaseCreatingCmd,
-	uploadReleaseCmd ReleaseUploadingCmd,
+	createReleaseCmd func(boshui.UI) ReleaseCreatingCmd,
+	uploadReleaseCmd func(boshui.UI) ReleaseUploadingCmd,
+	uploadStemcellCmd StemcellUploadingCmd,
+	releaseTarballProvider bitarball.Provider,
+	releaseLister ReleaseLister,
+	stage boshui.Stage,
+	ui boshui.UI,
+	fs boshsys.FileSystem,
 ) ReleaseManager {
-	return ReleaseManager{createReleaseCmd, uploadReleaseCmd}
+	return ReleaseManager{
+		createReleaseCmd: createReleaseCmd,
+		uploadReleaseCmd: uploadReleaseCmd,
+
+		uploadStemcellCmd: uploadStemcellCmd,
+
+		releaseTarballProvider: releaseTarballProvider,
+		releaseLister:          releaseLister,
+		stage:                  stage,
+		ui:                     ui,
+
+		fs: fs,
+	}
 }
 
-func (m ReleaseManager) UploadReleases(bytes []byte) ([]byte, error) {
+// UploadReleases returns release results in manifest order
+func (m ReleaseManager) UploadReleases(bytes []byte, opts UploadReleasesOpts) ([]byte, []ReleaseUploadResult, error) {
 	manifest, err := boshdir.NewManifestFromBytes(bytes)
 	if err != nil {
-		return nil, bosherr.WrapErrorf(err, "Parsing manifest")
+		return nil, nil, bosherr.WrapErrorf(err, "Parsing manifest")
+	}
+
+	err = m.checkReleasesInterpolated(manifest.Releases)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	stemcells := manifestStemcells(manifest)
+
+	err = m.checkStemcellsInterpolated(stemcells)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	releases, err := m.orderReleases(manifest.Releases, opts.Order)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	releases = m.replaceReleaseURLs(releases, opts.URLReplacements)
+
+	var results []ReleaseUploadResult
+
+	if !opts.ForceUpload {
+		var existingResults []ReleaseUploadResult
+
+		releases, existingResults, err = m.skipExistingReleases(releases)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		results = append(results, existingResults...)
+	}
+
+	if opts.VerifySHA1s {
+		err = m.verifyReleaseSHA1s(releases)
+		if err != nil {
+			return nil, releaseUploadResultsInManifestOrder(manifest.Releases, results), err
+		}
 	}
 
 	var opss patch.Ops
 
-	for _, rel := range manifest.Releases {
-		ops, err := m.createAndUploadRelease(rel)
+	if opts.Parallelism > 1 {
+		var parallelResults []ReleaseUploadResult
+
+		opss, parallelResults, err = m.createAndUploadReleasesInParallel(releases, opts.Parallelism, opts.ForceUpload)
+
+		results = append(results, parallelResults...)
+
 		if err != nil {
-			return nil, bosherr.WrapErrorf(err, "Processing release '%s/%s'", rel.Name, rel.Version)
+			return nil, releaseUploadResultsInManifestOrder(manifest.Releases, results), err
 		}
+	} else {
+		for _, rel := range releases {
+			ops, result, err := m.createAndUploadRelease(rel, opts.ForceUpload, m.ui)
+
+			results = append(results, result)
 
-		opss = append(opss, ops)
+			if err != nil {
+				return nil, releaseUploadResultsInManifestOrder(manifest.Releases, results),
+					bosherr.WrapErrorf(err, "Processing release '%s/%s'", rel.Name, rel.Version)
+			}
+
+			opss = append(opss, ops)
+		}
+	}
+
+	results = releaseUploadResultsInManifestOrder(manifest.Releases, results)
+
+	err = m.uploadStemcells(stemcells)
+	if err != nil {
+		return nil, results, err
 	}
 
 	tpl := boshtpl.NewTemplate(bytes)
 
-	bytes, err = tpl.Evaluate(boshtpl.StaticVariables{}, opss, boshtpl.EvaluateOpts{})
+	bytes, err = tpl.Evaluate(boshtpl.StaticVariables{}, opss, boshtpl.EvaluateOpts{PreserveKeyOrder: true})
 	if err != nil {
-		return nil, bosherr.WrapErrorf(err, "Updating manifest with created release versions")
+		return nil, results, bosherr.WrapErrorf(err, "Updating manifest with created release versions")
 	}
 
-	return bytes, nil
+	return bytes, results, nil
 }
 
-func (m ReleaseManager) createAndUploadRelease(rel boshdir.ManifestRelease) (patch.Ops, error) {
-	var ops patch.Ops
+// releaseUploadResultsInManifestOrder does not include releases
+// that were not processed because uploading stopped early
+func releaseUploadResultsInManifestOrder(rels []boshdir.ManifestRelease, results []ReleaseUploadResult) []ReleaseUploadResult {
+	var orderedResults []ReleaseUploadResult
 
-	if len(rel.URL) == 0 {
-		return nil, nil
+	for _, rel := range rels {
+		for _, result := range results {
+			if result.Name == rel.Name {
+				orderedResults = append(orderedResults, result)
+				break
+			}
+		}
 	}
 
-	ver, err := semver.NewVersionFromString(rel.Version)
+	return orderedResults
+}
+
+// checkReleasesInterpolated makes sure that release fields used for uploading
+// do not contain variables left unresolved by manifest interpolation
+func (m ReleaseManager) checkReleasesInterpolated(rels []boshdir.ManifestRelease) error {
+	var errs []error
+
+	for _, rel := range rels {
+		// Releases without URL are not uploaded (e.g. version is resolved by the Director)
+		if len(rel.URL) == 0 {
+			continue
+		}
+
+		fields := []struct{ name, value string }{
+			{"url", rel.URL},
+			{"sha1", rel.SHA1},
+			{"version", rel.Version},
+		}
+
+		for _, field := range fields {
+			refs := boshtpl.VariableReferences(field.value)
+			if len(refs) > 0 {
+				errs = append(errs, bosherr.Errorf(
+					"Expected release '%s' %s to be fully interpolated but found unresolved variables: %s",
+					rel.Name, field.name, strings.Join(refs, ", ")))
+			}
+		}
+	}
+
+	if len(errs) > 0 {
+		return bosherr.WrapError(bosherr.NewMultiError(errs...), "Checking release fields")
+	}
+
+	return nil
+}
+
+func (m ReleaseManager) orderReleases(rels []boshdir.ManifestRelease, order []string) ([]boshdir.ManifestRelease, error) {
+	var orderedRels []boshdir.ManifestRelease
+
+	ordered := map[string]struct{}{}
+
+	for _, name := range order {
+		if _, found := ordered[name]; found {
+			return nil, bosherr.Errorf("Expected release '%s' to be specified in upload order only once", name)
+		}
+
+		found := false
+
+		for _, rel := range rels {
+			if rel.Name == name {
+				orderedRels = append(orderedRels, rel)
+				found = true
+			}
+		}
+
+		if !found {
+			return nil, bosherr.Errorf("Expected release '%s' specified in upload order to be in the manifest", name)
+		}
+
+		ordered[name] = struct{}{}
+	}
+
+	for _, rel := range rels {
+		if _, found := ordered[rel.Name]; !found {
+			orderedRels = append(orderedRels, rel)
+		}
+	}
+
+	return orderedRels, nil
+}
+
+// replaceReleaseURLs only changes where releases are fetched from;
+// manifest sent to the Director keeps original URLs
+func (m ReleaseManager) replaceReleaseURLs(rels []boshdir.ManifestRelease, replacements []URLReplaceArg) []boshdir.ManifestRelease {
+	if len(replacements) == 0 {
+		return rels
+	}
+
+	var replacedRels []boshdir.ManifestRelease
+
+	for _, rel := range rels {
+		for _, replacement := range replacements {
+			if url, replaced := replacement.Replace(rel.URL); replaced {
+				rel.URL = url
+				break
+			}
+		}
+
+		replacedRels = append(replacedRels, rel)
+	}
+
+	return replacedRels
+}
+
+// skipExistingReleases removes remote releases that the Director already has
+// so that they are neither downloaded nor uploaded; versions are compared
+// semantically (e.g. '1.0+capi' matches '1+capi') same as the Director does
+func (m ReleaseManager) skipExistingReleases(rels []boshdir.ManifestRelease) ([]boshdir.ManifestRelease, []ReleaseUploadResult, error) {
+	var checkable bool
+
+	for _, rel := range rels {
+		if _, ok := existenceCheckableVersion(rel); ok {
+			checkable = true
+			break
+		}
+	}
+
+	// Avoid listing releases if none of them could be skipped
+	if !checkable {
+		return rels, nil, nil
+	}
+
+	existingRels, err := m.releaseLister.Releases()
+	if err != nil {
+		return nil, nil, bosherr.WrapErrorf(err, "Finding existing releases")
+	}
+
+	var remainingRels []boshdir.ManifestRelease
+	var results []ReleaseUploadResult
+
+	for _, rel := range rels {
+		if version, ok := existenceCheckableVersion(rel); ok && releaseExists(existingRels, rel.Name, version) {
+			m.ui.PrintLinef("Release '%s/%s' already exists.", rel.Name, version.AsString())
+			results = append(results, ReleaseUploadResult{Name: rel.Name, Version: rel.Version, Status: ReleaseUploadStatusAlreadyExists})
+			continue
+		}
+
+		remainingRels = append(remainingRels, rel)
+	}
+
+	return remainingRels, results, nil
+}
+
+// existenceCheckableVersion returns version of a remote release if it's specified
+// in the manifest instead of being determined while uploading
+func existenceCheckableVersion(rel boshdir.ManifestRelease) (semver.Version, bool) {
+	url := URLArg(rel.URL)
+
+	if !url.IsRemote() && !url.IsGit() {
+		return semver.Version{}, false
+	}
+
+	if len(rel.Version) == 0 || rel.Version == latestReleaseVersion || rel.Version == "create" {
+		return semver.Version{}, false
+	}
+
+	version, err := semver.NewVersionFromString(rel.Version)
 	if err != nil {
-		return nil, err
+		// Invalid version is reported when uploading
+		return semver.Version{}, false
+	}
+
+	return version, true
+}
+
+func releaseExists(existingRels []boshdir.Release, name string, version semver.Version) bool {
+	for _, existingRel := range existingRels {
+		if existingRel.Name() == name && existingRel.Version().IsEq(version) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// verifyReleaseSHA1s downloads remote releases and checks them against manifest SHA1s
+// so that a corrupt release fails deploy before any release is uploaded
+func (m ReleaseManager) verifyReleaseSHA1s(rels []boshdir.ManifestRelease) error {
+	for _, rel := range rels {
+		if len(rel.SHA1) == 0 {
+			continue
+		}
+
+		var err error
+
+		switch {
+		case URLArg(rel.URL).IsRemote():
+			releaseRef := birelmanifest.ReleaseRef{Name: rel.Name, URL: rel.URL, SHA1: rel.SHA1}
+			_, err = m.releaseTarballProvider.Get(releaseRef, m.stage)
+
+		case isReleaseFile(rel):
+			err = m.verifyReleaseFileSHA1(rel)
+
+		default:
+			continue
+		}
+
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Verifying SHA1 of release '%s' from '%s'", rel.Name, rel.URL)
+		}
+	}
+
+	return nil
+}
+
+func (m ReleaseManager) verifyReleaseFileSHA1(rel boshdir.ManifestRelease) error {
+	path, err := m.releaseFilePath(rel)
+	if err != nil {
+		return err
+	}
+
+	digest, err := boshcrypto.ParseMultipleDigest(rel.SHA1)
+	if err != nil {
+		return err
+	}
+
+	return digest.VerifyFilePath(path, m.fs)
+}
+
+// isReleaseFile is true for releases referencing release tarballs
+// via file:// URLs; release directories are only used to create releases
+func isReleaseFile(rel boshdir.ManifestRelease) bool {
+	return strings.HasPrefix(rel.URL, "file://") && rel.Version != "create"
+}
+
+func (m ReleaseManager) releaseFilePath(rel boshdir.ManifestRelease) (string, error) {
+	path, err := m.fs.ExpandPath(URLArg(rel.URL).FilePath())
+	if err != nil {
+		return "", bosherr.WrapErrorf(err, "Expanding release file path '%s'", rel.URL)
+	}
+
+	if !m.fs.FileExists(path) {
+		return "", bosherr.Errorf("Expected release '%s' file '%s' to exist", rel.Name, path)
+	}
+
+	return path, nil
+}
+
+// manifestStemcells returns stemcells declared with URLs either
+// in stemcells section or in resource pools of v1 manifests
+func manifestStemcells(manifest boshdir.Manifest) []boshdir.ManifestStemcell {
+	var stemcells []boshdir.ManifestStemcell
+
+	for _, stemcell := range manifest.Stemcells {
+		if len(stemcell.URL) > 0 {
+			stemcells = append(stemcells, stemcell)
+		}
+	}
+
+	for _, pool := range manifest.ResourcePools {
+		if len(pool.Stemcell.URL) > 0 {
+			stemcells = append(stemcells, pool.Stemcell)
+		}
+	}
+
+	return stemcells
+}
+
+func (m ReleaseManager) checkStemcellsInterpolated(stemcells []boshdir.ManifestStemcell) error {
+	var errs []error
+
+	for _, stemcell := range stemcells {
+		fields := []struct{ name, value string }{
+			{"url", stemcell.URL},
+			{"sha1", stemcell.SHA1},
+			{"version", stemcell.Version},
+		}
+
+		for _, field := range fields {
+			refs := boshtpl.VariableReferences(field.value)
+			if len(refs) > 0 {
+				errs = append(errs, bosherr.Errorf(
+					"Expected stemcell '%s' %s to be fully interpolated but found unresolved variables: %s",
+					stemcellDesc(stemcell), field.name, strings.Join(refs, ", ")))
+			}
+		}
+	}
+
+	if len(errs) > 0 {
+		return bosherr.WrapError(bosherr.NewMultiError(errs...), "Checking stemcell fields")
+	}
+
+	return nil
+}
+
+// uploadStemcells uploads stemcells after releases so that a bad release
+// does not leave behind a possibly large stemcell upload; the Director
+// verifies SHA1s of remote stemcells while local ones are verified here
+func (m ReleaseManager) uploadStemcells(stemcells []boshdir.ManifestStemcell) error {
+	for _, stemcell := range stemcells {
+		err := m.uploadStemcell(stemcell)
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Processing stemcell '%s'", stemcellDesc(stemcell))
+		}
+	}
+
+	return nil
+}
+
+func (m ReleaseManager) uploadStemcell(stemcell boshdir.ManifestStemcell) error {
+	url := URLArg(stemcell.URL)
+
+	opts := UploadStemcellOpts{
+		Args: UploadStemcellArgs{URL: url},
+		Name: stemcell.Name,
+		SHA1: stemcell.SHA1,
+	}
+
+	if url.IsRemote() {
+		// Version is only used for existence check so versions that
+		// cannot be checked (e.g. latest) result in stemcell being uploaded
+		if len(stemcell.Version) > 0 && stemcell.Version != latestReleaseVersion {
+			version, err := semver.NewVersionFromString(stemcell.Version)
+			if err == nil {
+				opts.Version = VersionArg(version)
+			}
+		}
+	} else if len(stemcell.SHA1) > 0 {
+		err := m.verifyStemcellFileSHA1(stemcell)
+		if err != nil {
+			return err
+		}
+	}
+
+	return m.uploadStemcellCmd.Run(opts)
+}
+
+func (m ReleaseManager) verifyStemcellFileSHA1(stemcell boshdir.ManifestStemcell) error {
+	path, err := m.fs.ExpandPath(URLArg(stemcell.URL).FilePath())
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Expanding stemcell file path '%s'", stemcell.URL)
+	}
+
+	digest, err := boshcrypto.ParseMultipleDigest(stemcell.SHA1)
+	if err != nil {
+		return err
+	}
+
+	err = digest.VerifyFilePath(path, m.fs)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Verifying SHA1 of stemcell file '%s'", path)
+	}
+
+	return nil
+}
+
+func stemcellDesc(stemcell boshdir.ManifestStemcell) string {
+	name := stemcell.Name
+	if len(name) == 0 {
+		name = stemcell.OS
+	}
+
+	if len(stemcell.Version) > 0 {
+		return name + "/" + stemcell.Version
+	}
+
+	return name
+}
+
+type parallelReleaseUploadResult struct {
+	index  int
+	ops    patch.Ops
+	result ReleaseUploadResult
+	err    error
+	ui     *boshui.BufferedUI
+}
+
+type releaseUploadResultsByName struct {
+	rels    []boshdir.ManifestRelease
+	results []parallelReleaseUploadResult
+}
+
+func (s releaseUploadResultsByName) Len() int { return len(s.results) }
+func (s releaseUploadResultsByName) Swap(i, j int) {
+	s.results[i], s.results[j] = s.results[j], s.results[i]
+}
+
+func (s releaseUploadResultsByName) Less(i, j int) bool {
+	return s.rels[s.results[i].index].Name < s.rels[s.results[j].index].Name
+}
+
+// createAndUploadReleasesInParallel processes releases with given number of workers;
+// returned ops keep releases order and errors are sorted by release name.
+// Since output of concurrent uploads would interleave, output of each release
+// is buffered and printed with its outcome sorted by release name
+// once all releases are processed.
+func (m ReleaseManager) createAndUploadReleasesInParallel(rels []boshdir.ManifestRelease, numOfParallelWorkers int, force bool) (patch.Ops, []ReleaseUploadResult, error) {
+	resultsCh := make(chan parallelReleaseUploadResult, len(rels))
+	defer close(resultsCh)
+
+	indicesCh := make(chan int, numOfParallelWorkers)
+	defer close(indicesCh)
+
+	for w := 0; w < numOfParallelWorkers; w++ {
+		go m.createAndUploadReleasesWorker(rels, indicesCh, resultsCh, force)
+	}
+
+	for i := range rels {
+		indicesCh <- i
+	}
+
+	results := make([]parallelReleaseUploadResult, len(rels))
+
+	for i := 0; i < len(rels); i++ {
+		result := <-resultsCh
+		results[result.index] = result
+	}
+
+	var opss patch.Ops
+	var uploadResults []ReleaseUploadResult
+
+	for _, result := range results {
+		uploadResults = append(uploadResults, result.result)
+
+		if result.err == nil {
+			opss = append(opss, result.ops)
+		}
+	}
+
+	sortedResults := append([]parallelReleaseUploadResult{}, results...)
+	sort.Sort(releaseUploadResultsByName{rels, sortedResults})
+
+	var failedResults []parallelReleaseUploadResult
+
+	for _, result := range sortedResults {
+		result.ui.Replay()
+
+		m.ui.PrintLinef("Release '%s/%s' %s", result.result.Name, result.result.Version, result.result.Status)
+
+		if result.err != nil {
+			failedResults = append(failedResults, result)
+		}
+	}
+
+	if len(failedResults) > 0 {
+		var errs []error
+
+		for _, result := range failedResults {
+			rel := rels[result.index]
+			errs = append(errs, bosherr.WrapErrorf(result.err, "Processing release '%s/%s'", rel.Name, rel.Version))
+		}
+
+		return nil, uploadResults, bosherr.NewMultiError(errs...)
+	}
+
+	return opss, uploadResults, nil
+}
+
+func (m ReleaseManager) createAndUploadReleasesWorker(rels []boshdir.ManifestRelease, indicesCh <-chan int, resultsCh chan<- parallelReleaseUploadResult, force bool) {
+	for i := range indicesCh {
+		ui := boshui.NewBufferedUI(m.ui)
+		ops, result, err := m.createAndUploadRelease(rels[i], force, ui)
+		resultsCh <- parallelReleaseUploadResult{index: i, ops: ops, result: result, err: err, ui: ui}
+	}
+}
+
+// createAndUploadRelease reports release as failed unless it was uploaded or skipped;
+// output of creating and uploading release is printed via given UI
+func (m ReleaseManager) createAndUploadRelease(rel boshdir.ManifestRelease, force bool, ui boshui.UI) (patch.Ops, ReleaseUploadResult, error) {
+	var ops patch.Ops
+
+	result := ReleaseUploadResult{Name: rel.Name, Version: rel.Version, Status: ReleaseUploadStatusFailed}
+
+	if len(rel.URL) == 0 {
+		// Release without URL is expected to already be uploaded
+		if len(rel.Version) == 0 {
+			return nil, result, bosherr.Errorf("Expected release '%s' to specify version since it does not specify url", rel.Name)
+		}
+
+		result.Status = ReleaseUploadStatusNoURL
+
+		return nil, result, nil
 	}
 
 	uploadOpts := UploadReleaseOpts{
-		Name:    rel.Name,
-		Version: VersionArg(ver),
+		Name: rel.Name,
 
 		Args: UploadReleaseArgs{URL: URLArg(rel.URL)},
 		SHA1: rel.SHA1,
+
+		Force: force,
+	}
+
+	switch {
+	case rel.Version == latestReleaseVersion:
+		uploadOpts.Latest = true
+
+	case len(rel.Version) == 0:
+		// Version will be determined from release.MF of the release tarball
+		if len(rel.SHA1) == 0 {
+			return nil, result, bosherr.Errorf("Expected release '%s' to specify version or sha1", rel.Name)
+		}
+
+	default:
+		ver, err := semver.NewVersionFromString(rel.Version)
+		if err != nil {
+			return nil, result, err
+		}
+
+		uploadOpts.Version = VersionArg(ver)
+	}
+
+	if isReleaseFile(rel) {
+		path, err := m.releaseFilePath(rel)
+		if err != nil {
+			return nil, result, err
+		}
+
+		uploadOpts.Args = UploadReleaseArgs{URL: URLArg(path)}
 	}
 
 	if rel.Version == "create" {
@@ -85,9 +694,9 @@ func (m ReleaseManager) createAndUploadRelease(rel boshdir.ManifestRelease) (pat
 			Force:            true,
 		}
 
-		release, err := m.createReleaseCmd.Run(createOpts)
+		release, err := m.createReleaseCmd(ui).Run(createOpts)
 		if err != nil {
-			return nil, err
+			return nil, result, err
 		}
 
 		uploadOpts = UploadReleaseOpts{Release: release}
@@ -104,7 +713,16 @@ func (m ReleaseManager) createAndUploadRelease(rel boshdir.ManifestRelease) (pat
 		}
 
 		ops = append(ops, replaceOp)
+
+		result.Version = release.Version()
 	}
 
-	return ops, m.uploadReleaseCmd.Run(uploadOpts)
+	err := m.uploadReleaseCmd(ui).Run(uploadOpts)
+	if err != nil {
+		return nil, result, err
+	}
+
+	result.Status = ReleaseUploadStatusUploaded
+
+	return ops, result, nil
 }
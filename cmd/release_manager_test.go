@@ -2,25 +2,49 @@ package cmd_test
 
 import (
 	"errors"
+	"strings"
 
 	semver "github.com/cppforlife/go-semi-semantic/version"
+	"github.com/golang/mock/gomock"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 
 	. "github.com/cloudfoundry/bosh-cli/cmd"
 	fakecmd "github.com/cloudfoundry/bosh-cli/cmd/cmdfakes"
+	boshdir "github.com/cloudfoundry/bosh-cli/director"
+	fakedir "github.com/cloudfoundry/bosh-cli/director/directorfakes"
+	mock_tarball "github.com/cloudfoundry/bosh-cli/installation/tarball/mocks"
 	boshrel "github.com/cloudfoundry/bosh-cli/release"
+	birelmanifest "github.com/cloudfoundry/bosh-cli/release/manifest"
 	fakerel "github.com/cloudfoundry/bosh-cli/release/releasefakes"
+	boshui "github.com/cloudfoundry/bosh-cli/ui"
+	fakeui "github.com/cloudfoundry/bosh-cli/ui/fakes"
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
 )
 
 var _ = Describe("ReleaseManager", func() {
 	var (
-		createReleaseCmd *fakecmd.FakeReleaseCreatingCmd
-		uploadReleaseCmd *fakecmd.FakeReleaseUploadingCmd
-		releaseManager   ReleaseManager
+		createReleaseCmd  *fakecmd.FakeReleaseCreatingCmd
+		uploadReleaseCmd  *fakecmd.FakeReleaseUploadingCmd
+		uploadStemcellCmd *fakecmd.FakeStemcellUploadingCmd
+		releaseManager    ReleaseManager
+
+		mockCtrl               *gomock.Controller
+		releaseTarballProvider *mock_tarball.MockProvider
+		director               *fakedir.FakeDirector
+		stage                  *fakeui.FakeStage
+		ui                     *fakeui.FakeUI
+		fs                     *fakesys.FakeFileSystem
 	)
 
 	BeforeEach(func() {
+		mockCtrl = gomock.NewController(GinkgoT())
+		releaseTarballProvider = mock_tarball.NewMockProvider(mockCtrl)
+		director = &fakedir.FakeDirector{}
+		stage = fakeui.NewFakeStage()
+		ui = &fakeui.FakeUI{}
+		fs = fakesys.NewFakeFileSystem()
+
 		createReleaseCmd = &fakecmd.FakeReleaseCreatingCmd{
 			RunStub: func(opts CreateReleaseOpts) (boshrel.Release, error) {
 				release := &fakerel.FakeRelease{
@@ -32,8 +56,16 @@ var _ = Describe("ReleaseManager", func() {
 		}
 
 		uploadReleaseCmd = &fakecmd.FakeReleaseUploadingCmd{}
+		uploadStemcellCmd = &fakecmd.FakeStemcellUploadingCmd{}
+
+		releaseManager = NewReleaseManager(
+			func(boshui.UI) ReleaseCreatingCmd { return createReleaseCmd },
+			func(boshui.UI) ReleaseUploadingCmd { return uploadReleaseCmd },
+			uploadStemcellCmd, releaseTarballProvider, director, stage, ui, fs)
+	})
 
-		releaseManager = NewReleaseManager(createReleaseCmd, uploadReleaseCmd)
+	AfterEach(func() {
+		mockCtrl.Finish()
 	})
 
 	Describe("UploadReleases", func() {
@@ -55,7 +87,7 @@ releases:
   version: create
 `)
 
-			_, err := releaseManager.UploadReleases(bytes)
+			_, _, err := releaseManager.UploadReleases(bytes, UploadReleasesOpts{})
 			Expect(err).ToNot(HaveOccurred())
 
 			Expect(uploadReleaseCmd.RunCallCount()).To(Equal(3))
@@ -79,6 +111,436 @@ releases:
 			Expect(arg).To(Equal(UploadReleaseOpts{Release: arg.Release})) // only Release should be set
 		})
 
+		It("returns what happened to each release in manifest order", func() {
+			bytes := []byte(`
+releases:
+- name: capi
+  url: https://capi-url
+  version: 1+capi
+- name: rel-without-upload
+  version: 1+rel
+- name: local
+  url: file:///local-dir
+  version: create
+`)
+
+			_, results, err := releaseManager.UploadReleases(bytes, UploadReleasesOpts{Order: []string{"local"}})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(results).To(Equal([]ReleaseUploadResult{
+				{Name: "capi", Version: "1+capi", Status: ReleaseUploadStatusUploaded},
+				{Name: "rel-without-upload", Version: "1+rel", Status: ReleaseUploadStatusNoURL},
+				{Name: "local", Version: "local-created-ver", Status: ReleaseUploadStatusUploaded},
+			}))
+		})
+
+		It("returns results of releases processed before uploading failed", func() {
+			bytes := []byte(`
+releases:
+- name: capi
+  url: https://capi-url
+  version: 1+capi
+- name: consul
+  url: https://consul-url
+  version: 1+consul
+- name: zookeeper
+  url: https://zookeeper-url
+  version: 1+zookeeper
+`)
+
+			uploadReleaseCmd.RunStub = func(opts UploadReleaseOpts) error {
+				if opts.Name == "consul" {
+					return errors.New("fake-err")
+				}
+				return nil
+			}
+
+			_, results, err := releaseManager.UploadReleases(bytes, UploadReleasesOpts{})
+			Expect(err).To(HaveOccurred())
+
+			Expect(results).To(Equal([]ReleaseUploadResult{
+				{Name: "capi", Version: "1+capi", Status: ReleaseUploadStatusUploaded},
+				{Name: "consul", Version: "1+consul", Status: ReleaseUploadStatusFailed},
+			}))
+		})
+
+		Context("when uploading in parallel", func() {
+			var (
+				bytes []byte
+			)
+
+			BeforeEach(func() {
+				bytes = []byte(`
+releases:
+- name: zookeeper
+  sha1: zookeeper-sha1
+  url: https://zookeeper-url
+  version: 1+zookeeper
+- name: capi
+  sha1: capi-sha1
+  url: https://capi-url
+  version: 1+capi
+- name: local
+  url: file:///local-dir
+  version: create
+- name: consul
+  sha1: consul-sha1
+  url: https://consul-url
+  version: 1+consul
+`)
+			})
+
+			It("uploads all remote releases and updates created release versions", func() {
+				newBytes, _, err := releaseManager.UploadReleases(bytes, UploadReleasesOpts{Parallelism: 2})
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(uploadReleaseCmd.RunCallCount()).To(Equal(4))
+
+				var names []string
+
+				for i := 0; i < uploadReleaseCmd.RunCallCount(); i++ {
+					opts := uploadReleaseCmd.RunArgsForCall(i)
+					if opts.Release != nil {
+						names = append(names, opts.Release.Name())
+					} else {
+						names = append(names, opts.Name)
+					}
+				}
+
+				Expect(names).To(ConsistOf("zookeeper", "capi", "local", "consul"))
+
+				Expect(string(newBytes)).To(ContainSubstring("version: local-created-ver"))
+			})
+
+			It("prints outcome of each release sorted by release name after all releases are processed", func() {
+				uploadReleaseCmd.RunStub = func(opts UploadReleaseOpts) error {
+					if opts.Name == "zookeeper" {
+						return errors.New("fake-err")
+					}
+					return nil
+				}
+
+				_, _, err := releaseManager.UploadReleases(bytes, UploadReleasesOpts{Parallelism: 4})
+				Expect(err).To(HaveOccurred())
+
+				Expect(ui.Said).To(Equal([]string{
+					"Release 'capi/1+capi' uploaded",
+					"Release 'consul/1+consul' uploaded",
+					"Release 'local/local-created-ver' uploaded",
+					"Release 'zookeeper/1+zookeeper' failed",
+				}))
+			})
+
+			It("prints output of each release together with its outcome sorted by release name", func() {
+				releaseManager = NewReleaseManager(
+					func(boshui.UI) ReleaseCreatingCmd { return createReleaseCmd },
+					func(cmdUI boshui.UI) ReleaseUploadingCmd {
+						return &fakecmd.FakeReleaseUploadingCmd{
+							RunStub: func(opts UploadReleaseOpts) error {
+								if opts.Release != nil {
+									cmdUI.PrintLinef("Uploading '%s'", opts.Release.Name())
+								} else {
+									cmdUI.PrintLinef("Uploading '%s'", opts.Name)
+								}
+								return nil
+							},
+						}
+					},
+					uploadStemcellCmd, releaseTarballProvider, director, stage, ui, fs)
+
+				_, _, err := releaseManager.UploadReleases(bytes, UploadReleasesOpts{Parallelism: 4})
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(ui.Said).To(Equal([]string{
+					"Uploading 'capi'",
+					"Release 'capi/1+capi' uploaded",
+					"Uploading 'consul'",
+					"Release 'consul/1+consul' uploaded",
+					"Uploading 'local'",
+					"Release 'local/local-created-ver' uploaded",
+					"Uploading 'zookeeper'",
+					"Release 'zookeeper/1+zookeeper' uploaded",
+				}))
+			})
+
+			It("returns all errors sorted by release name", func() {
+				uploadReleaseCmd.RunStub = func(opts UploadReleaseOpts) error {
+					if opts.Name == "zookeeper" || opts.Name == "capi" {
+						return errors.New("fake-err-" + opts.Name)
+					}
+					return nil
+				}
+
+				_, results, err := releaseManager.UploadReleases(bytes, UploadReleasesOpts{Parallelism: 4})
+				Expect(err).To(HaveOccurred())
+
+				Expect(results).To(Equal([]ReleaseUploadResult{
+					{Name: "zookeeper", Version: "1+zookeeper", Status: ReleaseUploadStatusFailed},
+					{Name: "capi", Version: "1+capi", Status: ReleaseUploadStatusFailed},
+					{Name: "local", Version: "local-created-ver", Status: ReleaseUploadStatusUploaded},
+					{Name: "consul", Version: "1+consul", Status: ReleaseUploadStatusUploaded},
+				}))
+
+				capiIdx := strings.Index(err.Error(), "Processing release 'capi/1+capi': fake-err-capi")
+				zookeeperIdx := strings.Index(err.Error(), "Processing release 'zookeeper/1+zookeeper': fake-err-zookeeper")
+
+				Expect(capiIdx).To(BeNumerically(">=", 0))
+				Expect(zookeeperIdx).To(BeNumerically(">", capiIdx))
+			})
+		})
+
+		It("uploads releases in specified order before uploading remaining releases in manifest order", func() {
+			bytes := []byte(`
+releases:
+- name: capi
+  sha1: capi-sha1
+  url: https://capi-url
+  version: 1+capi
+- name: consul
+  sha1: consul-sha1
+  url: https://consul-url
+  version: 1+consul
+- name: diego
+  sha1: diego-sha1
+  url: https://diego-url
+  version: 1+diego
+`)
+
+			_, _, err := releaseManager.UploadReleases(bytes, UploadReleasesOpts{Order: []string{"diego", "consul"}})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(uploadReleaseCmd.RunCallCount()).To(Equal(3))
+			Expect(uploadReleaseCmd.RunArgsForCall(0).Name).To(Equal("diego"))
+			Expect(uploadReleaseCmd.RunArgsForCall(1).Name).To(Equal("consul"))
+			Expect(uploadReleaseCmd.RunArgsForCall(2).Name).To(Equal("capi"))
+		})
+
+		It("uploads releases from URLs rewritten by first matching replacement keeping SHA1 and version", func() {
+			bytes := []byte(`
+releases:
+- name: capi
+  sha1: capi-sha1
+  url: https://public/capi.tgz
+  version: 1+capi
+- name: consul
+  sha1: consul-sha1
+  url: https://other/consul.tgz
+  version: 1+consul
+`)
+
+			replacements := []URLReplaceArg{
+				{Old: "https://public", New: "https://mirror1"},
+				{Old: "https://public/capi", New: "https://mirror2/capi"},
+			}
+
+			_, _, err := releaseManager.UploadReleases(bytes, UploadReleasesOpts{URLReplacements: replacements})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(uploadReleaseCmd.RunCallCount()).To(Equal(2))
+
+			Expect(uploadReleaseCmd.RunArgsForCall(0)).To(Equal(UploadReleaseOpts{
+				Name:    "capi",
+				Args:    UploadReleaseArgs{URL: URLArg("https://mirror1/capi.tgz")},
+				SHA1:    "capi-sha1",
+				Version: VersionArg(semver.MustNewVersionFromString("1+capi")),
+			}))
+
+			Expect(uploadReleaseCmd.RunArgsForCall(1)).To(Equal(UploadReleaseOpts{
+				Name:    "consul",
+				Args:    UploadReleaseArgs{URL: URLArg("https://other/consul.tgz")},
+				SHA1:    "consul-sha1",
+				Version: VersionArg(semver.MustNewVersionFromString("1+consul")),
+			}))
+		})
+
+		Context("when the Director already has some of the releases", func() {
+			bytes := []byte(`
+releases:
+- name: capi
+  sha1: capi-sha1
+  url: https://capi-url
+  version: 1.0+capi
+- name: consul
+  sha1: consul-sha1
+  url: https://consul-url
+  version: 2+consul
+- name: latest-rel
+  url: https://latest-url
+  version: latest
+- name: local
+  url: file:///local-dir
+  version: create
+`)
+
+			BeforeEach(func() {
+				director.ReleasesReturns([]boshdir.Release{
+					&fakedir.FakeRelease{
+						NameStub:    func() string { return "capi" },
+						VersionStub: func() semver.Version { return semver.MustNewVersionFromString("1+capi") },
+					},
+					&fakedir.FakeRelease{
+						NameStub:    func() string { return "consul" },
+						VersionStub: func() semver.Version { return semver.MustNewVersionFromString("1+consul") },
+					},
+					&fakedir.FakeRelease{
+						NameStub:    func() string { return "latest-rel" },
+						VersionStub: func() semver.Version { return semver.MustNewVersionFromString("1") },
+					},
+				}, nil)
+			})
+
+			It("skips remote releases with versions matching existing releases", func() {
+				_, results, err := releaseManager.UploadReleases(bytes, UploadReleasesOpts{})
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(results[0]).To(Equal(ReleaseUploadResult{
+					Name: "capi", Version: "1.0+capi", Status: ReleaseUploadStatusAlreadyExists}))
+
+				Expect(director.ReleasesCallCount()).To(Equal(1))
+
+				Expect(uploadReleaseCmd.RunCallCount()).To(Equal(3))
+				Expect(uploadReleaseCmd.RunArgsForCall(0).Name).To(Equal("consul"))
+				Expect(uploadReleaseCmd.RunArgsForCall(1).Name).To(Equal("latest-rel"))
+				Expect(uploadReleaseCmd.RunArgsForCall(2).Release.Name()).To(Equal("local"))
+
+				Expect(ui.Said).To(Equal([]string{"Release 'capi/1.0+capi' already exists."}))
+			})
+
+			It("uploads all releases without looking up existing releases when forced", func() {
+				_, _, err := releaseManager.UploadReleases(bytes, UploadReleasesOpts{ForceUpload: true})
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(director.ReleasesCallCount()).To(Equal(0))
+
+				Expect(uploadReleaseCmd.RunCallCount()).To(Equal(4))
+				Expect(uploadReleaseCmd.RunArgsForCall(0).Name).To(Equal("capi"))
+				Expect(uploadReleaseCmd.RunArgsForCall(0).Force).To(BeTrue())
+			})
+
+			It("returns an error and does not upload releases if existing releases cannot be found", func() {
+				director.ReleasesReturns(nil, errors.New("fake-err"))
+
+				_, _, err := releaseManager.UploadReleases(bytes, UploadReleasesOpts{})
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("Finding existing releases: fake-err"))
+
+				Expect(uploadReleaseCmd.RunCallCount()).To(Equal(0))
+			})
+
+			It("does not look up existing releases if no release specifies remote url and version", func() {
+				bytes := []byte(`
+releases:
+- name: rel-without-upload
+  version: 1+rel
+- name: local
+  url: file:///local-dir
+  version: create
+`)
+
+				_, _, err := releaseManager.UploadReleases(bytes, UploadReleasesOpts{})
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(director.ReleasesCallCount()).To(Equal(0))
+			})
+		})
+
+		Context("when verifying release SHA1s", func() {
+			bytes := []byte(`
+releases:
+- name: capi
+  sha1: capi-sha1
+  url: https://public/capi.tgz
+  version: 1+capi
+- name: consul
+  sha1: consul-sha1
+  url: https://public/consul.tgz
+  version: 1+consul
+- name: rel-without-sha1
+  url: https://public/rel.tgz
+  version: 1+rel
+- name: local
+  url: file:///local-dir
+  version: create
+`)
+
+			It("downloads and verifies remote releases with SHA1s before uploading them", func() {
+				capiRef := birelmanifest.ReleaseRef{Name: "capi", URL: "https://mirror/capi.tgz", SHA1: "capi-sha1"}
+				consulRef := birelmanifest.ReleaseRef{Name: "consul", URL: "https://mirror/consul.tgz", SHA1: "consul-sha1"}
+
+				gomock.InOrder(
+					releaseTarballProvider.EXPECT().Get(capiRef, stage).Return("/capi-path", nil),
+					releaseTarballProvider.EXPECT().Get(consulRef, stage).Return("/consul-path", nil),
+				)
+
+				opts := UploadReleasesOpts{
+					URLReplacements: []URLReplaceArg{{Old: "https://public", New: "https://mirror"}},
+					VerifySHA1s:     true,
+				}
+
+				_, _, err := releaseManager.UploadReleases(bytes, opts)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(uploadReleaseCmd.RunCallCount()).To(Equal(4))
+				Expect(uploadReleaseCmd.RunArgsForCall(0).Args.URL).To(Equal(URLArg("https://mirror/capi.tgz")))
+			})
+
+			It("returns an error identifying mismatched release and does not upload any release", func() {
+				releaseTarballProvider.EXPECT().Get(gomock.Any(), stage).Return("/capi-path", nil)
+				releaseTarballProvider.EXPECT().Get(gomock.Any(), stage).Return("", errors.New("fake-digest-err"))
+
+				_, _, err := releaseManager.UploadReleases(bytes, UploadReleasesOpts{VerifySHA1s: true})
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(Equal(
+					"Verifying SHA1 of release 'consul' from 'https://public/consul.tgz': fake-digest-err"))
+
+				Expect(uploadReleaseCmd.RunCallCount()).To(Equal(0))
+				Expect(createReleaseCmd.RunCallCount()).To(Equal(0))
+			})
+
+			It("does not download releases when verification is not requested", func() {
+				_, _, err := releaseManager.UploadReleases(bytes, UploadReleasesOpts{})
+				Expect(err).ToNot(HaveOccurred())
+			})
+		})
+
+		It("returns an error and does not upload if release in upload order is not in the manifest", func() {
+			bytes := []byte(`
+releases:
+- name: capi
+  sha1: capi-sha1
+  url: https://capi-url
+  version: 1+capi
+`)
+
+			_, _, err := releaseManager.UploadReleases(bytes, UploadReleasesOpts{Order: []string{"consul"}})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("Expected release 'consul' specified in upload order to be in the manifest"))
+
+			Expect(uploadReleaseCmd.RunCallCount()).To(Equal(0))
+		})
+
+		It("returns an error and does not upload if release is specified in upload order more than once", func() {
+			bytes := []byte(`
+releases:
+- name: capi
+  sha1: capi-sha1
+  url: https://capi-url
+  version: 1+capi
+- name: consul
+  sha1: consul-sha1
+  url: https://consul-url
+  version: 1+consul
+`)
+
+			opts := UploadReleasesOpts{Order: []string{"consul", "capi", "consul"}}
+
+			_, _, err := releaseManager.UploadReleases(bytes, opts)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("Expected release 'consul' to be specified in upload order only once"))
+
+			Expect(uploadReleaseCmd.RunCallCount()).To(Equal(0))
+		})
+
 		It("skips uploading releases if url is not provided, even if the version is invalid", func() {
 			bytes := []byte(`
 releases:
@@ -86,11 +548,34 @@ releases:
   version: ((/blah_interpolate_me_with_config_server))
 `)
 
-			_, err := releaseManager.UploadReleases(bytes)
+			_, _, err := releaseManager.UploadReleases(bytes, UploadReleasesOpts{})
 			Expect(err).ToNot(HaveOccurred())
 			Expect(uploadReleaseCmd.RunCallCount()).To(Equal(0))
 		})
 
+		It("keeps order of manifest keys when updating created release versions", func() {
+			bytes := []byte(`name: dep
+releases:
+- version: create
+  name: local
+  url: file:///local-dir
+stemcells: []
+instance_groups: []
+`)
+
+			bytes, _, err := releaseManager.UploadReleases(bytes, UploadReleasesOpts{})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(string(bytes)).To(Equal(`name: dep
+releases:
+- version: local-created-ver
+  name: local
+  url: file:///local-dir
+stemcells: []
+instance_groups: []
+`))
+		})
+
 		It("creates releases if version is 'create' skipping others", func() {
 			bytes := []byte(`
 releases:
@@ -104,7 +589,7 @@ releases:
   version: create
 `)
 
-			bytes, err := releaseManager.UploadReleases(bytes)
+			bytes, _, err := releaseManager.UploadReleases(bytes, UploadReleasesOpts{})
 			Expect(err).ToNot(HaveOccurred())
 
 			Expect(createReleaseCmd.RunCallCount()).To(Equal(2))
@@ -135,6 +620,69 @@ releases:
 `)))
 		})
 
+		Context("when release references release tarball via file:// url", func() {
+			bytes := []byte(`
+releases:
+- name: capi
+  sha1: ec4212c7cdccbc6b42c4a04fb05773217df98273
+  url: file:///releases/capi.tgz
+  version: 1+capi
+`)
+
+			It("uploads release from the local file", func() {
+				fs.WriteFileString("/releases/capi.tgz", "fake-release-content")
+
+				_, _, err := releaseManager.UploadReleases(bytes, UploadReleasesOpts{})
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(uploadReleaseCmd.RunCallCount()).To(Equal(1))
+				Expect(uploadReleaseCmd.RunArgsForCall(0)).To(Equal(UploadReleaseOpts{
+					Name:    "capi",
+					Args:    UploadReleaseArgs{URL: URLArg("/releases/capi.tgz")},
+					SHA1:    "ec4212c7cdccbc6b42c4a04fb05773217df98273",
+					Version: VersionArg(semver.MustNewVersionFromString("1+capi")),
+				}))
+			})
+
+			It("uploads release from expanded path", func() {
+				fs.ExpandPathExpanded = "/home/user/releases/capi.tgz"
+				fs.WriteFileString("/home/user/releases/capi.tgz", "fake-release-content")
+
+				_, _, err := releaseManager.UploadReleases(bytes, UploadReleasesOpts{})
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(fs.ExpandPathPath).To(Equal("/releases/capi.tgz"))
+				Expect(uploadReleaseCmd.RunArgsForCall(0).Args.URL).To(Equal(URLArg("/home/user/releases/capi.tgz")))
+			})
+
+			It("returns error and does not upload if local file does not exist", func() {
+				_, _, err := releaseManager.UploadReleases(bytes, UploadReleasesOpts{})
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("Expected release 'capi' file '/releases/capi.tgz' to exist"))
+
+				Expect(uploadReleaseCmd.RunCallCount()).To(Equal(0))
+			})
+
+			It("verifies SHA1 of the local file without downloading it if requested", func() {
+				fs.WriteFileString("/releases/capi.tgz", "fake-release-content")
+
+				_, _, err := releaseManager.UploadReleases(bytes, UploadReleasesOpts{VerifySHA1s: true})
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(uploadReleaseCmd.RunCallCount()).To(Equal(1))
+			})
+
+			It("returns error and does not upload if SHA1 of the local file does not match", func() {
+				fs.WriteFileString("/releases/capi.tgz", "fake-corrupt-content")
+
+				_, _, err := releaseManager.UploadReleases(bytes, UploadReleasesOpts{VerifySHA1s: true})
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("Verifying SHA1 of release 'capi' from 'file:///releases/capi.tgz'"))
+
+				Expect(uploadReleaseCmd.RunCallCount()).To(Equal(0))
+			})
+		})
+
 		It("returns error and does not upload if creating release fails", func() {
 			bytes := []byte(`
 releases:
@@ -144,7 +692,7 @@ releases:
 `)
 			createReleaseCmd.RunReturns(nil, errors.New("fake-err"))
 
-			_, err := releaseManager.UploadReleases(bytes)
+			_, _, err := releaseManager.UploadReleases(bytes, UploadReleasesOpts{})
 			Expect(err).To(HaveOccurred())
 			Expect(err.Error()).To(ContainSubstring("fake-err"))
 
@@ -161,11 +709,80 @@ releases:
 `)
 			uploadReleaseCmd.RunReturns(errors.New("fake-err"))
 
-			_, err := releaseManager.UploadReleases(bytes)
+			_, _, err := releaseManager.UploadReleases(bytes, UploadReleasesOpts{})
 			Expect(err).To(HaveOccurred())
 			Expect(err.Error()).To(ContainSubstring("fake-err"))
 		})
 
+		It("uploads remote release with latest version without parsing version", func() {
+			bytes := []byte(`
+releases:
+- name: capi
+  sha1: capi-sha1
+  url: https://capi-url
+  version: latest
+`)
+
+			_, _, err := releaseManager.UploadReleases(bytes, UploadReleasesOpts{})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(uploadReleaseCmd.RunCallCount()).To(Equal(1))
+
+			Expect(uploadReleaseCmd.RunArgsForCall(0)).To(Equal(UploadReleaseOpts{
+				Name:   "capi",
+				Args:   UploadReleaseArgs{URL: URLArg("https://capi-url")},
+				SHA1:   "capi-sha1",
+				Latest: true,
+			}))
+		})
+
+		It("uploads remote release identified by sha1 without version", func() {
+			bytes := []byte(`
+releases:
+- name: capi
+  sha1: capi-sha1
+  url: https://capi-url
+`)
+
+			_, _, err := releaseManager.UploadReleases(bytes, UploadReleasesOpts{})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(uploadReleaseCmd.RunCallCount()).To(Equal(1))
+
+			Expect(uploadReleaseCmd.RunArgsForCall(0)).To(Equal(UploadReleaseOpts{
+				Name: "capi",
+				Args: UploadReleaseArgs{URL: URLArg("https://capi-url")},
+				SHA1: "capi-sha1",
+			}))
+		})
+
+		It("returns an error and does not upload if remote release specifies neither version nor sha1", func() {
+			bytes := []byte(`
+releases:
+- name: capi
+  url: https://capi-url
+`)
+
+			_, _, err := releaseManager.UploadReleases(bytes, UploadReleasesOpts{})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Expected release 'capi' to specify version or sha1"))
+
+			Expect(uploadReleaseCmd.RunCallCount()).To(Equal(0))
+		})
+
+		It("returns an error if release without url does not specify version", func() {
+			bytes := []byte(`
+releases:
+- name: capi
+`)
+
+			_, _, err := releaseManager.UploadReleases(bytes, UploadReleasesOpts{})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Expected release 'capi' to specify version since it does not specify url"))
+
+			Expect(uploadReleaseCmd.RunCallCount()).To(Equal(0))
+		})
+
 		It("returns an error and does not upload if release version cannot be parsed", func() {
 			bytes := []byte(`
 releases:
@@ -175,17 +792,183 @@ releases:
   version: 1+capi+capi
 `)
 
-			_, err := releaseManager.UploadReleases(bytes)
+			_, _, err := releaseManager.UploadReleases(bytes, UploadReleasesOpts{})
 			Expect(err).To(HaveOccurred())
 			Expect(err.Error()).To(ContainSubstring("Expected version '1+capi+capi' to match version format"))
 
 			Expect(uploadReleaseCmd.RunCallCount()).To(Equal(0))
 		})
 
+		It("returns an error and does not upload if release fields contain unresolved variables", func() {
+			bytes := []byte(`
+releases:
+- name: capi
+  sha1: capi-sha1
+  url: https://((host))/capi
+  version: ((capi_version))
+- name: consul
+  sha1: ((consul_sha1))
+  url: https://consul-url
+  version: 1+consul
+`)
+
+			_, _, err := releaseManager.UploadReleases(bytes, UploadReleasesOpts{})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Checking release fields"))
+			Expect(err.Error()).To(ContainSubstring(
+				"Expected release 'capi' url to be fully interpolated but found unresolved variables: ((host))"))
+			Expect(err.Error()).To(ContainSubstring(
+				"Expected release 'capi' version to be fully interpolated but found unresolved variables: ((capi_version))"))
+			Expect(err.Error()).To(ContainSubstring(
+				"Expected release 'consul' sha1 to be fully interpolated but found unresolved variables: ((consul_sha1))"))
+
+			Expect(uploadReleaseCmd.RunCallCount()).To(Equal(0))
+		})
+
+		Context("when manifest references stemcells via urls", func() {
+			It("uploads stemcells skipping stemcells without url", func() {
+				bytes := []byte(`
+releases:
+- name: capi
+  sha1: capi-sha1
+  url: https://capi-url
+  version: 1+capi
+stemcells:
+- alias: default
+  name: bosh-warden-boshlite-ubuntu-trusty-go_agent
+  version: "3421.11"
+  url: https://stemcell-url
+  sha1: stemcell-sha1
+- alias: other
+  os: ubuntu-xenial
+  version: latest
+`)
+
+				_, _, err := releaseManager.UploadReleases(bytes, UploadReleasesOpts{})
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(uploadReleaseCmd.RunCallCount()).To(Equal(1))
+				Expect(uploadStemcellCmd.RunCallCount()).To(Equal(1))
+
+				Expect(uploadStemcellCmd.RunArgsForCall(0)).To(Equal(UploadStemcellOpts{
+					Args:    UploadStemcellArgs{URL: URLArg("https://stemcell-url")},
+					Name:    "bosh-warden-boshlite-ubuntu-trusty-go_agent",
+					Version: VersionArg(semver.MustNewVersionFromString("3421.11")),
+					SHA1:    "stemcell-sha1",
+				}))
+			})
+
+			It("uploads stemcells specified in resource pools", func() {
+				bytes := []byte(`
+resource_pools:
+- name: default
+  stemcell:
+    name: bosh-warden-boshlite-ubuntu-trusty-go_agent
+    version: latest
+    url: https://stemcell-url
+    sha1: stemcell-sha1
+- name: without-url
+  stemcell:
+    name: bosh-warden-boshlite-ubuntu-trusty-go_agent
+    version: "3421.11"
+`)
+
+				_, _, err := releaseManager.UploadReleases(bytes, UploadReleasesOpts{})
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(uploadStemcellCmd.RunCallCount()).To(Equal(1))
+
+				Expect(uploadStemcellCmd.RunArgsForCall(0)).To(Equal(UploadStemcellOpts{
+					Args: UploadStemcellArgs{URL: URLArg("https://stemcell-url")},
+					Name: "bosh-warden-boshlite-ubuntu-trusty-go_agent",
+					SHA1: "stemcell-sha1",
+				}))
+			})
+
+			It("verifies SHA1 of the local stemcell file before uploading it", func() {
+				bytes := []byte(`
+stemcells:
+- alias: default
+  os: ubuntu-trusty
+  version: "3421.11"
+  url: file:///stemcells/stemcell.tgz
+  sha1: d11c4c7781b5ad206e992d7c3c908557e08c12fd
+`)
+
+				fs.WriteFileString("/stemcells/stemcell.tgz", "fake-stemcell-content")
+
+				_, _, err := releaseManager.UploadReleases(bytes, UploadReleasesOpts{})
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(uploadStemcellCmd.RunCallCount()).To(Equal(1))
+				Expect(uploadStemcellCmd.RunArgsForCall(0).Args.URL).To(Equal(URLArg("file:///stemcells/stemcell.tgz")))
+			})
+
+			It("returns error and does not upload if SHA1 of the local stemcell file does not match", func() {
+				bytes := []byte(`
+stemcells:
+- alias: default
+  os: ubuntu-trusty
+  version: "3421.11"
+  url: file:///stemcells/stemcell.tgz
+  sha1: d11c4c7781b5ad206e992d7c3c908557e08c12fd
+`)
+
+				fs.WriteFileString("/stemcells/stemcell.tgz", "fake-corrupt-content")
+
+				_, _, err := releaseManager.UploadReleases(bytes, UploadReleasesOpts{})
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("Processing stemcell 'ubuntu-trusty/3421.11'"))
+				Expect(err.Error()).To(ContainSubstring("Verifying SHA1 of stemcell file '/stemcells/stemcell.tgz'"))
+
+				Expect(uploadStemcellCmd.RunCallCount()).To(Equal(0))
+			})
+
+			It("returns error if uploading stemcell fails", func() {
+				bytes := []byte(`
+stemcells:
+- alias: default
+  os: ubuntu-trusty
+  url: https://stemcell-url
+`)
+
+				uploadStemcellCmd.RunReturns(errors.New("fake-err"))
+
+				_, _, err := releaseManager.UploadReleases(bytes, UploadReleasesOpts{})
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("Processing stemcell 'ubuntu-trusty'"))
+				Expect(err.Error()).To(ContainSubstring("fake-err"))
+			})
+
+			It("returns an error and does not upload anything if stemcell fields contain unresolved variables", func() {
+				bytes := []byte(`
+releases:
+- name: capi
+  sha1: capi-sha1
+  url: https://capi-url
+  version: 1+capi
+stemcells:
+- alias: default
+  os: ubuntu-trusty
+  version: ((stemcell_version))
+  url: https://stemcell-url
+`)
+
+				_, _, err := releaseManager.UploadReleases(bytes, UploadReleasesOpts{})
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("Checking stemcell fields"))
+				Expect(err.Error()).To(ContainSubstring(
+					"Expected stemcell 'ubuntu-trusty/((stemcell_version))' version to be fully interpolated but found unresolved variables: ((stemcell_version))"))
+
+				Expect(uploadReleaseCmd.RunCallCount()).To(Equal(0))
+				Expect(uploadStemcellCmd.RunCallCount()).To(Equal(0))
+			})
+		})
+
 		It("returns an error if bytes cannot be parsed to find releases", func() {
 			bytes := []byte(`-`)
 
-			_, err := releaseManager.UploadReleases(bytes)
+			_, _, err := releaseManager.UploadReleases(bytes, UploadReleasesOpts{})
 			Expect(err).To(HaveOccurred())
 			Expect(err.Error()).To(ContainSubstring("Parsing manifest"))
 
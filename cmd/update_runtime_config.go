@@ -26,7 +26,7 @@ func (c UpdateRuntimeConfigCmd) Run(opts UpdateRuntimeConfigOpts) error {
 		return bosherr.WrapErrorf(err, "Evaluating runtime config")
 	}
 
-	bytes, err = c.releaseUploader.UploadReleases(bytes)
+	bytes, _, err = c.releaseUploader.UploadReleases(bytes, UploadReleasesOpts{})
 	if err != nil {
 		return err
 	}
@@ -26,7 +26,9 @@ var _ = Describe("UpdateRuntimeConfigCmd", func() {
 		ui = &fakeui.FakeUI{}
 		director = &fakedir.FakeDirector{}
 		releaseUploader = &fakecmd.FakeReleaseUploader{
-			UploadReleasesStub: func(bytes []byte) ([]byte, error) { return bytes, nil },
+			UploadReleasesStub: func(bytes []byte, _ UploadReleasesOpts) ([]byte, []ReleaseUploadResult, error) {
+				return bytes, nil, nil
+			},
 		}
 		command = NewUpdateRuntimeConfigCmd(ui, director, releaseUploader)
 	})
@@ -96,12 +98,12 @@ var _ = Describe("UpdateRuntimeConfigCmd", func() {
 				{Name: "key", Value: "key-val"},
 			}
 
-			releaseUploader.UploadReleasesReturns([]byte("after-upload-config"), nil)
+			releaseUploader.UploadReleasesReturns([]byte("after-upload-config"), nil, nil)
 
 			err := act()
 			Expect(err).ToNot(HaveOccurred())
 
-			bytes := releaseUploader.UploadReleasesArgsForCall(0)
+			bytes, _ := releaseUploader.UploadReleasesArgsForCall(0)
 			Expect(bytes).To(Equal([]byte("before-upload-config: key-val\n")))
 
 			Expect(director.UpdateRuntimeConfigCallCount()).To(Equal(1))
@@ -121,7 +123,7 @@ releases:
 `),
 			}
 
-			releaseUploader.UploadReleasesReturns(nil, errors.New("fake-err"))
+			releaseUploader.UploadReleasesReturns(nil, nil, errors.New("fake-err"))
 
 			err := act()
 			Expect(err).To(HaveOccurred())
@@ -145,7 +145,12 @@ func (c UploadReleaseCmd) uploadIfNecessary(opts UploadReleaseOpts, uploadFunc f
 }
 
 func (c UploadReleaseCmd) needToUpload(opts UploadReleaseOpts) (bool, error) {
-	if opts.Fix {
+	if opts.Fix || opts.Latest || opts.Force {
+		return true, nil
+	}
+
+	// Release identified only by SHA1 cannot be looked up by version
+	if semver.Version(opts.Version).Empty() && len(opts.SHA1) > 0 {
 		return true, nil
 	}
 
@@ -119,6 +119,43 @@ var _ = Describe("UploadReleaseCmd", func() {
 				Expect(fix).To(BeTrue())
 			})
 
+			It("uploads given release with latest version without checking if release exists", func() {
+				opts.Name = "existing-name"
+				opts.Latest = true
+
+				err := act()
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(director.HasReleaseCallCount()).To(Equal(0))
+				Expect(director.UploadReleaseURLCallCount()).To(Equal(1))
+			})
+
+			It("uploads given release with force without checking if release exists", func() {
+				opts.Name = "existing-name"
+				opts.Version = VersionArg(semver.MustNewVersionFromString("existing-ver"))
+				opts.Force = true
+
+				err := act()
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(director.HasReleaseCallCount()).To(Equal(0))
+				Expect(director.UploadReleaseURLCallCount()).To(Equal(1))
+			})
+
+			It("uploads given release identified only by sha1 without checking if release exists", func() {
+				opts.Name = "existing-name"
+				opts.SHA1 = "sha1"
+
+				err := act()
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(director.HasReleaseCallCount()).To(Equal(0))
+				Expect(director.UploadReleaseURLCallCount()).To(Equal(1))
+
+				_, sha1, _, _ := director.UploadReleaseURLArgsForCall(0)
+				Expect(sha1).To(Equal("sha1"))
+			})
+
 			It("uploads given release with a specified rebase, sha1, etc.", func() {
 				opts.Rebase = true
 				opts.SHA1 = "sha1"
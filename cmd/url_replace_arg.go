@@ -0,0 +1,37 @@
+package cmd
+
+import (
+	"strings"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+)
+
+// URLReplaceArg replaces URL prefix Old with New (e.g. 'https://public=https://mirror')
+type URLReplaceArg struct {
+	Old string
+	New string
+}
+
+func (a *URLReplaceArg) UnmarshalFlag(data string) error {
+	pieces := strings.SplitN(data, "=", 2)
+	if len(pieces) != 2 {
+		return bosherr.Errorf("Expected URL replacement '%s' to be in format 'old=new'", data)
+	}
+
+	if len(pieces[0]) == 0 {
+		return bosherr.Errorf("Expected URL replacement '%s' to specify non-empty old prefix", data)
+	}
+
+	*a = URLReplaceArg{Old: pieces[0], New: pieces[1]}
+
+	return nil
+}
+
+// Replace returns URL with replaced prefix and whether prefix matched
+func (a URLReplaceArg) Replace(url string) (string, bool) {
+	if !strings.HasPrefix(url, a.Old) {
+		return url, false
+	}
+
+	return a.New + strings.TrimPrefix(url, a.Old), true
+}
@@ -0,0 +1,58 @@
+package cmd_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-cli/cmd"
+)
+
+var _ = Describe("URLReplaceArg", func() {
+	Describe("UnmarshalFlag", func() {
+		var (
+			arg URLReplaceArg
+		)
+
+		BeforeEach(func() {
+			arg = URLReplaceArg{}
+		})
+
+		It("sets old and new prefixes", func() {
+			err := (&arg).UnmarshalFlag("https://public=https://mirror")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(arg).To(Equal(URLReplaceArg{Old: "https://public", New: "https://mirror"}))
+		})
+
+		It("allows new prefix with equal signs", func() {
+			err := (&arg).UnmarshalFlag("https://public=https://mirror?a=b")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(arg).To(Equal(URLReplaceArg{Old: "https://public", New: "https://mirror?a=b"}))
+		})
+
+		It("returns error if value is not in old=new format", func() {
+			err := (&arg).UnmarshalFlag("https://public")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("Expected URL replacement 'https://public' to be in format 'old=new'"))
+		})
+
+		It("returns error if old prefix is empty", func() {
+			err := (&arg).UnmarshalFlag("=https://mirror")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("Expected URL replacement '=https://mirror' to specify non-empty old prefix"))
+		})
+	})
+
+	Describe("Replace", func() {
+		It("replaces matching prefix", func() {
+			url, replaced := URLReplaceArg{Old: "https://public", New: "https://mirror"}.Replace("https://public/foo.tgz")
+			Expect(url).To(Equal("https://mirror/foo.tgz"))
+			Expect(replaced).To(BeTrue())
+		})
+
+		It("returns url unchanged if prefix does not match", func() {
+			url, replaced := URLReplaceArg{Old: "https://public", New: "https://mirror"}.Replace("https://other/foo.tgz")
+			Expect(url).To(Equal("https://other/foo.tgz"))
+			Expect(replaced).To(BeFalse())
+		})
+	})
+})
@@ -1,18 +1,40 @@
 package cmd
 
 import (
-	cfgtypes "config_server/types"
+	"strings"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 
 	boshtpl "github.com/cloudfoundry/bosh-cli/director/template"
 )
 
 // Shared
 type VarFlags struct {
-	VarKVs      []boshtpl.VarKV       `long:"var"        short:"v" value-name:"VAR=VALUE" description:"Set variable"`
-	VarFiles    []boshtpl.VarFileArg  `long:"var-file"             value-name:"VAR=PATH"  description:"Set variable to file contents"`
-	VarsFiles   []boshtpl.VarsFileArg `long:"vars-file"  short:"l" value-name:"PATH"      description:"Load variables from a YAML file"`
-	VarsEnvs    []boshtpl.VarsEnvArg  `long:"vars-env"             value-name:"PREFIX"    description:"Load variables from environment variables (e.g.: 'MY' to load MY_var=value)"`
-	VarsFSStore VarsFSStore           `long:"vars-store"           value-name:"PATH"      description:"Load/save variables from/to a YAML file"`
+	VarKVs       []boshtpl.VarKV          `long:"var"        short:"v" value-name:"VAR=VALUE" description:"Set variable"`
+	VarFiles     []boshtpl.VarFileArg     `long:"var-file"             value-name:"VAR=PATH"  description:"Set variable to file contents"`
+	VarFileYAMLs []boshtpl.VarFileYAMLArg `long:"var-file-yaml"        value-name:"VAR=PATH"  description:"Set variable to value parsed from a YAML file (e.g. a map or a list)"`
+	VarsFiles    []boshtpl.VarsFileArg    `long:"vars-file"  short:"l" value-name:"PATH"      description:"Load variables from a YAML file"`
+	VarsEnvs     []boshtpl.VarsEnvArg     `long:"vars-env"             value-name:"PREFIX"    description:"Load variables from environment variables (e.g.: 'MY' to load MY_var=value)"`
+	VarsFSStore  VarsFSStore              `long:"vars-store"           value-name:"PATH"      description:"Load/save variables from/to a YAML file"`
+	VarsMerge    string                   `long:"vars-merge"           value-name:"override"  description:"Allow later documents in a vars file to override variables" choice:"override"`
+}
+
+// ValidateVarsMerge fails if vars files redefine variables across documents
+// unless overriding was explicitly allowed
+func (f VarFlags) ValidateVarsMerge() error {
+	if f.VarsMerge == "override" {
+		return nil
+	}
+
+	for _, varsFile := range f.VarsFiles {
+		if len(varsFile.ConflictingKeys) > 0 {
+			return bosherr.Errorf(
+				"Expected variables file '%s' to not redefine variables across documents: %s (use --vars-merge=override to allow)",
+				varsFile.Path, strings.Join(varsFile.ConflictingKeys, ", "))
+		}
+	}
+
+	return nil
 }
 
 func (f VarFlags) AsVariables() boshtpl.Variables {
@@ -24,6 +46,10 @@ func (f VarFlags) AsVariables() boshtpl.Variables {
 		firstToUse = append(firstToUse, f.VarFiles[len(f.VarFiles)-i-1].Vars)
 	}
 
+	for i := range f.VarFileYAMLs {
+		firstToUse = append(firstToUse, f.VarFileYAMLs[len(f.VarFileYAMLs)-i-1].Vars)
+	}
+
 	for i, _ := range f.VarsFiles {
 		firstToUse = append(firstToUse, f.VarsFiles[len(f.VarsFiles)-i-1].Vars)
 	}
@@ -41,7 +67,7 @@ func (f VarFlags) AsVariables() boshtpl.Variables {
 	vars := boshtpl.NewMultiVars(firstToUse)
 
 	if f.VarsFSStore.IsSet() {
-		store.ValueGeneratorFactory = cfgtypes.NewValueGeneratorConcrete(NewVarsCertLoader(vars))
+		store.ValueGeneratorFactory = NewVarsValueGeneratorFactory(NewVarsCertLoader(vars))
 	}
 
 	return vars
@@ -91,6 +91,46 @@ var _ = Describe("VarFlags", func() {
 			}
 		})
 
+		It("prefers var files to YAML var files, and YAML var files to vars files", func() {
+			flags := VarFlags{
+				VarFiles: []VarFileArg{
+					{Vars: StaticVariables{"var_file_precedence": "var_file"}},
+				},
+				VarFileYAMLs: []VarFileYAMLArg{
+					{Vars: StaticVariables{
+						"var_file_precedence":      "var_file_yaml",
+						"var_file_yaml_precedence": "var_file_yaml",
+						"var_file_yaml":            map[interface{}]interface{}{"key": "val"},
+					}},
+					{Vars: StaticVariables{
+						"var_file_yaml_precedence": "var_file_yaml2",
+					}},
+				},
+				VarsFiles: []VarsFileArg{
+					{Vars: StaticVariables{
+						"var_file_yaml_precedence": "file",
+						"file":                     "file",
+					}},
+				},
+			}
+
+			vars := flags.AsVariables()
+
+			expectedVals := map[string]interface{}{
+				"var_file_precedence":      "var_file",
+				"var_file_yaml_precedence": "var_file_yaml2",
+				"var_file_yaml":            map[interface{}]interface{}{"key": "val"},
+				"file":                     "file",
+			}
+
+			for key, expectedVal := range expectedVals {
+				val, found, err := vars.Get(VariableDefinition{Name: key})
+				Expect(val).To(Equal(expectedVal), fmt.Sprintf("Expecting key '%s' value to match", key))
+				Expect(found).To(BeTrue())
+				Expect(err).ToNot(HaveOccurred())
+			}
+		})
+
 		It("adds vars store as last resort if configured", func() {
 			varsStore := &VarsFSStore{FS: fakesys.NewFakeFileSystem()}
 
@@ -179,3 +219,37 @@ env: store
 		})
 	})
 })
+
+var _ = Describe("VarFlags", func() {
+	Describe("ValidateVarsMerge", func() {
+		var (
+			flags VarFlags
+		)
+
+		BeforeEach(func() {
+			flags = VarFlags{
+				VarsFiles: []VarsFileArg{
+					{Path: "/file1", Vars: StaticVariables{"key1": "val"}},
+					{Path: "/file2", Vars: StaticVariables{"key1": "val"}, ConflictingKeys: []string{"key2", "key3"}},
+				},
+			}
+		})
+
+		It("returns error if any vars file redefines variables across documents", func() {
+			err := flags.ValidateVarsMerge()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal(
+				"Expected variables file '/file2' to not redefine variables across documents: key2, key3 (use --vars-merge=override to allow)"))
+		})
+
+		It("does not return error if overriding is allowed", func() {
+			flags.VarsMerge = "override"
+			Expect(flags.ValidateVarsMerge()).ToNot(HaveOccurred())
+		})
+
+		It("does not return error if no vars file redefines variables", func() {
+			flags.VarsFiles[1].ConflictingKeys = nil
+			Expect(flags.ValidateVarsMerge()).ToNot(HaveOccurred())
+		})
+	})
+})
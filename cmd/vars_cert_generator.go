@@ -0,0 +1,303 @@
+package cmd
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"time"
+
+	cfgtypes "config_server/types"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+)
+
+// VarsValueGeneratorFactory generates certificates with VarsCertGenerator
+// and delegates all other variable types to config server generators
+type VarsValueGeneratorFactory struct {
+	loader VarsCertLoader
+}
+
+var _ cfgtypes.ValueGeneratorFactory = VarsValueGeneratorFactory{}
+
+func NewVarsValueGeneratorFactory(loader VarsCertLoader) VarsValueGeneratorFactory {
+	return VarsValueGeneratorFactory{loader: loader}
+}
+
+func (f VarsValueGeneratorFactory) GetGenerator(valueType string) (cfgtypes.ValueGenerator, error) {
+	if valueType == "certificate" {
+		return NewVarsCertGenerator(f.loader), nil
+	}
+
+	return cfgtypes.NewValueGeneratorConcrete(f.loader).GetGenerator(valueType)
+}
+
+// VarsCertGenerator generates certificates for variables of type 'certificate'.
+// Supported options: common_name, alternative_names, ext_key_usage, ca, is_ca,
+// key_type (rsa or ecdsa) and key_length (RSA bits or ECDSA curve size).
+type VarsCertGenerator struct {
+	loader VarsCertLoader
+}
+
+type varsCertOpts struct {
+	CommonName       string
+	AlternativeNames []string
+	ExtKeyUsage      []x509.ExtKeyUsage
+
+	CA   string
+	IsCA bool
+
+	KeyType   string
+	KeyLength int
+}
+
+const (
+	varsCertKeyTypeRSA   = "rsa"
+	varsCertKeyTypeECDSA = "ecdsa"
+
+	varsCertDefaultRSAKeyLength   = 2048
+	varsCertDefaultECDSAKeyLength = 256
+
+	varsCertValidity = 365 * 24 * time.Hour
+)
+
+func NewVarsCertGenerator(loader VarsCertLoader) VarsCertGenerator {
+	return VarsCertGenerator{loader: loader}
+}
+
+func (g VarsCertGenerator) Generate(options interface{}) (interface{}, error) {
+	opts, err := g.parseOpts(options)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := g.generateKey(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	serialNumber, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Generating serial number")
+	}
+
+	now := time.Now()
+
+	template := &x509.Certificate{
+		SerialNumber: serialNumber,
+		Subject: pkix.Name{
+			Country:      []string{"USA"},
+			Organization: []string{"Cloud Foundry"},
+			CommonName:   opts.CommonName,
+		},
+		NotBefore:             now,
+		NotAfter:              now.Add(varsCertValidity),
+		BasicConstraintsValid: true,
+		IsCA:                  opts.IsCA,
+	}
+
+	if opts.IsCA {
+		template.KeyUsage = x509.KeyUsageCertSign | x509.KeyUsageCRLSign
+	} else {
+		template.KeyUsage = x509.KeyUsageKeyEncipherment | x509.KeyUsageDigitalSignature
+		template.ExtKeyUsage = opts.ExtKeyUsage
+	}
+
+	for _, altName := range opts.AlternativeNames {
+		if ip := net.ParseIP(altName); ip != nil {
+			template.IPAddresses = append(template.IPAddresses, ip)
+		} else {
+			template.DNSNames = append(template.DNSNames, altName)
+		}
+	}
+
+	// Certificate is self-signed unless CA is referenced
+	parent, parentKey := template, key
+
+	if len(opts.CA) > 0 {
+		parent, parentKey, err = g.loader.LoadCertAndSigner(opts.CA)
+		if err != nil {
+			return nil, bosherr.WrapErrorf(err, "Loading CA '%s'", opts.CA)
+		}
+	}
+
+	derBytes, err := x509.CreateCertificate(rand.Reader, template, parent, key.Public(), parentKey)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Generating certificate")
+	}
+
+	keyBlock, err := g.encodeKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	caDerBytes := derBytes
+
+	if len(opts.CA) > 0 {
+		caDerBytes = parent.Raw
+	}
+
+	return cfgtypes.CertResponse{
+		Certificate: string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: derBytes})),
+		PrivateKey:  string(pem.EncodeToMemory(keyBlock)),
+		CA:          string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: caDerBytes})),
+	}, nil
+}
+
+func (g VarsCertGenerator) parseOpts(options interface{}) (varsCertOpts, error) {
+	opts := varsCertOpts{KeyType: varsCertKeyTypeRSA}
+
+	optsMap, ok := options.(map[interface{}]interface{})
+	if !ok {
+		return opts, bosherr.Error("Expected certificate options to be a hash")
+	}
+
+	var found bool
+
+	opts.CommonName, found = optsMap["common_name"].(string)
+	if !found {
+		return opts, bosherr.Error("Expected certificate option 'common_name' to be a string")
+	}
+
+	if altNames, found := optsMap["alternative_names"]; found {
+		altNamesSlice, ok := altNames.([]interface{})
+		if !ok {
+			return opts, bosherr.Error("Expected certificate option 'alternative_names' to be an array")
+		}
+
+		for _, altName := range altNamesSlice {
+			altNameStr, ok := altName.(string)
+			if !ok {
+				return opts, bosherr.Error("Expected certificate option 'alternative_names' to contain strings")
+			}
+
+			opts.AlternativeNames = append(opts.AlternativeNames, altNameStr)
+		}
+	}
+
+	if usages, found := optsMap["ext_key_usage"]; found {
+		usagesSlice, ok := usages.([]interface{})
+		if !ok {
+			return opts, bosherr.Error("Expected certificate option 'ext_key_usage' to be an array")
+		}
+
+		for _, usage := range usagesSlice {
+			switch usage {
+			case "client_auth":
+				opts.ExtKeyUsage = append(opts.ExtKeyUsage, x509.ExtKeyUsageClientAuth)
+			case "server_auth":
+				opts.ExtKeyUsage = append(opts.ExtKeyUsage, x509.ExtKeyUsageServerAuth)
+			default:
+				return opts, bosherr.Errorf("Unsupported extended key usage value: %v", usage)
+			}
+		}
+	} else {
+		opts.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}
+	}
+
+	if ca, found := optsMap["ca"]; found {
+		opts.CA, ok = ca.(string)
+		if !ok {
+			return opts, bosherr.Error("Expected certificate option 'ca' to be a string")
+		}
+	}
+
+	// Certificates without CA used to always be generated as CAs
+	opts.IsCA = len(opts.CA) == 0
+
+	if isCA, found := optsMap["is_ca"]; found {
+		opts.IsCA, ok = isCA.(bool)
+		if !ok {
+			return opts, bosherr.Error("Expected certificate option 'is_ca' to be a boolean")
+		}
+	}
+
+	if keyType, found := optsMap["key_type"]; found {
+		opts.KeyType, ok = keyType.(string)
+		if !ok {
+			return opts, bosherr.Error("Expected certificate option 'key_type' to be a string")
+		}
+	}
+
+	if keyLength, found := optsMap["key_length"]; found {
+		opts.KeyLength, ok = keyLength.(int)
+		if !ok {
+			return opts, bosherr.Error("Expected certificate option 'key_length' to be an integer")
+		}
+	}
+
+	return opts, nil
+}
+
+func (g VarsCertGenerator) generateKey(opts varsCertOpts) (crypto.Signer, error) {
+	switch opts.KeyType {
+	case varsCertKeyTypeRSA:
+		keyLength := opts.KeyLength
+		if keyLength == 0 {
+			keyLength = varsCertDefaultRSAKeyLength
+		}
+
+		if keyLength < 1024 {
+			return nil, bosherr.Errorf("Expected RSA key length to be at least 1024 but was %d", keyLength)
+		}
+
+		key, err := rsa.GenerateKey(rand.Reader, keyLength)
+		if err != nil {
+			return nil, bosherr.WrapError(err, "Generating RSA key")
+		}
+
+		return key, nil
+
+	case varsCertKeyTypeECDSA:
+		keyLength := opts.KeyLength
+		if keyLength == 0 {
+			keyLength = varsCertDefaultECDSAKeyLength
+		}
+
+		var curve elliptic.Curve
+
+		switch keyLength {
+		case 256:
+			curve = elliptic.P256()
+		case 384:
+			curve = elliptic.P384()
+		case 521:
+			curve = elliptic.P521()
+		default:
+			return nil, bosherr.Errorf("Expected ECDSA key length to be 256, 384 or 521 but was %d", keyLength)
+		}
+
+		key, err := ecdsa.GenerateKey(curve, rand.Reader)
+		if err != nil {
+			return nil, bosherr.WrapError(err, "Generating ECDSA key")
+		}
+
+		return key, nil
+
+	default:
+		return nil, bosherr.Errorf("Expected key type to be 'rsa' or 'ecdsa' but was '%s'", opts.KeyType)
+	}
+}
+
+func (g VarsCertGenerator) encodeKey(key crypto.Signer) (*pem.Block, error) {
+	switch typedKey := key.(type) {
+	case *rsa.PrivateKey:
+		return &pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(typedKey)}, nil
+
+	case *ecdsa.PrivateKey:
+		bytes, err := x509.MarshalECPrivateKey(typedKey)
+		if err != nil {
+			return nil, bosherr.WrapError(err, "Marshaling ECDSA key")
+		}
+
+		return &pem.Block{Type: "EC PRIVATE KEY", Bytes: bytes}, nil
+
+	default:
+		return nil, bosherr.Errorf("Unexpected private key type %T", key)
+	}
+}
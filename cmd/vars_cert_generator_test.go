@@ -0,0 +1,221 @@
+package cmd_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"net"
+
+	cfgtypes "config_server/types"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-cli/cmd"
+	boshtpl "github.com/cloudfoundry/bosh-cli/director/template"
+)
+
+var _ = Describe("VarsValueGeneratorFactory", func() {
+	var (
+		factory VarsValueGeneratorFactory
+	)
+
+	BeforeEach(func() {
+		factory = NewVarsValueGeneratorFactory(NewVarsCertLoader(boshtpl.StaticVariables{}))
+	})
+
+	Describe("GetGenerator", func() {
+		It("returns certificate generator for certificates", func() {
+			generator, err := factory.GetGenerator("certificate")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(generator).To(BeAssignableToTypeOf(VarsCertGenerator{}))
+		})
+
+		It("delegates to config server generators for other types", func() {
+			generator, err := factory.GetGenerator("password")
+			Expect(err).ToNot(HaveOccurred())
+
+			val, err := generator.Generate(nil)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(val).ToNot(BeEmpty())
+		})
+
+		It("returns error for unknown types", func() {
+			_, err := factory.GetGenerator("unknown")
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
+
+var _ = Describe("VarsCertGenerator", func() {
+	var (
+		vars      boshtpl.StaticVariables
+		generator VarsCertGenerator
+	)
+
+	BeforeEach(func() {
+		vars = boshtpl.StaticVariables{}
+		generator = NewVarsCertGenerator(NewVarsCertLoader(vars))
+	})
+
+	generate := func(opts map[interface{}]interface{}) (cfgtypes.CertResponse, *x509.Certificate, interface{}) {
+		val, err := generator.Generate(opts)
+		Expect(err).ToNot(HaveOccurred())
+
+		resp := val.(cfgtypes.CertResponse)
+
+		crtBlock, _ := pem.Decode([]byte(resp.Certificate))
+		Expect(crtBlock).ToNot(BeNil())
+
+		crt, err := x509.ParseCertificate(crtBlock.Bytes)
+		Expect(err).ToNot(HaveOccurred())
+
+		keyBlock, _ := pem.Decode([]byte(resp.PrivateKey))
+		Expect(keyBlock).ToNot(BeNil())
+
+		var key interface{}
+
+		switch keyBlock.Type {
+		case "RSA PRIVATE KEY":
+			key, err = x509.ParsePKCS1PrivateKey(keyBlock.Bytes)
+		case "EC PRIVATE KEY":
+			key, err = x509.ParseECPrivateKey(keyBlock.Bytes)
+		default:
+			Fail("Unexpected private key type " + keyBlock.Type)
+		}
+		Expect(err).ToNot(HaveOccurred())
+
+		return resp, crt, key
+	}
+
+	storeCA := func(name string, resp cfgtypes.CertResponse) {
+		vars[name] = map[interface{}]interface{}{
+			"certificate": resp.Certificate,
+			"private_key": resp.PrivateKey,
+		}
+	}
+
+	It("generates self-signed RSA CA by default", func() {
+		resp, crt, key := generate(map[interface{}]interface{}{"common_name": "fake-ca"})
+
+		Expect(crt.Subject.CommonName).To(Equal("fake-ca"))
+		Expect(crt.IsCA).To(BeTrue())
+		Expect(crt.CheckSignatureFrom(crt)).ToNot(HaveOccurred())
+		Expect(resp.CA).To(Equal(resp.Certificate))
+
+		Expect(key).To(BeAssignableToTypeOf(&rsa.PrivateKey{}))
+		Expect(key.(*rsa.PrivateKey).N.BitLen()).To(Equal(2048))
+	})
+
+	It("generates RSA key with specified key_length", func() {
+		_, _, key := generate(map[interface{}]interface{}{
+			"common_name": "fake-ca",
+			"key_length":  1024,
+		})
+
+		Expect(key.(*rsa.PrivateKey).N.BitLen()).To(Equal(1024))
+	})
+
+	It("returns error if RSA key_length is too short", func() {
+		_, err := generator.Generate(map[interface{}]interface{}{
+			"common_name": "fake-ca",
+			"key_length":  512,
+		})
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(Equal("Expected RSA key length to be at least 1024 but was 512"))
+	})
+
+	It("generates ECDSA key with curve matching key_length", func() {
+		_, crt, key := generate(map[interface{}]interface{}{
+			"common_name": "fake-ca",
+			"key_type":    "ecdsa",
+			"key_length":  384,
+		})
+
+		Expect(crt.PublicKeyAlgorithm).To(Equal(x509.ECDSA))
+		Expect(key.(*ecdsa.PrivateKey).Curve).To(Equal(elliptic.P384()))
+	})
+
+	It("generates ECDSA key with P-256 curve by default", func() {
+		_, _, key := generate(map[interface{}]interface{}{
+			"common_name": "fake-ca",
+			"key_type":    "ecdsa",
+		})
+
+		Expect(key.(*ecdsa.PrivateKey).Curve).To(Equal(elliptic.P256()))
+	})
+
+	It("returns error if ECDSA key_length does not match supported curves", func() {
+		_, err := generator.Generate(map[interface{}]interface{}{
+			"common_name": "fake-ca",
+			"key_type":    "ecdsa",
+			"key_length":  2048,
+		})
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(Equal("Expected ECDSA key length to be 256, 384 or 521 but was 2048"))
+	})
+
+	It("returns error for unsupported key types", func() {
+		_, err := generator.Generate(map[interface{}]interface{}{
+			"common_name": "fake-ca",
+			"key_type":    "ed25519",
+		})
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(Equal("Expected key type to be 'rsa' or 'ecdsa' but was 'ed25519'"))
+	})
+
+	It("returns error if common_name is missing", func() {
+		_, err := generator.Generate(map[interface{}]interface{}{})
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(Equal("Expected certificate option 'common_name' to be a string"))
+	})
+
+	It("generates leaf certificate signed by referenced CA", func() {
+		caResp, caCrt, _ := generate(map[interface{}]interface{}{
+			"common_name": "fake-ca",
+			"key_type":    "ecdsa",
+		})
+		storeCA("fake-ca", caResp)
+
+		resp, crt, _ := generate(map[interface{}]interface{}{
+			"common_name":       "fake-leaf",
+			"ca":                "fake-ca",
+			"alternative_names": []interface{}{"10.0.0.1", "leaf.internal"},
+			"ext_key_usage":     []interface{}{"client_auth"},
+		})
+
+		Expect(crt.IsCA).To(BeFalse())
+		Expect(crt.CheckSignatureFrom(caCrt)).ToNot(HaveOccurred())
+		Expect(resp.CA).To(Equal(caResp.Certificate))
+
+		Expect(crt.DNSNames).To(Equal([]string{"leaf.internal"}))
+		Expect(crt.IPAddresses).To(HaveLen(1))
+		Expect(crt.IPAddresses[0].Equal(net.ParseIP("10.0.0.1"))).To(BeTrue())
+		Expect(crt.ExtKeyUsage).To(Equal([]x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}))
+	})
+
+	It("generates intermediate CA when is_ca is set together with ca", func() {
+		caResp, caCrt, _ := generate(map[interface{}]interface{}{"common_name": "fake-ca", "key_length": 1024})
+		storeCA("fake-ca", caResp)
+
+		_, crt, _ := generate(map[interface{}]interface{}{
+			"common_name": "fake-intermediate",
+			"ca":          "fake-ca",
+			"is_ca":       true,
+			"key_length":  1024,
+		})
+
+		Expect(crt.IsCA).To(BeTrue())
+		Expect(crt.CheckSignatureFrom(caCrt)).ToNot(HaveOccurred())
+	})
+
+	It("returns error if referenced CA cannot be loaded", func() {
+		_, err := generator.Generate(map[interface{}]interface{}{
+			"common_name": "fake-leaf",
+			"ca":          "unknown",
+		})
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("Loading CA 'unknown'"))
+	})
+})
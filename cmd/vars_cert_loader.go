@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"crypto"
 	"crypto/rsa"
 	"crypto/x509"
 	"encoding/pem"
@@ -21,6 +22,21 @@ func NewVarsCertLoader(vars boshtpl.Variables) VarsCertLoader {
 }
 
 func (l VarsCertLoader) LoadCerts(name string) (*x509.Certificate, *rsa.PrivateKey, error) {
+	crt, signer, err := l.LoadCertAndSigner(name)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	key, ok := signer.(*rsa.PrivateKey)
+	if !ok {
+		return nil, nil, bosherr.Errorf("Expected variable '%s' to have RSA private key", name)
+	}
+
+	return crt, key, nil
+}
+
+// LoadCertAndSigner is like LoadCerts but also allows ECDSA private keys
+func (l VarsCertLoader) LoadCertAndSigner(name string) (*x509.Certificate, crypto.Signer, error) {
 	val, found, err := l.vars.Get(boshtpl.VariableDefinition{Name: name})
 	if err != nil {
 		return nil, nil, err
@@ -73,12 +89,21 @@ func (VarsCertLoader) parseCertificate(data string) (*x509.Certificate, error) {
 	return crt, nil
 }
 
-func (VarsCertLoader) parsePrivateKey(data string) (*rsa.PrivateKey, error) {
+func (VarsCertLoader) parsePrivateKey(data string) (crypto.Signer, error) {
 	kpb, _ := pem.Decode([]byte(data))
 	if kpb == nil {
 		return nil, bosherr.Error("Private key did not contain PEM formatted block")
 	}
 
+	if kpb.Type == "EC PRIVATE KEY" {
+		key, err := x509.ParseECPrivateKey(kpb.Bytes)
+		if err != nil {
+			return nil, bosherr.WrapError(err, "Parsing private key")
+		}
+
+		return key, nil
+	}
+
 	key, err := x509.ParsePKCS1PrivateKey(kpb.Bytes)
 	if err != nil {
 		return nil, bosherr.WrapError(err, "Parsing private key")
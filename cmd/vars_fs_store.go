@@ -107,11 +107,20 @@ func (s VarsFSStore) save(vars boshtpl.StaticVariables) error {
 		return bosherr.WrapErrorf(err, "Serializing variables")
 	}
 
-	err = s.FS.WriteFile(s.path, bytes)
+	// Write to a sibling file first and rename it over the store
+	// so that an interrupted write does not corrupt existing variables
+	tmpPath := s.path + ".tmp"
+
+	err = s.FS.WriteFile(tmpPath, bytes)
 	if err != nil {
 		return bosherr.WrapErrorf(err, "Writing variables to file store '%s'", s.path)
 	}
 
+	err = s.FS.Rename(tmpPath, s.path)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Replacing variables file store '%s'", s.path)
+	}
+
 	return nil
 }
 
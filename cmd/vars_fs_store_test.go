@@ -60,6 +60,27 @@ var _ = Describe("VarsFSStore", func() {
 				Expect(fs.ReadFileString("/file")).To(Equal(fmt.Sprintf("key: val\nkey2: %s\n", val.(string))))
 			})
 
+			It("saves values by renaming temporary file over the store", func() {
+				_, _, err := store.Get(boshtpl.VariableDefinition{Name: "key2", Type: "password"})
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(fs.RenameOldPaths).To(Equal([]string{"/file.tmp"}))
+				Expect(fs.RenameNewPaths).To(Equal([]string{"/file"}))
+				Expect(fs.FileExists("/file.tmp")).To(BeFalse())
+			})
+
+			It("returns error and keeps existing store if renaming file fails", func() {
+				fs.RenameError = errors.New("fake-err")
+
+				val, found, err := store.Get(boshtpl.VariableDefinition{Name: "key2", Type: "password"})
+				Expect(val).To(BeNil())
+				Expect(found).To(BeFalse())
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("fake-err"))
+
+				Expect(fs.ReadFileString("/file")).To(Equal("key: val"))
+			})
+
 			It("returns error if variable type is not known", func() {
 				val, found, err := store.Get(boshtpl.VariableDefinition{Name: "key2", Type: "unknown"})
 				Expect(val).To(BeNil())
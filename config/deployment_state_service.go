@@ -1,20 +1,31 @@
 package config
 
 import (
+	"time"
+
 	biproperty "github.com/cloudfoundry/bosh-utils/property"
 )
 
+// DeploymentStateSchemaVersion is incremented whenever saved deployment state
+// changes in a way that requires migrating state saved by older versions
+const DeploymentStateSchemaVersion = 2
+
+// DefaultDeploymentName names the deployment managed via Load and Save; its state
+// is kept at the top level of the deployment state file as saved by older versions
+const DefaultDeploymentName = "default"
+
 type DeploymentState struct {
-	DirectorID         string           `json:"director_id"`
-	InstallationID     string           `json:"installation_id"`
-	CurrentVMCID       string           `json:"current_vm_cid"`
-	CurrentStemcellID  string           `json:"current_stemcell_id"`
-	CurrentDiskID      string           `json:"current_disk_id"`
-	CurrentReleaseIDs  []string         `json:"current_release_ids"`
-	CurrentManifestSHA string           `json:"current_manifest_sha"`
-	Disks              []DiskRecord     `json:"disks"`
-	Stemcells          []StemcellRecord `json:"stemcells"`
-	Releases           []ReleaseRecord  `json:"releases"`
+	DirectorID         string             `json:"director_id"`
+	InstallationID     string             `json:"installation_id"`
+	CurrentVMCID       string             `json:"current_vm_cid"`
+	CurrentStemcellID  string             `json:"current_stemcell_id"`
+	CurrentDiskID      string             `json:"current_disk_id"`
+	CurrentReleaseIDs  []string           `json:"current_release_ids"`
+	CurrentManifestSHA string             `json:"current_manifest_sha"`
+	Disks              []DiskRecord       `json:"disks"`
+	OrphanedDisks      []OrphanDiskRecord `json:"orphaned_disks,omitempty"`
+	Stemcells          []StemcellRecord   `json:"stemcells"`
+	Releases           []ReleaseRecord    `json:"releases"`
 }
 
 type StemcellRecord struct {
@@ -29,6 +40,14 @@ type DiskRecord struct {
 	CID             string         `json:"cid"`
 	Size            int            `json:"size"`
 	CloudProperties biproperty.Map `json:"cloud_properties"`
+
+	// AttachedToVM is CID of the VM that disk is attached to (empty if detached)
+	AttachedToVM string `json:"attached_to_vm,omitempty"`
+}
+
+type OrphanDiskRecord struct {
+	DiskRecord
+	OrphanedAt time.Time `json:"orphaned_at"`
 }
 
 type ReleaseRecord struct {
@@ -42,5 +61,16 @@ type DeploymentStateService interface {
 	Exists() bool
 	Load() (DeploymentState, error)
 	Save(DeploymentState) error
+
+	// LoadDeployment and SaveDeployment work with state of a named deployment
+	// kept in the same deployment state file
+	LoadDeployment(name string) (DeploymentState, error)
+	SaveDeployment(name string, deploymentState DeploymentState) error
+
 	Cleanup() error
+
+	// Lock prevents other processes from using the same deployment state
+	// until Unlock is called; it gives up after waiting for timeout
+	Lock(timeout time.Duration) error
+	Unlock() error
 }
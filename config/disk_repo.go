@@ -1,189 +1,261 @@
 package config
 
 import (
+	"sort"
+
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 	biproperty "github.com/cloudfoundry/bosh-utils/property"
 	boshuuid "github.com/cloudfoundry/bosh-utils/uuid"
+	"github.com/pivotal-golang/clock"
 )
 
 type DiskRepo interface {
 	UpdateCurrent(diskID string) error
 	FindCurrent() (DiskRecord, bool, error)
 	ClearCurrent() error
-	Save(cid string, size int, cloudProperties biproperty.Map) (DiskRecord, error)
+	Save(cid string, size int, cloudProperties biproperty.Map, mode DiskRepoSaveMode) (DiskRecord, error)
 	Find(cid string) (DiskRecord, bool, error)
+	FindByID(id string) (DiskRecord, bool, error)
+	Update(id string, size int, cloudProperties biproperty.Map) (DiskRecord, error)
 	All() ([]DiskRecord, error)
 	Delete(DiskRecord) error
+	DeleteByCID(cid string) (bool, error)
+	Orphan(id string) error
+	AllOrphaned() ([]OrphanDiskRecord, error)
+	MarkAttached(id, vmCID string) error
+	MarkDetached(id string) error
+
+	// Transaction applies multiple changes atomically
+	Transaction(func(DiskRepoTx) error) error
+
+	// Close waits for observer to handle notifications about persisted changes;
+	// it should be called before exiting once disk repo is no longer used
+	Close()
 }
 
+// DiskRepoSaveMode controls what Save does if record with the same CID already exists
+type DiskRepoSaveMode int
+
+const (
+	// ErrorOnDuplicateCID makes Save fail so that two records never point at the same disk
+	ErrorOnDuplicateCID DiskRepoSaveMode = iota
+
+	// ReturnExistingOnDuplicateCID makes Save idempotent by returning existing record unchanged
+	ReturnExistingOnDuplicateCID
+)
+
 type diskRepo struct {
 	deploymentStateService DeploymentStateService
+	deploymentName         string
 	uuidGenerator          boshuuid.Generator
+	timeService            clock.Clock
+	notifier               *diskRepoNotifier
 }
 
-func NewDiskRepo(deploymentStateService DeploymentStateService, uuidGenerator boshuuid.Generator) DiskRepo {
+// NewDiskRepo returns disk repo that notifies observer (if not nil)
+// about persisted changes without waiting for observer to handle them
+func NewDiskRepo(
+	deploymentStateService DeploymentStateService,
+	deploymentName string,
+	uuidGenerator boshuuid.Generator,
+	timeService clock.Clock,
+	observer DiskRepoObserver,
+	logger boshlog.Logger,
+) DiskRepo {
 	return diskRepo{
 		deploymentStateService: deploymentStateService,
+		deploymentName:         deploymentName,
 		uuidGenerator:          uuidGenerator,
+		timeService:            timeService,
+		notifier:               newDiskRepoNotifier(observer, logger),
 	}
 }
 
-func (r diskRepo) Save(cid string, size int, cloudProperties biproperty.Map) (DiskRecord, error) {
-	config, records, err := r.load()
+func (r diskRepo) Close() {
+	r.notifier.Close()
+}
+
+// Transaction runs fn against in-memory copy of disk records and persists all changes
+// at once if fn succeeds; if fn returns an error nothing is persisted. Observer is notified
+// only about persisted changes.
+func (r diskRepo) Transaction(fn func(DiskRepoTx) error) error {
+	deploymentState, err := r.deploymentStateService.LoadDeployment(r.deploymentName)
 	if err != nil {
-		return DiskRecord{}, err
+		return bosherr.WrapError(err, "Loading existing config")
 	}
 
-	oldRecord, found := r.find(records, cid)
-	if found {
-		return DiskRecord{}, bosherr.Errorf("Failed to save disk cid '%s', existing record found '%#v'", cid, oldRecord)
-	}
+	tx := newDiskRepoTx(deploymentState, r.uuidGenerator, r.timeService)
 
-	newRecord := DiskRecord{
-		CID:             cid,
-		Size:            size,
-		CloudProperties: cloudProperties,
-	}
-	newRecord.ID, err = r.uuidGenerator.Generate()
+	err = fn(tx)
 	if err != nil {
-		return newRecord, bosherr.WrapError(err, "Generating disk id")
+		return err
 	}
 
-	records = append(records, newRecord)
-	config.Disks = records
-
-	err = r.deploymentStateService.Save(config)
-	if err != nil {
-		return newRecord, bosherr.WrapError(err, "Saving new config")
+	if !tx.changed {
+		return nil
 	}
-	return newRecord, nil
-}
 
-func (r diskRepo) FindCurrent() (DiskRecord, bool, error) {
-	deploymentState, err := r.deploymentStateService.Load()
+	err = r.deploymentStateService.SaveDeployment(r.deploymentName, tx.state)
 	if err != nil {
-		return DiskRecord{}, false, bosherr.WrapError(err, "Loading existing config")
+		return bosherr.WrapError(err, "Saving new config")
 	}
 
-	currentDiskID := deploymentState.CurrentDiskID
-	if currentDiskID == "" {
-		return DiskRecord{}, false, nil
+	for _, notification := range tx.notifications {
+		notification(r.notifier)
 	}
 
-	for _, oldRecord := range deploymentState.Disks {
-		if oldRecord.ID == currentDiskID {
-			return oldRecord, true, nil
-		}
-	}
+	return nil
+}
 
-	return DiskRecord{}, false, nil
+func (r diskRepo) Save(cid string, size int, cloudProperties biproperty.Map, mode DiskRepoSaveMode) (DiskRecord, error) {
+	var record DiskRecord
+
+	err := r.Transaction(func(tx DiskRepoTx) error {
+		var err error
+		record, err = tx.Save(cid, size, cloudProperties, mode)
+		return err
+	})
+
+	return record, err
 }
 
-func (r diskRepo) UpdateCurrent(diskID string) error {
-	deploymentState, err := r.deploymentStateService.Load()
+func (r diskRepo) Update(id string, size int, cloudProperties biproperty.Map) (DiskRecord, error) {
+	var record DiskRecord
+
+	err := r.Transaction(func(tx DiskRepoTx) error {
+		var err error
+		record, err = tx.Update(id, size, cloudProperties)
+		return err
+	})
+
+	return record, err
+}
+
+func (r diskRepo) FindCurrent() (DiskRecord, bool, error) {
+	tx, err := r.snapshot()
 	if err != nil {
-		return bosherr.WrapError(err, "Loading existing config")
+		return DiskRecord{}, false, err
 	}
 
-	found := false
-	for _, oldRecord := range deploymentState.Disks {
-		if oldRecord.ID == diskID {
-			found = true
-		}
-	}
-	if !found {
-		return bosherr.Errorf("Verifying disk record exists with id '%s'", diskID)
-	}
+	record, found := tx.FindCurrent()
 
-	deploymentState.CurrentDiskID = diskID
+	return record, found, nil
+}
+
+func (r diskRepo) UpdateCurrent(diskID string) error {
+	return r.Transaction(func(tx DiskRepoTx) error { return tx.UpdateCurrent(diskID) })
+}
 
-	err = r.deploymentStateService.Save(deploymentState)
+func (r diskRepo) Find(cid string) (DiskRecord, bool, error) {
+	tx, err := r.snapshot()
 	if err != nil {
-		return bosherr.WrapError(err, "Saving new config")
+		return DiskRecord{}, false, err
 	}
-	return nil
+
+	record, found := tx.Find(cid)
+
+	return record, found, nil
 }
 
-func (r diskRepo) Find(cid string) (DiskRecord, bool, error) {
-	_, records, err := r.load()
+func (r diskRepo) FindByID(id string) (DiskRecord, bool, error) {
+	tx, err := r.snapshot()
 	if err != nil {
 		return DiskRecord{}, false, err
 	}
 
-	foundRecord, found := r.find(records, cid)
-	return foundRecord, found, nil
+	record, found := tx.FindByID(id)
+
+	return record, found, nil
 }
 
 func (r diskRepo) All() ([]DiskRecord, error) {
-	deploymentState, err := r.deploymentStateService.Load()
+	deploymentState, err := r.deploymentStateService.LoadDeployment(r.deploymentName)
 	if err != nil {
 		return []DiskRecord{}, bosherr.WrapError(err, "Loading existing config")
 	}
 
-	return deploymentState.Disks, nil
+	disks := deploymentState.Disks
+
+	sort.Sort(diskRecordsByCIDAndID(disks))
+
+	return disks, nil
 }
 
-func (r diskRepo) Delete(diskRecord DiskRecord) error {
-	config, records, err := r.load()
-	if err != nil {
-		return err
-	}
+// diskRecordsByCIDAndID orders records independently of how they were saved
+type diskRecordsByCIDAndID []DiskRecord
+
+func (s diskRecordsByCIDAndID) Len() int      { return len(s) }
+func (s diskRecordsByCIDAndID) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
 
-	newRecords := []DiskRecord{}
-	for _, record := range records {
-		if record.ID != diskRecord.ID {
-			newRecords = append(newRecords, record)
-		}
+func (s diskRecordsByCIDAndID) Less(i, j int) bool {
+	if s[i].CID != s[j].CID {
+		return s[i].CID < s[j].CID
 	}
 
-	config.Disks = newRecords
+	return s[i].ID < s[j].ID
+}
 
-	if config.CurrentDiskID == diskRecord.ID {
-		config.CurrentDiskID = ""
-	}
+func (r diskRepo) Delete(diskRecord DiskRecord) error {
+	return r.Transaction(func(tx DiskRepoTx) error {
+		tx.Delete(diskRecord)
+		return nil
+	})
+}
 
-	err = r.deploymentStateService.Save(config)
+// DeleteByCID removes disk record with given CID (clearing current disk if it was the one)
+// and returns whether such record was found
+func (r diskRepo) DeleteByCID(cid string) (bool, error) {
+	var found bool
+
+	err := r.Transaction(func(tx DiskRepoTx) error {
+		found = tx.DeleteByCID(cid)
+		return nil
+	})
 	if err != nil {
-		return bosherr.WrapError(err, "Saving new config")
+		return false, err
 	}
 
-	return nil
+	return found, nil
 }
 
-func (r diskRepo) ClearCurrent() error {
-	deploymentState, err := r.deploymentStateService.Load()
-	if err != nil {
-		return bosherr.WrapError(err, "Loading existing config")
-	}
+// Orphan moves disk record to orphaned disks recording when it was orphaned
+func (r diskRepo) Orphan(id string) error {
+	return r.Transaction(func(tx DiskRepoTx) error { return tx.Orphan(id) })
+}
 
-	deploymentState.CurrentDiskID = ""
+// MarkAttached records that disk is attached to VM with given CID
+func (r diskRepo) MarkAttached(id, vmCID string) error {
+	return r.Transaction(func(tx DiskRepoTx) error { return tx.MarkAttached(id, vmCID) })
+}
 
-	err = r.deploymentStateService.Save(deploymentState)
-	if err != nil {
-		return bosherr.WrapError(err, "Saving new config")
-	}
-	return nil
+// MarkDetached records that disk is not attached to any VM
+func (r diskRepo) MarkDetached(id string) error {
+	return r.Transaction(func(tx DiskRepoTx) error { return tx.MarkDetached(id) })
 }
 
-func (r diskRepo) load() (DeploymentState, []DiskRecord, error) {
-	deploymentState, err := r.deploymentStateService.Load()
+func (r diskRepo) AllOrphaned() ([]OrphanDiskRecord, error) {
+	deploymentState, err := r.deploymentStateService.LoadDeployment(r.deploymentName)
 	if err != nil {
-		return deploymentState, []DiskRecord{}, bosherr.WrapError(err, "Loading existing config")
+		return []OrphanDiskRecord{}, bosherr.WrapError(err, "Loading existing config")
 	}
 
-	records := deploymentState.Disks
-	if records == nil {
-		return deploymentState, []DiskRecord{}, nil
-	}
+	return deploymentState.OrphanedDisks, nil
+}
 
-	return deploymentState, records, nil
+func (r diskRepo) ClearCurrent() error {
+	return r.Transaction(func(tx DiskRepoTx) error {
+		tx.ClearCurrent()
+		return nil
+	})
 }
 
-func (r diskRepo) find(records []DiskRecord, cid string) (DiskRecord, bool) {
-	for _, existingRecord := range records {
-		if existingRecord.CID == cid {
-			return existingRecord, true
-		}
+// snapshot returns transaction that is only used for reading current disk records
+func (r diskRepo) snapshot() (*diskRepoTx, error) {
+	deploymentState, err := r.deploymentStateService.LoadDeployment(r.deploymentName)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Loading existing config")
 	}
-	return DiskRecord{}, false
+
+	return newDiskRepoTx(deploymentState, r.uuidGenerator, r.timeService), nil
 }
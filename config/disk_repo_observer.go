@@ -0,0 +1,150 @@
+package config
+
+import (
+	"sync"
+	"time"
+
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+)
+
+// DiskRepoObserver is notified after disk repo changes are persisted
+// so that embedders can keep external disk inventories in sync.
+// Errors returned from callbacks are logged and do not fail disk repo operations.
+type DiskRepoObserver interface {
+	OnSave(DiskRecord) error
+	OnDelete(DiskRecord) error
+
+	// OnCurrentChanged receives empty id if current disk was cleared
+	OnCurrentChanged(id string) error
+}
+
+// diskRepoNotifierQueueSize bounds number of pending notifications;
+// notifications are dropped instead of blocking disk repo operations
+const diskRepoNotifierQueueSize = 100
+
+// diskRepoNotifierCloseTimeout bounds how long Close waits for observer
+// to handle pending notifications so that a stuck observer does not hang the CLI
+const diskRepoNotifierCloseTimeout = 10 * time.Second
+
+// diskRepoNotifier calls observer from a single goroutine
+// so that notifications are delivered in order of changes
+type diskRepoNotifier struct {
+	observer      DiskRepoObserver
+	notifications chan diskRepoNotification
+	doneCh        chan struct{}
+
+	// closed and dropped are guarded by lock which is also held
+	// while enqueueing so that nothing is sent after notifications is closed
+	closed  bool
+	dropped int
+	lock    sync.Mutex
+
+	logger boshlog.Logger
+	logTag string
+}
+
+type diskRepoNotification struct {
+	desc   string
+	notify func(DiskRepoObserver) error
+}
+
+func newDiskRepoNotifier(observer DiskRepoObserver, logger boshlog.Logger) *diskRepoNotifier {
+	if observer == nil {
+		return nil
+	}
+
+	n := &diskRepoNotifier{
+		observer:      observer,
+		notifications: make(chan diskRepoNotification, diskRepoNotifierQueueSize),
+		doneCh:        make(chan struct{}),
+
+		logger: logger,
+		logTag: "diskRepoNotifier",
+	}
+
+	go n.run()
+
+	return n
+}
+
+func (n *diskRepoNotifier) run() {
+	defer close(n.doneCh)
+
+	for notification := range n.notifications {
+		err := notification.notify(n.observer)
+		if err != nil {
+			n.logger.Error(n.logTag, "Notifying observer %s: %s", notification.desc, err.Error())
+		}
+	}
+}
+
+func (n *diskRepoNotifier) Saved(record DiskRecord) {
+	n.enqueue("about saved disk record '"+record.ID+"'", func(o DiskRepoObserver) error {
+		return o.OnSave(record)
+	})
+}
+
+func (n *diskRepoNotifier) Deleted(record DiskRecord) {
+	n.enqueue("about deleted disk record '"+record.ID+"'", func(o DiskRepoObserver) error {
+		return o.OnDelete(record)
+	})
+}
+
+func (n *diskRepoNotifier) CurrentChanged(id string) {
+	n.enqueue("about current disk change to '"+id+"'", func(o DiskRepoObserver) error {
+		return o.OnCurrentChanged(id)
+	})
+}
+
+// Close waits for observer to handle pending notifications and stops the goroutine;
+// notifications about changes made afterwards are dropped
+func (n *diskRepoNotifier) Close() {
+	if n == nil {
+		return
+	}
+
+	n.lock.Lock()
+
+	if n.closed {
+		n.lock.Unlock()
+		return
+	}
+
+	n.closed = true
+	close(n.notifications)
+
+	if n.dropped > 0 {
+		n.logger.Error(n.logTag, "Dropped %d notification(s); observer may be out of sync with disk repo", n.dropped)
+	}
+
+	n.lock.Unlock()
+
+	select {
+	case <-n.doneCh:
+	case <-time.After(diskRepoNotifierCloseTimeout):
+		n.logger.Error(n.logTag, "Timed out after %s waiting for observer to handle %d pending notification(s)",
+			diskRepoNotifierCloseTimeout, len(n.notifications))
+	}
+}
+
+func (n *diskRepoNotifier) enqueue(desc string, notify func(DiskRepoObserver) error) {
+	if n == nil {
+		return
+	}
+
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	if n.closed {
+		n.dropped++
+		n.logger.Error(n.logTag, "Dropping notification %s since disk repo is closed", desc)
+		return
+	}
+
+	select {
+	case n.notifications <- diskRepoNotification{desc: desc, notify: notify}:
+	default:
+		n.dropped++
+		n.logger.Error(n.logTag, "Dropping notification %s since observer is not keeping up", desc)
+	}
+}
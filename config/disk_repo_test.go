@@ -1,6 +1,13 @@
 package config_test
 
 import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
 	. "github.com/cloudfoundry/bosh-cli/config"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -9,6 +16,7 @@ import (
 	biproperty "github.com/cloudfoundry/bosh-utils/property"
 	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
 	fakeuuid "github.com/cloudfoundry/bosh-utils/uuid/fakes"
+	"github.com/pivotal-golang/clock/fakeclock"
 )
 
 var _ = Describe("DiskRepo", func() {
@@ -17,15 +25,18 @@ var _ = Describe("DiskRepo", func() {
 		repo                   DiskRepo
 		fs                     *fakesys.FakeFileSystem
 		fakeUUIDGenerator      *fakeuuid.FakeGenerator
+		timeService            *fakeclock.FakeClock
 		cloudProperties        biproperty.Map
+		logger                 boshlog.Logger
 	)
 
 	BeforeEach(func() {
-		logger := boshlog.NewLogger(boshlog.LevelNone)
+		logger = boshlog.NewLogger(boshlog.LevelNone)
 		fs = fakesys.NewFakeFileSystem()
 		fakeUUIDGenerator = &fakeuuid.FakeGenerator{}
-		deploymentStateService = NewFileSystemDeploymentStateService(fs, fakeUUIDGenerator, logger, "/fake/path")
-		repo = NewDiskRepo(deploymentStateService, fakeUUIDGenerator)
+		deploymentStateService = NewFileSystemDeploymentStateService(fs, fakeUUIDGenerator, logger, "/fake/path", 0)
+		timeService = fakeclock.NewFakeClock(time.Date(2009, time.November, 10, 23, 1, 2, 333, time.UTC))
+		repo = NewDiskRepo(deploymentStateService, DefaultDeploymentName, fakeUUIDGenerator, timeService, nil, logger)
 		cloudProperties = biproperty.Map{
 			"fake-cloud_property-key": "fake-cloud-property-value",
 		}
@@ -33,7 +44,7 @@ var _ = Describe("DiskRepo", func() {
 
 	Describe("Save", func() {
 		It("saves the disk record using the config service", func() {
-			record, err := repo.Save("fake-cid", 1024, cloudProperties)
+			record, err := repo.Save("fake-cid", 1024, cloudProperties, ErrorOnDuplicateCID)
 			Expect(err).ToNot(HaveOccurred())
 			Expect(record).To(Equal(DiskRecord{
 				ID:              "fake-uuid-1",
@@ -58,11 +69,43 @@ var _ = Describe("DiskRepo", func() {
 			}
 			Expect(deploymentState).To(Equal(expectedConfig))
 		})
+
+		Context("when record with the same CID already exists", func() {
+			var (
+				existingRecord DiskRecord
+			)
+
+			BeforeEach(func() {
+				var err error
+				existingRecord, err = repo.Save("fake-cid", 1024, cloudProperties, ErrorOnDuplicateCID)
+				Expect(err).ToNot(HaveOccurred())
+			})
+
+			It("returns an error without saving another record when ErrorOnDuplicateCID is used", func() {
+				_, err := repo.Save("fake-cid", 2048, biproperty.Map{}, ErrorOnDuplicateCID)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("Failed to save disk cid 'fake-cid', existing record found"))
+
+				records, err := repo.All()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(records).To(Equal([]DiskRecord{existingRecord}))
+			})
+
+			It("returns existing record without modifying it when ReturnExistingOnDuplicateCID is used", func() {
+				record, err := repo.Save("fake-cid", 2048, biproperty.Map{}, ReturnExistingOnDuplicateCID)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(record).To(Equal(existingRecord))
+
+				records, err := repo.All()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(records).To(Equal([]DiskRecord{existingRecord}))
+			})
+		})
 	})
 
 	Describe("Find", func() {
 		It("finds existing disk records", func() {
-			savedRecord, err := repo.Save("fake-cid", 1024, cloudProperties)
+			savedRecord, err := repo.Save("fake-cid", 1024, cloudProperties, ErrorOnDuplicateCID)
 			Expect(err).ToNot(HaveOccurred())
 
 			foundRecord, found, err := repo.Find("fake-cid")
@@ -72,7 +115,7 @@ var _ = Describe("DiskRepo", func() {
 		})
 
 		It("when the disk is not in the records, returns not found", func() {
-			_, err := repo.Save("other-cid", 1024, cloudProperties)
+			_, err := repo.Save("other-cid", 1024, cloudProperties, ErrorOnDuplicateCID)
 			Expect(err).ToNot(HaveOccurred())
 
 			_, found, err := repo.Find("fake-cid")
@@ -81,6 +124,58 @@ var _ = Describe("DiskRepo", func() {
 		})
 	})
 
+	Describe("Update", func() {
+		It("updates size and cloud properties of existing record keeping its ID", func() {
+			savedRecord, err := repo.Save("fake-cid", 1024, cloudProperties, ErrorOnDuplicateCID)
+			Expect(err).ToNot(HaveOccurred())
+
+			newCloudProperties := biproperty.Map{"fake-new-key": "fake-new-value"}
+
+			updatedRecord, err := repo.Update(savedRecord.ID, 2048, newCloudProperties)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(updatedRecord).To(Equal(DiskRecord{
+				ID:              savedRecord.ID,
+				CID:             "fake-cid",
+				Size:            2048,
+				CloudProperties: newCloudProperties,
+			}))
+
+			deploymentState, err := deploymentStateService.Load()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(deploymentState.Disks).To(Equal([]DiskRecord{updatedRecord}))
+		})
+
+		It("returns an error if record with given ID does not exist", func() {
+			_, err := repo.Save("fake-cid", 1024, cloudProperties, ErrorOnDuplicateCID)
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = repo.Update("fake-unknown-id", 2048, cloudProperties)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("Verifying disk record exists with id 'fake-unknown-id'"))
+		})
+	})
+
+	Describe("FindByID", func() {
+		It("finds existing disk records by ID", func() {
+			savedRecord, err := repo.Save("fake-cid", 1024, cloudProperties, ErrorOnDuplicateCID)
+			Expect(err).ToNot(HaveOccurred())
+
+			foundRecord, found, err := repo.FindByID(savedRecord.ID)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(found).To(BeTrue())
+			Expect(foundRecord).To(Equal(savedRecord))
+		})
+
+		It("when the disk is not in the records, returns not found", func() {
+			_, err := repo.Save("fake-cid", 1024, cloudProperties, ErrorOnDuplicateCID)
+			Expect(err).ToNot(HaveOccurred())
+
+			_, found, err := repo.FindByID("fake-unknown-id")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(found).To(BeFalse())
+		})
+	})
+
 	Describe("UpdateCurrent", func() {
 		Context("when a disk record exists with the same ID", func() {
 			var (
@@ -88,7 +183,7 @@ var _ = Describe("DiskRepo", func() {
 			)
 
 			BeforeEach(func() {
-				record, err := repo.Save("fake-cid", 1024, cloudProperties)
+				record, err := repo.Save("fake-cid", 1024, cloudProperties, ErrorOnDuplicateCID)
 				Expect(err).ToNot(HaveOccurred())
 				recordID = record.ID
 			})
@@ -106,7 +201,7 @@ var _ = Describe("DiskRepo", func() {
 
 		Context("when a disk record does not exists with the same ID", func() {
 			BeforeEach(func() {
-				_, err := repo.Save("fake-cid", 1024, cloudProperties)
+				_, err := repo.Save("fake-cid", 1024, cloudProperties, ErrorOnDuplicateCID)
 				Expect(err).ToNot(HaveOccurred())
 			})
 
@@ -124,10 +219,10 @@ var _ = Describe("DiskRepo", func() {
 				diskID2 string
 			)
 			BeforeEach(func() {
-				_, err := repo.Save("fake-cid-1", 1024, cloudProperties)
+				_, err := repo.Save("fake-cid-1", 1024, cloudProperties, ErrorOnDuplicateCID)
 				Expect(err).ToNot(HaveOccurred())
 
-				record, err := repo.Save("fake-cid-2", 1024, cloudProperties)
+				record, err := repo.Save("fake-cid-2", 1024, cloudProperties, ErrorOnDuplicateCID)
 				Expect(err).ToNot(HaveOccurred())
 				diskID2 = record.ID
 
@@ -149,7 +244,7 @@ var _ = Describe("DiskRepo", func() {
 
 		Context("when current disk does not exist", func() {
 			BeforeEach(func() {
-				_, err := repo.Save("fake-cid", 1024, cloudProperties)
+				_, err := repo.Save("fake-cid", 1024, cloudProperties, ErrorOnDuplicateCID)
 				Expect(err).ToNot(HaveOccurred())
 			})
 
@@ -177,10 +272,10 @@ var _ = Describe("DiskRepo", func() {
 
 		BeforeEach(func() {
 			var err error
-			firstDisk, err = repo.Save("fake-cid-1", 1024, cloudProperties)
+			firstDisk, err = repo.Save("fake-cid-1", 1024, cloudProperties, ErrorOnDuplicateCID)
 			Expect(err).ToNot(HaveOccurred())
 
-			secondDisk, err = repo.Save("fake-cid-2", 2048, cloudProperties)
+			secondDisk, err = repo.Save("fake-cid-2", 2048, cloudProperties, ErrorOnDuplicateCID)
 			Expect(err).ToNot(HaveOccurred())
 		})
 
@@ -192,6 +287,45 @@ var _ = Describe("DiskRepo", func() {
 				secondDisk,
 			}))
 		})
+
+		It("returns disks sorted by CID and then by ID regardless of saving order", func() {
+			err := repo.Delete(firstDisk)
+			Expect(err).ToNot(HaveOccurred())
+
+			thirdDisk, err := repo.Save("fake-cid-0", 512, cloudProperties, ErrorOnDuplicateCID)
+			Expect(err).ToNot(HaveOccurred())
+
+			firstDisk, err = repo.Save("fake-cid-1", 1024, cloudProperties, ErrorOnDuplicateCID)
+			Expect(err).ToNot(HaveOccurred())
+
+			disks, err := repo.All()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(disks).To(Equal([]DiskRecord{
+				thirdDisk,
+				firstDisk,
+				secondDisk,
+			}))
+		})
+
+		It("orders disks with the same CID by ID", func() {
+			deploymentState, err := deploymentStateService.Load()
+			Expect(err).ToNot(HaveOccurred())
+
+			deploymentState.Disks = []DiskRecord{
+				{ID: "fake-uuid-b", CID: "fake-cid"},
+				{ID: "fake-uuid-a", CID: "fake-cid"},
+			}
+
+			err = deploymentStateService.Save(deploymentState)
+			Expect(err).ToNot(HaveOccurred())
+
+			disks, err := repo.All()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(disks).To(Equal([]DiskRecord{
+				{ID: "fake-uuid-a", CID: "fake-cid"},
+				{ID: "fake-uuid-b", CID: "fake-cid"},
+			}))
+		})
 	})
 
 	Describe("Delete", func() {
@@ -203,10 +337,10 @@ var _ = Describe("DiskRepo", func() {
 		BeforeEach(func() {
 			var err error
 
-			firstDisk, err = repo.Save("fake-cid-1", 1024, cloudProperties)
+			firstDisk, err = repo.Save("fake-cid-1", 1024, cloudProperties, ErrorOnDuplicateCID)
 			Expect(err).ToNot(HaveOccurred())
 
-			secondDisk, err = repo.Save("fake-cid-2", 2048, cloudProperties)
+			secondDisk, err = repo.Save("fake-cid-2", 2048, cloudProperties, ErrorOnDuplicateCID)
 			Expect(err).ToNot(HaveOccurred())
 		})
 
@@ -244,6 +378,86 @@ var _ = Describe("DiskRepo", func() {
 		})
 	})
 
+	Describe("DeleteByCID", func() {
+		var (
+			firstDisk  DiskRecord
+			secondDisk DiskRecord
+		)
+
+		BeforeEach(func() {
+			var err error
+
+			firstDisk, err = repo.Save("fake-cid-1", 1024, cloudProperties, ErrorOnDuplicateCID)
+			Expect(err).ToNot(HaveOccurred())
+
+			secondDisk, err = repo.Save("fake-cid-2", 2048, cloudProperties, ErrorOnDuplicateCID)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("removes the disk record with given cid and reports it was deleted", func() {
+			deleted, err := repo.DeleteByCID("fake-cid-1")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(deleted).To(BeTrue())
+
+			disks, err := repo.All()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(disks).To(Equal([]DiskRecord{
+				secondDisk,
+			}))
+		})
+
+		It("reports nothing was deleted if there is no disk record with given cid", func() {
+			deleted, err := repo.DeleteByCID("fake-unknown-cid")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(deleted).To(BeFalse())
+
+			disks, err := repo.All()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(disks).To(Equal([]DiskRecord{
+				firstDisk,
+				secondDisk,
+			}))
+		})
+
+		Context("when the disk to be deleted is also the current disk", func() {
+			BeforeEach(func() {
+				err := repo.UpdateCurrent(firstDisk.ID)
+				Expect(err).ToNot(HaveOccurred())
+			})
+
+			It("clears the current disk", func() {
+				deleted, err := repo.DeleteByCID("fake-cid-1")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(deleted).To(BeTrue())
+
+				_, found, err := repo.FindCurrent()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(found).To(BeFalse())
+
+				deploymentState, err := deploymentStateService.Load()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(deploymentState.CurrentDiskID).To(BeEmpty())
+			})
+		})
+
+		Context("when a disk other than the current disk is deleted", func() {
+			BeforeEach(func() {
+				err := repo.UpdateCurrent(secondDisk.ID)
+				Expect(err).ToNot(HaveOccurred())
+			})
+
+			It("keeps the current disk", func() {
+				_, err := repo.DeleteByCID("fake-cid-1")
+				Expect(err).ToNot(HaveOccurred())
+
+				currentDisk, found, err := repo.FindCurrent()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(found).To(BeTrue())
+				Expect(currentDisk).To(Equal(secondDisk))
+			})
+		})
+	})
+
 	Describe("ClearCurrent", func() {
 		It("updates disk cid", func() {
 			err := repo.ClearCurrent()
@@ -263,4 +477,512 @@ var _ = Describe("DiskRepo", func() {
 			Expect(found).To(BeFalse())
 		})
 	})
+
+	Describe("MarkAttached", func() {
+		var (
+			record DiskRecord
+		)
+
+		BeforeEach(func() {
+			var err error
+
+			record, err = repo.Save("fake-cid", 1024, cloudProperties, ErrorOnDuplicateCID)
+			Expect(err).ToNot(HaveOccurred())
+
+			err = repo.UpdateCurrent(record.ID)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("records VM that disk is attached to", func() {
+			err := repo.MarkAttached(record.ID, "fake-vm-cid")
+			Expect(err).ToNot(HaveOccurred())
+
+			currentRecord, found, err := repo.FindCurrent()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(found).To(BeTrue())
+			Expect(currentRecord.AttachedToVM).To(Equal("fake-vm-cid"))
+
+			records, err := repo.All()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(records).To(HaveLen(1))
+			Expect(records[0].AttachedToVM).To(Equal("fake-vm-cid"))
+		})
+
+		It("returns an error if record with given ID does not exist", func() {
+			err := repo.MarkAttached("fake-unknown-id", "fake-vm-cid")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("Verifying disk record exists with id 'fake-unknown-id'"))
+		})
+	})
+
+	Describe("MarkDetached", func() {
+		var (
+			record DiskRecord
+		)
+
+		BeforeEach(func() {
+			var err error
+
+			record, err = repo.Save("fake-cid", 1024, cloudProperties, ErrorOnDuplicateCID)
+			Expect(err).ToNot(HaveOccurred())
+
+			err = repo.MarkAttached(record.ID, "fake-vm-cid")
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("clears VM that disk was attached to", func() {
+			err := repo.MarkDetached(record.ID)
+			Expect(err).ToNot(HaveOccurred())
+
+			foundRecord, found, err := repo.FindByID(record.ID)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(found).To(BeTrue())
+			Expect(foundRecord.AttachedToVM).To(BeEmpty())
+		})
+
+		It("keeps orphaned disks attachment state for detecting disks that were never detached", func() {
+			err := repo.Orphan(record.ID)
+			Expect(err).ToNot(HaveOccurred())
+
+			orphanedRecords, err := repo.AllOrphaned()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(orphanedRecords[0].AttachedToVM).To(Equal("fake-vm-cid"))
+		})
+
+		It("returns an error if record with given ID does not exist", func() {
+			err := repo.MarkDetached("fake-unknown-id")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("Verifying disk record exists with id 'fake-unknown-id'"))
+		})
+	})
+
+	Describe("Orphan", func() {
+		var (
+			record DiskRecord
+		)
+
+		BeforeEach(func() {
+			var err error
+
+			record, err = repo.Save("fake-cid", 1024, cloudProperties, ErrorOnDuplicateCID)
+			Expect(err).ToNot(HaveOccurred())
+
+			err = repo.UpdateCurrent(record.ID)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("moves disk record to orphaned disks with a timestamp", func() {
+			err := repo.Orphan(record.ID)
+			Expect(err).ToNot(HaveOccurred())
+
+			records, err := repo.All()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(records).To(BeEmpty())
+
+			orphanedRecords, err := repo.AllOrphaned()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(orphanedRecords).To(Equal([]OrphanDiskRecord{
+				{
+					DiskRecord: record,
+					OrphanedAt: time.Date(2009, time.November, 10, 23, 1, 2, 333, time.UTC),
+				},
+			}))
+		})
+
+		It("clears current disk if orphaned disk was current", func() {
+			err := repo.Orphan(record.ID)
+			Expect(err).ToNot(HaveOccurred())
+
+			_, found, err := repo.FindCurrent()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(found).To(BeFalse())
+		})
+
+		It("returns an error if record with given ID does not exist", func() {
+			err := repo.Orphan("fake-unknown-id")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("Verifying disk record exists with id 'fake-unknown-id'"))
+		})
+	})
+
+	Describe("Transaction", func() {
+		var (
+			record DiskRecord
+		)
+
+		BeforeEach(func() {
+			var err error
+
+			record, err = repo.Save("fake-cid", 1024, cloudProperties, ErrorOnDuplicateCID)
+			Expect(err).ToNot(HaveOccurred())
+
+			err = repo.UpdateCurrent(record.ID)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("persists all changes made within transaction", func() {
+			var newRecord DiskRecord
+
+			err := repo.Transaction(func(tx DiskRepoTx) error {
+				var err error
+
+				newRecord, err = tx.Save("fake-new-cid", 2048, cloudProperties, ErrorOnDuplicateCID)
+				if err != nil {
+					return err
+				}
+
+				err = tx.Orphan(record.ID)
+				if err != nil {
+					return err
+				}
+
+				return tx.UpdateCurrent(newRecord.ID)
+			})
+			Expect(err).ToNot(HaveOccurred())
+
+			records, err := repo.All()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(records).To(Equal([]DiskRecord{newRecord}))
+
+			currentRecord, found, err := repo.FindCurrent()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(found).To(BeTrue())
+			Expect(currentRecord).To(Equal(newRecord))
+
+			orphanedRecords, err := repo.AllOrphaned()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(orphanedRecords).To(HaveLen(1))
+			Expect(orphanedRecords[0].DiskRecord).To(Equal(record))
+		})
+
+		It("allows to read changes made earlier within the same transaction", func() {
+			err := repo.Transaction(func(tx DiskRepoTx) error {
+				tx.ClearCurrent()
+
+				_, found := tx.FindCurrent()
+				Expect(found).To(BeFalse())
+
+				Expect(tx.DeleteByCID("fake-cid")).To(BeTrue())
+
+				_, found = tx.Find("fake-cid")
+				Expect(found).To(BeFalse())
+
+				return nil
+			})
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("does not persist any changes if function returns an error", func() {
+			err := repo.Transaction(func(tx DiskRepoTx) error {
+				err := tx.Orphan(record.ID)
+				Expect(err).ToNot(HaveOccurred())
+
+				return errors.New("fake-tx-err")
+			})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("fake-tx-err"))
+
+			records, err := repo.All()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(records).To(Equal([]DiskRecord{record}))
+
+			_, found, err := repo.FindCurrent()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(found).To(BeTrue())
+
+			orphanedRecords, err := repo.AllOrphaned()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(orphanedRecords).To(BeEmpty())
+		})
+
+		It("does not persist anything if nothing changed", func() {
+			fs.WriteFileError = errors.New("fake-write-error")
+
+			err := repo.Transaction(func(tx DiskRepoTx) error {
+				_, found := tx.FindByID(record.ID)
+				Expect(found).To(BeTrue())
+
+				_, err := tx.Save("fake-cid", 1024, cloudProperties, ReturnExistingOnDuplicateCID)
+				return err
+			})
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("returns an error if persisting changes fails", func() {
+			fs.WriteFileError = errors.New("fake-write-error")
+
+			err := repo.Transaction(func(tx DiskRepoTx) error {
+				tx.ClearCurrent()
+				return nil
+			})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Saving new config"))
+			Expect(err.Error()).To(ContainSubstring("fake-write-error"))
+		})
+	})
+
+	Context("when observer is given", func() {
+		var (
+			observer *recordingDiskRepoObserver
+		)
+
+		BeforeEach(func() {
+			observer = &recordingDiskRepoObserver{}
+			repo = NewDiskRepo(deploymentStateService, DefaultDeploymentName, fakeUUIDGenerator, timeService, observer, logger)
+		})
+
+		It("notifies observer about persisted changes in order", func() {
+			record, err := repo.Save("fake-cid", 1024, cloudProperties, ErrorOnDuplicateCID)
+			Expect(err).ToNot(HaveOccurred())
+
+			err = repo.UpdateCurrent(record.ID)
+			Expect(err).ToNot(HaveOccurred())
+
+			updatedRecord, err := repo.Update(record.ID, 2048, cloudProperties)
+			Expect(err).ToNot(HaveOccurred())
+
+			err = repo.MarkAttached(record.ID, "fake-vm-cid")
+			Expect(err).ToNot(HaveOccurred())
+
+			attachedRecord := updatedRecord
+			attachedRecord.AttachedToVM = "fake-vm-cid"
+
+			err = repo.Delete(attachedRecord)
+			Expect(err).ToNot(HaveOccurred())
+
+			Eventually(observer.Events).Should(Equal([]string{
+				"save fake-uuid-1 fake-cid 1024 ",
+				"current fake-uuid-1",
+				"save fake-uuid-1 fake-cid 2048 ",
+				"save fake-uuid-1 fake-cid 2048 fake-vm-cid",
+				"delete fake-uuid-1",
+				"current ",
+			}))
+		})
+
+		It("notifies observer about orphaned disks and cleared current disk", func() {
+			record, err := repo.Save("fake-cid", 1024, cloudProperties, ErrorOnDuplicateCID)
+			Expect(err).ToNot(HaveOccurred())
+
+			err = repo.Orphan(record.ID)
+			Expect(err).ToNot(HaveOccurred())
+
+			err = repo.ClearCurrent()
+			Expect(err).ToNot(HaveOccurred())
+
+			Eventually(observer.Events).Should(Equal([]string{
+				"save fake-uuid-1 fake-cid 1024 ",
+				"delete fake-uuid-1",
+				"current ",
+			}))
+		})
+
+		It("notifies observer about changes made within transaction once it's persisted", func() {
+			record, err := repo.Save("fake-cid", 1024, cloudProperties, ErrorOnDuplicateCID)
+			Expect(err).ToNot(HaveOccurred())
+
+			err = repo.Transaction(func(tx DiskRepoTx) error {
+				err := tx.UpdateCurrent(record.ID)
+				if err != nil {
+					return err
+				}
+
+				return tx.Orphan(record.ID)
+			})
+			Expect(err).ToNot(HaveOccurred())
+
+			Eventually(observer.Events).Should(Equal([]string{
+				"save fake-uuid-1 fake-cid 1024 ",
+				"current fake-uuid-1",
+				"delete fake-uuid-1",
+				"current ",
+			}))
+		})
+
+		It("does not notify observer about changes made within failed transaction", func() {
+			record, err := repo.Save("fake-cid", 1024, cloudProperties, ErrorOnDuplicateCID)
+			Expect(err).ToNot(HaveOccurred())
+
+			Eventually(observer.Events).Should(HaveLen(1))
+
+			err = repo.Transaction(func(tx DiskRepoTx) error {
+				tx.Delete(record)
+				return errors.New("fake-tx-err")
+			})
+			Expect(err).To(HaveOccurred())
+
+			Consistently(observer.Events).Should(Equal([]string{
+				"save fake-uuid-1 fake-cid 1024 ",
+			}))
+		})
+
+		It("does not notify observer if persisting fails", func() {
+			fs.WriteFileError = errors.New("fake-write-error")
+
+			_, err := repo.Save("fake-cid", 1024, cloudProperties, ErrorOnDuplicateCID)
+			Expect(err).To(HaveOccurred())
+
+			Consistently(observer.Events).Should(BeEmpty())
+		})
+
+		It("does not fail operations if observer returns an error", func() {
+			observer.Err = errors.New("fake-observer-err")
+
+			_, err := repo.Save("fake-cid", 1024, cloudProperties, ErrorOnDuplicateCID)
+			Expect(err).ToNot(HaveOccurred())
+
+			Eventually(observer.Events).Should(HaveLen(1))
+		})
+
+		It("does not wait for observer to handle notifications", func() {
+			blockCh := make(chan struct{})
+			defer close(blockCh)
+
+			observer.BlockCh = blockCh
+
+			for i := 0; i < 3; i++ {
+				_, err := repo.Save(fmt.Sprintf("fake-cid-%d", i), 1024, cloudProperties, ErrorOnDuplicateCID)
+				Expect(err).ToNot(HaveOccurred())
+			}
+
+			records, err := repo.All()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(records).To(HaveLen(3))
+		})
+
+		Describe("Close", func() {
+			var (
+				logBuf *bytes.Buffer
+			)
+
+			BeforeEach(func() {
+				logBuf = bytes.NewBufferString("")
+				logger = boshlog.NewWriterLogger(boshlog.LevelError, logBuf, logBuf)
+				repo = NewDiskRepo(deploymentStateService, DefaultDeploymentName, fakeUUIDGenerator, timeService, observer, logger)
+			})
+
+			It("waits for observer to handle pending notifications", func() {
+				blockCh := make(chan struct{})
+				observer.BlockCh = blockCh
+
+				for i := 0; i < 3; i++ {
+					_, err := repo.Save(fmt.Sprintf("fake-cid-%d", i), 1024, cloudProperties, ErrorOnDuplicateCID)
+					Expect(err).ToNot(HaveOccurred())
+				}
+
+				time.AfterFunc(10*time.Millisecond, func() { close(blockCh) })
+
+				repo.Close()
+
+				Expect(observer.Events()).To(HaveLen(3))
+			})
+
+			It("logs every notification dropped since observer is not keeping up", func() {
+				blockCh := make(chan struct{})
+				observer.BlockCh = blockCh
+
+				for i := 0; i < 105; i++ {
+					_, err := repo.Save(fmt.Sprintf("fake-cid-%d", i), 1024, cloudProperties, ErrorOnDuplicateCID)
+					Expect(err).ToNot(HaveOccurred())
+				}
+
+				close(blockCh)
+				repo.Close()
+
+				dropped := strings.Count(logBuf.String(), "since observer is not keeping up")
+				Expect(dropped).To(BeNumerically(">=", 4))
+				Expect(len(observer.Events()) + dropped).To(Equal(105))
+				Expect(logBuf.String()).To(ContainSubstring(fmt.Sprintf("Dropped %d notification(s)", dropped)))
+			})
+
+			It("logs notifications about changes made after closing as dropped", func() {
+				repo.Close()
+
+				_, err := repo.Save("fake-cid", 1024, cloudProperties, ErrorOnDuplicateCID)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(logBuf.String()).To(ContainSubstring(
+					"Dropping notification about saved disk record 'fake-uuid-1' since disk repo is closed"))
+
+				Consistently(observer.Events).Should(BeEmpty())
+			})
+
+			It("can be called more than once", func() {
+				repo.Close()
+				repo.Close()
+			})
+		})
+	})
+
+	It("can be closed without observer", func() {
+		repo.Close()
+	})
+
+	Context("when scoped to a named deployment", func() {
+		var otherRepo DiskRepo
+
+		BeforeEach(func() {
+			otherRepo = NewDiskRepo(deploymentStateService, "fake-other-deployment", fakeUUIDGenerator, timeService, nil, logger)
+		})
+
+		It("keeps disk records separate from other deployments", func() {
+			_, err := repo.Save("fake-cid", 1024, cloudProperties, ErrorOnDuplicateCID)
+			Expect(err).ToNot(HaveOccurred())
+
+			otherRecord, err := otherRepo.Save("fake-other-cid", 2048, cloudProperties, ErrorOnDuplicateCID)
+			Expect(err).ToNot(HaveOccurred())
+
+			records, err := otherRepo.All()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(records).To(Equal([]DiskRecord{otherRecord}))
+
+			_, found, err := repo.Find("fake-other-cid")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(found).To(BeFalse())
+
+			deploymentState, err := deploymentStateService.LoadDeployment("fake-other-deployment")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(deploymentState.Disks).To(Equal([]DiskRecord{otherRecord}))
+		})
+	})
 })
+
+type recordingDiskRepoObserver struct {
+	Err     error
+	BlockCh chan struct{}
+
+	events     []string
+	eventsLock sync.Mutex
+}
+
+func (o *recordingDiskRepoObserver) OnSave(record DiskRecord) error {
+	return o.record(fmt.Sprintf("save %s %s %d %s", record.ID, record.CID, record.Size, record.AttachedToVM))
+}
+
+func (o *recordingDiskRepoObserver) OnDelete(record DiskRecord) error {
+	return o.record("delete " + record.ID)
+}
+
+func (o *recordingDiskRepoObserver) OnCurrentChanged(id string) error {
+	return o.record("current " + id)
+}
+
+func (o *recordingDiskRepoObserver) Events() []string {
+	o.eventsLock.Lock()
+	defer o.eventsLock.Unlock()
+
+	return append([]string{}, o.events...)
+}
+
+func (o *recordingDiskRepoObserver) record(event string) error {
+	if o.BlockCh != nil {
+		<-o.BlockCh
+	}
+
+	o.eventsLock.Lock()
+	defer o.eventsLock.Unlock()
+
+	o.events = append(o.events, event)
+
+	return o.Err
+}
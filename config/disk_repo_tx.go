@@ -0,0 +1,217 @@
+package config
+
+import (
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	biproperty "github.com/cloudfoundry/bosh-utils/property"
+	boshuuid "github.com/cloudfoundry/bosh-utils/uuid"
+	"github.com/pivotal-golang/clock"
+)
+
+// DiskRepoTx changes disk records in memory; changes made within
+// DiskRepo.Transaction are persisted together once it commits
+type DiskRepoTx interface {
+	UpdateCurrent(diskID string) error
+	FindCurrent() (DiskRecord, bool)
+	ClearCurrent()
+	Save(cid string, size int, cloudProperties biproperty.Map, mode DiskRepoSaveMode) (DiskRecord, error)
+	Find(cid string) (DiskRecord, bool)
+	FindByID(id string) (DiskRecord, bool)
+	Update(id string, size int, cloudProperties biproperty.Map) (DiskRecord, error)
+	Delete(DiskRecord)
+	DeleteByCID(cid string) bool
+	Orphan(id string) error
+	MarkAttached(id, vmCID string) error
+	MarkDetached(id string) error
+}
+
+type diskRepoTx struct {
+	state         DeploymentState
+	uuidGenerator boshuuid.Generator
+	timeService   clock.Clock
+
+	// changed is false if state does not need to be persisted
+	changed bool
+
+	// notifications are sent in order only after changes are persisted
+	notifications []func(*diskRepoNotifier)
+}
+
+func newDiskRepoTx(state DeploymentState, uuidGenerator boshuuid.Generator, timeService clock.Clock) *diskRepoTx {
+	return &diskRepoTx{
+		state:         state,
+		uuidGenerator: uuidGenerator,
+		timeService:   timeService,
+	}
+}
+
+func (tx *diskRepoTx) Save(cid string, size int, cloudProperties biproperty.Map, mode DiskRepoSaveMode) (DiskRecord, error) {
+	oldRecord, found := tx.Find(cid)
+	if found {
+		if mode == ReturnExistingOnDuplicateCID {
+			return oldRecord, nil
+		}
+
+		return DiskRecord{}, bosherr.Errorf("Failed to save disk cid '%s', existing record found '%#v'", cid, oldRecord)
+	}
+
+	newRecord := DiskRecord{
+		CID:             cid,
+		Size:            size,
+		CloudProperties: cloudProperties,
+	}
+
+	var err error
+
+	newRecord.ID, err = tx.uuidGenerator.Generate()
+	if err != nil {
+		return newRecord, bosherr.WrapError(err, "Generating disk id")
+	}
+
+	tx.state.Disks = append(tx.state.Disks, newRecord)
+	tx.saved(newRecord)
+
+	return newRecord, nil
+}
+
+func (tx *diskRepoTx) Update(id string, size int, cloudProperties biproperty.Map) (DiskRecord, error) {
+	return tx.updateRecord(id, func(record *DiskRecord) {
+		record.Size = size
+		record.CloudProperties = cloudProperties
+	})
+}
+
+func (tx *diskRepoTx) FindCurrent() (DiskRecord, bool) {
+	if tx.state.CurrentDiskID == "" {
+		return DiskRecord{}, false
+	}
+
+	return tx.FindByID(tx.state.CurrentDiskID)
+}
+
+func (tx *diskRepoTx) UpdateCurrent(diskID string) error {
+	_, found := tx.FindByID(diskID)
+	if !found {
+		return bosherr.Errorf("Verifying disk record exists with id '%s'", diskID)
+	}
+
+	tx.setCurrent(diskID)
+
+	return nil
+}
+
+func (tx *diskRepoTx) ClearCurrent() {
+	tx.setCurrent("")
+}
+
+func (tx *diskRepoTx) Find(cid string) (DiskRecord, bool) {
+	for _, existingRecord := range tx.state.Disks {
+		if existingRecord.CID == cid {
+			return existingRecord, true
+		}
+	}
+
+	return DiskRecord{}, false
+}
+
+func (tx *diskRepoTx) FindByID(id string) (DiskRecord, bool) {
+	for _, existingRecord := range tx.state.Disks {
+		if existingRecord.ID == id {
+			return existingRecord, true
+		}
+	}
+
+	return DiskRecord{}, false
+}
+
+func (tx *diskRepoTx) Delete(diskRecord DiskRecord) {
+	tx.remove(diskRecord)
+}
+
+// DeleteByCID removes disk record with given CID (clearing current disk if it was the one)
+// and returns whether such record was found
+func (tx *diskRepoTx) DeleteByCID(cid string) bool {
+	record, found := tx.Find(cid)
+	if !found {
+		return false
+	}
+
+	tx.Delete(record)
+
+	return true
+}
+
+// Orphan moves disk record to orphaned disks recording when it was orphaned
+func (tx *diskRepoTx) Orphan(id string) error {
+	record, found := tx.FindByID(id)
+	if !found {
+		return bosherr.Errorf("Verifying disk record exists with id '%s'", id)
+	}
+
+	tx.state.OrphanedDisks = append(tx.state.OrphanedDisks, OrphanDiskRecord{
+		DiskRecord: record,
+		OrphanedAt: tx.timeService.Now().UTC(),
+	})
+
+	// Orphaned disk is no longer part of the deployment
+	tx.remove(record)
+
+	return nil
+}
+
+// MarkAttached records that disk is attached to VM with given CID
+func (tx *diskRepoTx) MarkAttached(id, vmCID string) error {
+	_, err := tx.updateRecord(id, func(record *DiskRecord) { record.AttachedToVM = vmCID })
+	return err
+}
+
+// MarkDetached records that disk is not attached to any VM
+func (tx *diskRepoTx) MarkDetached(id string) error {
+	_, err := tx.updateRecord(id, func(record *DiskRecord) { record.AttachedToVM = "" })
+	return err
+}
+
+func (tx *diskRepoTx) updateRecord(id string, updateFunc func(*DiskRecord)) (DiskRecord, error) {
+	for i := range tx.state.Disks {
+		if tx.state.Disks[i].ID == id {
+			updateFunc(&tx.state.Disks[i])
+			tx.saved(tx.state.Disks[i])
+			return tx.state.Disks[i], nil
+		}
+	}
+
+	return DiskRecord{}, bosherr.Errorf("Verifying disk record exists with id '%s'", id)
+}
+
+// remove drops disk record and clears current disk if it was the one
+func (tx *diskRepoTx) remove(diskRecord DiskRecord) {
+	newRecords := []DiskRecord{}
+
+	for _, record := range tx.state.Disks {
+		if record.ID != diskRecord.ID {
+			newRecords = append(newRecords, record)
+		}
+	}
+
+	tx.state.Disks = newRecords
+	tx.changed = true
+	tx.notify(func(n *diskRepoNotifier) { n.Deleted(diskRecord) })
+
+	if tx.state.CurrentDiskID == diskRecord.ID {
+		tx.setCurrent("")
+	}
+}
+
+func (tx *diskRepoTx) setCurrent(id string) {
+	tx.state.CurrentDiskID = id
+	tx.changed = true
+	tx.notify(func(n *diskRepoNotifier) { n.CurrentChanged(id) })
+}
+
+func (tx *diskRepoTx) saved(record DiskRecord) {
+	tx.changed = true
+	tx.notify(func(n *diskRepoNotifier) { n.Saved(record) })
+}
+
+func (tx *diskRepoTx) notify(notification func(*diskRepoNotifier)) {
+	tx.notifications = append(tx.notifications, notification)
+}
@@ -14,12 +14,38 @@ type FakeDiskRepo struct {
 	SaveInputs []DiskRepoSaveInput
 	saveOutput diskRepoSaveOutput
 
-	findOutput map[string]diskRepoFindOutput
+	findOutput     map[string]diskRepoFindOutput
+	findByIDOutput map[string]diskRepoFindOutput
+
+	UpdateInputs []DiskRepoUpdateInput
+	updateOutput diskRepoSaveOutput
 
 	DeleteInputs []DiskRepoDeleteInput
 	DeleteErr    error
 
+	DeleteByCIDInputs []string
+	DeleteByCIDFound  bool
+	DeleteByCIDErr    error
+
 	allOutput diskRepoAllOutput
+
+	OrphanInputs []string
+	OrphanErr    error
+
+	AllOrphanedRecords []biconfig.OrphanDiskRecord
+	AllOrphanedErr     error
+
+	MarkAttachedInputs []DiskRepoMarkAttachedInput
+	MarkAttachedErr    error
+
+	MarkDetachedInputs []string
+	MarkDetachedErr    error
+
+	// TransactionCallCount counts transactions; changes made within them
+	// are recorded in the same inputs as changes made directly
+	TransactionCallCount int
+
+	Closed bool
 }
 
 type DiskRepoUpdateCurrentInput struct {
@@ -36,6 +62,7 @@ type DiskRepoSaveInput struct {
 	CID             string
 	Size            int
 	CloudProperties biproperty.Map
+	Mode            biconfig.DiskRepoSaveMode
 }
 
 type diskRepoSaveOutput struct {
@@ -43,6 +70,17 @@ type diskRepoSaveOutput struct {
 	err        error
 }
 
+type DiskRepoUpdateInput struct {
+	ID              string
+	Size            int
+	CloudProperties biproperty.Map
+}
+
+type DiskRepoMarkAttachedInput struct {
+	ID    string
+	VMCID string
+}
+
 type DiskRepoDeleteInput struct {
 	DiskRecord biconfig.DiskRecord
 }
@@ -62,8 +100,10 @@ func NewFakeDiskRepo() *FakeDiskRepo {
 	return &FakeDiskRepo{
 		UpdateCurrentInputs: []DiskRepoUpdateCurrentInput{},
 		SaveInputs:          []DiskRepoSaveInput{},
+		UpdateInputs:        []DiskRepoUpdateInput{},
 		DeleteInputs:        []DiskRepoDeleteInput{},
 		findOutput:          map[string]diskRepoFindOutput{},
+		findByIDOutput:      map[string]diskRepoFindOutput{},
 	}
 }
 
@@ -82,20 +122,35 @@ func (r *FakeDiskRepo) ClearCurrent() error {
 	return nil
 }
 
-func (r *FakeDiskRepo) Save(cid string, size int, cloudProperties biproperty.Map) (biconfig.DiskRecord, error) {
+func (r *FakeDiskRepo) Save(cid string, size int, cloudProperties biproperty.Map, mode biconfig.DiskRepoSaveMode) (biconfig.DiskRecord, error) {
 	r.SaveInputs = append(r.SaveInputs, DiskRepoSaveInput{
 		CID:             cid,
 		Size:            size,
 		CloudProperties: cloudProperties,
+		Mode:            mode,
 	})
 
 	return r.saveOutput.diskRecord, r.saveOutput.err
 }
 
+func (r *FakeDiskRepo) Update(id string, size int, cloudProperties biproperty.Map) (biconfig.DiskRecord, error) {
+	r.UpdateInputs = append(r.UpdateInputs, DiskRepoUpdateInput{
+		ID:              id,
+		Size:            size,
+		CloudProperties: cloudProperties,
+	})
+
+	return r.updateOutput.diskRecord, r.updateOutput.err
+}
+
 func (r *FakeDiskRepo) Find(cid string) (biconfig.DiskRecord, bool, error) {
 	return r.findOutput[cid].diskRecord, r.findOutput[cid].found, r.findOutput[cid].err
 }
 
+func (r *FakeDiskRepo) FindByID(id string) (biconfig.DiskRecord, bool, error) {
+	return r.findByIDOutput[id].diskRecord, r.findByIDOutput[id].found, r.findByIDOutput[id].err
+}
+
 func (r *FakeDiskRepo) All() ([]biconfig.DiskRecord, error) {
 	return r.allOutput.diskRecords, r.allOutput.err
 }
@@ -108,6 +163,85 @@ func (r *FakeDiskRepo) Delete(diskRecord biconfig.DiskRecord) error {
 	return r.DeleteErr
 }
 
+func (r *FakeDiskRepo) DeleteByCID(cid string) (bool, error) {
+	r.DeleteByCIDInputs = append(r.DeleteByCIDInputs, cid)
+	return r.DeleteByCIDFound, r.DeleteByCIDErr
+}
+
+func (r *FakeDiskRepo) Orphan(id string) error {
+	r.OrphanInputs = append(r.OrphanInputs, id)
+	return r.OrphanErr
+}
+
+func (r *FakeDiskRepo) AllOrphaned() ([]biconfig.OrphanDiskRecord, error) {
+	return r.AllOrphanedRecords, r.AllOrphanedErr
+}
+
+func (r *FakeDiskRepo) MarkAttached(id, vmCID string) error {
+	r.MarkAttachedInputs = append(r.MarkAttachedInputs, DiskRepoMarkAttachedInput{ID: id, VMCID: vmCID})
+	return r.MarkAttachedErr
+}
+
+func (r *FakeDiskRepo) MarkDetached(id string) error {
+	r.MarkDetachedInputs = append(r.MarkDetachedInputs, id)
+	return r.MarkDetachedErr
+}
+
+func (r *FakeDiskRepo) Close() {
+	r.Closed = true
+}
+
+func (r *FakeDiskRepo) Transaction(fn func(biconfig.DiskRepoTx) error) error {
+	r.TransactionCallCount++
+	return fn(fakeDiskRepoTx{r})
+}
+
+// fakeDiskRepoTx delegates to the fake repo ignoring errors of operations
+// that cannot fail within a transaction
+type fakeDiskRepoTx struct {
+	repo *FakeDiskRepo
+}
+
+func (tx fakeDiskRepoTx) UpdateCurrent(diskID string) error { return tx.repo.UpdateCurrent(diskID) }
+
+func (tx fakeDiskRepoTx) FindCurrent() (biconfig.DiskRecord, bool) {
+	record, found, _ := tx.repo.FindCurrent()
+	return record, found
+}
+
+func (tx fakeDiskRepoTx) ClearCurrent() { tx.repo.ClearCurrent() }
+
+func (tx fakeDiskRepoTx) Save(cid string, size int, cloudProperties biproperty.Map, mode biconfig.DiskRepoSaveMode) (biconfig.DiskRecord, error) {
+	return tx.repo.Save(cid, size, cloudProperties, mode)
+}
+
+func (tx fakeDiskRepoTx) Find(cid string) (biconfig.DiskRecord, bool) {
+	record, found, _ := tx.repo.Find(cid)
+	return record, found
+}
+
+func (tx fakeDiskRepoTx) FindByID(id string) (biconfig.DiskRecord, bool) {
+	record, found, _ := tx.repo.FindByID(id)
+	return record, found
+}
+
+func (tx fakeDiskRepoTx) Update(id string, size int, cloudProperties biproperty.Map) (biconfig.DiskRecord, error) {
+	return tx.repo.Update(id, size, cloudProperties)
+}
+
+func (tx fakeDiskRepoTx) Delete(diskRecord biconfig.DiskRecord) { tx.repo.Delete(diskRecord) }
+
+func (tx fakeDiskRepoTx) DeleteByCID(cid string) bool {
+	found, _ := tx.repo.DeleteByCID(cid)
+	return found
+}
+
+func (tx fakeDiskRepoTx) Orphan(id string) error { return tx.repo.Orphan(id) }
+
+func (tx fakeDiskRepoTx) MarkAttached(id, vmCID string) error { return tx.repo.MarkAttached(id, vmCID) }
+
+func (tx fakeDiskRepoTx) MarkDetached(id string) error { return tx.repo.MarkDetached(id) }
+
 func (r *FakeDiskRepo) SetUpdateBehavior(err error) {
 	r.updateErr = err
 }
@@ -127,6 +261,13 @@ func (r *FakeDiskRepo) SetSaveBehavior(diskRecord biconfig.DiskRecord, found boo
 	}
 }
 
+func (r *FakeDiskRepo) SetUpdateRecordBehavior(diskRecord biconfig.DiskRecord, err error) {
+	r.updateOutput = diskRepoSaveOutput{
+		diskRecord: diskRecord,
+		err:        err,
+	}
+}
+
 func (r *FakeDiskRepo) SetFindBehavior(cid string, diskRecord biconfig.DiskRecord, found bool, err error) {
 	r.findOutput[cid] = diskRepoFindOutput{
 		diskRecord: diskRecord,
@@ -135,6 +276,14 @@ func (r *FakeDiskRepo) SetFindBehavior(cid string, diskRecord biconfig.DiskRecor
 	}
 }
 
+func (r *FakeDiskRepo) SetFindByIDBehavior(id string, diskRecord biconfig.DiskRecord, found bool, err error) {
+	r.findByIDOutput[id] = diskRepoFindOutput{
+		diskRecord: diskRecord,
+		found:      found,
+		err:        err,
+	}
+}
+
 func (r *FakeDiskRepo) SetAllBehavior(diskRecords []biconfig.DiskRecord, err error) {
 	r.allOutput = diskRepoAllOutput{
 		diskRecords: diskRecords,
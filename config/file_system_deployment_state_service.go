@@ -3,8 +3,13 @@ package config
 import (
 	"encoding/json"
 	"fmt"
+	"os"
 	"path/filepath"
+	"runtime"
+	"strconv"
 	"strings"
+	"syscall"
+	"time"
 
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
@@ -12,17 +17,27 @@ import (
 	boshuuid "github.com/cloudfoundry/bosh-utils/uuid"
 )
 
+const deploymentStateLockRetryInterval = 500 * time.Millisecond
+
+// DefaultDeploymentStateBackups is a number of previous deployment state file
+// versions kept next to it (as '<path>.bak.1' being the newest, '<path>.bak.2', etc.)
+const DefaultDeploymentStateBackups = 5
+
 type fileSystemDeploymentStateService struct {
 	configPath    string
+	backups       int
 	fs            boshsys.FileSystem
 	uuidGenerator boshuuid.Generator
 	logger        boshlog.Logger
 	logTag        string
 }
 
-func NewFileSystemDeploymentStateService(fs boshsys.FileSystem, uuidGenerator boshuuid.Generator, logger boshlog.Logger, deploymentStatePath string) DeploymentStateService {
+// NewFileSystemDeploymentStateService returns service that backs up deployment state file
+// once it's locked (i.e. once per command) keeping given number of backups; 0 disables backups
+func NewFileSystemDeploymentStateService(fs boshsys.FileSystem, uuidGenerator boshuuid.Generator, logger boshlog.Logger, deploymentStatePath string, backups int) DeploymentStateService {
 	return &fileSystemDeploymentStateService{
 		configPath:    deploymentStatePath,
+		backups:       backups,
 		fs:            fs,
 		uuidGenerator: uuidGenerator,
 		logger:        logger,
@@ -48,56 +63,220 @@ func (s *fileSystemDeploymentStateService) Exists() bool {
 }
 
 func (s *fileSystemDeploymentStateService) Load() (DeploymentState, error) {
+	return s.LoadDeployment(DefaultDeploymentName)
+}
+
+func (s *fileSystemDeploymentStateService) Save(deploymentState DeploymentState) error {
+	return s.SaveDeployment(DefaultDeploymentName, deploymentState)
+}
+
+func (s *fileSystemDeploymentStateService) LoadDeployment(name string) (DeploymentState, error) {
 	if s.configPath == "" {
 		panic("configPath not yet set!")
 	}
 
-	s.logger.Debug(s.logTag, "Loading deployment state: %s", s.configPath)
-
-	deploymentState := &DeploymentState{}
-
-	if s.fs.FileExists(s.configPath) {
-		deploymentStateFileContents, err := s.fs.ReadFile(s.configPath)
-		if err != nil {
-			return DeploymentState{}, bosherr.WrapErrorf(err, "Reading deployment state file '%s'", s.configPath)
-		}
-		s.logger.Debug(s.logTag, "Deployment File Contents %#s", deploymentStateFileContents)
+	s.logger.Debug(s.logTag, "Loading deployment state for '%s': %s", name, s.configPath)
 
-		err = json.Unmarshal(deploymentStateFileContents, deploymentState)
-		if err != nil {
-			return DeploymentState{}, bosherr.WrapErrorf(err, "Unmarshalling deployment state file '%s'", s.configPath)
-		}
+	stateFile, err := s.loadFile()
+	if err != nil {
+		return DeploymentState{}, err
 	}
 
-	err := s.initDefaults(deploymentState)
+	deploymentState := stateFile.deployment(name)
+
+	err = s.initDefaults(name, &deploymentState)
 	if err != nil {
 		return DeploymentState{}, bosherr.WrapErrorf(err, "Initializing deployment state defaults")
 	}
 
-	return *deploymentState, nil
+	return deploymentState, nil
 }
 
-func (s *fileSystemDeploymentStateService) Save(deploymentState DeploymentState) error {
+func (s *fileSystemDeploymentStateService) SaveDeployment(name string, deploymentState DeploymentState) error {
 	if s.configPath == "" {
 		panic("configPath not yet set!")
 	}
 
-	s.logger.Debug(s.logTag, "Saving deployment state %#v", deploymentState)
+	s.logger.Debug(s.logTag, "Saving deployment state for '%s' %#v", name, deploymentState)
 
-	jsonContent, err := json.MarshalIndent(deploymentState, "", "    ")
+	stateFile, err := s.loadFile()
+	if err != nil {
+		return err
+	}
+
+	stateFile.setDeployment(name, deploymentState)
+
+	return s.saveFile(stateFile)
+}
+
+// deploymentStateFile is the on-disk layout of the deployment state file.
+// State of the default deployment is kept at the top level as it was before
+// multiple deployments were supported so that older versions keep reading it;
+// state of other deployments is kept under a key that older versions ignore.
+type deploymentStateFile struct {
+	SchemaVersion int `json:"schema_version"`
+	DeploymentState
+	Deployments map[string]DeploymentState `json:"deployments,omitempty"`
+}
+
+func (f deploymentStateFile) deployment(name string) DeploymentState {
+	if name == DefaultDeploymentName {
+		return f.DeploymentState
+	}
+
+	return f.Deployments[name]
+}
+
+func (f *deploymentStateFile) setDeployment(name string, deploymentState DeploymentState) {
+	if name == DefaultDeploymentName {
+		f.DeploymentState = deploymentState
+		return
+	}
+
+	if f.Deployments == nil {
+		f.Deployments = map[string]DeploymentState{}
+	}
+
+	f.Deployments[name] = deploymentState
+}
+
+func (s *fileSystemDeploymentStateService) loadFile() (deploymentStateFile, error) {
+	stateFile := deploymentStateFile{SchemaVersion: DeploymentStateSchemaVersion}
+
+	if !s.fs.FileExists(s.configPath) {
+		return stateFile, nil
+	}
+
+	deploymentStateFileContents, err := s.fs.ReadFile(s.configPath)
+	if err != nil {
+		return deploymentStateFile{}, bosherr.WrapErrorf(err, "Reading deployment state file '%s'", s.configPath)
+	}
+	s.logger.Debug(s.logTag, "Deployment File Contents %#s", deploymentStateFileContents)
+
+	stateFile.SchemaVersion = 0
+
+	err = json.Unmarshal(deploymentStateFileContents, &stateFile)
+	if err != nil {
+		return deploymentStateFile{}, bosherr.WrapErrorf(err, "Unmarshalling deployment state file '%s'", s.configPath)
+	}
+
+	err = s.migrateSchema(&stateFile)
+	if err != nil {
+		return deploymentStateFile{}, err
+	}
+
+	return stateFile, nil
+}
+
+func (s *fileSystemDeploymentStateService) saveFile(stateFile deploymentStateFile) error {
+	stateFile.SchemaVersion = DeploymentStateSchemaVersion
+
+	jsonContent, err := json.MarshalIndent(stateFile, "", "    ")
 	if err != nil {
 		return bosherr.WrapError(err, "Marshalling deployment state into JSON")
 	}
 
-	err = s.fs.WriteFile(s.configPath, jsonContent)
+	// Write to a sibling file first and rename it over the state file
+	// so that an interrupted write does not truncate existing state
+	tmpPath := s.configPath + ".tmp"
+
+	err = s.fs.WriteFile(tmpPath, jsonContent)
 	if err != nil {
 		return bosherr.WrapErrorf(err, "Writing deployment state file '%s'", s.configPath)
 	}
 
+	err = s.fs.Rename(tmpPath, s.configPath)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Replacing deployment state file '%s'", s.configPath)
+	}
+
+	return nil
+}
+
+// backup copies current deployment state file to '<path>.bak.1' shifting
+// previous backups by one and pruning the oldest ones beyond configured number.
+// State file is copied (not moved) so that it's never missing.
+func (s *fileSystemDeploymentStateService) backup() error {
+	if s.backups <= 0 || !s.fs.FileExists(s.configPath) {
+		return nil
+	}
+
+	err := s.pruneBackups(s.backups - 1)
+	if err != nil {
+		return err
+	}
+
+	for i := s.backups - 1; i >= 1; i-- {
+		if s.fs.FileExists(s.backupPath(i)) {
+			err = s.fs.Rename(s.backupPath(i), s.backupPath(i+1))
+			if err != nil {
+				return bosherr.WrapErrorf(err, "Rotating deployment state backup '%s'", s.backupPath(i))
+			}
+		}
+	}
+
+	err = s.fs.CopyFile(s.configPath, s.backupPath(1))
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Backing up deployment state file '%s'", s.configPath)
+	}
+
+	return nil
+}
+
+// pruneBackups deletes backups older than keep newest ones
+func (s *fileSystemDeploymentStateService) pruneBackups(keep int) error {
+	paths, err := s.fs.Glob(s.configPath + ".bak.*")
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Listing deployment state backups of '%s'", s.configPath)
+	}
+
+	for _, path := range paths {
+		i, err := strconv.Atoi(strings.TrimPrefix(path, s.configPath+".bak."))
+		if err != nil || i <= keep {
+			continue
+		}
+
+		err = s.fs.RemoveAll(path)
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Deleting deployment state backup '%s'", path)
+		}
+	}
+
+	return nil
+}
+
+func (s *fileSystemDeploymentStateService) backupPath(i int) string {
+	return fmt.Sprintf("%s.bak.%d", s.configPath, i)
+}
+
+// migrateSchema upgrades deployment state saved by older versions one version at a time
+// in memory (file is rewritten only when state is saved); state saved by newer versions
+// is rejected to avoid losing unknown data
+func (s *fileSystemDeploymentStateService) migrateSchema(stateFile *deploymentStateFile) error {
+	if stateFile.SchemaVersion > DeploymentStateSchemaVersion {
+		return bosherr.Errorf(
+			"Expected deployment state file '%s' to have schema version %d or older but was %d",
+			s.configPath, DeploymentStateSchemaVersion, stateFile.SchemaVersion)
+	}
+
+	for stateFile.SchemaVersion < DeploymentStateSchemaVersion {
+		s.logger.Info(s.logTag, "Migrating deployment state from schema version %d", stateFile.SchemaVersion)
+
+		switch stateFile.SchemaVersion {
+		case 0:
+			// Version 0 (unversioned state) has the same shape as version 1
+		case 1:
+			// Version 1 kept state of the default deployment only
+			// which version 2 keeps at the same place
+		}
+
+		stateFile.SchemaVersion++
+	}
+
 	return nil
 }
 
-func (s *fileSystemDeploymentStateService) initDefaults(deploymentState *DeploymentState) error {
+func (s *fileSystemDeploymentStateService) initDefaults(name string, deploymentState *DeploymentState) error {
 	if deploymentState.DirectorID == "" {
 		uuid, err := s.uuidGenerator.Generate()
 		if err != nil {
@@ -105,7 +284,7 @@ func (s *fileSystemDeploymentStateService) initDefaults(deploymentState *Deploym
 		}
 		deploymentState.DirectorID = uuid
 
-		err = s.Save(*deploymentState)
+		err = s.SaveDeployment(name, *deploymentState)
 		if err != nil {
 			return bosherr.WrapError(err, "Saving deployment state")
 		}
@@ -114,10 +293,130 @@ func (s *fileSystemDeploymentStateService) initDefaults(deploymentState *Deploym
 	return nil
 }
 
+// Cleanup forgets state of the default deployment and deletes the deployment state file
+// with its backups once it does not keep state of any other deployment
 func (s *fileSystemDeploymentStateService) Cleanup() error {
-	err := s.fs.RemoveAll(s.configPath)
+	stateFile, err := s.loadFile()
+	if err != nil {
+		return err
+	}
+
+	stateFile.DeploymentState = DeploymentState{}
+
+	if len(stateFile.Deployments) > 0 {
+		return s.saveFile(stateFile)
+	}
+
+	err = s.fs.RemoveAll(s.configPath)
 	if err != nil {
 		return bosherr.WrapErrorf(err, "Could not delete deployment state file %s", s.configPath)
 	}
+
+	return s.pruneBackups(0)
+}
+
+// Lock creates '<path>.lock' file recording pid of the current process and backs up
+// deployment state file; lock file left behind by a process that is no longer running
+// (e.g. killed) is removed
+func (s *fileSystemDeploymentStateService) Lock(timeout time.Duration) error {
+	if s.configPath == "" {
+		panic("configPath not yet set!")
+	}
+
+	lockPath := s.lockPath()
+	deadline := time.Now().Add(timeout)
+
+	for {
+		lockFile, err := s.fs.OpenFile(lockPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, os.FileMode(0644))
+		if err == nil {
+			_, err = lockFile.Write([]byte(strconv.Itoa(os.Getpid())))
+			lockFile.Close()
+			if err != nil {
+				s.fs.RemoveAll(lockPath)
+				return bosherr.WrapErrorf(err, "Writing deployment state lock file '%s'", lockPath)
+			}
+
+			s.logger.Debug(s.logTag, "Locked deployment state: %s", s.configPath)
+
+			err = s.backup()
+			if err != nil {
+				s.fs.RemoveAll(lockPath)
+				return err
+			}
+
+			return nil
+		}
+
+		if !os.IsExist(err) {
+			return bosherr.WrapErrorf(err, "Creating deployment state lock file '%s'", lockPath)
+		}
+
+		ownerPID, found := s.lockOwnerPID()
+		if found && !processExists(ownerPID) {
+			s.logger.Warn(s.logTag, "Removing stale deployment state lock file '%s' of process %d", lockPath, ownerPID)
+
+			err = s.fs.RemoveAll(lockPath)
+			if err != nil {
+				return bosherr.WrapErrorf(err, "Removing stale deployment state lock file '%s'", lockPath)
+			}
+
+			continue
+		}
+
+		if !time.Now().Before(deadline) {
+			return bosherr.Errorf(
+				"Deployment state file '%s' is locked by another process (lock file '%s'); "+
+					"delete the lock file if no other create-env or delete-env is running", s.configPath, lockPath)
+		}
+
+		s.logger.Debug(s.logTag, "Waiting for deployment state lock: %s", lockPath)
+		time.Sleep(deploymentStateLockRetryInterval)
+	}
+}
+
+// lockOwnerPID returns pid recorded in the lock file; it's not found
+// when the lock file is gone or its owner has not written it yet
+func (s *fileSystemDeploymentStateService) lockOwnerPID() (int, bool) {
+	contents, err := s.fs.ReadFileString(s.lockPath())
+	if err != nil {
+		return 0, false
+	}
+
+	pid, err := strconv.Atoi(strings.TrimSpace(contents))
+	if err != nil || pid <= 0 {
+		return 0, false
+	}
+
+	return pid, true
+}
+
+// processExists checks whether process with given pid is running
+// (finding a process on Windows already fails once it exits)
+func processExists(pid int) bool {
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+
+	if runtime.GOOS == "windows" {
+		return true
+	}
+
+	err = process.Signal(syscall.Signal(0))
+
+	return err == nil || os.IsPermission(err)
+}
+
+func (s *fileSystemDeploymentStateService) Unlock() error {
+	err := s.fs.RemoveAll(s.lockPath())
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Removing deployment state lock file '%s'", s.lockPath())
+	}
+
+	s.logger.Debug(s.logTag, "Unlocked deployment state: %s", s.configPath)
 	return nil
 }
+
+func (s *fileSystemDeploymentStateService) lockPath() string {
+	return s.configPath + ".lock"
+}
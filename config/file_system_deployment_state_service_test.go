@@ -7,9 +7,16 @@ import (
 
 	"encoding/json"
 	"errors"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"time"
 
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 	biproperty "github.com/cloudfoundry/bosh-utils/property"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
 	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
 	fakeuuid "github.com/cloudfoundry/bosh-utils/uuid/fakes"
 )
@@ -27,7 +34,7 @@ var _ = Describe("fileSystemDeploymentStateService", func() {
 		deploymentStatePath = "/some/deployment.json"
 		logger := boshlog.NewLogger(boshlog.LevelNone)
 		fakeUUIDGenerator = fakeuuid.NewFakeGenerator()
-		service = NewFileSystemDeploymentStateService(fakeFs, fakeUUIDGenerator, logger, deploymentStatePath)
+		service = NewFileSystemDeploymentStateService(fakeFs, fakeUUIDGenerator, logger, deploymentStatePath, 0)
 	})
 
 	Describe("DeploymentStatePath", func() {
@@ -114,6 +121,72 @@ var _ = Describe("fileSystemDeploymentStateService", func() {
 			})
 		})
 
+		Context("when the config was saved without schema version", func() {
+			BeforeEach(func() {
+				fakeFs.WriteFileString(deploymentStatePath, `{"director_id":"fake-director-id","current_vm_cid":"fake-vm-cid"}`)
+			})
+
+			It("migrates it into the default deployment without rewriting the file", func() {
+				deploymentState, err := service.Load()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(deploymentState.DirectorID).To(Equal("fake-director-id"))
+				Expect(deploymentState.CurrentVMCID).To(Equal("fake-vm-cid"))
+
+				Expect(fakeFs.ReadFileString(deploymentStatePath)).To(Equal(
+					`{"director_id":"fake-director-id","current_vm_cid":"fake-vm-cid"}`))
+			})
+
+			It("saves migrated state with current schema version when state is saved", func() {
+				deploymentState, err := service.Load()
+				Expect(err).NotTo(HaveOccurred())
+
+				err = service.Save(deploymentState)
+				Expect(err).NotTo(HaveOccurred())
+
+				contents, err := fakeFs.ReadFileString(deploymentStatePath)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(contents).To(ContainSubstring(`"schema_version": 2,`))
+
+				var savedContents map[string]interface{}
+				err = json.Unmarshal([]byte(contents), &savedContents)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(savedContents).To(HaveKeyWithValue("director_id", "fake-director-id"))
+				Expect(savedContents).To(HaveKeyWithValue("current_vm_cid", "fake-vm-cid"))
+				Expect(savedContents).ToNot(HaveKey("deployments"))
+			})
+		})
+
+		Context("when the config was saved with schema version 1", func() {
+			BeforeEach(func() {
+				fakeFs.WriteFileString(deploymentStatePath, `{"schema_version":1,"director_id":"fake-director-id","current_disk_id":"fake-disk-id"}`)
+			})
+
+			It("reads it as the default deployment", func() {
+				deploymentState, err := service.Load()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(deploymentState.DirectorID).To(Equal("fake-director-id"))
+				Expect(deploymentState.CurrentDiskID).To(Equal("fake-disk-id"))
+
+				otherDeploymentState, err := service.LoadDeployment("fake-other-deployment")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(otherDeploymentState.CurrentDiskID).To(BeEmpty())
+			})
+		})
+
+		Context("when the config was saved with newer schema version", func() {
+			It("returns an error and does not modify the file", func() {
+				contents := `{"schema_version":3,"deployments":{}}`
+				fakeFs.WriteFileString(deploymentStatePath, contents)
+
+				_, err := service.Load()
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(Equal(
+					"Expected deployment state file '/some/deployment.json' to have schema version 2 or older but was 3"))
+
+				Expect(fakeFs.ReadFileString(deploymentStatePath)).To(Equal(contents))
+			})
+		})
+
 		Context("when reading config file fails", func() {
 			BeforeEach(func() {
 				fakeFs.WriteFileString(deploymentStatePath, "{}")
@@ -139,6 +212,8 @@ var _ = Describe("fileSystemDeploymentStateService", func() {
 	})
 
 	Describe("Save", func() {
+		originalContents := `{"schema_version":2,"director_id":"fake-director-id"}`
+
 		It("writes the deployment state to the deployment file", func() {
 			config := DeploymentState{
 				DirectorID: "deadbeef",
@@ -185,10 +260,56 @@ var _ = Describe("fileSystemDeploymentStateService", func() {
 					},
 				},
 			}
-			expectedDeploymentStateFileContents, err := json.MarshalIndent(deploymentState, "", "    ")
+			expectedDeploymentStateFileContents, err := json.MarshalIndent(struct {
+				SchemaVersion int `json:"schema_version"`
+				DeploymentState
+			}{
+				SchemaVersion:   DeploymentStateSchemaVersion,
+				DeploymentState: deploymentState,
+			}, "", "    ")
 			Expect(deploymentStateFileContents).To(Equal(string(expectedDeploymentStateFileContents)))
 		})
 
+		It("keeps state of other deployments saved in the same file", func() {
+			err := service.SaveDeployment("fake-other-deployment", DeploymentState{DirectorID: "fake-other-director-id"})
+			Expect(err).NotTo(HaveOccurred())
+
+			err = service.Save(DeploymentState{DirectorID: "deadbeef"})
+			Expect(err).NotTo(HaveOccurred())
+
+			deploymentState, err := service.Load()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(deploymentState.DirectorID).To(Equal("deadbeef"))
+
+			otherDeploymentState, err := service.LoadDeployment("fake-other-deployment")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(otherDeploymentState.DirectorID).To(Equal("fake-other-director-id"))
+		})
+
+		It("keeps state of the default deployment at the top level so that older versions can read it", func() {
+			err := service.SaveDeployment("fake-other-deployment", DeploymentState{DirectorID: "fake-other-director-id"})
+			Expect(err).NotTo(HaveOccurred())
+
+			err = service.Save(DeploymentState{DirectorID: "deadbeef", CurrentVMCID: "fake-vm-cid"})
+			Expect(err).NotTo(HaveOccurred())
+
+			contents, err := fakeFs.ReadFile(deploymentStatePath)
+			Expect(err).NotTo(HaveOccurred())
+
+			var deploymentState DeploymentState
+			err = json.Unmarshal(contents, &deploymentState)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(deploymentState).To(Equal(DeploymentState{DirectorID: "deadbeef", CurrentVMCID: "fake-vm-cid"}))
+
+			var savedContents map[string]interface{}
+			err = json.Unmarshal(contents, &savedContents)
+			Expect(err).NotTo(HaveOccurred())
+
+			deployments := savedContents["deployments"].(map[string]interface{})
+			Expect(deployments).To(HaveLen(1))
+			Expect(deployments["fake-other-deployment"]).To(HaveKeyWithValue("director_id", "fake-other-director-id"))
+		})
+
 		Context("when the deployment file cannot be written", func() {
 			BeforeEach(func() {
 				fakeFs.WriteFileError = errors.New("")
@@ -203,11 +324,197 @@ var _ = Describe("fileSystemDeploymentStateService", func() {
 				Expect(err.Error()).To(ContainSubstring("Writing deployment state file '/some/deployment.json'"))
 			})
 		})
+
+		It("does not leave a temporary file behind", func() {
+			err := service.Save(DeploymentState{DirectorID: "deadbeef"})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(fakeFs.FileExists(deploymentStatePath + ".tmp")).To(BeFalse())
+		})
+
+		Context("when writing the new deployment state fails", func() {
+			BeforeEach(func() {
+				fakeFs.WriteFileString(deploymentStatePath, originalContents)
+				fakeFs.WriteFileErrors[deploymentStatePath+".tmp"] = errors.New("fake-write-error")
+			})
+
+			It("leaves the original deployment state file intact", func() {
+				err := service.Save(DeploymentState{DirectorID: "deadbeef"})
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("fake-write-error"))
+
+				Expect(fakeFs.ReadFileString(deploymentStatePath)).To(Equal(originalContents))
+			})
+		})
+
+		Context("when the temporary file cannot be renamed into place", func() {
+			BeforeEach(func() {
+				fakeFs.WriteFileString(deploymentStatePath, originalContents)
+				fakeFs.RenameError = errors.New("fake-rename-error")
+			})
+
+			It("returns an error and leaves the original deployment state file intact", func() {
+				err := service.Save(DeploymentState{DirectorID: "deadbeef"})
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("Replacing deployment state file '/some/deployment.json'"))
+				Expect(err.Error()).To(ContainSubstring("fake-rename-error"))
+
+				Expect(fakeFs.ReadFileString(deploymentStatePath)).To(Equal(originalContents))
+			})
+		})
+	})
+
+	Describe("backups", func() {
+		var (
+			tmpDir    string
+			statePath string
+			fs        boshsys.FileSystem
+			logger    boshlog.Logger
+		)
+
+		BeforeEach(func() {
+			var err error
+			tmpDir, err = ioutil.TempDir("", "deployment-state-backups")
+			Expect(err).ToNot(HaveOccurred())
+
+			logger = boshlog.NewLogger(boshlog.LevelNone)
+			fs = boshsys.NewOsFileSystem(logger)
+			statePath = filepath.Join(tmpDir, "state.json")
+
+			service = NewFileSystemDeploymentStateService(fs, fakeUUIDGenerator, logger, statePath, 2)
+		})
+
+		AfterEach(func() {
+			os.RemoveAll(tmpDir)
+		})
+
+		loadBackup := func(i int) DeploymentState {
+			backupService := NewFileSystemDeploymentStateService(
+				fs, fakeUUIDGenerator, logger, statePath+".bak."+strconv.Itoa(i), 0)
+
+			deploymentState, err := backupService.Load()
+			Expect(err).ToNot(HaveOccurred())
+
+			return deploymentState
+		}
+
+		It("does not create a backup when deployment state file does not exist yet", func() {
+			Expect(service.Lock(0)).To(Succeed())
+
+			err := service.Save(DeploymentState{DirectorID: "fake-director-id-1"})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(service.Unlock()).To(Succeed())
+
+			Expect(fs.FileExists(statePath + ".bak.1")).To(BeFalse())
+		})
+
+		It("backs up deployment state once it's locked keeping newest backups", func() {
+			for i := 1; i <= 4; i++ {
+				Expect(service.Lock(0)).To(Succeed())
+
+				err := service.Save(DeploymentState{DirectorID: "fake-director-id-" + strconv.Itoa(i)})
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(service.Unlock()).To(Succeed())
+			}
+
+			deploymentState, err := service.Load()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(deploymentState.DirectorID).To(Equal("fake-director-id-4"))
+
+			Expect(loadBackup(1).DirectorID).To(Equal("fake-director-id-3"))
+			Expect(loadBackup(2).DirectorID).To(Equal("fake-director-id-2"))
+
+			Expect(fs.FileExists(statePath + ".bak.3")).To(BeFalse())
+		})
+
+		It("does not back up deployment state on every save", func() {
+			err := service.Save(DeploymentState{DirectorID: "fake-director-id-1"})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(service.Lock(0)).To(Succeed())
+
+			for i := 2; i <= 4; i++ {
+				err = service.Save(DeploymentState{DirectorID: "fake-director-id-" + strconv.Itoa(i)})
+				Expect(err).ToNot(HaveOccurred())
+			}
+
+			Expect(service.Unlock()).To(Succeed())
+
+			Expect(loadBackup(1).DirectorID).To(Equal("fake-director-id-1"))
+			Expect(fs.FileExists(statePath + ".bak.2")).To(BeFalse())
+		})
+
+		It("prunes oldest backups beyond configured number of backups", func() {
+			for i := 1; i <= 4; i++ {
+				Expect(fs.WriteFileString(statePath+".bak."+strconv.Itoa(i), "{}")).To(Succeed())
+			}
+
+			err := service.Save(DeploymentState{DirectorID: "fake-director-id-1"})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(service.Lock(0)).To(Succeed())
+			Expect(service.Unlock()).To(Succeed())
+
+			Expect(loadBackup(1).DirectorID).To(Equal("fake-director-id-1"))
+			Expect(fs.FileExists(statePath + ".bak.2")).To(BeTrue())
+			Expect(fs.FileExists(statePath + ".bak.3")).To(BeFalse())
+			Expect(fs.FileExists(statePath + ".bak.4")).To(BeFalse())
+		})
+
+		It("deletes backups together with deployment state file", func() {
+			err := service.Save(DeploymentState{DirectorID: "fake-director-id-1"})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(service.Lock(0)).To(Succeed())
+			Expect(fs.FileExists(statePath + ".bak.1")).To(BeTrue())
+
+			err = service.Cleanup()
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(service.Unlock()).To(Succeed())
+
+			Expect(service.Exists()).To(BeFalse())
+			Expect(fs.FileExists(statePath + ".bak.1")).To(BeFalse())
+		})
+
+		It("does not create backups if number of backups is 0", func() {
+			service = NewFileSystemDeploymentStateService(fs, fakeUUIDGenerator, logger, statePath, 0)
+
+			err := service.Save(DeploymentState{DirectorID: "fake-director-id-1"})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(service.Lock(0)).To(Succeed())
+			Expect(service.Unlock()).To(Succeed())
+
+			Expect(fs.FileExists(statePath + ".bak.1")).To(BeFalse())
+		})
+
+		It("returns an error and releases the lock if backing up fails", func() {
+			service = NewFileSystemDeploymentStateService(fakeFs, fakeUUIDGenerator, logger, deploymentStatePath, 2)
+
+			err := service.Save(DeploymentState{DirectorID: "fake-director-id-1"})
+			Expect(err).ToNot(HaveOccurred())
+
+			fakeFs.CopyFileError = errors.New("fake-copy-error")
+
+			err = service.Lock(0)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Backing up deployment state file"))
+			Expect(err.Error()).To(ContainSubstring("fake-copy-error"))
+
+			Expect(fakeFs.FileExists(deploymentStatePath + ".lock")).To(BeFalse())
+
+			deploymentState, err := service.Load()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(deploymentState.DirectorID).To(Equal("fake-director-id-1"))
+		})
 	})
 
 	Describe("Cleanup", func() {
 		It("returns true if deployment state file deleted", func() {
-			fakeFs.WriteFileString(deploymentStatePath, "")
+			fakeFs.WriteFileString(deploymentStatePath, "{}")
 			Expect(service.Exists()).To(BeTrue())
 
 			err := service.Cleanup()
@@ -229,5 +536,157 @@ var _ = Describe("fileSystemDeploymentStateService", func() {
 			Expect(err).To(HaveOccurred())
 			Expect(err.Error()).To(ContainSubstring("Could not do that Dave"))
 		})
+
+		It("keeps deployment state file with state of other deployments", func() {
+			err := service.Save(DeploymentState{DirectorID: "fake-director-id"})
+			Expect(err).NotTo(HaveOccurred())
+
+			err = service.SaveDeployment("fake-other-deployment", DeploymentState{DirectorID: "fake-other-director-id"})
+			Expect(err).NotTo(HaveOccurred())
+
+			err = service.Cleanup()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(service.Exists()).To(BeTrue())
+
+			otherDeploymentState, err := service.LoadDeployment("fake-other-deployment")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(otherDeploymentState.DirectorID).To(Equal("fake-other-director-id"))
+
+			deploymentState, err := service.Load()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(deploymentState.DirectorID).To(Equal("fake-uuid-0"))
+		})
+	})
+
+	Describe("Lock", func() {
+		It("creates a lock file next to the deployment state file with the current pid", func() {
+			err := service.Lock(0)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(fakeFs.ReadFileString(deploymentStatePath + ".lock")).To(Equal(strconv.Itoa(os.Getpid())))
+		})
+
+		It("returns error if the lock is held by another process", func() {
+			fakeFs.OpenFileErr = &os.PathError{Op: "open", Path: deploymentStatePath + ".lock", Err: os.ErrExist}
+
+			err := service.Lock(0)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("Deployment state file '/some/deployment.json' is locked by another process " +
+				"(lock file '/some/deployment.json.lock'); delete the lock file if no other create-env or delete-env is running"))
+		})
+
+		It("removes the lock file and returns error if writing pid fails", func() {
+			lockFile := fakesys.NewFakeFile(deploymentStatePath+".lock", fakeFs)
+			lockFile.WriteErr = errors.New("fake-write-err")
+			fakeFs.RegisterOpenFile(deploymentStatePath+".lock", lockFile)
+
+			err := service.Lock(0)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Writing deployment state lock file '/some/deployment.json.lock'"))
+			Expect(err.Error()).To(ContainSubstring("fake-write-err"))
+
+			Expect(fakeFs.FileExists(deploymentStatePath + ".lock")).To(BeFalse())
+		})
+
+		It("returns error if the lock file cannot be created", func() {
+			fakeFs.OpenFileErr = errors.New("fake-open-err")
+
+			err := service.Lock(0)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Creating deployment state lock file '/some/deployment.json.lock'"))
+			Expect(err.Error()).To(ContainSubstring("fake-open-err"))
+		})
+
+		Context("when using a real file system", func() {
+			var (
+				tmpDir        string
+				lockPath      string
+				firstService  DeploymentStateService
+				secondService DeploymentStateService
+			)
+
+			BeforeEach(func() {
+				var err error
+				tmpDir, err = ioutil.TempDir("", "deployment-state-lock")
+				Expect(err).ToNot(HaveOccurred())
+
+				logger := boshlog.NewLogger(boshlog.LevelNone)
+				fs := boshsys.NewOsFileSystem(logger)
+				statePath := filepath.Join(tmpDir, "state.json")
+				lockPath = statePath + ".lock"
+
+				firstService = NewFileSystemDeploymentStateService(fs, fakeUUIDGenerator, logger, statePath, 0)
+				secondService = NewFileSystemDeploymentStateService(fs, fakeUUIDGenerator, logger, statePath, 0)
+			})
+
+			AfterEach(func() {
+				os.RemoveAll(tmpDir)
+			})
+
+			It("fails if the lock is not released before the timeout", func() {
+				Expect(firstService.Lock(0)).To(Succeed())
+
+				err := secondService.Lock(0)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("is locked by another process"))
+			})
+
+			It("waits for the lock to be released", func() {
+				Expect(firstService.Lock(0)).To(Succeed())
+
+				go func() {
+					defer GinkgoRecover()
+					time.Sleep(100 * time.Millisecond)
+					Expect(firstService.Unlock()).To(Succeed())
+				}()
+
+				Expect(secondService.Lock(5 * time.Second)).To(Succeed())
+				Expect(secondService.Unlock()).To(Succeed())
+			})
+
+			It("takes over the lock left behind by a process that is no longer running", func() {
+				deadProcess := exec.Command("true")
+				Expect(deadProcess.Run()).To(Succeed())
+
+				err := ioutil.WriteFile(lockPath, []byte(strconv.Itoa(deadProcess.Process.Pid)), 0644)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(secondService.Lock(0)).To(Succeed())
+
+				contents, err := ioutil.ReadFile(lockPath)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(string(contents)).To(Equal(strconv.Itoa(os.Getpid())))
+			})
+
+			It("does not take over the lock of a running process", func() {
+				err := ioutil.WriteFile(lockPath, []byte(strconv.Itoa(os.Getppid())), 0644)
+				Expect(err).ToNot(HaveOccurred())
+
+				err = secondService.Lock(0)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring(lockPath))
+			})
+		})
+	})
+
+	Describe("Unlock", func() {
+		It("removes the lock file", func() {
+			Expect(service.Lock(0)).To(Succeed())
+
+			err := service.Unlock()
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(fakeFs.FileExists(deploymentStatePath + ".lock")).To(BeFalse())
+		})
+
+		It("returns error if the lock file cannot be removed", func() {
+			fakeFs.RemoveAllStub = func(_ string) error {
+				return errors.New("fake-remove-err")
+			}
+
+			err := service.Unlock()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Removing deployment state lock file '/some/deployment.json.lock'"))
+		})
 	})
 })
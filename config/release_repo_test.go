@@ -27,7 +27,7 @@ var _ = Describe("ReleaseRepo", func() {
 		fs = fakesys.NewFakeFileSystem()
 		fakeUUIDGenerator = &fakeuuid.FakeGenerator{}
 		fakeUUIDGenerator.GeneratedUUID = "fake-uuid"
-		deploymentStateService = NewFileSystemDeploymentStateService(fs, fakeUUIDGenerator, logger, "/fake/path")
+		deploymentStateService = NewFileSystemDeploymentStateService(fs, fakeUUIDGenerator, logger, "/fake/path", 0)
 		deploymentStateService.Load()
 		repo = NewReleaseRepo(deploymentStateService, fakeUUIDGenerator)
 	})
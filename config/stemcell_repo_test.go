@@ -21,7 +21,7 @@ var _ = Describe("StemcellRepo", func() {
 		logger := boshlog.NewLogger(boshlog.LevelNone)
 		fs = fakesys.NewFakeFileSystem()
 		fakeUUIDGenerator = &fakeuuid.FakeGenerator{}
-		deploymentStateService = NewFileSystemDeploymentStateService(fs, fakeUUIDGenerator, logger, "/fake/path")
+		deploymentStateService = NewFileSystemDeploymentStateService(fs, fakeUUIDGenerator, logger, "/fake/path", 0)
 		repo = NewStemcellRepo(deploymentStateService, fakeUUIDGenerator)
 	})
 
@@ -271,6 +271,31 @@ var _ = Describe("StemcellRepo", func() {
 		})
 	})
 
+	Describe("All", func() {
+		It("returns all stemcells", func() {
+			fakeUUIDGenerator.GeneratedUUID = "fake-guid-1"
+			firstStemcell, err := repo.Save("fake-name", "fake-version-1", "fake-cid-1")
+			Expect(err).ToNot(HaveOccurred())
+
+			fakeUUIDGenerator.GeneratedUUID = "fake-guid-2"
+			secondStemcell, err := repo.Save("fake-name", "fake-version-2", "fake-cid-2")
+			Expect(err).ToNot(HaveOccurred())
+
+			stemcells, err := repo.All()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(stemcells).To(Equal([]StemcellRecord{
+				firstStemcell,
+				secondStemcell,
+			}))
+		})
+
+		It("returns no stemcells when none were saved", func() {
+			stemcells, err := repo.All()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(stemcells).To(BeEmpty())
+		})
+	})
+
 	Describe("FindCurrent", func() {
 		Context("when current stemcell exists", func() {
 			BeforeEach(func() {
@@ -26,6 +26,7 @@ import (
 	boshsys "github.com/cloudfoundry/bosh-utils/system"
 	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
 	fakeuuid "github.com/cloudfoundry/bosh-utils/uuid/fakes"
+	"github.com/pivotal-golang/clock"
 
 	fakebiui "github.com/cloudfoundry/bosh-cli/ui/fakes"
 )
@@ -114,11 +115,11 @@ var _ = Describe("Deployment", func() {
 			fs = fakesys.NewFakeFileSystem()
 
 			fakeUUIDGenerator = fakeuuid.NewFakeGenerator()
-			deploymentStateService = biconfig.NewFileSystemDeploymentStateService(fs, fakeUUIDGenerator, logger, "/deployment.json")
+			deploymentStateService = biconfig.NewFileSystemDeploymentStateService(fs, fakeUUIDGenerator, logger, "/deployment.json", 0)
 
 			fakeRepoUUIDGenerator = fakeuuid.NewFakeGenerator()
 			vmRepo = biconfig.NewVMRepo(deploymentStateService)
-			diskRepo = biconfig.NewDiskRepo(deploymentStateService, fakeRepoUUIDGenerator)
+			diskRepo = biconfig.NewDiskRepo(deploymentStateService, biconfig.DefaultDeploymentName, fakeRepoUUIDGenerator, clock.NewClock(), nil, logger)
 			stemcellRepo = biconfig.NewStemcellRepo(deploymentStateService, fakeRepoUUIDGenerator)
 
 			mockCloud = mock_cloud.NewMockCloud(mockCtrl)
@@ -346,7 +347,7 @@ var _ = Describe("Deployment", func() {
 		Context("when a current disk exists", func() {
 			BeforeEach(func() {
 				deploymentStateService.Save(biconfig.DeploymentState{})
-				diskRecord, err := diskRepo.Save("fake-disk-cid", 100, nil)
+				diskRecord, err := diskRepo.Save("fake-disk-cid", 100, nil, biconfig.ErrorOnDuplicateCID)
 				Expect(err).ToNot(HaveOccurred())
 				diskRepo.UpdateCurrent(diskRecord.ID)
 			})
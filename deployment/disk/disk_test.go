@@ -11,6 +11,7 @@ import (
 	biproperty "github.com/cloudfoundry/bosh-utils/property"
 	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
 	fakeuuid "github.com/cloudfoundry/bosh-utils/uuid/fakes"
+	"github.com/pivotal-golang/clock"
 
 	fakebicloud "github.com/cloudfoundry/bosh-cli/cloud/fakes"
 
@@ -42,8 +43,8 @@ var _ = Describe("Disk", func() {
 		logger := boshlog.NewLogger(boshlog.LevelNone)
 		fakeUUIDGenerator = &fakeuuid.FakeGenerator{}
 		//		todo: come back to this?
-		deploymentStateService := biconfig.NewFileSystemDeploymentStateService(fs, fakeUUIDGenerator, logger, "/fake/path")
-		diskRepo = biconfig.NewDiskRepo(deploymentStateService, fakeUUIDGenerator)
+		deploymentStateService := biconfig.NewFileSystemDeploymentStateService(fs, fakeUUIDGenerator, logger, "/fake/path", 0)
+		diskRepo = biconfig.NewDiskRepo(deploymentStateService, biconfig.DefaultDeploymentName, fakeUUIDGenerator, clock.NewClock(), nil, logger)
 
 		disk = NewDisk(diskRecord, fakeCloud, diskRepo)
 	})
@@ -94,7 +95,7 @@ var _ = Describe("Disk", func() {
 		})
 
 		It("deletes disk from repo", func() {
-			_, err := diskRepo.Save("fake-disk-cid", 1024, diskCloudProperties)
+			_, err := diskRepo.Save("fake-disk-cid", 1024, diskCloudProperties, biconfig.ErrorOnDuplicateCID)
 			Expect(err).ToNot(HaveOccurred())
 
 			err = disk.Delete()
@@ -105,7 +106,7 @@ var _ = Describe("Disk", func() {
 
 		Context("when deleted disk is the current disk", func() {
 			BeforeEach(func() {
-				diskRecord, err := diskRepo.Save("fake-disk-cid", 1024, diskCloudProperties)
+				diskRecord, err := diskRepo.Save("fake-disk-cid", 1024, diskCloudProperties, biconfig.ErrorOnDuplicateCID)
 				Expect(err).ToNot(HaveOccurred())
 
 				err = diskRepo.UpdateCurrent(diskRecord.ID)
@@ -141,7 +142,7 @@ var _ = Describe("Disk", func() {
 			})
 
 			BeforeEach(func() {
-				diskRecord, err := diskRepo.Save("fake-disk-cid", 1024, diskCloudProperties)
+				diskRecord, err := diskRepo.Save("fake-disk-cid", 1024, diskCloudProperties, biconfig.ErrorOnDuplicateCID)
 				Expect(err).ToNot(HaveOccurred())
 
 				err = diskRepo.UpdateCurrent(diskRecord.ID)
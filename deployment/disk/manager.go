@@ -67,7 +67,7 @@ func (m *manager) Create(diskPool bideplmanifest.DiskPool, vmCID string) (Disk,
 			)
 	}
 
-	diskRecord, err := m.diskRepo.Save(cid, diskPool.DiskSize, diskCloudProperties)
+	diskRecord, err := m.diskRepo.Save(cid, diskPool.DiskSize, diskCloudProperties, biconfig.ErrorOnDuplicateCID)
 	if err != nil {
 		return nil, bosherr.WrapError(err, "Saving deployment disk record")
 	}
@@ -15,6 +15,7 @@ import (
 	fakeuuid "github.com/cloudfoundry/bosh-utils/uuid/fakes"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
+	"github.com/pivotal-golang/clock"
 )
 
 var _ = Describe("Manager", func() {
@@ -30,8 +31,8 @@ var _ = Describe("Manager", func() {
 		logger := boshlog.NewLogger(boshlog.LevelNone)
 		fakeFs = fakesys.NewFakeFileSystem()
 		fakeUUIDGenerator = &fakeuuid.FakeGenerator{}
-		deploymentStateService := biconfig.NewFileSystemDeploymentStateService(fakeFs, fakeUUIDGenerator, logger, "/fake/path")
-		diskRepo = biconfig.NewDiskRepo(deploymentStateService, fakeUUIDGenerator)
+		deploymentStateService := biconfig.NewFileSystemDeploymentStateService(fakeFs, fakeUUIDGenerator, logger, "/fake/path", 0)
+		diskRepo = biconfig.NewDiskRepo(deploymentStateService, biconfig.DefaultDeploymentName, fakeUUIDGenerator, clock.NewClock(), nil, logger)
 		managerFactory := NewManagerFactory(diskRepo, logger)
 		fakeCloud = fakebicloud.NewFakeCloud()
 		manager = managerFactory.NewManager(fakeCloud)
@@ -112,7 +113,7 @@ var _ = Describe("Manager", func() {
 	Describe("FindCurrent", func() {
 		Context("when disk already exists in disk repo", func() {
 			BeforeEach(func() {
-				diskRecord, err := diskRepo.Save("fake-existing-disk-cid", 1024, biproperty.Map{})
+				diskRecord, err := diskRepo.Save("fake-existing-disk-cid", 1024, biproperty.Map{}, biconfig.ErrorOnDuplicateCID)
 				Expect(err).ToNot(HaveOccurred())
 
 				err = diskRepo.UpdateCurrent(diskRecord.ID)
@@ -157,18 +158,18 @@ var _ = Describe("Manager", func() {
 
 		BeforeEach(func() {
 			fakeUUIDGenerator.GeneratedUUID = "fake-guid-1"
-			firstDiskRecord, err := diskRepo.Save("fake-disk-cid-1", 1024, biproperty.Map{})
+			firstDiskRecord, err := diskRepo.Save("fake-disk-cid-1", 1024, biproperty.Map{}, biconfig.ErrorOnDuplicateCID)
 			Expect(err).ToNot(HaveOccurred())
 			firstDisk = NewDisk(firstDiskRecord, fakeCloud, diskRepo)
 
 			fakeUUIDGenerator.GeneratedUUID = "fake-guid-2"
-			_, err = diskRepo.Save("fake-disk-cid-2", 1024, biproperty.Map{})
+			_, err = diskRepo.Save("fake-disk-cid-2", 1024, biproperty.Map{}, biconfig.ErrorOnDuplicateCID)
 			Expect(err).ToNot(HaveOccurred())
 			err = diskRepo.UpdateCurrent("fake-guid-2")
 			Expect(err).ToNot(HaveOccurred())
 
 			fakeUUIDGenerator.GeneratedUUID = "fake-guid-3"
-			thirdDiskRecord, err := diskRepo.Save("fake-disk-cid-3", 1024, biproperty.Map{})
+			thirdDiskRecord, err := diskRepo.Save("fake-disk-cid-3", 1024, biproperty.Map{}, biconfig.ErrorOnDuplicateCID)
 			Expect(err).ToNot(HaveOccurred())
 			thirdDisk = NewDisk(thirdDiskRecord, fakeCloud, diskRepo)
 		})
@@ -193,17 +194,17 @@ var _ = Describe("Manager", func() {
 			fakeStage = fakebiui.NewFakeStage()
 
 			fakeUUIDGenerator.GeneratedUUID = "fake-disk-id-1"
-			_, err := diskRepo.Save("fake-disk-cid-1", 100, nil)
+			_, err := diskRepo.Save("fake-disk-cid-1", 100, nil, biconfig.ErrorOnDuplicateCID)
 			Expect(err).ToNot(HaveOccurred())
 
 			fakeUUIDGenerator.GeneratedUUID = "fake-disk-id-2"
-			secondDiskRecord, err = diskRepo.Save("fake-disk-cid-2", 100, nil)
+			secondDiskRecord, err = diskRepo.Save("fake-disk-cid-2", 100, nil, biconfig.ErrorOnDuplicateCID)
 			Expect(err).ToNot(HaveOccurred())
 			err = diskRepo.UpdateCurrent(secondDiskRecord.ID)
 			Expect(err).ToNot(HaveOccurred())
 
 			fakeUUIDGenerator.GeneratedUUID = "fake-disk-id-3"
-			_, err = diskRepo.Save("fake-disk-cid-3", 100, nil)
+			_, err = diskRepo.Save("fake-disk-cid-3", 100, nil, biconfig.ErrorOnDuplicateCID)
 			Expect(err).ToNot(HaveOccurred())
 		})
 
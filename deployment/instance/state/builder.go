@@ -206,7 +206,7 @@ func (b *builder) renderJobTemplates(
 		}
 		defer renderedJobListArchive.DeleteSilently()
 
-		blobID, err = b.blobstore.Add(renderedJobListArchive.Path())
+		blobID, err = b.blobstore.Add(renderedJobListArchive.Path(), biblobstore.DefaultContentType)
 		if err != nil {
 			return bosherr.WrapErrorf(err, "Uploading rendered job template archive '%s' to the blobstore", renderedJobListArchive.Path())
 		}
@@ -9,6 +9,7 @@ import (
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 
+	biblobstore "github.com/cloudfoundry/bosh-cli/blobstore"
 	mock_blobstore "github.com/cloudfoundry/bosh-cli/blobstore/mocks"
 	. "github.com/cloudfoundry/bosh-cli/deployment/instance/state"
 	bideplmanifest "github.com/cloudfoundry/bosh-cli/deployment/manifest"
@@ -295,7 +296,7 @@ var _ = Describe("Builder", func() {
 			mockRenderedJobListArchive.EXPECT().Path().Return("fake-rendered-job-list-archive-path")
 			mockRenderedJobListArchive.EXPECT().SHA1().Return("fake-rendered-job-list-archive-sha1")
 
-			mockBlobstore.EXPECT().Add("fake-rendered-job-list-archive-path").Return("fake-rendered-job-list-archive-blob-id", nil)
+			mockBlobstore.EXPECT().Add("fake-rendered-job-list-archive-path", biblobstore.DefaultContentType).Return("fake-rendered-job-list-archive-blob-id", nil)
 		})
 
 		It("compiles the dependencies of the jobs", func() {
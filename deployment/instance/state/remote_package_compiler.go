@@ -29,7 +29,7 @@ func NewRemotePackageCompiler(
 func (c *remotePackageCompiler) Compile(pkg birelpkg.Compilable) (bistatepkg.CompiledPackageRecord, bool, error) {
 	var record bistatepkg.CompiledPackageRecord
 
-	blobID, err := c.blobstore.Add(pkg.ArchivePath())
+	blobID, err := c.blobstore.Add(pkg.ArchivePath(), biblobstore.DefaultContentType)
 	if err != nil {
 		return bistatepkg.CompiledPackageRecord{}, false, bosherr.WrapErrorf(err, "Adding release package archive '%s' to blobstore", pkg.ArchivePath())
 	}
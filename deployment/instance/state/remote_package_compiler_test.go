@@ -7,6 +7,7 @@ import (
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 
+	biblobstore "github.com/cloudfoundry/bosh-cli/blobstore"
 	mock_blobstore "github.com/cloudfoundry/bosh-cli/blobstore/mocks"
 	. "github.com/cloudfoundry/bosh-cli/deployment/instance/state"
 	biindex "github.com/cloudfoundry/bosh-cli/index"
@@ -104,7 +105,7 @@ var _ = Describe("RemotePackageCompiler", func() {
 					SHA1:        "fake-compiled-package-sha1",
 				}
 
-				expectBlobstoreAdd = mockBlobstore.EXPECT().Add(archivePath).Return("fake-source-package-blob-id", nil).AnyTimes()
+				expectBlobstoreAdd = mockBlobstore.EXPECT().Add(archivePath, biblobstore.DefaultContentType).Return("fake-source-package-blob-id", nil).AnyTimes()
 				expectAgentCompile = mockAgentClient.EXPECT().CompilePackage(packageSource, packageDependencies).Return(compiledPackageRef, nil).AnyTimes()
 			})
 
@@ -167,7 +168,7 @@ var _ = Describe("RemotePackageCompiler", func() {
 				})
 				Expect(err).ToNot(HaveOccurred())
 
-				expectBlobstoreAdd = mockBlobstore.EXPECT().Add(archivePath).Return("fake-source-package-blob-id", nil).AnyTimes()
+				expectBlobstoreAdd = mockBlobstore.EXPECT().Add(archivePath, biblobstore.DefaultContentType).Return("fake-source-package-blob-id", nil).AnyTimes()
 				expectAgentCompile = mockAgentClient.EXPECT().CompilePackage(gomock.Any(), gomock.Any()).AnyTimes()
 
 				compiledPackageRecord, isAlreadyCompiled, err := remotePackageCompiler.Compile(pkg)
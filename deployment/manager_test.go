@@ -26,6 +26,7 @@ import (
 	boshsys "github.com/cloudfoundry/bosh-utils/system"
 	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
 	fakeuuid "github.com/cloudfoundry/bosh-utils/uuid/fakes"
+	"github.com/pivotal-golang/clock"
 
 	fakebiui "github.com/cloudfoundry/bosh-cli/ui/fakes"
 )
@@ -170,11 +171,11 @@ var _ = Describe("Manager", func() {
 			mockDeploymentFactory = mock_deployment.NewMockFactory(mockCtrl)
 
 			fakeUUIDGenerator = fakeuuid.NewFakeGenerator()
-			deploymentStateService = biconfig.NewFileSystemDeploymentStateService(fs, fakeUUIDGenerator, logger, "/deployment.json")
+			deploymentStateService = biconfig.NewFileSystemDeploymentStateService(fs, fakeUUIDGenerator, logger, "/deployment.json", 0)
 
 			fakeRepoUUIDGenerator = fakeuuid.NewFakeGenerator()
 			vmRepo = biconfig.NewVMRepo(deploymentStateService)
-			diskRepo = biconfig.NewDiskRepo(deploymentStateService, fakeRepoUUIDGenerator)
+			diskRepo = biconfig.NewDiskRepo(deploymentStateService, biconfig.DefaultDeploymentName, fakeRepoUUIDGenerator, clock.NewClock(), nil, logger)
 			stemcellRepo = biconfig.NewStemcellRepo(deploymentStateService, fakeRepoUUIDGenerator)
 
 			mockCloud = mock_cloud.NewMockCloud(mockCtrl)
@@ -210,7 +211,7 @@ var _ = Describe("Manager", func() {
 
 			BeforeEach(func() {
 				var err error
-				currentDiskRecord, err = diskRepo.Save("fake-disk-cid", 100, nil)
+				currentDiskRecord, err = diskRepo.Save("fake-disk-cid", 100, nil, biconfig.ErrorOnDuplicateCID)
 				Expect(err).ToNot(HaveOccurred())
 				err = diskRepo.UpdateCurrent(currentDiskRecord.ID)
 				Expect(err).ToNot(HaveOccurred())
@@ -246,7 +247,7 @@ var _ = Describe("Manager", func() {
 
 		Context("orphan disk records exist", func() {
 			BeforeEach(func() {
-				_, err := diskRepo.Save("orphan-disk-cid", 100, nil)
+				_, err := diskRepo.Save("orphan-disk-cid", 100, nil, biconfig.ErrorOnDuplicateCID)
 				Expect(err).ToNot(HaveOccurred())
 			})
 
@@ -1,6 +1,7 @@
 package director
 
 import (
+	"context"
 	"time"
 
 	boshhttp "github.com/cloudfoundry/bosh-utils/httpclient"
@@ -32,3 +33,12 @@ func (c Client) WithContext(contextId string) Client {
 
 	return Client{clientRequest, taskClientRequest}
 }
+
+func (c Client) WithRequestContext(ctx context.Context) Client {
+	clientRequest := c.clientRequest.WithRequestContext(ctx)
+
+	taskClientRequest := c.taskClientRequest
+	taskClientRequest.clientRequest = clientRequest
+
+	return Client{clientRequest, taskClientRequest}
+}
@@ -1,6 +1,7 @@
 package director
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -16,6 +17,7 @@ import (
 type ClientRequest struct {
 	endpoint     string
 	contextId    string
+	requestCtx   context.Context
 	httpClient   boshhttp.HTTPClient
 	fileReporter FileReporter
 	logger       boshlog.Logger
@@ -40,6 +42,13 @@ func (r ClientRequest) WithContext(contextId string) ClientRequest {
 	return r
 }
 
+// WithRequestContext returns a copy of the ClientRequest
+// which aborts requests once ctx is done
+func (r ClientRequest) WithRequestContext(ctx context.Context) ClientRequest {
+	r.requestCtx = ctx
+	return r
+}
+
 func (r ClientRequest) Get(path string, response interface{}) error {
 	respBody, _, err := r.RawGet(path, nil, nil)
 	if err != nil {
@@ -99,7 +108,7 @@ func (r ClientRequest) Delete(path string, response interface{}) error {
 func (r ClientRequest) RawGet(path string, out io.Writer, f func(*http.Request)) ([]byte, *http.Response, error) {
 	url := fmt.Sprintf("%s%s", r.endpoint, path)
 
-	wrapperFunc := r.setContextIDHeader(f)
+	wrapperFunc := r.setRequestContext(r.setContextIDHeader(f))
 
 	resp, err := r.httpClient.GetCustomized(url, wrapperFunc)
 	if err != nil {
@@ -125,7 +134,7 @@ func (r ClientRequest) RawPost(path string, payload []byte, f func(*http.Request
 		}
 	}
 
-	wrapperFunc = r.setContextIDHeader(wrapperFunc)
+	wrapperFunc = r.setRequestContext(r.setContextIDHeader(wrapperFunc))
 
 	resp, err := r.httpClient.PostCustomized(url, payload, wrapperFunc)
 	if err != nil {
@@ -139,7 +148,7 @@ func (r ClientRequest) RawPost(path string, payload []byte, f func(*http.Request
 func (r ClientRequest) RawPut(path string, payload []byte, f func(*http.Request)) ([]byte, *http.Response, error) {
 	url := fmt.Sprintf("%s%s", r.endpoint, path)
 
-	wrapperFunc := r.setContextIDHeader(f)
+	wrapperFunc := r.setRequestContext(r.setContextIDHeader(f))
 
 	resp, err := r.httpClient.PutCustomized(url, payload, wrapperFunc)
 	if err != nil {
@@ -153,7 +162,7 @@ func (r ClientRequest) RawPut(path string, payload []byte, f func(*http.Request)
 func (r ClientRequest) RawDelete(path string) ([]byte, *http.Response, error) {
 	url := fmt.Sprintf("%s%s", r.endpoint, path)
 
-	resp, err := r.httpClient.Delete(url)
+	resp, err := r.httpClient.DeleteCustomized(url, r.setRequestContext(nil))
 	if err != nil {
 		return nil, nil, bosherr.WrapErrorf(err, "Performing request DELETE '%s'", url)
 	}
@@ -172,6 +181,17 @@ func (r ClientRequest) setContextIDHeader(f func(*http.Request)) func(*http.Requ
 	}
 }
 
+func (r ClientRequest) setRequestContext(f func(*http.Request)) func(*http.Request) {
+	return func(req *http.Request) {
+		if f != nil {
+			f(req)
+		}
+		if r.requestCtx != nil {
+			*req = *req.WithContext(r.requestCtx)
+		}
+	}
+}
+
 func (r ClientRequest) optionallyFollowResponse(url string, resp *http.Response) ([]byte, *http.Response, error) {
 	body, resp, err := r.readResponse(resp, nil)
 	if err != nil {
@@ -2,6 +2,7 @@ package director_test
 
 import (
 	"bytes"
+	"context"
 	"crypto/tls"
 	"fmt"
 	"io"
@@ -230,6 +231,21 @@ var _ = Describe("ClientRequest", func() {
 					Expect(err).ToNot(HaveOccurred())
 				})
 			})
+
+			Context("when request context is done", func() {
+				It("returns error without making request", func() {
+					ctx, cancel := context.WithCancel(context.Background())
+					cancel()
+
+					req = req.WithRequestContext(ctx)
+
+					_, _, err := req.RawGet("/path", nil, nil)
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(ContainSubstring("context canceled"))
+
+					Expect(server.ReceivedRequests()).To(BeEmpty())
+				})
+			})
 		})
 
 		Describe("Request logging", func() {
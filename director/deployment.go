@@ -462,6 +462,14 @@ func (c Client) UpdateDeployment(manifest []byte, opts UpdateOpts) error {
 		query.Add("recreate", "true")
 	}
 
+	if opts.RecreatePersistentDisks {
+		query.Add("recreate_persistent_disks", "true")
+	}
+
+	if len(opts.RecreateInstanceGroups) > 0 {
+		query.Add("recreate_instance_groups", strings.Join(opts.RecreateInstanceGroups, ","))
+	}
+
 	if opts.Fix {
 		query.Add("fix", "true")
 	}
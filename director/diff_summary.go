@@ -0,0 +1,109 @@
+package director
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+type DiffSummary struct {
+	Added   int
+	Removed int
+
+	// InstanceGroups lists names of instance groups with changes in order of appearance
+	InstanceGroups []string
+}
+
+var (
+	diffSectionRegexp = regexp.MustCompile(`\A([^\s-][^:]*):`)
+	diffGroupRegexp   = regexp.MustCompile(`\A- name: (\S+)`)
+)
+
+func (d DeploymentDiff) Summary() DiffSummary {
+	return NewDiffSummary(DiffLines(d.Diff))
+}
+
+func NewDiffSummary(lines DiffLines) DiffSummary {
+	var summary DiffSummary
+	var section, group string
+
+	seenGroups := map[string]struct{}{}
+
+	for _, line := range lines {
+		if len(line) < 2 {
+			continue
+		}
+
+		text, _ := line[0].(string)
+		lineMod, _ := line[1].(string)
+
+		if matches := diffSectionRegexp.FindStringSubmatch(text); len(matches) > 0 {
+			section = matches[1]
+			group = ""
+		} else if section == "instance_groups" || section == "jobs" {
+			if matches := diffGroupRegexp.FindStringSubmatch(text); len(matches) > 0 {
+				group = matches[1]
+			}
+		}
+
+		switch lineMod {
+		case "added":
+			summary.Added++
+		case "removed":
+			summary.Removed++
+		default:
+			continue
+		}
+
+		if len(group) > 0 {
+			if _, found := seenGroups[group]; !found {
+				seenGroups[group] = struct{}{}
+				summary.InstanceGroups = append(summary.InstanceGroups, group)
+			}
+		}
+	}
+
+	return summary
+}
+
+func (s DiffSummary) HasChanges() bool {
+	return s.Added > 0 || s.Removed > 0
+}
+
+// Line returns compact single line summary (e.g. "dep: +3 -1 ~2 (web, worker changed)")
+func (s DiffSummary) Line(deploymentName string) string {
+	if !s.HasChanges() {
+		return fmt.Sprintf("%s: no changes", deploymentName)
+	}
+
+	line := fmt.Sprintf("%s: +%d -%d ~%d", deploymentName, s.Added, s.Removed, len(s.InstanceGroups))
+
+	if len(s.InstanceGroups) > 0 {
+		line += fmt.Sprintf(" (%s changed)", strings.Join(s.InstanceGroups, ", "))
+	}
+
+	return line
+}
+
+// Description returns human readable summary (e.g. "12 changes across 3 instance groups")
+func (s DiffSummary) Description() string {
+	if !s.HasChanges() {
+		return "no changes"
+	}
+
+	desc := pluralizeDiffCount(s.Added+s.Removed, "change", "changes")
+
+	if len(s.InstanceGroups) > 0 {
+		desc += " across " + pluralizeDiffCount(len(s.InstanceGroups), "instance group", "instance groups")
+	}
+
+	return desc
+}
+
+func pluralizeDiffCount(count int, singular, plural string) string {
+	if count == 1 {
+		return fmt.Sprintf("%d %s", count, singular)
+	}
+
+	return fmt.Sprintf("%d %s", count, plural)
+}
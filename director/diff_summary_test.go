@@ -0,0 +1,103 @@
+package director_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-cli/director"
+)
+
+var _ = Describe("DiffSummary", func() {
+	Describe("NewDiffSummary", func() {
+		It("counts added and removed lines and finds changed instance groups", func() {
+			summary := NewDiffSummary(DiffLines{
+				{"name: dep", nil},
+				{"instance_groups:", nil},
+				{"- name: web", nil},
+				{"  instances: 1", "removed"},
+				{"  instances: 2", "added"},
+				{"- name: db", nil},
+				{"  instances: 1", nil},
+				{"- name: worker", "added"},
+				{"  instances: 1", "added"},
+				{"properties:", nil},
+				{"  name: web", "added"},
+			})
+
+			Expect(summary).To(Equal(DiffSummary{
+				Added:          4,
+				Removed:        1,
+				InstanceGroups: []string{"web", "worker"},
+			}))
+		})
+
+		It("finds changed instance groups in legacy jobs section", func() {
+			summary := NewDiffSummary(DiffLines{
+				{"jobs:", nil},
+				{"- name: web", nil},
+				{"  instances: 2", "added"},
+				{"  instances: 2", "added"},
+			})
+
+			Expect(summary).To(Equal(DiffSummary{
+				Added:          2,
+				InstanceGroups: []string{"web"},
+			}))
+		})
+
+		It("returns empty summary for empty diff", func() {
+			summary := NewDiffSummary(nil)
+			Expect(summary).To(Equal(DiffSummary{}))
+			Expect(summary.HasChanges()).To(BeFalse())
+		})
+	})
+
+	Describe("Summary", func() {
+		It("summarizes deployment diff", func() {
+			diff := NewDeploymentDiff([][]interface{}{
+				{"instance_groups:", nil},
+				{"- name: web", nil},
+				{"  instances: 2", "added"},
+			}, nil)
+
+			Expect(diff.Summary()).To(Equal(DiffSummary{Added: 1, InstanceGroups: []string{"web"}}))
+		})
+	})
+
+	Describe("Line", func() {
+		It("returns compact summary", func() {
+			summary := DiffSummary{Added: 3, Removed: 1, InstanceGroups: []string{"web", "worker"}}
+			Expect(summary.Line("dep")).To(Equal("dep: +3 -1 ~2 (web, worker changed)"))
+		})
+
+		It("does not include instance groups if none changed", func() {
+			summary := DiffSummary{Added: 1}
+			Expect(summary.Line("dep")).To(Equal("dep: +1 -0 ~0"))
+		})
+
+		It("returns no changes if there are no changes", func() {
+			Expect(DiffSummary{}.Line("dep")).To(Equal("dep: no changes"))
+		})
+	})
+
+	Describe("Description", func() {
+		It("returns number of changes and changed instance groups", func() {
+			summary := DiffSummary{Added: 9, Removed: 3, InstanceGroups: []string{"web", "worker", "db"}}
+			Expect(summary.Description()).To(Equal("12 changes across 3 instance groups"))
+		})
+
+		It("uses singular forms for single change", func() {
+			summary := DiffSummary{Removed: 1, InstanceGroups: []string{"web"}}
+			Expect(summary.Description()).To(Equal("1 change across 1 instance group"))
+		})
+
+		It("does not include instance groups if none changed", func() {
+			summary := DiffSummary{Added: 2}
+			Expect(summary.Description()).To(Equal("2 changes"))
+		})
+
+		It("returns no changes if there are no changes", func() {
+			Expect(DiffSummary{}.Description()).To(Equal("no changes"))
+		})
+	})
+})
@@ -1,6 +1,7 @@
 package director
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -17,6 +18,10 @@ func (d DirectorImpl) WithContext(id string) Director {
 	return DirectorImpl{client: d.client.WithContext(id)}
 }
 
+func (d DirectorImpl) WithRequestContext(ctx context.Context) Director {
+	return DirectorImpl{client: d.client.WithRequestContext(ctx)}
+}
+
 func (d DirectorImpl) EnableResurrection(enabled bool) error {
 	return d.client.EnableResurrectionAll(enabled)
 }
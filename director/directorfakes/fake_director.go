@@ -2,6 +2,7 @@
 package directorfakes
 
 import (
+	"context"
 	"io"
 	"sync"
 
@@ -24,6 +25,14 @@ type FakeDirector struct {
 	withContextReturns struct {
 		result1 director.Director
 	}
+	WithRequestContextStub        func(ctx context.Context) director.Director
+	withRequestContextMutex       sync.RWMutex
+	withRequestContextArgsForCall []struct {
+		ctx context.Context
+	}
+	withRequestContextReturns struct {
+		result1 director.Director
+	}
 	InfoStub        func() (director.Info, error)
 	infoMutex       sync.RWMutex
 	infoArgsForCall []struct{}
@@ -358,6 +367,38 @@ func (fake *FakeDirector) WithContextReturns(result1 director.Director) {
 	}{result1}
 }
 
+func (fake *FakeDirector) WithRequestContext(ctx context.Context) director.Director {
+	fake.withRequestContextMutex.Lock()
+	fake.withRequestContextArgsForCall = append(fake.withRequestContextArgsForCall, struct {
+		ctx context.Context
+	}{ctx})
+	fake.recordInvocation("WithRequestContext", []interface{}{ctx})
+	fake.withRequestContextMutex.Unlock()
+	if fake.WithRequestContextStub != nil {
+		return fake.WithRequestContextStub(ctx)
+	}
+	return fake.withRequestContextReturns.result1
+}
+
+func (fake *FakeDirector) WithRequestContextCallCount() int {
+	fake.withRequestContextMutex.RLock()
+	defer fake.withRequestContextMutex.RUnlock()
+	return len(fake.withRequestContextArgsForCall)
+}
+
+func (fake *FakeDirector) WithRequestContextArgsForCall(i int) context.Context {
+	fake.withRequestContextMutex.RLock()
+	defer fake.withRequestContextMutex.RUnlock()
+	return fake.withRequestContextArgsForCall[i].ctx
+}
+
+func (fake *FakeDirector) WithRequestContextReturns(result1 director.Director) {
+	fake.WithRequestContextStub = nil
+	fake.withRequestContextReturns = struct {
+		result1 director.Director
+	}{result1}
+}
+
 func (fake *FakeDirector) Info() (director.Info, error) {
 	fake.infoMutex.Lock()
 	fake.infoArgsForCall = append(fake.infoArgsForCall, struct{}{})
@@ -1367,6 +1408,8 @@ func (fake *FakeDirector) Invocations() map[string][][]interface{} {
 	defer fake.isAuthenticatedMutex.RUnlock()
 	fake.withContextMutex.RLock()
 	defer fake.withContextMutex.RUnlock()
+	fake.withRequestContextMutex.RLock()
+	defer fake.withRequestContextMutex.RUnlock()
 	fake.infoMutex.RLock()
 	defer fake.infoMutex.RUnlock()
 	fake.locksMutex.RLock()
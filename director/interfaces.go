@@ -1,6 +1,7 @@
 package director
 
 import (
+	"context"
 	"io"
 	"os"
 	"time"
@@ -14,6 +15,7 @@ import (
 type Director interface {
 	IsAuthenticated() (bool, error)
 	WithContext(id string) Director
+	WithRequestContext(ctx context.Context) Director
 	Info() (Info, error)
 
 	Locks() ([]Lock, error)
@@ -166,13 +168,15 @@ type RecreateOpts struct {
 }
 
 type UpdateOpts struct {
-	Recreate    bool
-	Fix         bool
-	SkipDrain   SkipDrains
-	Canaries    string
-	MaxInFlight string
-	DryRun      bool
-	Diff        DeploymentDiff
+	Recreate                bool
+	RecreatePersistentDisks bool
+	RecreateInstanceGroups  []string
+	Fix                     bool
+	SkipDrain               SkipDrains
+	Canaries                string
+	MaxInFlight             string
+	DryRun                  bool
+	Diff                    DeploymentDiff
 }
 
 //go:generate counterfeiter . ReleaseSeries
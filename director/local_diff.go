@@ -0,0 +1,162 @@
+package director
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	"gopkg.in/yaml.v2"
+)
+
+const localDiffRedactedValue = "<redacted>"
+
+// NewLocalDeploymentDiff compares two manifests without contacting the Director;
+// diff lines are in the same format as the ones returned by the Director.
+// Similarly to the Director values under 'properties' are redacted if redact is true.
+func NewLocalDeploymentDiff(before, after []byte, redact bool) (DeploymentDiff, error) {
+	beforeLines, err := localDiffManifestLines(before, redact)
+	if err != nil {
+		return DeploymentDiff{}, bosherr.WrapError(err, "Parsing previous manifest")
+	}
+
+	afterLines, err := localDiffManifestLines(after, redact)
+	if err != nil {
+		return DeploymentDiff{}, bosherr.WrapError(err, "Parsing current manifest")
+	}
+
+	return NewDeploymentDiff(localDiffLines(beforeLines, afterLines), nil), nil
+}
+
+// localDiffManifestLines normalizes manifest so that only differences in contents
+// are reported; keys are sorted with 'name' going first to make instance groups
+// recognizable in diff summary
+func localDiffManifestLines(bytes []byte, redact bool) ([]string, error) {
+	var obj interface{}
+
+	err := yaml.Unmarshal(bytes, &obj)
+	if err != nil {
+		return nil, err
+	}
+
+	if obj == nil {
+		return nil, nil
+	}
+
+	bytes, err = yaml.Marshal(localDiffNormalize(obj, false, redact))
+	if err != nil {
+		return nil, err
+	}
+
+	return strings.Split(strings.TrimSuffix(string(bytes), "\n"), "\n"), nil
+}
+
+func localDiffNormalize(node interface{}, inProperties, redact bool) interface{} {
+	switch typedNode := node.(type) {
+	case map[interface{}]interface{}:
+		var keys []string
+		keysByStr := map[string]interface{}{}
+
+		for k := range typedNode {
+			keyStr := fmt.Sprintf("%v", k)
+			keysByStr[keyStr] = k
+
+			if keyStr != "name" {
+				keys = append(keys, keyStr)
+			}
+		}
+
+		sort.Strings(keys)
+
+		if _, found := keysByStr["name"]; found {
+			keys = append([]string{"name"}, keys...)
+		}
+
+		var items yaml.MapSlice
+
+		for _, keyStr := range keys {
+			k := keysByStr[keyStr]
+			v := localDiffNormalize(typedNode[k], inProperties || k == "properties", redact)
+			items = append(items, yaml.MapItem{Key: k, Value: v})
+		}
+
+		return items
+
+	case []interface{}:
+		for i, v := range typedNode {
+			typedNode[i] = localDiffNormalize(v, inProperties, redact)
+		}
+		return typedNode
+
+	default:
+		if inProperties && redact {
+			return localDiffRedactedValue
+		}
+		return node
+	}
+}
+
+// localDiffLines finds the longest common subsequence of lines;
+// common prefix and suffix are skipped to keep comparison table small
+func localDiffLines(before, after []string) [][]interface{} {
+	var prefix, suffix int
+
+	for prefix < len(before) && prefix < len(after) && before[prefix] == after[prefix] {
+		prefix++
+	}
+
+	for suffix < len(before)-prefix && suffix < len(after)-prefix &&
+		before[len(before)-1-suffix] == after[len(after)-1-suffix] {
+		suffix++
+	}
+
+	b := before[prefix : len(before)-suffix]
+	a := after[prefix : len(after)-suffix]
+
+	// lcs[i][j] is a length of the longest common subsequence of b[i:] and a[j:]
+	lcs := make([][]int, len(b)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(a)+1)
+	}
+
+	for i := len(b) - 1; i >= 0; i-- {
+		for j := len(a) - 1; j >= 0; j-- {
+			if b[i] == a[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	lines := [][]interface{}{}
+
+	for _, line := range before[:prefix] {
+		lines = append(lines, []interface{}{line, nil})
+	}
+
+	i, j := 0, 0
+
+	for i < len(b) || j < len(a) {
+		switch {
+		case i < len(b) && j < len(a) && b[i] == a[j]:
+			lines = append(lines, []interface{}{b[i], nil})
+			i++
+			j++
+		case i < len(b) && (j == len(a) || lcs[i+1][j] >= lcs[i][j+1]):
+			lines = append(lines, []interface{}{b[i], "removed"})
+			i++
+		default:
+			lines = append(lines, []interface{}{a[j], "added"})
+			j++
+		}
+	}
+
+	for _, line := range before[len(before)-suffix:] {
+		lines = append(lines, []interface{}{line, nil})
+	}
+
+	return lines
+}
@@ -0,0 +1,122 @@
+package director_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-cli/director"
+)
+
+var _ = Describe("NewLocalDeploymentDiff", func() {
+	before := []byte(`name: dep
+instance_groups:
+- name: web
+  instances: 1
+  properties:
+    password: secret1
+- name: db
+  instances: 1
+`)
+
+	It("returns lines of normalized manifests marking added and removed ones", func() {
+		after := []byte(`name: dep
+instance_groups:
+- instances: 2
+  name: web
+  properties:
+    password: secret2
+- name: db
+  instances: 1
+`)
+
+		diff, err := NewLocalDeploymentDiff(before, after, false)
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(diff.Diff).To(Equal([][]interface{}{
+			{"name: dep", nil},
+			{"instance_groups:", nil},
+			{"- name: web", nil},
+			{"  instances: 1", "removed"},
+			{"  instances: 2", "added"},
+			{"  properties:", nil},
+			{"    password: secret1", "removed"},
+			{"    password: secret2", "added"},
+			{"- name: db", nil},
+			{"  instances: 1", nil},
+		}))
+	})
+
+	It("redacts values under properties if asked", func() {
+		after := []byte(`name: dep
+instance_groups:
+- name: web
+  instances: 1
+  properties:
+    password: secret2
+    ports: [80, 443]
+- name: db
+  instances: 1
+`)
+
+		diff, err := NewLocalDeploymentDiff(before, after, true)
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(diff.Diff).To(Equal([][]interface{}{
+			{"name: dep", nil},
+			{"instance_groups:", nil},
+			{"- name: web", nil},
+			{"  instances: 1", nil},
+			{"  properties:", nil},
+			{"    password: <redacted>", nil},
+			{"    ports:", "added"},
+			{"    - <redacted>", "added"},
+			{"    - <redacted>", "added"},
+			{"- name: db", nil},
+			{"  instances: 1", nil},
+		}))
+	})
+
+	It("returns diff without changes if manifests only differ in formatting", func() {
+		diff, err := NewLocalDeploymentDiff(before, []byte(`---
+instance_groups: [{name: web, instances: 1, properties: {password: secret1}}, {name: db, instances: 1}]
+name: dep
+`), false)
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(diff.Summary().HasChanges()).To(BeFalse())
+	})
+
+	It("returns all lines as added if there was no previous manifest", func() {
+		diff, err := NewLocalDeploymentDiff(nil, []byte("name: dep\n"), false)
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(diff.Diff).To(Equal([][]interface{}{{"name: dep", "added"}}))
+	})
+
+	It("finds changed instance groups", func() {
+		after := []byte(`name: dep
+instance_groups:
+- name: web
+  instances: 1
+  properties:
+    password: secret1
+- name: db
+  instances: 2
+`)
+
+		diff, err := NewLocalDeploymentDiff(before, after, false)
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(diff.Summary().InstanceGroups).To(Equal([]string{"db"}))
+	})
+
+	It("returns error if manifests cannot be parsed", func() {
+		_, err := NewLocalDeploymentDiff([]byte("name: ["), before, false)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("Parsing previous manifest"))
+
+		_, err = NewLocalDeploymentDiff(before, []byte("name: ["), false)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("Parsing current manifest"))
+	})
+})
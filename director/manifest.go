@@ -16,6 +16,9 @@ type Manifest struct {
 	Name string
 
 	Releases []ManifestRelease
+
+	Stemcells     []ManifestStemcell
+	ResourcePools []ManifestResourcePool `yaml:"resource_pools"`
 }
 
 type ManifestRelease struct {
@@ -26,6 +29,22 @@ type ManifestRelease struct {
 	SHA1 string
 }
 
+type ManifestStemcell struct {
+	Alias   string
+	Name    string
+	OS      string
+	Version string
+
+	URL  string
+	SHA1 string
+}
+
+// ManifestResourcePool is only used to find stemcells in v1 manifests
+type ManifestResourcePool struct {
+	Name     string
+	Stemcell ManifestStemcell
+}
+
 func NewManifestFromPath(path string, fs boshsys.FileSystem) (Manifest, error) {
 	var manifest Manifest
 
@@ -27,8 +27,9 @@ func NewTaskClientRequest(
 }
 
 type taskShortResp struct {
-	ID    int    // 165
-	State string // e.g. "queued", "processing", "done", "error", "cancelled"
+	ID     int    // 165
+	State  string // e.g. "queued", "processing", "done", "error", "cancelled"
+	Result string // e.g. error description for failed tasks
 }
 
 func (r taskShortResp) IsRunning() bool {
@@ -119,9 +120,7 @@ func (r TaskClientRequest) WaitForCompletion(id int, type_ string, taskReporter
 			return nil
 		}
 
-		msgFmt := "Expected task '%d' to succeed but was state is '%s'"
-
-		return bosherr.Errorf(msgFmt, taskResp.ID, taskResp.State)
+		return TaskError{ID: taskResp.ID, State: taskResp.State, Result: taskResp.Result}
 	}
 }
 
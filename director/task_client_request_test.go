@@ -334,6 +334,23 @@ var _ = Describe("TaskClientRequest", func() {
 			Expect(taskReporter.TaskFinishedCallCount()).To(Equal(1))
 		})
 
+		It("returns task error with task result if task fails", func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/tasks/123"),
+					ghttp.RespondWith(http.StatusOK, `{"id":123, "state":"error", "result":"fake-result"}`),
+				),
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/tasks/123/output", "type=event"),
+					ghttp.RespondWith(http.StatusOK, ""),
+				),
+			)
+
+			err := act()
+			Expect(err).To(Equal(TaskError{ID: 123, State: "error", Result: "fake-result"}))
+			Expect(err.Error()).To(Equal("Expected task '123' to succeed but was state is 'error'"))
+		})
+
 		It("notifies task reporter when task has started, progressed and finished", func() {
 			server.AppendHandlers(
 				// #1: not satisfiable
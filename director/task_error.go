@@ -0,0 +1,64 @@
+package director
+
+import (
+	"fmt"
+	"strings"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+)
+
+// TaskError is returned when a task did not finish successfully;
+// Result keeps the description of the failure reported by the Director
+type TaskError struct {
+	ID     int
+	State  string
+	Result string
+}
+
+func (e TaskError) Error() string {
+	return fmt.Sprintf("Expected task '%d' to succeed but was state is '%s'", e.ID, e.State)
+}
+
+// deploymentLockedMsgs are reported by the Director when a task
+// cannot acquire deployment lock held by another task
+var deploymentLockedMsgs = []string{
+	"Failed to acquire lock for lock:deployment:",
+	"Timed out getting lock",
+}
+
+// IsDeploymentLockedError returns true if err or any of its causes
+// reports that deployment is locked by another task
+func IsDeploymentLockedError(err error) bool {
+	for err != nil {
+		if taskErr, ok := err.(TaskError); ok && isDeploymentLockedMsg(taskErr.Result) {
+			return true
+		}
+
+		if isDeploymentLockedMsg(err.Error()) {
+			return true
+		}
+
+		switch typedErr := err.(type) {
+		case bosherr.ComplexError:
+			err = typedErr.Cause
+		case interface {
+			Cause() error
+		}:
+			err = typedErr.Cause()
+		default:
+			err = nil
+		}
+	}
+
+	return false
+}
+
+func isDeploymentLockedMsg(msg string) bool {
+	for _, lockedMsg := range deploymentLockedMsgs {
+		if strings.Contains(msg, lockedMsg) {
+			return true
+		}
+	}
+
+	return false
+}
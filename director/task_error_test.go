@@ -0,0 +1,55 @@
+package director_test
+
+import (
+	"errors"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-cli/director"
+)
+
+type causedError struct {
+	cause error
+}
+
+func (e causedError) Error() string { return "caused" }
+func (e causedError) Cause() error  { return e.cause }
+
+var _ = Describe("IsDeploymentLockedError", func() {
+	lockedTaskErr := TaskError{
+		ID:     123,
+		State:  "error",
+		Result: "Failed to acquire lock for lock:deployment:dep uid: fake-uid. Locking task id is 122",
+	}
+
+	It("returns true for failed task reporting deployment lock", func() {
+		Expect(IsDeploymentLockedError(lockedTaskErr)).To(BeTrue())
+	})
+
+	It("returns true for failed task reporting lock timeout", func() {
+		err := TaskError{ID: 123, State: "error", Result: "Timed out getting lock"}
+		Expect(IsDeploymentLockedError(err)).To(BeTrue())
+	})
+
+	It("returns true if wrapped error is caused by deployment lock", func() {
+		err := bosherr.WrapError(lockedTaskErr, "Updating deployment")
+		Expect(IsDeploymentLockedError(err)).To(BeTrue())
+
+		Expect(IsDeploymentLockedError(causedError{cause: err})).To(BeTrue())
+	})
+
+	It("returns true for director responses reporting deployment lock", func() {
+		err := errors.New("Director responded with non-successful status code '500' response 'Timed out getting lock'")
+		Expect(IsDeploymentLockedError(err)).To(BeTrue())
+	})
+
+	It("returns false for other errors", func() {
+		Expect(IsDeploymentLockedError(nil)).To(BeFalse())
+		Expect(IsDeploymentLockedError(errors.New("fake-err"))).To(BeFalse())
+		Expect(IsDeploymentLockedError(TaskError{ID: 123, State: "error", Result: "fake-result"})).To(BeFalse())
+		Expect(IsDeploymentLockedError(bosherr.WrapError(errors.New("fake-err"), "Updating deployment"))).To(BeFalse())
+		Expect(IsDeploymentLockedError(causedError{})).To(BeFalse())
+	})
+})
@@ -0,0 +1,114 @@
+package template
+
+import (
+	"fmt"
+	"sort"
+
+	"gopkg.in/yaml.v2"
+)
+
+// keyOrder reorders map keys of evaluated objects to follow their order in the source template
+type keyOrder struct {
+	src interface{}
+}
+
+func newKeyOrder(bytes []byte) keyOrder {
+	var src yaml.MapSlice
+
+	// Templates that are not maps at the root are left in default (sorted) order
+	err := yaml.Unmarshal(bytes, &src)
+	if err != nil {
+		return keyOrder{}
+	}
+
+	return keyOrder{src: src}
+}
+
+// Apply returns obj with maps converted into yaml.MapSlice; keys that are not
+// found in the source template (e.g. added by ops) follow known keys in sorted order
+func (o keyOrder) Apply(obj interface{}) interface{} {
+	return o.apply(obj, o.src)
+}
+
+func (o keyOrder) apply(node, src interface{}) interface{} {
+	switch typedNode := node.(type) {
+	case map[interface{}]interface{}:
+		srcMap, _ := src.(yaml.MapSlice)
+
+		ordered := yaml.MapSlice{}
+		added := map[interface{}]struct{}{}
+
+		for _, srcItem := range srcMap {
+			if _, found := added[srcItem.Key]; found {
+				continue
+			}
+
+			val, found := typedNode[srcItem.Key]
+			if !found {
+				continue
+			}
+
+			ordered = append(ordered, yaml.MapItem{Key: srcItem.Key, Value: o.apply(val, srcItem.Value)})
+			added[srcItem.Key] = struct{}{}
+		}
+
+		var otherKeys []interface{}
+
+		for k := range typedNode {
+			if _, found := added[k]; !found {
+				otherKeys = append(otherKeys, k)
+			}
+		}
+
+		sort.Sort(keysByString(otherKeys))
+
+		for _, k := range otherKeys {
+			ordered = append(ordered, yaml.MapItem{Key: k, Value: o.apply(typedNode[k], nil)})
+		}
+
+		return ordered
+
+	case []interface{}:
+		srcSlice, _ := src.([]interface{})
+
+		ordered := make([]interface{}, len(typedNode))
+
+		for i, item := range typedNode {
+			ordered[i] = o.apply(item, o.srcItem(srcSlice, item, i))
+		}
+
+		return ordered
+	}
+
+	return node
+}
+
+// srcItem finds source item by its name (since ops may insert or remove items)
+// falling back to the item at the same index
+func (o keyOrder) srcItem(srcSlice []interface{}, item interface{}, idx int) interface{} {
+	if itemMap, ok := item.(map[interface{}]interface{}); ok {
+		if name, ok := itemMap["name"].(string); ok {
+			for _, srcItem := range srcSlice {
+				if srcItemMap, ok := srcItem.(yaml.MapSlice); ok {
+					for _, srcMapItem := range srcItemMap {
+						if srcMapItem.Key == "name" && srcMapItem.Value == name {
+							return srcItem
+						}
+					}
+				}
+			}
+		}
+	}
+
+	if idx < len(srcSlice) {
+		return srcSlice[idx]
+	}
+
+	return nil
+}
+
+type keysByString []interface{}
+
+func (s keysByString) Len() int           { return len(s) }
+func (s keysByString) Less(i, j int) bool { return fmt.Sprintf("%v", s[i]) < fmt.Sprintf("%v", s[j]) }
+func (s keysByString) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
@@ -0,0 +1,36 @@
+package template
+
+import (
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+)
+
+// VariableProvider resolves variables from an external source (e.g. a secrets manager);
+// name may contain slashes (e.g. 'secret/path/key')
+type VariableProvider interface {
+	Get(name string) (interface{}, bool, error)
+}
+
+// ProviderVars makes VariableProvider usable as Variables
+type ProviderVars struct {
+	provider VariableProvider
+}
+
+func NewProviderVars(provider VariableProvider) ProviderVars {
+	return ProviderVars{provider}
+}
+
+var _ Variables = ProviderVars{}
+
+func (v ProviderVars) Get(varDef VariableDefinition) (interface{}, bool, error) {
+	val, found, err := v.provider.Get(varDef.Name)
+	if err != nil {
+		return nil, false, bosherr.WrapErrorf(err, "Getting variable '%s' from variable provider", varDef.Name)
+	}
+
+	return val, found, nil
+}
+
+// List returns no definitions since providers are not expected to enumerate their variables
+func (v ProviderVars) List() ([]VariableDefinition, error) {
+	return nil, nil
+}
@@ -0,0 +1,76 @@
+package template_test
+
+import (
+	"errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-cli/director/template"
+)
+
+type fakeVariableProvider struct {
+	vars     map[string]interface{}
+	getErr   error
+	getNames []string
+}
+
+func (p *fakeVariableProvider) Get(name string) (interface{}, bool, error) {
+	p.getNames = append(p.getNames, name)
+
+	if p.getErr != nil {
+		return nil, false, p.getErr
+	}
+
+	val, found := p.vars[name]
+
+	return val, found, nil
+}
+
+var _ = Describe("ProviderVars", func() {
+	var (
+		provider *fakeVariableProvider
+		vars     ProviderVars
+	)
+
+	BeforeEach(func() {
+		provider = &fakeVariableProvider{
+			vars: map[string]interface{}{"secret/path/key": "foo"},
+		}
+		vars = NewProviderVars(provider)
+	})
+
+	Describe("Get", func() {
+		It("returns value and found if provider finds variable", func() {
+			val, found, err := vars.Get(VariableDefinition{Name: "secret/path/key"})
+			Expect(val).To(Equal("foo"))
+			Expect(found).To(BeTrue())
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(provider.getNames).To(Equal([]string{"secret/path/key"}))
+		})
+
+		It("returns nil and not found if provider does not find variable", func() {
+			val, found, err := vars.Get(VariableDefinition{Name: "secret/other"})
+			Expect(val).To(BeNil())
+			Expect(found).To(BeFalse())
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("returns error if provider fails", func() {
+			provider.getErr = errors.New("fake-err")
+
+			_, _, err := vars.Get(VariableDefinition{Name: "secret/path/key"})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("Getting variable 'secret/path/key' from variable provider: fake-err"))
+		})
+	})
+
+	Describe("List", func() {
+		It("returns no definitions", func() {
+			defs, err := vars.List()
+			Expect(defs).To(BeEmpty())
+			Expect(err).ToNot(HaveOccurred())
+		})
+	})
+})
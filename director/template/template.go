@@ -20,8 +20,25 @@ type EvaluateOpts struct {
 	ExpectAllVarsUsed     bool
 	PostVarSubstitutionOp patch.Op
 	UnescapedMultiline    bool
+
+	// PreserveKeyOrder keeps map keys in the order they appear in the template
+	// instead of sorting them; it's ignored when PostVarSubstitutionOp is given
+	PreserveKeyOrder bool
+
+	// InterpolationPasses bounds how many times variable references found
+	// in resolved values (e.g. '((a))' resolving to '((b))') are resolved again;
+	// values of 0 and 1 resolve references only once
+	InterpolationPasses int
+
+	// SlashedVariableNames allows variable names with slashes (e.g. '((secret/path/key))');
+	// it's meant to be set only when variables are resolved via a VariableProvider
+	SlashedVariableNames bool
 }
 
+// DefaultInterpolationPasses is a number of interpolation passes
+// sufficient for resolving commonly chained variable references
+const DefaultInterpolationPasses = 3
+
 func NewTemplate(bytes []byte) Template {
 	return Template{bytes: bytes}
 }
@@ -41,7 +58,11 @@ func (t Template) Evaluate(vars Variables, op patch.Op, opts EvaluateOpts) ([]by
 		}
 	}
 
-	obj, err = t.interpolateRoot(obj, newVarsTracker(vars, opts.ExpectAllKeys, opts.ExpectAllVarsUsed))
+	tracker := newVarsTracker(vars, opts.ExpectAllKeys, opts.ExpectAllVarsUsed)
+
+	i := interpolator{passes: opts.InterpolationPasses, slashedNames: opts.SlashedVariableNames}
+
+	obj, err = t.interpolateRoot(obj, tracker, i)
 	if err != nil {
 		return []byte{}, err
 	}
@@ -57,6 +78,10 @@ func (t Template) Evaluate(vars Variables, op patch.Op, opts EvaluateOpts) ([]by
 		return []byte(fmt.Sprintf("%s\n", obj)), nil
 	}
 
+	if opts.PreserveKeyOrder && opts.PostVarSubstitutionOp == nil {
+		obj = newKeyOrder(t.bytes).Apply(obj)
+	}
+
 	bytes, err := yaml.Marshal(obj)
 	if err != nil {
 		return []byte{}, err
@@ -65,13 +90,13 @@ func (t Template) Evaluate(vars Variables, op patch.Op, opts EvaluateOpts) ([]by
 	return bytes, nil
 }
 
-func (t Template) interpolateRoot(obj interface{}, tracker varsTracker) (interface{}, error) {
+func (t Template) interpolateRoot(obj interface{}, tracker varsTracker, i interpolator) (interface{}, error) {
 	err := tracker.ExtractDefinitions(obj)
 	if err != nil {
 		return nil, err
 	}
 
-	obj, err = interpolator{}.Interpolate(obj, varsLookup{tracker})
+	obj, err = i.Interpolate(obj, varsLookup{tracker})
 	if err != nil {
 		return nil, err
 	}
@@ -79,13 +104,26 @@ func (t Template) interpolateRoot(obj interface{}, tracker varsTracker) (interfa
 	return obj, tracker.Error()
 }
 
-type interpolator struct{}
+type interpolator struct {
+	passes       int
+	chain        []string // names of variables whose values are being interpolated
+	slashedNames bool
+}
 
 var (
-	interpolationRegex         = regexp.MustCompile(`\(\((!?[-\.\w\pL]+)\)\)`)
+	interpolationRegex         = regexp.MustCompile(`\(\((!?[-\.\w\pL]+)((?:\s*\|\s*\w+)*)\s*\)\)`)
 	interpolationAnchoredRegex = regexp.MustCompile("\\A" + interpolationRegex.String() + "\\z")
+
+	slashedInterpolationRegex         = regexp.MustCompile(`\(\((!?[-/\.\w\pL]+)((?:\s*\|\s*\w+)*)\s*\)\)`)
+	slashedInterpolationAnchoredRegex = regexp.MustCompile("\\A" + slashedInterpolationRegex.String() + "\\z")
 )
 
+// VariableReferences returns variable references (e.g. '((name))') found in the string;
+// it can be used to find references left unresolved after evaluation
+func VariableReferences(str string) []string {
+	return interpolationRegex.FindAllString(str, -1)
+}
+
 func (i interpolator) Interpolate(node interface{}, varsLookup varsLookup) (interface{}, error) {
 	switch typedNode := node.(type) {
 	case map[interface{}]interface{}:
@@ -114,23 +152,39 @@ func (i interpolator) Interpolate(node interface{}, varsLookup varsLookup) (inte
 		}
 
 	case string:
-		for _, name := range i.extractVarNames(typedNode) {
+		refs, err := i.extractVarRefs(typedNode)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, ref := range refs {
+			name := ref.Name
+
 			foundVal, found, err := varsLookup.Get(name)
 			if err != nil {
 				return nil, bosherr.WrapErrorf(err, "Finding variable '%s'", name)
 			}
 
 			if found {
+				foundVal, err = i.interpolateNested(name, foundVal, varsLookup)
+				if err != nil {
+					return nil, err
+				}
+
+				foundVal, err = ref.Transform(foundVal)
+				if err != nil {
+					return nil, err
+				}
+
 				// ensure that value type is preserved when replacing the entire field
-				if interpolationAnchoredRegex.MatchString(typedNode) {
+				if i.anchoredRegex().MatchString(typedNode) {
 					return foundVal, nil
 				}
 
 				switch foundVal.(type) {
 				case string, int, int16, int32, int64, uint, uint16, uint32, uint64:
 					foundValStr := fmt.Sprintf("%v", foundVal)
-					typedNode = strings.Replace(typedNode, fmt.Sprintf("((%s))", name), foundValStr, -1)
-					typedNode = strings.Replace(typedNode, fmt.Sprintf("((!%s))", name), foundValStr, -1)
+					typedNode = strings.Replace(typedNode, ref.Placeholder, foundValStr, -1)
 				default:
 					errMsg := "Invalid type '%T' for value '%v' and variable '%s'. Supported types for interpolation within a string are integers and strings."
 					return nil, fmt.Errorf(errMsg, foundVal, foundVal, name)
@@ -144,14 +198,59 @@ func (i interpolator) Interpolate(node interface{}, varsLookup varsLookup) (inte
 	return node, nil
 }
 
-func (i interpolator) extractVarNames(value string) []string {
-	var names []string
+// interpolateNested resolves variable references found in a string value
+// of a variable as long as the number of passes allows it
+func (i interpolator) interpolateNested(name string, val interface{}, varsLookup varsLookup) (interface{}, error) {
+	str, ok := val.(string)
+	if !ok || i.passes <= 1 || !i.regex().MatchString(str) {
+		return val, nil
+	}
+
+	chain := append(append([]string{}, i.chain...), name)
+
+	for _, prevName := range i.chain {
+		if prevName == name {
+			return nil, bosherr.Errorf(
+				"Detected cycle in variable references: %s", strings.Join(chain, " -> "))
+		}
+	}
+
+	if len(chain) >= i.passes {
+		return nil, bosherr.Errorf(
+			"Expected variable references to be resolved within %d interpolation passes: %s -> %s",
+			i.passes, strings.Join(chain, " -> "), str)
+	}
+
+	return interpolator{passes: i.passes, chain: chain, slashedNames: i.slashedNames}.Interpolate(str, varsLookup)
+}
 
-	for _, match := range interpolationRegex.FindAllSubmatch([]byte(value), -1) {
-		names = append(names, strings.TrimPrefix(string(match[1]), "!"))
+func (i interpolator) extractVarRefs(value string) ([]varRef, error) {
+	var refs []varRef
+
+	for _, match := range i.regex().FindAllStringSubmatch(value, -1) {
+		ref, err := newVarRef(match)
+		if err != nil {
+			return nil, err
+		}
+
+		refs = append(refs, ref)
+	}
+
+	return refs, nil
+}
+
+func (i interpolator) regex() *regexp.Regexp {
+	if i.slashedNames {
+		return slashedInterpolationRegex
 	}
+	return interpolationRegex
+}
 
-	return names
+func (i interpolator) anchoredRegex() *regexp.Regexp {
+	if i.slashedNames {
+		return slashedInterpolationAnchoredRegex
+	}
+	return interpolationAnchoredRegex
 }
 
 type varsLookup struct {
@@ -33,6 +33,24 @@ var _ = Describe("Template", func() {
 		Expect(result).To(Equal([]byte("foo: bar\n")))
 	})
 
+	It("can interpolate values of variables with slashes in their names if allowed", func() {
+		template := NewTemplate([]byte("key: ((secret/path/key))\nother: prefix-((secret/other))"))
+		vars := StaticVariables{"secret/path/key": "foo", "secret/other": "bar"}
+
+		result, err := template.Evaluate(vars, nil, EvaluateOpts{SlashedVariableNames: true})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result).To(Equal([]byte("key: foo\nother: prefix-bar\n")))
+	})
+
+	It("leaves references with slashes in variable names as is by default", func() {
+		template := NewTemplate([]byte("key: ((secret/path/key))"))
+		vars := StaticVariables{"secret/path/key": "foo"}
+
+		result, err := template.Evaluate(vars, nil, EvaluateOpts{ExpectAllKeys: true})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result).To(Equal([]byte("key: ((secret/path/key))\n")))
+	})
+
 	It("can interpolate boolean values into a byte slice", func() {
 		template := NewTemplate([]byte("otherstuff: ((boule))"))
 		vars := StaticVariables{"boule": true}
@@ -244,6 +262,47 @@ dup-key: ((key3))
 		Expect(result).To(Equal([]byte("acct_and_password: nats:nats\n")))
 	})
 
+	It("can apply transforms to interpolated values", func() {
+		template := NewTemplate([]byte("cert: ((cert | base64))\nname: ((name|trim))\nurl: https://((host | trim)):((port))"))
+		vars := StaticVariables{
+			"cert": "cert-val",
+			"name": "  name-val\n",
+			"host": " host-val ",
+			"port": 4222,
+		}
+
+		result, err := template.Evaluate(vars, nil, EvaluateOpts{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result).To(Equal([]byte("cert: Y2VydC12YWw=\nname: name-val\nurl: https://host-val:4222\n")))
+	})
+
+	It("can apply multiple transforms in order", func() {
+		template := NewTemplate([]byte("cert: ((!cert | trim | base64))"))
+		vars := StaticVariables{"cert": " cert-val\n"}
+
+		result, err := template.Evaluate(vars, nil, EvaluateOpts{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result).To(Equal([]byte("cert: Y2VydC12YWw=\n")))
+	})
+
+	It("returns an error if transform is unknown", func() {
+		template := NewTemplate([]byte("cert: ((cert | unknown))"))
+		vars := StaticVariables{"cert": "cert-val"}
+
+		_, err := template.Evaluate(vars, nil, EvaluateOpts{})
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(Equal("Unknown transform 'unknown' for variable 'cert'"))
+	})
+
+	It("returns an error if transform cannot be applied to a value", func() {
+		template := NewTemplate([]byte("cert: ((cert | base64))"))
+		vars := StaticVariables{"cert": 123}
+
+		_, err := template.Evaluate(vars, nil, EvaluateOpts{})
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(Equal("Applying transform 'base64' to variable 'cert': Expected value to be a string but was 'int'"))
+	})
+
 	It("can interpolate values into the middle of a key", func() {
 		template := NewTemplate([]byte("((iaas))_cpi: props"))
 		vars := StaticVariables{
@@ -380,6 +439,101 @@ dup-key: ((key3))
 		Expect(result).To(Equal([]byte("value\n")))
 	})
 
+	Context("when PreserveKeyOrder is true", func() {
+		opts := EvaluateOpts{PreserveKeyOrder: true}
+
+		It("keeps map keys in the order they appear in the template", func() {
+			template := NewTemplate([]byte(`name: dep
+releases:
+- version: ((version))
+  name: rel
+instance_groups:
+- name: zookeeper
+  jobs: []
+  azs: [z1]
+`))
+			vars := StaticVariables{"version": "1"}
+
+			result, err := template.Evaluate(vars, nil, opts)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(result)).To(Equal(`name: dep
+releases:
+- version: "1"
+  name: rel
+instance_groups:
+- name: zookeeper
+  jobs: []
+  azs:
+  - z1
+`))
+		})
+
+		It("places keys not found in the template after known keys in sorted order", func() {
+			template := NewTemplate([]byte("z: 1\na: 2\n"))
+			ops := patch.Ops{
+				patch.ReplaceOp{Path: patch.MustNewPointerFromString("/c?"), Value: 3},
+				patch.ReplaceOp{Path: patch.MustNewPointerFromString("/b?"), Value: 4},
+			}
+
+			result, err := template.Evaluate(StaticVariables{}, ops, opts)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(result)).To(Equal("z: 1\na: 2\nb: 4\nc: 3\n"))
+		})
+
+		It("finds order of array items' keys by their names if operations moved items", func() {
+			template := NewTemplate([]byte(`jobs:
+- name: first
+  z: 1
+  a: 2
+- name: second
+  m: 1
+  b: 2
+`))
+			ops := patch.RemoveOp{Path: patch.MustNewPointerFromString("/jobs/0")}
+
+			result, err := template.Evaluate(StaticVariables{}, ops, opts)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(result)).To(Equal(`jobs:
+- name: second
+  m: 1
+  b: 2
+`))
+		})
+
+		It("produces the same output when evaluated multiple times", func() {
+			template := NewTemplate([]byte("name: dep\nb: {z: 1, x: 2}\na: [3]\n"))
+
+			result1, err := template.Evaluate(StaticVariables{}, nil, opts)
+			Expect(err).NotTo(HaveOccurred())
+
+			result2, err := template.Evaluate(StaticVariables{}, nil, opts)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(string(result1)).To(Equal("name: dep\nb:\n  z: 1\n  x: 2\na:\n- 3\n"))
+			Expect(result2).To(Equal(result1))
+		})
+
+		It("keeps sorted order of keys when evaluating template that is not a map", func() {
+			template := NewTemplate([]byte("- {z: 1, a: 2}"))
+
+			result, err := template.Evaluate(StaticVariables{}, nil, opts)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(result)).To(Equal("- a: 2\n  z: 1\n"))
+		})
+
+		It("ignores key order when PostVarSubstitutionOp is given", func() {
+			template := NewTemplate([]byte("a: {z: 1, b: 2}"))
+			opts := EvaluateOpts{
+				PreserveKeyOrder:      true,
+				PostVarSubstitutionOp: patch.FindOp{Path: patch.MustNewPointerFromString("/a")},
+			}
+
+			result, err := template.Evaluate(StaticVariables{}, nil, opts)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(result)).To(Equal("b: 2\nz: 1\n"))
+		})
+	})
+
 	It("provides associated variable definition if found so that variables can be generated", func() {
 		template := NewTemplate([]byte(`abc: ((!key1))
 variables:
@@ -692,4 +846,100 @@ variables:
 		Expect(err).To(HaveOccurred())
 		Expect(err.Error()).To(ContainSubstring("fake-err"))
 	})
+
+	Context("when InterpolationPasses is set", func() {
+		opts := EvaluateOpts{InterpolationPasses: 3}
+
+		It("resolves variable references found in values of variables", func() {
+			template := NewTemplate([]byte("url: ((url))\nport: ((port))\n"))
+			vars := StaticVariables{
+				"url":  "https://((host)):((port))/((path))",
+				"host": "((domain))",
+				"path": "api",
+				"port": 8443,
+
+				"domain": "example.com",
+			}
+
+			result, err := template.Evaluate(vars, nil, opts)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result).To(Equal([]byte("port: 8443\nurl: https://example.com:8443/api\n")))
+		})
+
+		It("preserves type of value resolved from a nested variable reference", func() {
+			template := NewTemplate([]byte("port: ((port))"))
+			vars := StaticVariables{"port": "((default_port))", "default_port": 8443}
+
+			result, err := template.Evaluate(vars, nil, opts)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result).To(Equal([]byte("port: 8443\n")))
+		})
+
+		It("applies transforms after resolving nested variable references", func() {
+			template := NewTemplate([]byte("key: ((key | base64))"))
+			vars := StaticVariables{"key": "((value))", "value": "val"}
+
+			result, err := template.Evaluate(vars, nil, opts)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result).To(Equal([]byte("key: dmFs\n")))
+		})
+
+		It("tracks variables that are missing in values of variables", func() {
+			template := NewTemplate([]byte("key: ((key))"))
+			vars := StaticVariables{"key": "((missing))"}
+
+			_, err := template.Evaluate(vars, nil, EvaluateOpts{ExpectAllKeys: true, InterpolationPasses: 3})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("Expected to find variables: missing"))
+
+			result, err := template.Evaluate(vars, nil, opts)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result).To(Equal([]byte("key: ((missing))\n")))
+		})
+
+		It("returns an error if variable references form a cycle", func() {
+			template := NewTemplate([]byte("key: ((a))"))
+			vars := StaticVariables{"a": "((b))", "b": "prefix-((a))"}
+
+			_, err := template.Evaluate(vars, nil, opts)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("Detected cycle in variable references: a -> b -> a"))
+		})
+
+		It("returns an error if variable references are not resolved within given number of passes", func() {
+			template := NewTemplate([]byte("key: ((a))"))
+			vars := StaticVariables{"a": "((b))", "b": "((c))", "c": "((d))", "d": "val"}
+
+			_, err := template.Evaluate(vars, nil, opts)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal(
+				"Expected variable references to be resolved within 3 interpolation passes: a -> b -> c -> ((d))"))
+
+			vars = StaticVariables{"a": "((b))", "b": "((c))", "c": "val"}
+
+			result, err := template.Evaluate(vars, nil, opts)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result).To(Equal([]byte("key: val\n")))
+		})
+	})
+
+	It("does not resolve variable references found in values of variables by default", func() {
+		template := NewTemplate([]byte("key: ((a))"))
+		vars := StaticVariables{"a": "((b))", "b": "val"}
+
+		result, err := template.Evaluate(vars, nil, EvaluateOpts{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result).To(Equal([]byte("key: ((b))\n")))
+	})
+})
+
+var _ = Describe("VariableReferences", func() {
+	It("returns variable references found in the string", func() {
+		Expect(VariableReferences("https://((host))/rel?v=((version))")).To(Equal(
+			[]string{"((host))", "((version))"}))
+	})
+
+	It("returns no references if string does not contain variables", func() {
+		Expect(VariableReferences("https://host/rel?v=1")).To(BeEmpty())
+	})
 })
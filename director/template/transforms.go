@@ -0,0 +1,72 @@
+package template
+
+import (
+	"encoding/base64"
+	"strings"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+)
+
+type valueTransform func(interface{}) (interface{}, error)
+
+// valueTransforms are applied to variable values via ((name | transform)) syntax
+var valueTransforms = map[string]valueTransform{
+	"base64": func(val interface{}) (interface{}, error) {
+		str, ok := val.(string)
+		if !ok {
+			return nil, bosherr.Errorf("Expected value to be a string but was '%T'", val)
+		}
+
+		return base64.StdEncoding.EncodeToString([]byte(str)), nil
+	},
+
+	"trim": func(val interface{}) (interface{}, error) {
+		str, ok := val.(string)
+		if !ok {
+			return nil, bosherr.Errorf("Expected value to be a string but was '%T'", val)
+		}
+
+		return strings.TrimSpace(str), nil
+	},
+}
+
+type varRef struct {
+	Name        string
+	Transforms  []string
+	Placeholder string
+}
+
+func (r varRef) Transform(val interface{}) (interface{}, error) {
+	for _, name := range r.Transforms {
+		var err error
+
+		val, err = valueTransforms[name](val)
+		if err != nil {
+			return nil, bosherr.WrapErrorf(err, "Applying transform '%s' to variable '%s'", name, r.Name)
+		}
+	}
+
+	return val, nil
+}
+
+func newVarRef(match []string) (varRef, error) {
+	ref := varRef{
+		Name:        strings.TrimPrefix(match[1], "!"),
+		Placeholder: match[0],
+	}
+
+	for _, piece := range strings.Split(match[2], "|") {
+		name := strings.TrimSpace(piece)
+		if len(name) == 0 {
+			continue
+		}
+
+		if _, found := valueTransforms[name]; !found {
+			return varRef{}, bosherr.Errorf("Unknown transform '%s' for variable '%s'", name, ref.Name)
+		}
+
+		ref.Transforms = append(ref.Transforms, name)
+	}
+
+	return ref, nil
+}
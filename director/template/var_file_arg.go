@@ -34,7 +34,7 @@ func (a *VarFileArg) UnmarshalFlag(data string) error {
 
 	bytes, err := a.FS.ReadFile(absPath)
 	if err != nil {
-		return bosherr.WrapErrorf(err, "Reading variable from file '%s'", absPath)
+		return bosherr.WrapErrorf(err, "Reading variable '%s' from file '%s'", pieces[0], absPath)
 	}
 
 	(*a).Vars = StaticVariables{pieces[0]: string(bytes)}
@@ -65,6 +65,12 @@ var _ = Describe("VarFileArg", func() {
 			Expect(err.Error()).To(ContainSubstring("fake-err"))
 		})
 
+		It("returns an error naming variable and path if file does not exist", func() {
+			err := (&arg).UnmarshalFlag("cert_pem=/missing/cert.pem")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Reading variable 'cert_pem' from file '/missing/cert.pem'"))
+		})
+
 		It("returns an error if expanding path fails", func() {
 			fs.ExpandPathErr = errors.New("fake-err")
 
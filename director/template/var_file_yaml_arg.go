@@ -0,0 +1,54 @@
+package template
+
+import (
+	"strings"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+	"gopkg.in/yaml.v2"
+)
+
+// VarFileYAMLArg sets variable to a value parsed from a YAML file
+// so that variable can hold structured values (e.g. maps and lists);
+// VarFileArg should be used to set variable to raw file contents
+type VarFileYAMLArg struct {
+	FS boshsys.FileSystem
+
+	Vars StaticVariables
+}
+
+func (a *VarFileYAMLArg) UnmarshalFlag(data string) error {
+	pieces := strings.SplitN(data, "=", 2)
+	if len(pieces) != 2 {
+		return bosherr.Errorf("Expected var '%s' to be in format 'name=path'", data)
+	}
+
+	if len(pieces[0]) == 0 {
+		return bosherr.Errorf("Expected var '%s' to specify non-empty name", data)
+	}
+
+	if len(pieces[1]) == 0 {
+		return bosherr.Errorf("Expected var '%s' to specify non-empty path", data)
+	}
+
+	absPath, err := a.FS.ExpandPath(pieces[1])
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Getting absolute path '%s'", pieces[1])
+	}
+
+	bytes, err := a.FS.ReadFile(absPath)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Reading variable '%s' from file '%s'", pieces[0], absPath)
+	}
+
+	var val interface{}
+
+	err = yaml.Unmarshal(bytes, &val)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Deserializing variable '%s' from YAML file '%s'", pieces[0], absPath)
+	}
+
+	(*a).Vars = StaticVariables{pieces[0]: val}
+
+	return nil
+}
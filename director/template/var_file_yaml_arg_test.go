@@ -0,0 +1,86 @@
+package template_test
+
+import (
+	"errors"
+
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-cli/director/template"
+)
+
+var _ = Describe("VarFileYAMLArg", func() {
+	Describe("UnmarshalFlag", func() {
+		var (
+			fs  *fakesys.FakeFileSystem
+			arg VarFileYAMLArg
+		)
+
+		BeforeEach(func() {
+			fs = fakesys.NewFakeFileSystem()
+			arg = VarFileYAMLArg{FS: fs}
+		})
+
+		It("sets name and structured value parsed from a YAML file", func() {
+			fs.WriteFileString("/some/path.yml", "key: val\nlist:\n- item1\n- item2\n")
+
+			err := (&arg).UnmarshalFlag("name=/some/path.yml")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(arg.Vars).To(Equal(StaticVariables{
+				"name": map[interface{}]interface{}{
+					"key":  "val",
+					"list": []interface{}{"item1", "item2"},
+				},
+			}))
+		})
+
+		It("sets name and scalar value parsed from a YAML file", func() {
+			fs.WriteFileString("/some/path.yml", "123")
+
+			err := (&arg).UnmarshalFlag("name=/some/path.yml")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(arg.Vars).To(Equal(StaticVariables{"name": 123}))
+		})
+
+		It("returns error if string does not have 2 pieces", func() {
+			err := (&arg).UnmarshalFlag("val")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("Expected var 'val' to be in format 'name=path'"))
+		})
+
+		It("returns error if name is empty", func() {
+			err := (&arg).UnmarshalFlag("=val")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("Expected var '=val' to specify non-empty name"))
+		})
+
+		It("returns error if value is empty", func() {
+			err := (&arg).UnmarshalFlag("name=")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("Expected var 'name=' to specify non-empty path"))
+		})
+
+		It("returns an error naming variable and path if file does not exist", func() {
+			err := (&arg).UnmarshalFlag("config=/missing/config.yml")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Reading variable 'config' from file '/missing/config.yml'"))
+		})
+
+		It("returns an error naming variable and path if file is not valid YAML", func() {
+			fs.WriteFileString("/some/path.yml", "key: [")
+
+			err := (&arg).UnmarshalFlag("config=/some/path.yml")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Deserializing variable 'config' from YAML file '/some/path.yml'"))
+		})
+
+		It("returns an error if expanding path fails", func() {
+			fs.ExpandPathErr = errors.New("fake-err")
+
+			err := (&arg).UnmarshalFlag("var=/some/path.yml")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-err"))
+		})
+	})
+})
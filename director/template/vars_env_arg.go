@@ -43,7 +43,8 @@ func (a *VarsEnvArg) UnmarshalFlag(prefix string) error {
 			return bosherr.WrapErrorf(err, "Deserializing YAML from environment variable '%s'", pieces[0])
 		}
 
-		vars[strings.TrimPrefix(pieces[0], prefix+"_")] = val
+		// Variable names are lowercased since env vars are conventionally uppercased
+		vars[strings.ToLower(strings.TrimPrefix(pieces[0], prefix+"_"))] = val
 	}
 
 	(*a).Vars = vars
@@ -32,6 +32,19 @@ var _ = Describe("VarsEnvArg", func() {
 			}))
 		})
 
+		It("lowercases variable names after stripping prefix", func() {
+			arg.EnvironFunc = func() []string {
+				return []string{"CI_DB_PASSWORD=secret", "CI_Mixed_Key=val", "ci_other=val2"}
+			}
+
+			err := (&arg).UnmarshalFlag("CI")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(arg.Vars).To(Equal(StaticVariables{
+				"db_password": "secret",
+				"mixed_key":   "val",
+			}))
+		})
+
 		It("allows values with equal signs", func() {
 			arg.EnvironFunc = func() []string { return []string{"name_key1=var1=foo"} }
 
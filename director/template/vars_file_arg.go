@@ -1,6 +1,10 @@
 package template
 
 import (
+	"bytes"
+	"sort"
+	"strings"
+
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 	boshsys "github.com/cloudfoundry/bosh-utils/system"
 	"gopkg.in/yaml.v2"
@@ -9,7 +13,12 @@ import (
 type VarsFileArg struct {
 	FS boshsys.FileSystem
 
+	Path string
 	Vars StaticVariables
+
+	// ConflictingKeys lists variables defined in more than one document;
+	// values from later documents take precedence in Vars
+	ConflictingKeys []string
 }
 
 func (a *VarsFileArg) UnmarshalFlag(filePath string) error {
@@ -24,12 +33,78 @@ func (a *VarsFileArg) UnmarshalFlag(filePath string) error {
 
 	var vars StaticVariables
 
-	err = yaml.Unmarshal(bytes, &vars)
-	if err != nil {
-		return bosherr.WrapErrorf(err, "Deserializing variables file '%s'", filePath)
+	conflicts := map[string]struct{}{}
+
+	for _, doc := range splitYAMLDocuments(bytes) {
+		var docVars StaticVariables
+
+		err = yaml.Unmarshal(doc, &docVars)
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Deserializing variables file '%s'", filePath)
+		}
+
+		if vars == nil {
+			vars = docVars
+			continue
+		}
+
+		for k, v := range docVars {
+			if _, found := vars[k]; found {
+				conflicts[k] = struct{}{}
+			}
+			vars[k] = v
+		}
+	}
+
+	var conflictingKeys []string
+
+	for k := range conflicts {
+		conflictingKeys = append(conflictingKeys, k)
 	}
 
+	sort.Strings(conflictingKeys)
+
+	(*a).Path = filePath
 	(*a).Vars = vars
+	(*a).ConflictingKeys = conflictingKeys
 
 	return nil
 }
+
+// splitYAMLDocuments splits content on '---' document separators
+// (keeping content that follows separator on the same line, e.g. '--- {a: 1}')
+// and '...' document end markers; content without them is returned as a single document
+func splitYAMLDocuments(content []byte) [][]byte {
+	var docs [][]byte
+	var current [][]byte
+
+	for _, line := range bytes.Split(content, []byte("\n")) {
+		trimmed := strings.TrimRight(string(line), " \t\r")
+
+		switch {
+		case isYAMLMarker(trimmed, "---"):
+			docs = append(docs, bytes.Join(current, []byte("\n")))
+			current = [][]byte{[]byte(strings.TrimLeft(trimmed[len("---"):], " \t"))}
+
+		case isYAMLMarker(trimmed, "..."):
+			docs = append(docs, bytes.Join(current, []byte("\n")))
+			current = nil
+
+		default:
+			current = append(current, line)
+		}
+	}
+
+	return append(docs, bytes.Join(current, []byte("\n")))
+}
+
+// isYAMLMarker returns true if line starts with marker followed by nothing or whitespace
+func isYAMLMarker(line, marker string) bool {
+	if !strings.HasPrefix(line, marker) {
+		return false
+	}
+
+	rest := line[len(marker):]
+
+	return len(rest) == 0 || rest[0] == ' ' || rest[0] == '\t'
+}
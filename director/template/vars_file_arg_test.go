@@ -27,6 +27,7 @@ var _ = Describe("VarsFileArg", func() {
 
 			err := (&arg).UnmarshalFlag("/some/path")
 			Expect(err).ToNot(HaveOccurred())
+			Expect(arg.Path).To(Equal("/some/path"))
 			Expect(arg.Vars).To(Equal(StaticVariables{
 				"name1": "var1",
 				"name2": "var2",
@@ -56,6 +57,63 @@ var _ = Describe("VarsFileArg", func() {
 			}))
 		})
 
+		It("merges all documents from left to right", func() {
+			fs.WriteFileString("/some/path", "---\nname1: var1\n--- # group2\nname2: var2\n---\nname3: var3\n")
+
+			err := (&arg).UnmarshalFlag("/some/path")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(arg.Vars).To(Equal(StaticVariables{
+				"name1": "var1",
+				"name2": "var2",
+				"name3": "var3",
+			}))
+			Expect(arg.ConflictingKeys).To(BeEmpty())
+		})
+
+		It("keeps document content that follows separator on the same line", func() {
+			fs.WriteFileString("/some/path", "--- {name1: var1}\n---\t{name2: var2}\n---\nname3: var3\n")
+
+			err := (&arg).UnmarshalFlag("/some/path")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(arg.Vars).To(Equal(StaticVariables{
+				"name1": "var1",
+				"name2": "var2",
+				"name3": "var3",
+			}))
+		})
+
+		It("ends documents at '...' document end markers", func() {
+			fs.WriteFileString("/some/path", "name1: var1\n...\n---\nname2: var2\n... # end\nname1: var1-3\n")
+
+			err := (&arg).UnmarshalFlag("/some/path")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(arg.Vars).To(Equal(StaticVariables{
+				"name1": "var1-3",
+				"name2": "var2",
+			}))
+			Expect(arg.ConflictingKeys).To(Equal([]string{"name1"}))
+		})
+
+		It("prefers values from later documents and records conflicting keys", func() {
+			fs.WriteFileString("/some/path", "name2: var2\nname1: var1\n---\nname1: var1-2\nname2: var2-2\n---\nname1: var1-3\n")
+
+			err := (&arg).UnmarshalFlag("/some/path")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(arg.Vars).To(Equal(StaticVariables{
+				"name1": "var1-3",
+				"name2": "var2-2",
+			}))
+			Expect(arg.ConflictingKeys).To(Equal([]string{"name1", "name2"}))
+		})
+
+		It("returns an error if parsing any document fails", func() {
+			fs.WriteFileString("/some/path", "name1: var1\n---\ncontent")
+
+			err := (&arg).UnmarshalFlag("/some/path")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Deserializing variables file '/some/path'"))
+		})
+
 		It("returns an error if reading file fails", func() {
 			fs.WriteFileString("/some/path", "content")
 			fs.ReadFileError = errors.New("fake-err")
@@ -33,6 +33,7 @@ var _ = Describe("TargetProvider", func() {
 			fakeUUIDGenerator,
 			logger,
 			configPath,
+			0,
 		)
 		targetProvider = NewTargetProvider(deploymentStateService, fakeUUIDGenerator, installationsRootPath)
 	})
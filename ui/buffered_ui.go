@@ -0,0 +1,95 @@
+package ui
+
+import (
+	"sync"
+
+	. "github.com/cloudfoundry/bosh-cli/ui/table"
+)
+
+// BufferedUI keeps output until Replay is called so that output
+// of concurrent operations does not interleave; questions are
+// asked via parent UI right away since they cannot be postponed
+type BufferedUI struct {
+	parent UI
+
+	calls     []func(UI)
+	callsLock sync.Mutex
+}
+
+func NewBufferedUI(parent UI) *BufferedUI {
+	return &BufferedUI{parent: parent}
+}
+
+func (ui *BufferedUI) ErrorLinef(pattern string, args ...interface{}) {
+	ui.record(func(parent UI) { parent.ErrorLinef(pattern, args...) })
+}
+
+func (ui *BufferedUI) PrintLinef(pattern string, args ...interface{}) {
+	ui.record(func(parent UI) { parent.PrintLinef(pattern, args...) })
+}
+
+func (ui *BufferedUI) BeginLinef(pattern string, args ...interface{}) {
+	ui.record(func(parent UI) { parent.BeginLinef(pattern, args...) })
+}
+
+func (ui *BufferedUI) EndLinef(pattern string, args ...interface{}) {
+	ui.record(func(parent UI) { parent.EndLinef(pattern, args...) })
+}
+
+func (ui *BufferedUI) PrintBlock(block string) {
+	ui.record(func(parent UI) { parent.PrintBlock(block) })
+}
+
+func (ui *BufferedUI) PrintErrorBlock(block string) {
+	ui.record(func(parent UI) { parent.PrintErrorBlock(block) })
+}
+
+func (ui *BufferedUI) PrintTable(table Table) {
+	ui.record(func(parent UI) { parent.PrintTable(table) })
+}
+
+func (ui *BufferedUI) AskForText(label string) (string, error) {
+	return ui.parent.AskForText(label)
+}
+
+func (ui *BufferedUI) AskForChoice(label string, options []string) (int, error) {
+	return ui.parent.AskForChoice(label, options)
+}
+
+func (ui *BufferedUI) AskForPassword(label string) (string, error) {
+	return ui.parent.AskForPassword(label)
+}
+
+func (ui *BufferedUI) AskForConfirmation() error {
+	return ui.parent.AskForConfirmation()
+}
+
+func (ui *BufferedUI) AskForConfirmationWithLabel(expected string) error {
+	return ui.parent.AskForConfirmationWithLabel(expected)
+}
+
+func (ui *BufferedUI) IsInteractive() bool {
+	return ui.parent.IsInteractive()
+}
+
+// Flush does nothing since parent UI is flushed by its owner
+func (ui *BufferedUI) Flush() {}
+
+// Replay prints output kept so far via parent UI in original order
+func (ui *BufferedUI) Replay() {
+	ui.callsLock.Lock()
+	calls := ui.calls
+	ui.calls = nil
+	ui.callsLock.Unlock()
+
+	for _, call := range calls {
+		call(ui.parent)
+	}
+}
+
+func (ui *BufferedUI) record(call func(UI)) {
+	ui.callsLock.Lock()
+	defer ui.callsLock.Unlock()
+
+	ui.calls = append(ui.calls, call)
+}
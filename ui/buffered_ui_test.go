@@ -0,0 +1,95 @@
+package ui_test
+
+import (
+	"errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-cli/ui"
+	fakeui "github.com/cloudfoundry/bosh-cli/ui/fakes"
+	. "github.com/cloudfoundry/bosh-cli/ui/table"
+)
+
+var _ = Describe("BufferedUI", func() {
+	var (
+		parentUI *fakeui.FakeUI
+		ui       *BufferedUI
+	)
+
+	BeforeEach(func() {
+		parentUI = &fakeui.FakeUI{}
+		ui = NewBufferedUI(parentUI)
+	})
+
+	Describe("Replay", func() {
+		It("prints nothing via parent UI until replayed", func() {
+			ui.PrintLinef("fake-line")
+			ui.ErrorLinef("fake-error-line")
+			ui.PrintBlock("block")
+
+			Expect(parentUI.Said).To(BeEmpty())
+			Expect(parentUI.Errors).To(BeEmpty())
+			Expect(parentUI.Blocks).To(BeEmpty())
+		})
+
+		It("prints kept output via parent UI in original order", func() {
+			table := Table{
+				Content: "things",
+				Header:  []string{"header1"},
+			}
+
+			ui.BeginLinef("fake-start %s", "arg")
+			ui.EndLinef("fake-end")
+			ui.PrintLinef("fake-line")
+			ui.ErrorLinef("fake-error-line")
+			ui.PrintBlock("block")
+			ui.PrintErrorBlock("error-block")
+			ui.PrintTable(table)
+
+			ui.Replay()
+
+			Expect(parentUI.Said).To(Equal([]string{"fake-start arg", "fake-end", "fake-line"}))
+			Expect(parentUI.Errors).To(Equal([]string{"fake-error-line"}))
+			Expect(parentUI.Blocks).To(Equal([]string{"block", "error-block"}))
+			Expect(parentUI.Table).To(Equal(table))
+		})
+
+		It("does not print the same output again when replayed twice", func() {
+			ui.PrintLinef("fake-line-1")
+			ui.Replay()
+
+			ui.PrintLinef("fake-line-2")
+			ui.Replay()
+
+			Expect(parentUI.Said).To(Equal([]string{"fake-line-1", "fake-line-2"}))
+		})
+	})
+
+	Describe("AskForConfirmation", func() {
+		It("asks via parent UI right away", func() {
+			parentUI.AskedConfirmationErr = errors.New("fake-err")
+
+			err := ui.AskForConfirmation()
+			Expect(err).To(Equal(errors.New("fake-err")))
+			Expect(parentUI.AskedConfirmationCalled).To(BeTrue())
+		})
+	})
+
+	Describe("IsInteractive", func() {
+		It("delegates to the parent UI", func() {
+			parentUI.Interactive = true
+			Expect(ui.IsInteractive()).To(BeTrue())
+
+			parentUI.Interactive = false
+			Expect(ui.IsInteractive()).To(BeFalse())
+		})
+	})
+
+	Describe("Flush", func() {
+		It("does not flush parent UI", func() {
+			ui.Flush()
+			Expect(parentUI.Flushed).To(BeFalse())
+		})
+	})
+})
@@ -93,6 +93,10 @@ func (ui *ColorUI) AskForConfirmation() error {
 	return ui.parent.AskForConfirmation()
 }
 
+func (ui *ColorUI) AskForConfirmationWithLabel(expected string) error {
+	return ui.parent.AskForConfirmationWithLabel(expected)
+}
+
 func (ui *ColorUI) IsInteractive() bool {
 	return ui.parent.IsInteractive()
 }
@@ -1,7 +1,10 @@
 package ui
 
 import (
+	"io"
+
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	"github.com/pivotal-golang/clock"
 
 	. "github.com/cloudfoundry/bosh-cli/ui/table"
 )
@@ -43,6 +46,11 @@ func (ui *ConfUI) EnableNonInteractive() {
 	ui.parent = NewNonInteractiveUI(ui.parent)
 }
 
+// EnableLogFile copies printed output into writer with timestamps
+func (ui *ConfUI) EnableLogFile(writer io.Writer, timeService clock.Clock) {
+	ui.parent = NewLogFileUI(ui.parent, writer, timeService, ui.logger)
+}
+
 func (ui *ConfUI) ErrorLinef(pattern string, args ...interface{}) {
 	ui.parent.ErrorLinef(pattern, args...)
 }
@@ -87,6 +95,10 @@ func (ui *ConfUI) AskForConfirmation() error {
 	return ui.parent.AskForConfirmation()
 }
 
+func (ui *ConfUI) AskForConfirmationWithLabel(expected string) error {
+	return ui.parent.AskForConfirmationWithLabel(expected)
+}
+
 func (ui *ConfUI) IsInteractive() bool {
 	return ui.parent.IsInteractive()
 }
@@ -0,0 +1,42 @@
+package ui
+
+import (
+	"github.com/fatih/color"
+)
+
+const (
+	ColorModeAuto   = "auto"
+	ColorModeAlways = "always"
+	ColorModeNever  = "never"
+)
+
+// DiffColors colorizes added lines green and removed lines red;
+// lines are otherwise unchanged so that output stays greppable without color
+type DiffColors struct {
+	added   *color.Color
+	removed *color.Color
+}
+
+// NewDiffColors returns colors for given mode; in auto mode
+// color is only used if stdout is a TTY
+func NewDiffColors(mode string) DiffColors {
+	colors := DiffColors{
+		added:   color.New(color.FgGreen),
+		removed: color.New(color.FgRed),
+	}
+
+	switch mode {
+	case ColorModeAlways:
+		colors.added.EnableColor()
+		colors.removed.EnableColor()
+	case ColorModeNever:
+		colors.added.DisableColor()
+		colors.removed.DisableColor()
+	}
+
+	return colors
+}
+
+func (c DiffColors) Added(line string) string     { return c.added.SprintFunc()(line) }
+func (c DiffColors) Removed(line string) string   { return c.removed.SprintFunc()(line) }
+func (c DiffColors) Unchanged(line string) string { return line }
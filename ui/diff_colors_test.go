@@ -0,0 +1,30 @@
+package ui_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-cli/ui"
+)
+
+var _ = Describe("DiffColors", func() {
+	It("colorizes added and removed lines if color is always enabled", func() {
+		colors := NewDiffColors(ColorModeAlways)
+		Expect(colors.Added("+ line")).To(Equal("\x1b[32m+ line\x1b[0m"))
+		Expect(colors.Removed("- line")).To(Equal("\x1b[31m- line\x1b[0m"))
+		Expect(colors.Unchanged("  line")).To(Equal("  line"))
+	})
+
+	It("does not colorize lines if color is never enabled", func() {
+		colors := NewDiffColors(ColorModeNever)
+		Expect(colors.Added("+ line")).To(Equal("+ line"))
+		Expect(colors.Removed("- line")).To(Equal("- line"))
+		Expect(colors.Unchanged("  line")).To(Equal("  line"))
+	})
+
+	It("does not colorize lines in auto mode if stdout is not a TTY", func() {
+		colors := NewDiffColors(ColorModeAuto)
+		Expect(colors.Added("+ line")).To(Equal("+ line"))
+		Expect(colors.Removed("- line")).To(Equal("- line"))
+	})
+})
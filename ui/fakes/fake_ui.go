@@ -28,6 +28,7 @@ type FakeUI struct {
 	AskedChoiceErrs    []error
 
 	AskedConfirmationCalled bool
+	AskedConfirmationLabels []string
 	AskedConfirmationErr    error
 
 	Interactive bool
@@ -103,6 +104,12 @@ func (ui *FakeUI) AskForConfirmation() error {
 	return ui.AskedConfirmationErr
 }
 
+func (ui *FakeUI) AskForConfirmationWithLabel(expected string) error {
+	ui.AskedConfirmationCalled = true
+	ui.AskedConfirmationLabels = append(ui.AskedConfirmationLabels, expected)
+	return ui.AskedConfirmationErr
+}
+
 func (ui *FakeUI) IsInteractive() bool {
 	return ui.Interactive
 }
@@ -58,6 +58,10 @@ func (ui *indentingUI) AskForConfirmation() error {
 	return ui.parent.AskForConfirmation()
 }
 
+func (ui *indentingUI) AskForConfirmationWithLabel(expected string) error {
+	return ui.parent.AskForConfirmationWithLabel(expected)
+}
+
 func (ui *indentingUI) IsInteractive() bool {
 	return ui.parent.IsInteractive()
 }
@@ -23,6 +23,9 @@ type UI interface {
 	// AskForConfirmation returns error if user doesnt want to continue
 	AskForConfirmation() error
 
+	// AskForConfirmationWithLabel returns error if user does not type expected text
+	AskForConfirmationWithLabel(expected string) error
+
 	IsInteractive() bool
 
 	Flush()
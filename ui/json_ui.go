@@ -98,6 +98,10 @@ func (ui *jsonUI) AskForConfirmation() error {
 	panic("Cannot ask for confirmation in JSON UI")
 }
 
+func (ui *jsonUI) AskForConfirmationWithLabel(expected string) error {
+	panic("Cannot ask for confirmation in JSON UI")
+}
+
 func (ui *jsonUI) IsInteractive() bool {
 	return ui.parent.IsInteractive()
 }
@@ -244,6 +244,12 @@ var _ = Describe("JSONUI", func() {
 		})
 	})
 
+	Describe("AskForConfirmationWithLabel", func() {
+		It("panics", func() {
+			Expect(func() { ui.AskForConfirmationWithLabel("dep") }).To(Panic())
+		})
+	})
+
 	Describe("IsInteractive", func() {
 		It("delegates to the parent UI", func() {
 			parentUI.Interactive = true
@@ -0,0 +1,156 @@
+package ui
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	"github.com/pivotal-golang/clock"
+
+	. "github.com/cloudfoundry/bosh-cli/ui/table"
+)
+
+// logFileANSIRegexp matches color codes which are only useful in a terminal
+var logFileANSIRegexp = regexp.MustCompile("\x1b\\[[0-9;]*m")
+
+// logFileUI copies printed output into a writer (typically a file opened for appending)
+// prefixing each line with a timestamp; each line is written as soon as it's complete
+// so that killed process still leaves partial log behind
+type logFileUI struct {
+	parent      UI
+	writer      io.Writer
+	timeService clock.Clock
+
+	// partialLine keeps text printed with BeginLinef until it's terminated
+	// with a new line or finished with EndLinef
+	partialLine string
+	writerLock  sync.Mutex
+
+	logTag string
+	logger boshlog.Logger
+}
+
+func NewLogFileUI(parent UI, writer io.Writer, timeService clock.Clock, logger boshlog.Logger) UI {
+	return &logFileUI{
+		parent:      parent,
+		writer:      writer,
+		timeService: timeService,
+
+		logTag: "logFileUI",
+		logger: logger,
+	}
+}
+
+func (ui *logFileUI) ErrorLinef(pattern string, args ...interface{}) {
+	ui.parent.ErrorLinef(pattern, args...)
+	ui.writeLines(fmt.Sprintf(pattern, args...))
+}
+
+func (ui *logFileUI) PrintLinef(pattern string, args ...interface{}) {
+	ui.parent.PrintLinef(pattern, args...)
+	ui.writeLines(fmt.Sprintf(pattern, args...))
+}
+
+func (ui *logFileUI) BeginLinef(pattern string, args ...interface{}) {
+	ui.parent.BeginLinef(pattern, args...)
+
+	ui.writerLock.Lock()
+
+	text := ui.partialLine + fmt.Sprintf(pattern, args...)
+
+	// Finished lines are written immediately (e.g. diff lines never end with EndLinef)
+	idx := strings.LastIndex(text, "\n")
+	ui.partialLine = text[idx+1:]
+
+	ui.writerLock.Unlock()
+
+	if idx >= 0 {
+		ui.writeLines(text[:idx])
+	}
+}
+
+func (ui *logFileUI) EndLinef(pattern string, args ...interface{}) {
+	ui.parent.EndLinef(pattern, args...)
+
+	ui.writerLock.Lock()
+	line := ui.partialLine + fmt.Sprintf(pattern, args...)
+	ui.partialLine = ""
+	ui.writerLock.Unlock()
+
+	ui.writeLines(line)
+}
+
+func (ui *logFileUI) PrintBlock(block string) {
+	ui.parent.PrintBlock(block)
+	ui.writeLines(strings.TrimSuffix(block, "\n"))
+}
+
+func (ui *logFileUI) PrintErrorBlock(block string) {
+	ui.parent.PrintErrorBlock(block)
+	ui.writeLines(strings.TrimSuffix(block, "\n"))
+}
+
+func (ui *logFileUI) PrintTable(table Table) {
+	ui.parent.PrintTable(table)
+
+	buf := bytes.NewBufferString("")
+
+	err := table.Print(buf)
+	if err != nil {
+		ui.logger.Error(ui.logTag, "Rendering table for log file: %s", err)
+		return
+	}
+
+	ui.writeLines(strings.TrimSuffix(buf.String(), "\n"))
+}
+
+func (ui *logFileUI) AskForText(label string) (string, error) {
+	return ui.parent.AskForText(label)
+}
+
+func (ui *logFileUI) AskForChoice(label string, options []string) (int, error) {
+	return ui.parent.AskForChoice(label, options)
+}
+
+func (ui *logFileUI) AskForPassword(label string) (string, error) {
+	return ui.parent.AskForPassword(label)
+}
+
+func (ui *logFileUI) AskForConfirmation() error {
+	return ui.parent.AskForConfirmation()
+}
+
+func (ui *logFileUI) AskForConfirmationWithLabel(expected string) error {
+	return ui.parent.AskForConfirmationWithLabel(expected)
+}
+
+func (ui *logFileUI) IsInteractive() bool {
+	return ui.parent.IsInteractive()
+}
+
+func (ui *logFileUI) Flush() {
+	ui.parent.Flush()
+}
+
+func (ui *logFileUI) writeLines(text string) {
+	ui.writerLock.Lock()
+	defer ui.writerLock.Unlock()
+
+	timestamp := ui.timeService.Now().Format(time.RFC3339)
+
+	for _, line := range strings.Split(text, "\n") {
+		// Trailing padding (e.g. of table columns) is not useful in the log
+		line = strings.TrimRight(logFileANSIRegexp.ReplaceAllString(line, ""), " ")
+
+		_, err := fmt.Fprintf(ui.writer, "[%s] %s\n", timestamp, line)
+		if err != nil {
+			ui.logger.Error(ui.logTag, "Writing to log file: %s", err)
+			return
+		}
+	}
+}
@@ -0,0 +1,147 @@
+package ui_test
+
+import (
+	"bytes"
+	"errors"
+	"time"
+
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/pivotal-golang/clock/fakeclock"
+
+	. "github.com/cloudfoundry/bosh-cli/ui"
+	fakeui "github.com/cloudfoundry/bosh-cli/ui/fakes"
+	. "github.com/cloudfoundry/bosh-cli/ui/table"
+)
+
+type failingLogWriter struct{}
+
+func (failingLogWriter) Write([]byte) (int, error) { return 0, errors.New("fake-write-err") }
+
+var _ = Describe("LogFileUI", func() {
+	var (
+		parentUI    *fakeui.FakeUI
+		logBuf      *bytes.Buffer
+		timeService *fakeclock.FakeClock
+		ui          UI
+	)
+
+	BeforeEach(func() {
+		parentUI = &fakeui.FakeUI{}
+		logBuf = bytes.NewBufferString("")
+		timeService = fakeclock.NewFakeClock(time.Date(2017, time.January, 2, 3, 4, 5, 0, time.UTC))
+
+		logger := boshlog.NewLogger(boshlog.LevelNone)
+		ui = NewLogFileUI(parentUI, logBuf, timeService, logger)
+	})
+
+	Describe("PrintLinef", func() {
+		It("delegates to the parent UI and writes timestamped line", func() {
+			ui.PrintLinef("fake-line %d", 1)
+			Expect(parentUI.Said).To(Equal([]string{"fake-line 1"}))
+			Expect(logBuf.String()).To(Equal("[2017-01-02T03:04:05Z] fake-line 1\n"))
+		})
+
+		It("prefixes every line of multi-line text", func() {
+			ui.PrintLinef("fake-line1\nfake-line2")
+
+			timeService.Increment(time.Second)
+			ui.PrintLinef("fake-line3")
+
+			Expect(logBuf.String()).To(Equal(
+				"[2017-01-02T03:04:05Z] fake-line1\n" +
+					"[2017-01-02T03:04:05Z] fake-line2\n" +
+					"[2017-01-02T03:04:06Z] fake-line3\n",
+			))
+		})
+	})
+
+	Describe("ErrorLinef", func() {
+		It("delegates to the parent UI and writes timestamped line", func() {
+			ui.ErrorLinef("fake-error")
+			Expect(parentUI.Errors).To(Equal([]string{"fake-error"}))
+			Expect(logBuf.String()).To(Equal("[2017-01-02T03:04:05Z] fake-error\n"))
+		})
+	})
+
+	Describe("BeginLinef/EndLinef", func() {
+		It("writes line only once it is finished", func() {
+			ui.BeginLinef("fake-start")
+			Expect(logBuf.String()).To(BeEmpty())
+
+			ui.EndLinef(" fake-end")
+			Expect(parentUI.Said).To(Equal([]string{"fake-start", " fake-end"}))
+			Expect(logBuf.String()).To(Equal("[2017-01-02T03:04:05Z] fake-start fake-end\n"))
+		})
+
+		It("writes lines terminated with a new line without waiting for EndLinef", func() {
+			ui.BeginLinef("fake-line1\nfake-")
+			Expect(logBuf.String()).To(Equal("[2017-01-02T03:04:05Z] fake-line1\n"))
+
+			ui.BeginLinef("line2\n")
+			Expect(logBuf.String()).To(Equal(
+				"[2017-01-02T03:04:05Z] fake-line1\n[2017-01-02T03:04:05Z] fake-line2\n"))
+		})
+	})
+
+	It("removes color codes from written lines", func() {
+		ui.PrintLinef("\x1b[32m+ fake-added\x1b[0m")
+		Expect(parentUI.Said).To(Equal([]string{"\x1b[32m+ fake-added\x1b[0m"}))
+		Expect(logBuf.String()).To(Equal("[2017-01-02T03:04:05Z] + fake-added\n"))
+	})
+
+	Describe("PrintBlock", func() {
+		It("delegates to the parent UI and writes timestamped lines", func() {
+			ui.PrintBlock("block1\nblock2\n")
+			Expect(parentUI.Blocks).To(Equal([]string{"block1\nblock2\n"}))
+			Expect(logBuf.String()).To(Equal(
+				"[2017-01-02T03:04:05Z] block1\n[2017-01-02T03:04:05Z] block2\n"))
+		})
+	})
+
+	Describe("PrintErrorBlock", func() {
+		It("delegates to the parent UI and writes timestamped lines", func() {
+			ui.PrintErrorBlock("block")
+			Expect(parentUI.Blocks).To(Equal([]string{"block"}))
+			Expect(logBuf.String()).To(Equal("[2017-01-02T03:04:05Z] block\n"))
+		})
+	})
+
+	Describe("PrintTable", func() {
+		It("delegates to the parent UI and writes rendered table", func() {
+			table := Table{
+				Content: "things",
+				Header:  []string{"header1"},
+				Rows:    [][]Value{{NewValueString("row1")}},
+			}
+
+			ui.PrintTable(table)
+
+			Expect(parentUI.Table).To(Equal(table))
+			Expect(logBuf.String()).To(ContainSubstring("[2017-01-02T03:04:05Z] header1\n"))
+			Expect(logBuf.String()).To(ContainSubstring("[2017-01-02T03:04:05Z] row1\n"))
+		})
+	})
+
+	Describe("IsInteractive", func() {
+		It("delegates to the parent UI", func() {
+			parentUI.Interactive = true
+			Expect(ui.IsInteractive()).To(BeTrue())
+		})
+	})
+
+	Describe("Flush", func() {
+		It("delegates to the parent UI", func() {
+			ui.Flush()
+			Expect(parentUI.Flushed).To(BeTrue())
+		})
+	})
+
+	It("keeps printing to the parent UI if writing fails", func() {
+		ui = NewLogFileUI(parentUI, failingLogWriter{}, timeService, boshlog.NewLogger(boshlog.LevelNone))
+
+		ui.PrintLinef("fake-line")
+		Expect(parentUI.Said).To(Equal([]string{"fake-line"}))
+	})
+})
@@ -57,6 +57,11 @@ func (ui *nonInteractiveUI) AskForConfirmation() error {
 	return nil
 }
 
+func (ui *nonInteractiveUI) AskForConfirmationWithLabel(expected string) error {
+	// Always respond successfully
+	return nil
+}
+
 func (ui *nonInteractiveUI) IsInteractive() bool {
 	return false
 }
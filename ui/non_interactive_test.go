@@ -99,6 +99,12 @@ var _ = Describe("NonInteractiveUI", func() {
 		})
 	})
 
+	Describe("AskForConfirmationWithLabel", func() {
+		It("responds affirmatively with no error", func() {
+			Expect(ui.AskForConfirmationWithLabel("dep")).To(BeNil())
+		})
+	})
+
 	Describe("IsInteractive", func() {
 		It("returns false", func() {
 			Expect(ui.IsInteractive()).To(BeFalse())
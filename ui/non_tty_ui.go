@@ -56,6 +56,10 @@ func (ui *NonTTYUI) AskForConfirmation() error {
 	return ui.parent.AskForConfirmation()
 }
 
+func (ui *NonTTYUI) AskForConfirmationWithLabel(expected string) error {
+	return ui.parent.AskForConfirmationWithLabel(expected)
+}
+
 func (ui *NonTTYUI) IsInteractive() bool {
 	return ui.parent.IsInteractive()
 }
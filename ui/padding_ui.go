@@ -77,6 +77,11 @@ func (ui *paddingUI) AskForConfirmation() error {
 	return ui.parent.AskForConfirmation()
 }
 
+func (ui *paddingUI) AskForConfirmationWithLabel(expected string) error {
+	ui.padBefore(paddingUIModeAuto)
+	return ui.parent.AskForConfirmationWithLabel(expected)
+}
+
 func (ui *paddingUI) IsInteractive() bool {
 	return ui.parent.IsInteractive()
 }
@@ -0,0 +1,65 @@
+package ui
+
+import (
+	"sync"
+
+	"github.com/cheggaaa/pb"
+)
+
+// TransferReporter renders progress bars of transfers reported via Track
+// one after another; a new bar is started once a transfer completes
+// or when reported progress shows that another transfer began
+type TransferReporter struct {
+	fileReporter FileReporter
+
+	bar         *pb.ProgressBar
+	transferred int64
+	total       int64
+	lock        sync.Mutex
+}
+
+func NewTransferReporter(ui UI) *TransferReporter {
+	return &TransferReporter{fileReporter: NewFileReporter(ui)}
+}
+
+// Track reports number of bytes transferred so far
+// and total number of bytes (-1 if total is not known)
+func (r *TransferReporter) Track(transferred, total int64) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if r.bar != nil && (transferred < r.transferred || total != r.total) {
+		r.finish()
+	}
+
+	if r.bar == nil {
+		barTotal := total
+		if barTotal < 0 {
+			barTotal = 0
+		}
+
+		r.bar = r.fileReporter.buildBar(barTotal)
+		r.total = total
+	}
+
+	r.bar.Set64(transferred)
+	r.transferred = transferred
+
+	if total >= 0 && transferred >= total {
+		r.finish()
+	}
+}
+
+// InProgress returns whether a transfer was reported that has not completed yet
+func (r *TransferReporter) InProgress() bool {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	return r.bar != nil
+}
+
+func (r *TransferReporter) finish() {
+	r.bar.Finish()
+	r.bar = nil
+	r.transferred = 0
+}
@@ -0,0 +1,44 @@
+package ui_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-cli/ui"
+	"github.com/cloudfoundry/bosh-cli/ui/fakes"
+)
+
+var _ = Describe("TransferReporter", func() {
+	var (
+		reporter *TransferReporter
+	)
+
+	BeforeEach(func() {
+		reporter = NewTransferReporter(&fakes.FakeUI{})
+	})
+
+	Describe("Track", func() {
+		It("completes transfer once total number of bytes is transferred", func() {
+			reporter.Track(5, 10)
+			Expect(reporter.InProgress()).To(BeTrue())
+
+			reporter.Track(10, 10)
+			Expect(reporter.InProgress()).To(BeFalse())
+		})
+
+		It("starts tracking another transfer when transferred bytes go back", func() {
+			reporter.Track(5, 10)
+			reporter.Track(2, 10)
+			Expect(reporter.InProgress()).To(BeTrue())
+
+			reporter.Track(10, 10)
+			Expect(reporter.InProgress()).To(BeFalse())
+		})
+
+		It("keeps tracking transfer of unknown size", func() {
+			reporter.Track(5, -1)
+			reporter.Track(100, -1)
+			Expect(reporter.InProgress()).To(BeTrue())
+		})
+	})
+})
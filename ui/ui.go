@@ -152,6 +152,21 @@ func (ui *WriterUI) AskForConfirmation() error {
 	return nil
 }
 
+func (ui *WriterUI) AskForConfirmationWithLabel(expected string) error {
+	var text string
+
+	err := interact.NewInteraction(fmt.Sprintf("Type '%s' to continue", expected)).Resolve(&text)
+	if err != nil {
+		return bosherr.WrapError(err, "Asking for confirmation")
+	}
+
+	if text != expected {
+		return bosherr.Errorf("Stopped: expected '%s' to be typed but was '%s'", expected, text)
+	}
+
+	return nil
+}
+
 func (ui *WriterUI) IsInteractive() bool {
 	return true
 }